@@ -3,28 +3,45 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
 	ig2wacmd "ig2wa/internal/cli/cmd"
+	"ig2wa/internal/util"
 )
 
 func main() {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	// Ctrl+C cancels at once. SIGTERM and SIGHUP (a service manager stopping
+	// us, a closed terminal) drain instead: running jobs get a grace period to
+	// finish and the rest is kept for later; a second one cancels at once.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
 	defer stop()
+	drain := util.NewDrain()
+	ctx = util.WithDrain(ctx, drain)
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		sig := <-sigs
+		slog.Warn("finishing running jobs before exiting; send the signal again to stop at once", "signal", sig.String())
+		drain.Start(sig)
+		<-sigs
+		cancel()
+	}()
 
 	if err := ig2wacmd.Execute(ctx); err != nil {
 		var ee *ig2wacmd.ExitError
 		if errors.As(err, &ee) {
 			if ee.Err != nil {
-				fmt.Fprintln(os.Stderr, ee.Err)
+				slog.Error(ee.Err.Error())
 			}
 			os.Exit(ee.Code)
 		}
-		fmt.Fprintln(os.Stderr, err)
+		slog.Error(err.Error())
 		os.Exit(ig2wacmd.ExitCLIError)
 	}
 	os.Exit(ig2wacmd.ExitOK)
-}
\ No newline at end of file
+}
@@ -0,0 +1,158 @@
+package buildinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ig2wa/internal/dirs"
+)
+
+// ReleasesURL is the feed of published sniplette releases.
+const ReleasesURL = "https://api.github.com/repos/najibninaba/sniplette/releases/latest"
+
+// UpdateCheckInterval is how often the startup check asks the feed.
+const (
+	UpdateCheckInterval = 24 * time.Hour
+	updateCheckTimeout  = 3 * time.Second
+	updateCacheFile     = "update-check.json"
+)
+
+// Release is the newest published release.
+type Release struct {
+	Version string `json:"tag_name"`
+	URL     string `json:"html_url"`
+}
+
+type updateCache struct {
+	Checked time.Time `json:"checked"`
+	Latest  Release   `json:"latest"`
+}
+
+// UpdateCheckDue reports whether UpdateCheckInterval has passed since the
+// last check recorded in the cache dir.
+func UpdateCheckDue(now time.Time) bool {
+	c, err := readUpdateCache()
+	return err != nil || now.Sub(c.Checked) >= UpdateCheckInterval || now.Before(c.Checked)
+}
+
+// LatestRelease asks the releases feed for the newest release and records
+// the check in the cache dir. A failed request is recorded too, so an
+// offline machine doesn't retry on every start; the last known release is
+// returned with the error.
+func LatestRelease(ctx context.Context, now time.Time) (Release, error) {
+	prev, _ := readUpdateCache()
+	rel, err := fetchRelease(ctx)
+	c := updateCache{Checked: now, Latest: prev.Latest}
+	if err == nil {
+		c.Latest = rel
+	}
+	writeUpdateCache(c)
+	return c.Latest, err
+}
+
+func fetchRelease(ctx context.Context) (Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ReleasesURL, nil)
+	if err != nil {
+		return Release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "sniplette/"+Get().Version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Release{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("GET %s: %s", ReleasesURL, resp.Status)
+	}
+	var rel Release
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return Release{}, fmt.Errorf("decode release: %w", err)
+	}
+	if rel.Version == "" {
+		return Release{}, fmt.Errorf("release feed has no tag")
+	}
+	return rel, nil
+}
+
+func updateCachePath() (string, error) {
+	dir, err := dirs.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, updateCacheFile), nil
+}
+
+func readUpdateCache() (updateCache, error) {
+	var c updateCache
+	path, err := updateCachePath()
+	if err != nil {
+		return c, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// writeUpdateCache is best effort: without a cache the check just runs again.
+func writeUpdateCache(c updateCache) {
+	path, err := updateCachePath()
+	if err != nil || dirs.Ensure(filepath.Dir(path)) != nil {
+		return
+	}
+	if b, err := json.Marshal(c); err == nil {
+		_ = os.WriteFile(path, b, 0o644)
+	}
+}
+
+// Newer reports whether version latest is newer than current. Both are
+// compared as vMAJOR.MINOR.PATCH; a release beats its own pre-releases. It
+// is false when either can't be parsed, as for "dev" builds.
+func Newer(latest, current string) bool {
+	l, lpre, ok := parseSemver(latest)
+	if !ok {
+		return false
+	}
+	c, cpre, ok := parseSemver(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return cpre && !lpre
+}
+
+// parseSemver splits "v1.2.3[-pre][+build]" into its numbers and whether it
+// is a pre-release. Missing minor or patch numbers count as 0.
+func parseSemver(v string) (nums [3]int, pre bool, ok bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, p, hasPre := strings.Cut(v, "-")
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return nums, false, false
+	}
+	for i, s := range parts {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nums, false, false
+		}
+		nums[i] = n
+	}
+	return nums, hasPre && p != "", true
+}
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// A batch entry is a URL, optionally followed by run flags for that URL
+// alone: "https://youtu.be/x --max-size-mb 16 --trim 0:05-0:35". Entries come
+// from --input (one per line) or from arguments containing whitespace. The
+// entry's flags are applied on top of the batch's own flags and config.
+
+// urlSpec is one batch entry.
+type urlSpec struct {
+	URL  string
+	Args []string // The entry's own flags; empty = batch options
+}
+
+// jobOptions are the options of a batch entry with flags of its own.
+type jobOptions struct {
+	Options   model.CLIOptions
+	PresetCRF int
+}
+
+// batchOnlyFlags apply to a whole run and can't be set per URL.
+var batchOnlyFlags = map[string]bool{
+	"input": true, "file": true, "profile": true, "report": true, "jobs": true, "max-jobs": true,
+	"no-ui": true, "keep-going": true, "fail-fast": true, "dry-run": true, "quiet": true,
+	"verbose": true, "log-level": true, "log-file": true, "skip-version-check": true,
+	"auto-update": true, "pick-format": true, "no-thumbnails": true, "accessible": true, "no-daemon": true,
+	"single-instance": true, "download-archive": true, "progress-file": true, "progress-webhook": true,
+}
+
+// urlArgs accepts any number of URL arguments with --input or --file, at
+// least one without.
+func urlArgs(cmd *cobra.Command, args []string) error {
+	if batchFileGiven(cmd) {
+		return nil
+	}
+	return cobra.MinimumNArgs(1)(cmd, args)
+}
+
+// parseURLSpec splits a batch entry into its URL and flags (shell-style).
+func parseURLSpec(s string) (urlSpec, error) {
+	words, err := util.SplitArgs(s)
+	if err != nil {
+		return urlSpec{}, i18n.Errorf("invalid entry %q: %v", s, err)
+	}
+	if len(words) == 0 {
+		return urlSpec{}, nil
+	}
+	if strings.HasPrefix(words[0], "-") {
+		return urlSpec{}, i18n.Errorf("invalid entry %q: the URL comes first", s)
+	}
+	return urlSpec{URL: words[0], Args: words[1:]}, nil
+}
+
+// batchFileGiven reports whether URLs come from --input or --file.
+func batchFileGiven(cmd *cobra.Command) bool {
+	input, _ := cmd.Flags().GetString("input")
+	file, _ := cmd.Flags().GetString("file")
+	return input != "" || file != ""
+}
+
+// batchSpecs collects the run's entries: the --file manifest's jobs, the
+// --input file's lines (blank lines and # comments skipped), then the
+// arguments.
+func batchSpecs(cmd *cobra.Command, args []string) ([]urlSpec, error) {
+	var specs []urlSpec
+	if path, _ := cmd.Flags().GetString("file"); path != "" {
+		var err error
+		if specs, err = readManifest(cmd, path); err != nil {
+			return nil, err
+		}
+	}
+	var lines []string
+	if path, _ := cmd.Flags().GetString("input"); path != "" {
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, i18n.Errorf("read --input: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				lines = append(lines, line)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, i18n.Errorf("read --input: %w", err)
+		}
+	}
+	for _, s := range append(lines, args...) {
+		if !strings.ContainsAny(strings.TrimSpace(s), " \t") {
+			specs = append(specs, urlSpec{URL: strings.TrimSpace(s)})
+			continue
+		}
+		spec, err := parseURLSpec(s)
+		if err != nil {
+			return nil, err
+		}
+		if spec.URL != "" {
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+// resolveShortLinks replaces short links and share wrappers (bit.ly,
+// l.instagram.com, ...) with the links they lead to. A link that doesn't
+// resolve is kept; its job tries again and fails with the error (see
+// pipeline.Service.RunJob), and the others run.
+func resolveShortLinks(cmd *cobra.Command, specs []urlSpec) []urlSpec {
+	for i, s := range specs {
+		if !util.IsShortLink(s.URL) {
+			continue
+		}
+		resolved, err := util.ResolveShortLink(cmd.Context(), s.URL)
+		if err != nil {
+			slog.Warn("could not resolve short link", "url", s.URL, "err", err)
+			continue
+		}
+		slog.Info("resolved short link", "url", s.URL, "to", resolved)
+		specs[i].URL = resolved
+	}
+	return specs
+}
+
+// dedupeSpecs strips tracking parameters from the entries' URLs and drops
+// entries for a video already in the batch with the same flags, e.g. a
+// youtu.be and a youtube.com/watch link to one video, saying which.
+func dedupeSpecs(cmd *cobra.Command, specs []urlSpec) []urlSpec {
+	quiet := getPersistentBool(cmd, "quiet", false)
+	first := make(map[string]string, len(specs))
+	out := specs[:0]
+	for _, s := range specs {
+		s.URL = util.CleanURL(s.URL)
+		key := util.SameVideoKey(s.URL) + "\x00" + strings.Join(s.Args, "\x00")
+		if prev, ok := first[key]; ok {
+			if !quiet {
+				if prev == s.URL {
+					fmt.Fprintln(cmd.ErrOrStderr(), i18n.Sprintf("Skipping duplicate %s", s.URL))
+				} else {
+					fmt.Fprintln(cmd.ErrOrStderr(), i18n.Sprintf("Skipping %s: same video as %s", s.URL, prev))
+				}
+			}
+			continue
+		}
+		first[key] = s.URL
+		out = append(out, s)
+	}
+	return out
+}
+
+// assembleBatch builds the inputs of a run whose entries may carry their own
+// flags (see urlSpec).
+func assembleBatch(cmd *cobra.Command, args []string) (runInputs, error) {
+	specs, err := batchSpecs(cmd, args)
+	if err != nil {
+		return runInputs{}, err
+	}
+	if len(specs) == 0 {
+		return runInputs{}, i18n.Errorf("no URLs given")
+	}
+	specs = dedupeSpecs(cmd, resolveShortLinks(cmd, specs))
+	var plain, all []string
+	for _, s := range specs {
+		all = append(all, s.URL)
+		if len(s.Args) == 0 {
+			plain = append(plain, s.URL)
+		}
+	}
+	_, opts, presetCRF, err := assembleRunInputs(cmd, plain)
+	if err != nil {
+		return runInputs{}, err
+	}
+	in := runInputs{URLs: all, Options: opts, PresetCRF: presetCRF}
+	for i, s := range specs {
+		if len(s.Args) == 0 {
+			continue
+		}
+		jo, err := specOptions(cmd, s)
+		if err != nil {
+			return runInputs{}, err
+		}
+		if in.Entries == nil {
+			in.Entries = make([]*jobOptions, len(specs))
+		}
+		in.Entries[i] = &jo
+	}
+	return in, nil
+}
+
+// specOptions resolves the options of an entry with flags of its own: the
+// run's flags, then the entry's, parsed into a scratch copy of the run
+// command and read the usual way.
+func specOptions(cmd *cobra.Command, s urlSpec) (jobOptions, error) {
+	own := scratchRunCmd(cmd)
+	if err := own.ParseFlags(s.Args); err != nil {
+		return jobOptions{}, fmt.Errorf("%s: %v", s.URL, err)
+	}
+	if rest := own.Flags().Args(); len(rest) > 0 {
+		return jobOptions{}, i18n.Errorf("%s: unexpected argument %q (one URL per entry)", s.URL, rest[0])
+	}
+	var batchOnly []string
+	own.Flags().Visit(func(f *pflag.Flag) {
+		if batchOnlyFlags[f.Name] {
+			batchOnly = append(batchOnly, "--"+f.Name)
+		}
+	})
+	if len(batchOnly) > 0 {
+		return jobOptions{}, i18n.Errorf("%s: %s can't be set per URL", s.URL, strings.Join(batchOnly, ", "))
+	}
+
+	merged := scratchRunCmd(cmd)
+	if err := merged.ParseFlags(append(changedFlagArgs(cmd, merged), s.Args...)); err != nil {
+		return jobOptions{}, fmt.Errorf("%s: %v", s.URL, err)
+	}
+	_, opts, presetCRF, err := assembleRunInputs(merged, []string{s.URL})
+	if err != nil {
+		return jobOptions{}, fmt.Errorf("%s: %v", s.URL, err)
+	}
+	return jobOptions{Options: opts, PresetCRF: presetCRF}, nil
+}
+
+// scratchRunCmd returns an unparsed run command under its own root, with the
+// same flags as cmd.
+func scratchRunCmd(cmd *cobra.Command) *cobra.Command {
+	root := &cobra.Command{Use: "sniplette"}
+	bindPersistentFlags(root.PersistentFlags())
+	run := &cobra.Command{Use: "run"}
+	bindRunFlags(run.Flags())
+	root.AddCommand(run)
+	run.SetContext(cmd.Context())
+	return run
+}
+
+// changedFlagArgs turns the flags set on cmd's command line back into
+// arguments, keeping those that target has, except --input and --file.
+func changedFlagArgs(cmd, target *cobra.Command) []string {
+	var args []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "input" || f.Name == "file" || (target.Flags().Lookup(f.Name) == nil && target.InheritedFlags().Lookup(f.Name) == nil) {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range sv.GetSlice() {
+				args = append(args, "--"+f.Name+"="+v)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+// addURL appends url to in's URLs, to run with the options of batch entry jo
+// (nil = the batch's).
+func (in *runInputs) addURL(url string, jo *jobOptions) {
+	if jo != nil && in.Entries == nil {
+		in.Entries = make([]*jobOptions, len(in.URLs))
+	}
+	in.URLs = append(in.URLs, url)
+	if in.Entries != nil {
+		in.Entries = append(in.Entries, jo)
+	}
+}
+
+// entry returns the options of the i-th URL's batch entry when it had flags
+// of its own, else nil.
+func (in runInputs) entry(i int) *jobOptions {
+	if i >= len(in.Entries) {
+		return nil
+	}
+	return in.Entries[i]
+}
+
+// forEntry returns the inputs for the i-th URL: its own options if its batch
+// entry had flags, the batch's otherwise. Settings of the whole run (such as
+// dry-run, which plan sets after assembly) always come from the batch.
+func (in runInputs) forEntry(i int) runInputs {
+	jo := in.entry(i)
+	if jo == nil {
+		return in
+	}
+	run := in.Options
+	in.Options, in.PresetCRF = jo.Options, jo.PresetCRF
+	in.Options.DryRun, in.Options.NoUI, in.Options.KeepGoing = run.DryRun, run.NoUI, run.KeepGoing
+	in.Options.SampleEncode, in.Options.ShowCommands, in.Options.ShowFormats = run.SampleEncode, run.ShowCommands, run.ShowFormats
+	in.Options.Quiet, in.Options.Verbose, in.Options.Report = run.Quiet, run.Verbose, run.Report
+	in.Options.ProgressFile, in.Options.ProgressWebhook = run.ProgressFile, run.ProgressWebhook
+	return in
+}
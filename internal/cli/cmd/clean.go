@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/util"
+)
+
+func newCleanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "clean",
+		Short:         "Remove orphaned temp workdirs and stale partial downloads",
+		Long:          "Remove temp workdirs left behind by crashed or --keep-temp runs, stray .part/.ytdl files, and legacy temp dirs from older versions. Workdirs of running sniplette processes are never touched. With --cache, also remove originals from the source cache (--source-cache-mb) that were not used for --older-than days.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			days, _ := cmd.Flags().GetInt("older-than")
+			all, _ := cmd.Flags().GetBool("all")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			cache, _ := cmd.Flags().GetBool("cache")
+			maxAge := time.Duration(days) * 24 * time.Hour
+			if all {
+				maxAge = 0
+			}
+
+			items, err := util.ScanStaleTemp(maxAge)
+			if err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			if cache {
+				cached, err := downloader.SourceCacheEntries()
+				if err != nil {
+					return &ExitError{Code: ExitCLIError, Err: err}
+				}
+				cutoff := time.Now().Add(-maxAge)
+				for _, it := range cached {
+					if maxAge <= 0 || it.ModTime.Before(cutoff) {
+						items = append(items, it)
+					}
+				}
+			}
+			w := cmd.OutOrStdout()
+			if len(items) == 0 {
+				fmt.Fprintln(w, i18n.String("Nothing to clean."))
+				return nil
+			}
+			verb := i18n.String("Removed")
+			if dryRun {
+				verb = i18n.String("Would remove")
+			}
+			var total int64
+			for _, it := range items {
+				if !dryRun {
+					if err := util.RemoveStaleTemp(it); err != nil {
+						fmt.Fprintln(w, i18n.Sprintf("Failed to remove %s: %v", it.Path, err))
+						continue
+					}
+				}
+				total += it.Bytes
+				fmt.Fprintln(w, i18n.Sprintf("%s %s (%s, %s, modified %s)", verb, it.Path, util.HumanizeBytes(it.Bytes), it.Reason, it.ModTime.Format("2006-01-02")))
+			}
+			fmt.Fprintln(w, i18n.Sprintf("%s %s in total.", verb, util.HumanizeBytes(total)))
+			return nil
+		},
+	}
+	cmd.Flags().Int("older-than", 3, "Only remove items not modified for this many days")
+	cmd.Flags().Bool("all", false, "Remove all leftovers regardless of age (still skips running jobs)")
+	cmd.Flags().Bool("cache", false, "Also remove cached source downloads (all of them with --all)")
+	cmd.Flags().Bool("dry-run", false, "List what would be removed without deleting anything")
+	return cmd
+}
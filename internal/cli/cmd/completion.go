@@ -44,4 +44,4 @@ PowerShell:
 		},
 	}
 	return cmd
-}
\ No newline at end of file
+}
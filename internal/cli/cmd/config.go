@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ig2wa/internal/config"
+	"ig2wa/internal/dirs"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/util"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and edit the configuration file",
+	}
+	cmd.AddCommand(newConfigListCmd(), newConfigGetCmd(), newConfigSetCmd(),
+		newConfigEditCmd(), newConfigInitCmd(), newConfigPathCmd())
+	return cmd
+}
+
+// configEntry is one row of `config list`.
+type configEntry struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+func effectiveConfig(cmd *cobra.Command) []configEntry {
+	var out []configEntry
+	for _, k := range config.Keys {
+		v := viper.Get(k.Name)
+		if v == nil {
+			if k.Kind == config.KindMap {
+				continue
+			}
+			v = k.Default
+		}
+		out = append(out, configEntry{Key: k.Name, Value: v, Source: config.Source(k.Name, cmd.Flags())})
+	}
+	return out
+}
+
+func newConfigListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "list",
+		Short:         "Print the effective configuration and where each value comes from",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			entries := effectiveConfig(cmd)
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(entries)
+			}
+			w := cmd.OutOrStdout()
+			if p, err := config.FilePath(); err == nil {
+				fmt.Fprintln(w, i18n.Sprintf("# config file: %s", p))
+			}
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			for _, e := range entries {
+				fmt.Fprintf(tw, "%s\t%s\t(%s)\n", e.Key, formatConfigValue(e.Value), e.Source)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			for _, k := range config.UnknownKeys() {
+				fmt.Fprintln(w, i18n.Sprintf("! unknown key %q is ignored", k))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("json", false, "Print as JSON")
+	return cmd
+}
+
+func newConfigGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "get <key>",
+		Short:         "Print the effective value of a key (dotted keys reach into sections)",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := strings.ReplaceAll(strings.ToLower(args[0]), "-", "_")
+			k, known := config.LookupKey(strings.SplitN(key, ".", 2)[0])
+			if !known {
+				return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("unknown config key %q", args[0])}
+			}
+			v := viper.Get(key)
+			if v == nil && key == k.Name {
+				v = k.Default
+			}
+			if v == nil {
+				return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("%s is not set", key)}
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), formatConfigValue(v))
+			return nil
+		},
+	}
+}
+
+func newConfigSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Write a key to the config file",
+		Long: "Write a key to the config file, creating it if needed. List values are comma-separated; " +
+			"dotted keys set entries in sections (e.g. platforms.youtube.resolution 480). " +
+			"The file is rewritten, so comments are not preserved.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := config.SetInFile(args[0], args[1])
+			if err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Set %s in %s", args[0], path))
+			return nil
+		},
+	}
+}
+
+func newConfigEditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "edit",
+		Short:         "Open the config file in $VISUAL/$EDITOR (creating a starter file if missing)",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := config.FilePath()
+			if err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				if err := writeStarterConfig(path); err != nil {
+					return &ExitError{Code: ExitCLIError, Err: err}
+				}
+			}
+			editor := os.Getenv("VISUAL")
+			if editor == "" {
+				editor = os.Getenv("EDITOR")
+			}
+			if editor == "" {
+				editor = "vi"
+				if runtime.GOOS == "windows" {
+					editor = "notepad"
+				}
+			}
+			// $EDITOR may carry arguments, e.g. "code --wait".
+			words, err := util.SplitArgs(editor)
+			if err != nil || len(words) == 0 {
+				return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("invalid editor %q", editor)}
+			}
+			ed := exec.Command(words[0], append(words[1:], path)...)
+			ed.Stdin, ed.Stdout, ed.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := ed.Run(); err != nil {
+				return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("editor: %w", err)}
+			}
+			return nil
+		},
+	}
+}
+
+func newConfigInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "init",
+		Short:         "Write a commented starter config file",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, _ := cmd.Flags().GetString("path")
+			if path == "" {
+				dir, err := dirs.ConfigDir()
+				if err != nil {
+					return &ExitError{Code: ExitCLIError, Err: err}
+				}
+				path = filepath.Join(dir, "config.yaml")
+			}
+			if force, _ := cmd.Flags().GetBool("force"); !force {
+				if _, err := os.Stat(path); err == nil {
+					return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("%s already exists (use --force to overwrite)", path)}
+				}
+			}
+			if err := writeStarterConfig(path); err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Wrote %s", path))
+			return nil
+		},
+	}
+	cmd.Flags().String("path", "", "Write to this path instead of the default config location")
+	cmd.Flags().Bool("force", false, "Overwrite an existing file")
+	return cmd
+}
+
+func newConfigPathCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "path",
+		Short:         "Print the config file location",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := config.FilePath()
+			if err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+			return nil
+		},
+	}
+}
+
+func writeStarterConfig(path string) error {
+	if err := dirs.Ensure(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(config.Starter), 0o644)
+}
+
+func formatConfigValue(v any) string {
+	switch t := v.(type) {
+	case string:
+		if t == "" {
+			return `""`
+		}
+		return t
+	case []string:
+		return "[" + strings.Join(t, ", ") + "]"
+	case map[string]any, []any:
+		b, err := json.Marshal(t)
+		if err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
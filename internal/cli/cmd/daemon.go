@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ig2wa/internal/daemon"
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+	"ig2wa/internal/util/deps"
+)
+
+func newDaemonCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Keep a worker pool running and take jobs from 'sniplette add' and 'run'",
+		Long: "Listens on a unix socket in the state directory. While it runs, `sniplette add <url>` (and `run`, unless --no-daemon) hands jobs to it " +
+			"and returns at once: the dependency checks happen once at daemon start, and no UI is started per job. " +
+			"Each job keeps the options of the command that submitted it. Stop it with Ctrl+C or `sniplette daemon stop`.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE:          runDaemon,
+	}
+	cmd.Flags().Int("workers", 2, "Jobs run at the same time")
+	cmd.Flags().String("listen", "", "Also take encodes and jobs from other machines' --encode-remote and --remote-workers on this address, e.g. :8765 (needs remote_token)")
+
+	status := &cobra.Command{
+		Use:           "status",
+		Short:         "List the daemon's jobs",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			resp, err := daemon.Send(daemon.Request{Op: daemon.OpStatus})
+			if err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			printDaemonJobs(cmd, resp.Jobs)
+			return nil
+		},
+	}
+	stop := &cobra.Command{
+		Use:           "stop",
+		Short:         "Stop the daemon, cancelling running jobs",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if _, err := daemon.Send(daemon.Request{Op: daemon.OpStop}); err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			return nil
+		},
+	}
+	cmd.AddCommand(status, stop)
+	return cmd
+}
+
+func newAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "add <url>...",
+		Short:         "Hand URLs to the running daemon",
+		Long:          "Submits the URLs, with the run flags given here, to `sniplette daemon` and returns without waiting. Fails if no daemon is running.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          urlArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := assembleBatch(cmd, args)
+			if err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			return sendToDaemon(cmd, in)
+		},
+	}
+	bindRunFlags(cmd.Flags())
+	return cmd
+}
+
+func runDaemon(cmd *cobra.Command, _ []string) error {
+	if daemon.Running() {
+		return &ExitError{Code: ExitCLIError, Err: errors.New(i18n.String("a daemon or single-instance TUI is already running"))}
+	}
+	if err := checkToolVersions(cmd, getPersistentString(cmd, "dl-binary", "")); err != nil {
+		return &ExitError{Code: ExitMissingDep, Err: err}
+	}
+	ffmpegPath, err := deps.FindFFmpeg()
+	if err != nil {
+		return &ExitError{Code: ExitMissingDep, Err: err}
+	}
+	workers, _ := cmd.Flags().GetInt("workers")
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	encodeErr := make(chan error, 1)
+	if listen, _ := cmd.Flags().GetString("listen"); listen != "" {
+		dlPath, err := deps.FindDownloader(getPersistentString(cmd, "dl-binary", ""))
+		if err != nil {
+			return &ExitError{Code: ExitMissingDep, Err: err}
+		}
+		es := &daemon.RemoteServer{DownloaderPath: dlPath, FFmpegPath: ffmpegPath, Token: viper.GetString("remote_token"), Workers: workers}
+		if es.Token == "" {
+			return &ExitError{Code: ExitCLIError, Err: errors.New(i18n.String("--listen needs a token: set remote_token in the config or SNIPLETTE_REMOTE_TOKEN"))}
+		}
+		go func() {
+			if err := es.ListenAndServe(ctx, listen); err != nil {
+				encodeErr <- err
+				cancel()
+			}
+		}()
+	}
+	srv := &daemon.Server{Workers: workers, Run: daemonJob(ffmpegPath), Grace: getPersistentDuration(cmd, "shutdown-grace", time.Minute)}
+	if err := srv.Serve(ctx); err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	select {
+	case err := <-encodeErr:
+		return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("remote encoding: %w", err)}
+	default:
+	}
+	if drain := util.DrainFrom(cmd.Context()); drain.Started() {
+		if unfinished := srv.Unfinished(); len(unfinished) > 0 {
+			return drained(drain, unfinished, true)
+		}
+	}
+	return nil
+}
+
+// daemonJob processes a submitted URL the way a --no-ui run would, minus the
+// per-run dependency checks done once at daemon start.
+func daemonJob(ffmpegPath string) daemon.RunFunc {
+	return func(ctx context.Context, rawURL string, opts model.CLIOptions, presetCRF int, jobID string) error {
+		opts.NoUI = true
+		opts.RemoteToken = viper.GetString("remote_token")
+		dlPath, err := deps.FindDownloader(opts.DLBinary)
+		if err != nil {
+			return err
+		}
+		if opts.Upload != "" {
+			if _, err := deps.FindRclone(); err != nil {
+				return err
+			}
+		}
+		urls := []string{rawURL}
+		if opts.Latest > 0 {
+			expanded, err := expandLatest(ctx, runInputs{URLs: urls, Options: opts})
+			if err != nil {
+				return err
+			}
+			urls = expanded.URLs
+		}
+		var archive *downloader.Archive
+		if opts.DownloadArchive != "" {
+			if archive, err = downloader.OpenArchive(opts.DownloadArchive); err != nil {
+				return i18n.Errorf("read download archive: %w", err)
+			}
+		}
+		in := runInputs{URLs: urls, Options: opts, PresetCRF: presetCRF}
+		var failed []string
+		for i, u := range urls {
+			if archive != nil && archive.Has(u, util.VideoIDFromURL(u)) {
+				slog.Info("skipping: already in the download archive", "url", u)
+				continue
+			}
+			id := jobID
+			if len(urls) > 1 {
+				id = fmt.Sprintf("%s.%d", jobID, i+1)
+			}
+			if _, err := processOne(ctx, i, id, in, dlPath, ffmpegPath, nil); err != nil {
+				var ee *ExitError
+				if errors.As(err, &ee) {
+					err = ee.Err
+				}
+				failed = append(failed, fmt.Sprintf("%s: %v", u, err))
+			}
+		}
+		if len(failed) > 0 {
+			return errors.New(strings.Join(failed, "; "))
+		}
+		return nil
+	}
+}
+
+// useDaemon reports whether a run should be handed to a running daemon, or
+// to a single-instance TUI: plain runs only, not previews, interactive runs
+// (unless --single-instance), or callers that need the results (--report,
+// queue run, schedule).
+func useDaemon(cmd *cobra.Command, in runInputs, mode runMode) bool {
+	if (mode.ForceTUI && !in.Options.SingleInstance) || mode.DryRunOnly || mode.Local || in.Options.DryRun || in.Options.PickFormat {
+		return false
+	}
+	if in.Report != nil || in.Options.Report != "" || in.Options.ProgressFile != "" || in.Options.ProgressWebhook != "" || runFlagBool(cmd, "no-daemon") {
+		return false
+	}
+	return daemon.Running()
+}
+
+// sendToDaemon submits in's URLs to the daemon and lists the jobs it queued.
+// Batch entries with flags of their own go in requests of their own.
+func sendToDaemon(cmd *cobra.Command, in runInputs) error {
+	var plain []string
+	var reqs []daemon.Request
+	for i, u := range in.URLs {
+		if jo := in.entry(i); jo != nil {
+			reqs = append(reqs, daemon.Request{Op: daemon.OpAdd, URLs: []string{u}, Options: absOptionPaths(jo.Options), PresetCRF: jo.PresetCRF})
+		} else {
+			plain = append(plain, u)
+		}
+	}
+	if len(plain) > 0 {
+		reqs = append([]daemon.Request{{Op: daemon.OpAdd, URLs: plain, Options: absOptionPaths(in.Options), PresetCRF: in.PresetCRF}}, reqs...)
+	}
+	var jobs []daemon.Job
+	where := i18n.String("the daemon")
+	for _, req := range reqs {
+		resp, err := daemon.Send(req)
+		if err != nil {
+			return &ExitError{Code: ExitCLIError, Err: err}
+		}
+		if resp.Instance == daemon.InstanceTUI {
+			where = i18n.String("the running TUI")
+		}
+		jobs = append(jobs, resp.Jobs...)
+	}
+	if !in.Options.Quiet {
+		for _, j := range jobs {
+			if j.State == daemon.StateFailed {
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Not queued: %s (%s)", j.URL, j.Error))
+				continue
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Queued on %s as job %d: %s", where, j.ID, j.URL))
+		}
+	}
+	return nil
+}
+
+// absOptionPaths makes the file paths in opts absolute, since the daemon
+// runs in its own working directory.
+func absOptionPaths(opts model.CLIOptions) model.CLIOptions {
+	abs := func(p string) string {
+		if p == "" {
+			return p
+		}
+		if a, err := filepath.Abs(p); err == nil {
+			return a
+		}
+		return p
+	}
+	opts.OutDir = abs(opts.OutDir)
+	opts.TempBase = abs(opts.TempBase)
+	opts.Intro = abs(opts.Intro)
+	opts.Outro = abs(opts.Outro)
+	opts.Source = abs(opts.Source)
+	opts.Cookies = abs(opts.Cookies)
+	opts.DownloadArchive = abs(opts.DownloadArchive)
+	if strings.ContainsRune(opts.DLBinary, filepath.Separator) {
+		opts.DLBinary = abs(opts.DLBinary)
+	}
+	return opts
+}
+
+func printDaemonJobs(cmd *cobra.Command, jobs []daemon.Job) {
+	w := cmd.OutOrStdout()
+	if len(jobs) == 0 {
+		fmt.Fprintln(w, i18n.String("The daemon has no jobs."))
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, i18n.String("ID\tSTATE\tADDED\tURL\tERROR"))
+	for _, j := range jobs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", j.ID, j.State, j.Added.Local().Format(time.TimeOnly), j.URL, j.Error)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/util/deps"
+)
+
+func newDepsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deps",
+		Short: "Manage sniplette's own copies of yt-dlp and ffmpeg",
+	}
+	cmd.AddCommand(newDepsInstallCmd())
+	cmd.AddCommand(newDepsUpdateCmd())
+	return cmd
+}
+
+func newDepsInstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "install",
+		Short:         "Download yt-dlp (and optionally a static ffmpeg) into the data dir",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			withFFmpeg, _ := cmd.Flags().GetBool("ffmpeg")
+			opts := deps.InstallOptions{
+				Force: force,
+				Progress: func(msg string) {
+					fmt.Fprintln(cmd.OutOrStdout(), msg)
+				},
+			}
+			if _, err := deps.InstallYTDLP(cmd.Context(), opts); err != nil {
+				return &ExitError{Code: ExitMissingDep, Err: err}
+			}
+			if withFFmpeg {
+				if _, err := deps.InstallFFmpeg(cmd.Context(), opts); err != nil {
+					return &ExitError{Code: ExitMissingDep, Err: err}
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("ffmpeg", false, "Also install a static ffmpeg/ffprobe build (Linux and Windows)")
+	cmd.Flags().Bool("force", false, "Re-download even if already installed")
+	return cmd
+}
+
+func newDepsUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "update",
+		Short:         "Update yt-dlp (re-download the managed copy, or run yt-dlp -U)",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			out := cmd.OutOrStdout()
+			dl, err := deps.FindDownloader(getPersistentString(cmd, "dl-binary", ""))
+			if err != nil {
+				return &ExitError{Code: ExitMissingDep, Err: err}
+			}
+			before, _ := deps.ToolVersion(cmd.Context(), dl, "--version")
+			opts := deps.InstallOptions{
+				Progress: func(msg string) {
+					fmt.Fprintln(out, msg)
+				},
+			}
+			if err := deps.UpdateDownloader(cmd.Context(), dl, opts); err != nil {
+				return &ExitError{Code: ExitMissingDep, Err: err}
+			}
+			if withFFmpeg, _ := cmd.Flags().GetBool("ffmpeg"); withFFmpeg {
+				opts.Force = true
+				if _, err := deps.InstallFFmpeg(cmd.Context(), opts); err != nil {
+					return &ExitError{Code: ExitMissingDep, Err: err}
+				}
+			}
+			after, _ := deps.ToolVersion(cmd.Context(), dl, "--version")
+			if before != "" && before == after {
+				fmt.Fprintln(out, i18n.Sprintf("yt-dlp is up to date (%s)", after))
+			} else if after != "" {
+				fmt.Fprintf(out, "yt-dlp %s -> %s\n", before, after)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("ffmpeg", false, "Also re-download the managed static ffmpeg/ffprobe build")
+	return cmd
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+
+	"ig2wa/internal/encoder"
+	"ig2wa/internal/model"
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/ui"
+	"ig2wa/internal/util/media"
+)
+
+func newDocsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate reference documentation",
+		Args:  cobra.NoArgs,
+	}
+	man := &cobra.Command{
+		Use:   "man",
+		Short: "Write man pages for every command and help topic",
+		Long: "Writes a section 1 man page per command (sniplette.1, sniplette-run.1, ...) and a section 7 page per help topic " +
+			"(sniplette-presets.7, ...) to --dir. View one with `man -l <file>`, or install them under a man1/ and man7/ directory on your MANPATH.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dir, _ := cmd.Flags().GetString("dir")
+			if err := ensureDir(dir); err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			root := cmd.Root()
+			root.DisableAutoGenTag = true // same pages for the same version
+			if err := doc.GenManTree(root, &doc.GenManHeader{Title: "SNIPLETTE", Section: "1", Source: "sniplette"}, dir); err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			for _, t := range root.Commands() {
+				if !t.IsAdditionalHelpTopicCommand() {
+					continue
+				}
+				if err := writeTopicMan(t, dir); err != nil {
+					return &ExitError{Code: ExitCLIError, Err: err}
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote man pages to %s\n", dir)
+			return nil
+		},
+	}
+	man.Flags().String("dir", "man", "Directory to write the pages to")
+	cmd.AddCommand(man)
+	return cmd
+}
+
+// writeTopicMan writes help topic t as dir/sniplette-<topic>.7.
+func writeTopicMan(t *cobra.Command, dir string) error {
+	f, err := os.Create(filepath.Join(dir, t.Root().Name()+"-"+t.Name()+".7"))
+	if err != nil {
+		return err
+	}
+	if err := doc.GenMan(t, &doc.GenManHeader{Title: strings.ToUpper(t.Root().Name() + "-" + t.Name()), Section: "7", Source: "sniplette"}, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// helpTopics are the extended reference pages shown by `sniplette help
+// <topic>`: commands without a Run, which cobra lists as additional help
+// topics. Their text is built from the tables the code uses, so it can't
+// drift from the behavior.
+func helpTopics() []*cobra.Command {
+	topic := func(name, short, long string) *cobra.Command {
+		return &cobra.Command{Use: name, Short: short, Long: long}
+	}
+	return []*cobra.Command{
+		topic("presets", "Quality, speed, and filter presets", presetsTopic()),
+		topic("targets", "Size targets for messaging apps and how size mode meets them", targetsTopic()),
+		topic("templates", "Placeholders of --organize folders and --on-success/--on-failure hooks", templatesTopic()),
+		topic("exit-codes", "What sniplette's exit codes mean", exitCodesTopic()),
+	}
+}
+
+func presetsTopic() string {
+	var b strings.Builder
+	b.WriteString("Quality presets (--quality-preset) set the long side of the output, the size target, and the CRF used with --max-size-mb 0:\n\n")
+	for _, q := range []model.QualityPreset{model.PresetLow, model.PresetMedium, model.PresetHigh} {
+		res, maxMB, crf := pipeline.PresetDefaults(q)
+		fmt.Fprintf(&b, "    %-8s %5d px  %4d MB  CRF %d\n", q, res, maxMB, crf)
+	}
+	b.WriteString("\n--resolution and --max-size-mb override a preset's values; videos are never upscaled.\n\n")
+	fmt.Fprintf(&b, "x264 speed presets (--x264-preset, default %s), fastest first; slower ones make smaller files at the same quality:\n\n    %s\n\n",
+		pipeline.DefaultX264Preset, strings.Join(encoder.X264Presets(), ", "))
+	fmt.Fprintf(&b, "H.264 profiles (--h264-profile, default %s): %s. Pixel formats (--pix-fmt, default %s): %s; only %s plays everywhere.\n\n",
+		pipeline.DefaultH264Profile, strings.Join(encoder.H264Profiles(), ", "), pipeline.DefaultPixFmt, strings.Join(encoder.PixFmts(), ", "), pipeline.DefaultPixFmt)
+	fmt.Fprintf(&b, "Filters: --denoise %s (before scaling), --sharpen %s (after scaling).",
+		strings.Join(encoder.DenoisePresets(), "|"), strings.Join(encoder.SharpenPresets(), "|"))
+	return b.String()
+}
+
+func targetsTopic() string {
+	var b strings.Builder
+	b.WriteString("In size mode (the default), sniplette picks the video bitrate that makes the whole clip fit --max-size-mb: " +
+		"the target minus the audio, spread over the (trimmed) duration. Peaks are capped at 1.25x that bitrate with a 2 s buffer, " +
+		"so short spikes don't push a file over an app's limit; --cbr holds the bitrate constant instead, for the strictest size control.\n\n")
+	fmt.Fprintf(&b, "The bitrate stays between %d and %d kbps: a long clip at a small target can come out larger than asked, "+
+		"and a short one smaller. `sniplette plan` shows the estimate before anything is downloaded.\n\n", pipeline.DefaultVideoMinKbps, pipeline.DefaultVideoMaxKbps)
+	b.WriteString("Size targets of the apps offered by `sniplette wizard`:\n\n")
+	for _, t := range ui.WizardTargets {
+		if t.MaxSizeMB == 0 {
+			fmt.Fprintf(&b, "    %-30s --max-size-mb 0\n", t.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "    %-30s --max-size-mb %d\n", t.Name, t.MaxSizeMB)
+	}
+	b.WriteString("\n--max-size-mb 0 switches to CRF mode: constant quality from the preset's CRF, whatever the size (see `sniplette help presets`).")
+	return b.String()
+}
+
+func templatesTopic() string {
+	placeholders := func(b *strings.Builder, ps []media.Placeholder) {
+		for _, p := range ps {
+			fmt.Fprintf(b, "    %-16s %s\n", "{"+p.Name+"}", p.Desc)
+		}
+	}
+	var b strings.Builder
+	b.WriteString(`--organize nests outputs in subfolders of the output directory. It takes "platform" ({platform}/{uploader}), "date" ({year}/{month}), or a template of these placeholders:` + "\n\n")
+	placeholders(&b, media.OrganizePlaceholders)
+	b.WriteString("\nEach folder name is made safe for the file system, so metadata can't lead outside the output directory. Example: --organize '{platform}/{upload_year}'.\n\n")
+	b.WriteString("--on-success and --on-failure commands are split like a shell command line (but not run by a shell) and these placeholders in their arguments are filled in, empty when unknown:\n\n")
+	placeholders(&b, pipeline.HookPlaceholders)
+	b.WriteString("\nThe same details are in the environment as SNIPLETTE_OUTPUT, SNIPLETTE_URL, and so on, plus SNIPLETTE_BYTES, SNIPLETTE_DURATION, and SNIPLETTE_JOB_ID. Example: --on-success 'rsync {output} nas:/videos/'.")
+	return b.String()
+}
+
+func exitCodesTopic() string {
+	codes := []struct {
+		code int
+		desc string
+	}{
+		{ExitOK, "success"},
+		{ExitCLIError, "invalid usage or CLI error"},
+		{ExitMissingDep, "missing dependency (yt-dlp/youtube-dl, ffmpeg, or rclone with --upload)"},
+		{ExitDownloadError, "download error"},
+		{ExitTranscodeError, "transcode error"},
+		{ExitTimeout, "a stage or job exceeded its time limit (--metadata-timeout, --download-timeout, --encode-timeout, --job-timeout)"},
+		{ExitPartialFailure, "some jobs failed and others succeeded (--keep-going, or the TUI, which always runs every job)"},
+		{ExitUploadError, "upload error (--upload); the local output is kept"},
+	}
+	var b strings.Builder
+	for _, c := range codes {
+		fmt.Fprintf(&b, "    %3d  %s\n", c.code, c.desc)
+	}
+	b.WriteString("    129  stopped by SIGHUP with URLs left unfinished (see --shutdown-grace)\n")
+	b.WriteString("    143  stopped by SIGTERM with URLs left unfinished\n\n")
+	b.WriteString("With several URLs, the plain (non-TUI) output stops at the first failure and exits with that job's code (--fail-fast, the default). " +
+		"With --keep-going, and always in the TUI, every URL is tried and the failures are listed at the end; " +
+		"if all of them failed, the exit code is the first failure's, and otherwise 6.")
+	return b.String()
+}
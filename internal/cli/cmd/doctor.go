@@ -1,30 +1,189 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"ig2wa/internal/diag"
+	"ig2wa/internal/i18n"
 	"ig2wa/internal/util/deps"
 )
 
 func newDoctorCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:           "doctor",
 		Short:         "Diagnose external tools (yt-dlp/youtube-dl, ffmpeg)",
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			dl, derr := deps.FindDownloader(getPersistentString(cmd, "dl-binary", ""))
+			ff, ferr := deps.FindFFmpeg()
+
+			// The bundle is most useful when something is broken, so build it first.
+			if bundle, _ := cmd.Flags().GetBool("bundle"); bundle {
+				path, _ := cmd.Flags().GetString("bundle-path")
+				if path == "" {
+					path = fmt.Sprintf("sniplette-debug-%s.tar.gz", time.Now().Format("20060102-150405"))
+				}
+				var depErrs []error
+				for _, e := range []error{derr, ferr} {
+					if e != nil {
+						depErrs = append(depErrs, e)
+					}
+				}
+				if err := diag.WriteBundle(cmd.Context(), diag.BundleOptions{
+					OutputPath:     path,
+					DownloaderPath: dl,
+					FFmpegPath:     ff,
+					DepErrors:      depErrs,
+					Config:         viper.AllSettings(),
+					ConfigFile:     viper.ConfigFileUsed(),
+					LogFile:        getPersistentString(cmd, "log-file", ""),
+				}); err != nil {
+					return &ExitError{Code: ExitCLIError, Err: err}
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Debug bundle: %s", path))
+			}
+
+			var checks []deps.Check
 			if derr != nil {
-				return &ExitError{Code: ExitMissingDep, Err: derr}
+				checks = append(checks, deps.Check{Tool: "downloader", Problems: []string{derr.Error()}})
+			} else {
+				checks = append(checks, deps.CheckDownloader(cmd.Context(), dl, time.Now()))
 			}
-			ff, ferr := deps.FindFFmpeg()
 			if ferr != nil {
-				return &ExitError{Code: ExitMissingDep, Err: ferr}
+				checks = append(checks, deps.Check{Tool: "ffmpeg", Problems: []string{ferr.Error()}})
+			} else {
+				checks = append(checks, deps.CheckFFmpeg(cmd.Context(), ff))
+			}
+
+			// Capability probe: which features work with this ffmpeg build
+			var features []deps.FeatureStatus
+			var hwaccels []string
+			if ferr == nil {
+				if caps, err := deps.ProbeFFmpeg(cmd.Context(), ff); err == nil {
+					features = caps.Evaluate()
+					hwaccels = caps.HWAccels
+				}
+			}
+
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				out := map[string]any{"checks": checks}
+				if features != nil {
+					out["ffmpeg_features"] = features
+					out["ffmpeg_hwaccels"] = hwaccels
+				}
+				if err := enc.Encode(out); err != nil {
+					return &ExitError{Code: ExitCLIError, Err: err}
+				}
+			} else {
+				printChecks(cmd, checks)
+				printFeatures(cmd, features, hwaccels)
+			}
+
+			for _, c := range checks {
+				if !c.OK {
+					return &ExitError{Code: ExitMissingDep, Err: fmt.Errorf("%s: %s", c.Tool, c.Problems[0])}
+				}
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "Downloader: %s\n", dl)
-			fmt.Fprintf(cmd.OutOrStdout(), "FFmpeg:    %s\n", ff)
 			return nil
 		},
 	}
-}
\ No newline at end of file
+	cmd.Flags().Bool("json", false, "Print results as JSON")
+	cmd.Flags().Bool("bundle", false, "Write a debug bundle (versions, redacted config, logs, last failure) for bug reports")
+	cmd.Flags().String("bundle-path", "", "Bundle output path (default: ./sniplette-debug-<timestamp>.tar.gz)")
+	return cmd
+}
+
+func printChecks(cmd *cobra.Command, checks []deps.Check) {
+	w := cmd.OutOrStdout()
+	for _, c := range checks {
+		label := i18n.String("Downloader:")
+		if c.Tool == "ffmpeg" {
+			label = i18n.String("FFmpeg:    ")
+		}
+		if c.Path == "" {
+			fmt.Fprintln(w, i18n.Sprintf("%s (not found)", label))
+		} else if c.Version != "" {
+			fmt.Fprintf(w, "%s %s (%s)\n", label, c.Path, c.Version)
+		} else {
+			fmt.Fprintf(w, "%s %s\n", label, c.Path)
+		}
+		for _, p := range c.Problems {
+			fmt.Fprintf(w, "  ✗ %s\n", p)
+		}
+		for _, warn := range c.Warnings {
+			fmt.Fprintf(w, "  ! %s\n", warn)
+		}
+	}
+}
+
+func printFeatures(cmd *cobra.Command, features []deps.FeatureStatus, hwaccels []string) {
+	if features == nil {
+		return
+	}
+	w := cmd.OutOrStdout()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, i18n.String("FFmpeg features:"))
+	for _, f := range features {
+		mark := "✓"
+		note := ""
+		if !f.Available {
+			mark = "✗"
+			note = i18n.Sprintf(" (missing: %s)", strings.Join(f.Missing, ", "))
+			if f.Required {
+				note += i18n.String(" [required]")
+			}
+		}
+		fmt.Fprintf(w, "  %s %s%s\n", mark, f.Name, note)
+	}
+	if len(hwaccels) > 0 {
+		fmt.Fprintln(w, i18n.Sprintf("  Hardware acceleration: %s", strings.Join(hwaccels, ", ")))
+	} else {
+		fmt.Fprintln(w, i18n.String("  Hardware acceleration: none"))
+	}
+}
+
+// checkToolVersions runs the startup version checks: problems fail the run,
+// warnings are logged. Lookup failures are left to the regular dependency path.
+// With --auto-update, an outdated downloader is updated before it is judged.
+func checkToolVersions(cmd *cobra.Command, dlBinary string) error {
+	if getPersistentBool(cmd, "skip-version-check", false) {
+		return nil
+	}
+	var checks []deps.Check
+	if dl, err := deps.FindDownloader(dlBinary); err == nil {
+		c := deps.CheckDownloader(cmd.Context(), dl, time.Now())
+		if c.Outdated && getPersistentBool(cmd, "auto-update", false) {
+			slog.Info("downloader is outdated; updating", "path", dl, "version", c.Version)
+			opts := deps.InstallOptions{Progress: func(msg string) { slog.Debug(msg) }}
+			if err := deps.UpdateDownloader(cmd.Context(), dl, opts); err != nil {
+				slog.Warn("auto-update failed: "+err.Error(), "path", dl)
+			} else {
+				c = deps.CheckDownloader(cmd.Context(), dl, time.Now())
+			}
+		}
+		checks = append(checks, c)
+	}
+	if ff, err := deps.FindFFmpeg(); err == nil {
+		checks = append(checks, deps.CheckFFmpeg(cmd.Context(), ff))
+	}
+	var errs []error
+	for _, c := range checks {
+		for _, w := range c.Warnings {
+			slog.Warn(c.Tool+": "+w, "path", c.Path)
+		}
+		for _, p := range c.Problems {
+			errs = append(errs, i18n.Errorf("%s: %s (run 'sniplette doctor' for details, or pass --skip-version-check)", c.Tool, p))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/model"
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/util"
+	"ig2wa/internal/util/deps"
+)
+
+// presetEstimate is the expected output of one quality preset in size mode.
+type presetEstimate struct {
+	Preset     string `json:"preset"`
+	LongSidePx int    `json:"long_side_px"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	EstBytes   int64  `json:"est_bytes,omitempty"` // 0 when the duration is unknown
+}
+
+// mediaInfo is what `sniplette info` reports.
+type mediaInfo struct {
+	downloader.YTDLPInfo
+	URL       string           `json:"url"`
+	Estimates []presetEstimate `json:"estimates"`
+}
+
+func newInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "info <url>",
+		Short:         "Show a URL's metadata and available formats without planning an encode",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rawURL := args[0]
+			dl, err := deps.FindDownloader(getPersistentString(cmd, "dl-binary", ""))
+			if err != nil {
+				return &ExitError{Code: ExitMissingDep, Err: err}
+			}
+			info, err := fetchInfo(cmd, dl, rawURL)
+			if err != nil {
+				return &ExitError{Code: exitCodeFor(err, ExitDownloadError), Err: err}
+			}
+
+			mi := mediaInfo{YTDLPInfo: info, URL: rawURL, Estimates: presetEstimates(rawURL, info)}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(mi)
+			}
+			printInfo(cmd.OutOrStdout(), mi)
+			return nil
+		},
+	}
+	cmd.Flags().Bool("json", false, "Print machine-readable JSON")
+	return cmd
+}
+
+// fetchInfo fetches rawURL's metadata with yt-dlp, using the same
+// per-platform source options (cookies, format) as a run.
+func fetchInfo(cmd *cobra.Command, dlPath, rawURL string) (downloader.YTDLPInfo, error) {
+	opts := pipeline.OptionsForURL(model.CLIOptions{
+		GeoBypass:          viper.GetString("geo_bypass"),
+		SourceAddress:      viper.GetString("source_address"),
+		Cookies:            viper.GetString("cookies"),
+		CookiesFromBrowser: viper.GetString("cookies_from_browser"),
+		Platforms:          platformOverrides(cmd),
+	}, rawURL)
+	return downloader.FetchInfo(cmd.Context(), rawURL, downloader.Options{
+		DownloaderPath:     dlPath,
+		Format:             opts.Format,
+		Cookies:            opts.Cookies,
+		CookiesFromBrowser: opts.CookiesFromBrowser,
+		GeoBypass:          opts.GeoBypass,
+		SourceAddress:      opts.SourceAddress,
+		ExtraArgs:          viper.GetStringSlice("dl_args"),
+		MetadataTimeout:    viper.GetDuration("metadata_timeout"),
+		MetadataCacheTTL:   metadataCacheTTL(),
+	})
+}
+
+// metadataCacheTTL is the metadata_cache_ttl setting for commands without
+// run flags.
+func metadataCacheTTL() time.Duration {
+	if viper.IsSet("metadata_cache_ttl") {
+		return viper.GetDuration("metadata_cache_ttl")
+	}
+	return time.Hour
+}
+
+// liveLabels describe yt-dlp's live_status values other than not_live.
+var liveLabels = map[string]string{
+	"is_live":     "live now (can't be snipped until it ends)",
+	"is_upcoming": "scheduled, not started yet",
+	"post_live":   "ended; the replay is still being processed",
+	"was_live":    "replay of a past stream",
+}
+
+// presetEstimates predicts the size-mode output of each quality preset: the
+// target size, or less when the bitrate cap is reached first.
+func presetEstimates(rawURL string, info downloader.YTDLPInfo) []presetEstimate {
+	dv := pipeline.AssumeShortsShape(model.DownloadedVideo{DurationSec: info.Duration, Width: info.Width, Height: info.Height, URL: rawURL})
+	var out []presetEstimate
+	for _, p := range []model.QualityPreset{model.PresetLow, model.PresetMedium, model.PresetHigh} {
+		res, maxMB, crf := pipeline.PresetDefaults(p)
+		longSide, _ := pipeline.PlanResolutionAndCRF(model.CLIOptions{Resolution: res}, dv, crf)
+		out = append(out, presetEstimate{
+			Preset:     string(p),
+			LongSidePx: longSide,
+			MaxSizeMB:  maxMB,
+			EstBytes:   pipeline.FormulaBytes(maxMB, viper.GetInt("audio_kbps"), info.Duration),
+		})
+	}
+	return out
+}
+
+func printInfo(w io.Writer, mi mediaInfo) {
+	fmt.Fprintln(w, i18n.Sprintf("Title:     %s", mi.Title))
+	fmt.Fprintln(w, i18n.Sprintf("Uploader:  %s", mi.Uploader))
+	fmt.Fprintln(w, i18n.Sprintf("ID:        %s", mi.ID))
+	fmt.Fprintln(w, i18n.Sprintf("URL:       %s", mi.URL))
+	fmt.Fprintln(w, i18n.Sprintf("Duration:  %s", planDuration(mi.Duration)))
+	if mi.Width > 0 && mi.Height > 0 {
+		fmt.Fprintln(w, i18n.Sprintf("Size:      %dx%d", mi.Width, mi.Height))
+	}
+	if l := liveLabels[mi.LiveStatus]; l != "" {
+		fmt.Fprintln(w, i18n.Sprintf("Live:      %s", l))
+	}
+
+	if len(mi.Formats) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, i18n.Sprintf("Formats (%d):", len(mi.Formats)))
+		for _, f := range mi.Formats {
+			fmt.Fprintf(w, "  %s\n", f.Label())
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, i18n.String("Estimated output per preset (size mode):"))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, e := range mi.Estimates {
+		est := "-"
+		if e.EstBytes > 0 {
+			est = "~" + util.HumanizeBytes(e.EstBytes)
+		}
+		fmt.Fprintf(tw, "  %s\t%dp\t≤%d MB\t%s\n", e.Preset, e.LongSidePx, e.MaxSizeMB, est)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ig2wa/internal/config"
+	"ig2wa/internal/i18n"
+)
+
+// A manifest (--file) describes a batch in YAML, JSON, or TOML: options for
+// the whole run under "defaults" and the jobs under "jobs", each a URL with
+// options of its own (or just a URL string). Option names are the config
+// file's keys (quality_preset, max_size_mb, trim, caption, out_dir, ...);
+// relative paths are resolved against the manifest's directory.
+//
+//	defaults:
+//	  quality_preset: medium
+//	  out_dir: snips
+//	jobs:
+//	  - url: https://youtu.be/AAA
+//	    max_size_mb: 16
+//	    trim: "0:05-0:35"
+//	  - https://youtu.be/BBB
+
+// manifestPathFlags hold file paths, resolved against the manifest's directory.
+var manifestPathFlags = map[string]bool{"out-dir": true, "intro": true, "outro": true, "cookies": true, "download-archive": true, "dl-binary": true}
+
+// manifestFlag is one option of a manifest as a flag.
+type manifestFlag struct {
+	Name  string
+	Value string
+}
+
+// readManifest loads the manifest at path, applies its defaults to cmd's
+// flags (flags given on the command line win), and returns its jobs.
+func readManifest(cmd *cobra.Command, path string) ([]urlSpec, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, i18n.Errorf("read manifest: %w", err)
+	}
+	dir := filepath.Dir(path)
+	defaults, err := manifestFlags(cmd, v.GetStringMap("defaults"), dir)
+	if err != nil {
+		return nil, i18n.Errorf("manifest defaults: %w", err)
+	}
+	if err := applyManifestDefaults(cmd, defaults); err != nil {
+		return nil, i18n.Errorf("manifest defaults: %w", err)
+	}
+
+	list, ok := v.Get("jobs").([]any)
+	if !ok || len(list) == 0 {
+		return nil, i18n.Errorf("manifest %s: no jobs", path)
+	}
+	specs := make([]urlSpec, 0, len(list))
+	for i, item := range list {
+		var job map[string]any
+		switch j := item.(type) {
+		case string:
+			job = map[string]any{"url": j}
+		case map[string]any:
+			job = j
+		default:
+			return nil, i18n.Errorf("manifest job %d: want a URL or a map of options", i+1)
+		}
+		url, _ := job["url"].(string)
+		if url = strings.TrimSpace(url); url == "" {
+			return nil, i18n.Errorf("manifest job %d: no url", i+1)
+		}
+		delete(job, "url")
+		flags, err := manifestFlags(cmd, job, dir)
+		if err != nil {
+			return nil, i18n.Errorf("manifest job %d (%s): %w", i+1, url, err)
+		}
+		spec := urlSpec{URL: url}
+		for _, f := range flags {
+			spec.Args = append(spec.Args, "--"+f.Name+"="+f.Value)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// manifestFlags turns manifest options into flags of the run command, in key
+// order. Lists become a repeated flag.
+func manifestFlags(cmd *cobra.Command, opts map[string]any, dir string) ([]manifestFlag, error) {
+	run := scratchRunCmd(cmd)
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var out []manifestFlag
+	for _, k := range keys {
+		name := strings.ReplaceAll(strings.ToLower(k), "_", "-")
+		switch {
+		case name == "input" || name == "file" || name == "log-level" || name == "log-file":
+			return nil, i18n.Errorf("%s can't be set in a manifest", k)
+		case run.Flags().Lookup(name) == nil && run.InheritedFlags().Lookup(name) == nil:
+			return nil, i18n.Errorf("unknown option %q", k)
+		}
+		var values []string
+		switch v := opts[k].(type) {
+		case []any:
+			for _, e := range v {
+				values = append(values, fmt.Sprint(e))
+			}
+		case map[string]any:
+			return nil, i18n.Errorf("option %q: want a value or a list", k)
+		case nil:
+		default:
+			values = []string{fmt.Sprint(v)}
+		}
+		for _, val := range values {
+			if manifestPathFlags[name] && val != "" && !filepath.IsAbs(val) && !strings.HasPrefix(val, "~") {
+				val = filepath.Join(dir, val)
+			}
+			out = append(out, manifestFlag{Name: name, Value: val})
+		}
+	}
+	return out, nil
+}
+
+// applyManifestDefaults sets the manifest's defaults on cmd's flags, except
+// those given on the command line. A profile is applied to the config.
+func applyManifestDefaults(cmd *cobra.Command, defaults []manifestFlag) error {
+	given := map[string]bool{}
+	for _, f := range defaults {
+		if _, ok := given[f.Name]; !ok {
+			given[f.Name] = cmd.Flags().Changed(f.Name)
+		}
+	}
+	for _, f := range defaults {
+		if given[f.Name] {
+			continue
+		}
+		if f.Name == "profile" {
+			if err := config.ApplyProfile(f.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := cmd.Flags().Set(f.Name, f.Value); err != nil {
+			return fmt.Errorf("%s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
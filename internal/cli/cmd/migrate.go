@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"ig2wa/internal/config"
+	"ig2wa/internal/i18n"
+)
+
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move config, data, and temp leftovers from the ig2wa name to sniplette",
+		Long: "Merges the ig2wa config, data, state, and cache directories into sniplette's (keeping sniplette's copy of anything both have), " +
+			"removes old $TMPDIR/ig2wa* temp dirs, and lists IG2WA_* environment variables with the SNIPLETTE_* names to use instead. " +
+			"Reports every change; run with --dry-run first to see them.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			items := config.Migrate(dryRun)
+			w := cmd.OutOrStdout()
+			if len(items) == 0 {
+				fmt.Fprintln(w, i18n.String("Nothing to migrate."))
+				return nil
+			}
+			move, remove := i18n.String("Moved"), i18n.String("Removed")
+			if dryRun {
+				move, remove = i18n.String("Would move"), i18n.String("Would remove")
+			}
+			failed := 0
+			for _, it := range items {
+				if it.Err != nil {
+					failed++
+					fmt.Fprintln(w, i18n.Sprintf("Failed to migrate %s: %v", it.From, it.Err))
+					continue
+				}
+				switch {
+				case it.Action == config.ActionManual:
+					fmt.Fprintln(w, i18n.Sprintf("%s is set: sniplette reads %s instead; rename it where you set it (shell profile, service file)", it.From, it.To))
+				case it.Action == config.ActionMoved:
+					fmt.Fprintf(w, "%s %s %s -> %s\n", move, it.Kind, it.From, it.To)
+				case it.Action == config.ActionRemoved:
+					fmt.Fprintln(w, i18n.Sprintf("%s old temp dir %s", remove, it.From))
+				case it.Kind == "temp":
+					fmt.Fprintln(w, i18n.Sprintf("Kept %s: modified within the last hour, so it may still be in use", it.From))
+				default:
+					fmt.Fprintln(w, i18n.Sprintf("Kept %s: %s already exists; compare them and merge by hand", it.From, it.To))
+				}
+			}
+			if failed > 0 {
+				return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("%d item(s) could not be migrated", failed)}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "List what would change without changing anything")
+	return cmd
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"ig2wa/internal/buildinfo"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/util/deps"
+)
+
+// noNoticeCmds are top-level commands that never print startup notices:
+// shell completion and generated docs are read by programs, and the daemon
+// runs unattended.
+var noNoticeCmds = map[string]bool{
+	"completion": true, cobra.ShellCompRequestCmd: true, cobra.ShellCompNoDescRequestCmd: true,
+	"help": true, "docs": true, "daemon": true,
+}
+
+// startupNotices prints, at most once per buildinfo.UpdateCheckInterval, a
+// line about a newer sniplette release and a warning when the downloader is
+// too old to work with Instagram. It stays silent with --quiet, when stderr
+// isn't a terminal, and with update_check: false.
+func startupNotices(cmd *cobra.Command) {
+	if !getPersistentBool(cmd, "update-check", true) || getPersistentBool(cmd, "quiet", false) ||
+		!term.IsTerminal(int(os.Stderr.Fd())) || noNoticeCmds[topLevelName(cmd)] {
+		return
+	}
+	now := time.Now()
+	if !buildinfo.UpdateCheckDue(now) {
+		return
+	}
+	w := cmd.ErrOrStderr()
+	current := buildinfo.Get().Version
+	if rel, err := buildinfo.LatestRelease(cmd.Context(), now); err == nil && buildinfo.Newer(rel.Version, current) {
+		fmt.Fprintln(w, i18n.Sprintf("A new sniplette release is available: %s (you have %s) %s", rel.Version, current, rel.URL))
+	}
+	if dl, err := deps.FindDownloader(getPersistentString(cmd, "dl-binary", "")); err == nil {
+		if c := deps.CheckDownloader(cmd.Context(), dl, now); c.Outdated && !c.OK {
+			fmt.Fprintln(w, i18n.Sprintf("WARNING: %s %s is known to be broken for Instagram (minimum %s); run 'sniplette deps update'",
+				strings.TrimSuffix(filepath.Base(dl), ".exe"), c.Version, deps.MinYTDLPVersion))
+		}
+	}
+}
+
+// topLevelName returns the name of the root's subcommand that cmd is, or is
+// under; "" for the root itself.
+func topLevelName(cmd *cobra.Command) string {
+	for cmd.HasParent() {
+		if !cmd.Parent().HasParent() {
+			return cmd.Name()
+		}
+		cmd = cmd.Parent()
+	}
+	return ""
+}
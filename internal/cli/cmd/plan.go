@@ -10,7 +10,7 @@ func newPlanCmd() *cobra.Command {
 		Short:         "Show a tiny plan (metadata-only) without executing",
 		SilenceUsage:  true,
 		SilenceErrors: true,
-		Args:          cobra.MinimumNArgs(1),
+		Args:          urlArgs,
 		PreRunE:       runPreRun,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runExecute(cmd, args, runMode{
@@ -21,5 +21,9 @@ func newPlanCmd() *cobra.Command {
 	}
 	// Reuse same flags; plan ignores actual encode
 	bindRunFlags(cmd.Flags())
+	cmd.Flags().Bool("json", false, "Print the plans as JSON")
+	cmd.Flags().Bool("show-commands", false, "Also print the exact yt-dlp and ffmpeg command lines")
+	cmd.Flags().Bool("formats", false, "Also list the source formats yt-dlp reports and mark the one --format picks")
+	cmd.Flags().Bool("sample", false, "Predict the output size by encoding three 5-second samples from the source stream")
 	return cmd
-}
\ No newline at end of file
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/util"
+)
+
+// renderPlans prints plans as JSON or as one aligned table with a column per
+// URL, so a batch can be compared side by side.
+func renderPlans(w io.Writer, plans []pipeline.Plan, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if plans == nil {
+			plans = []pipeline.Plan{}
+		}
+		return enc.Encode(plans)
+	}
+	if len(plans) == 0 {
+		return nil
+	}
+
+	// Tools are the same for every URL of a run; show them once.
+	if len(plans) == 1 {
+		fmt.Fprintln(w, i18n.String("Dry-run plan (1 URL)"))
+	} else {
+		fmt.Fprintln(w, i18n.Sprintf("Dry-run plan (%d URLs)", len(plans)))
+	}
+	fmt.Fprintln(w, i18n.Sprintf("Downloader: %s", plans[0].Downloader))
+	fmt.Fprintln(w, i18n.Sprintf("FFmpeg:     %s", plans[0].FFmpeg))
+	fmt.Fprintln(w)
+
+	rows := []struct {
+		label string
+		value func(p pipeline.Plan) string
+	}{
+		{"URL", func(p pipeline.Plan) string { return p.URL }},
+		{"Title", func(p pipeline.Plan) string { return truncate(p.Title, 40) }},
+		{"Duration", func(p pipeline.Plan) string {
+			if t := planTrim(p); t != "" {
+				return planDuration(p.DurationSec) + " (" + t + ")"
+			}
+			return planDuration(p.DurationSec)
+		}},
+		{"Source", planSource},
+		{"Uploaded", func(p pipeline.Plan) string { return orDash(p.UploadDate) }},
+		{"Output", func(p pipeline.Plan) string { return p.OutputPath }},
+		{"Mode", planMode},
+		{"Est. size", planEstimate},
+		{"Caption", func(p pipeline.Plan) string { return strings.ToUpper(p.Caption) }},
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, r := range rows {
+		cells := []string{r.label + ":"}
+		for _, p := range plans {
+			if p.Error != "" {
+				switch r.label {
+				case "URL":
+					cells = append(cells, p.URL)
+				case "Title":
+					cells = append(cells, "error: "+truncate(p.Error, 60))
+				default:
+					cells = append(cells, "-")
+				}
+				continue
+			}
+			cells = append(cells, r.value(p))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, p := range plans {
+		if len(p.Formats) > 0 {
+			if err := renderPlanFormats(w, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range plans {
+		if len(p.DownloadCmd) == 0 && len(p.FFmpegCmd) == 0 {
+			continue
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, i18n.Sprintf("Commands for %s:", p.URL))
+		if len(p.DownloadCmd) > 0 {
+			fmt.Fprintf(w, "  %s\n", util.ShellQuote(p.DownloadCmd[0], p.DownloadCmd[1:]))
+		}
+		if len(p.FFmpegCmd) > 0 {
+			fmt.Fprintf(w, "  %s\n", util.ShellQuote(p.FFmpegCmd[0], p.FFmpegCmd[1:]))
+		}
+	}
+	return nil
+}
+
+// renderPlanFormats lists p's source formats like yt-dlp -F, with "*" on the
+// ones the --format selector picks.
+func renderPlanFormats(w io.Writer, p pipeline.Plan) error {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, i18n.Sprintf("Formats for %s (* = picked by --format):", p.URL))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  \tID\tEXT\tRESOLUTION\tFPS\tVCODEC\tACODEC\tKBPS\tSIZE\tNOTE")
+	for _, f := range p.Formats {
+		mark, res, fps, kbps, size := " ", "audio only", "-", "-", "-"
+		if f.Selected {
+			mark = "*"
+		}
+		if f.Width > 0 && f.Height > 0 {
+			res = fmt.Sprintf("%dx%d", f.Width, f.Height)
+		} else if f.VCodec != "" {
+			res = "-"
+		}
+		if f.FPS > 0 {
+			fps = fmt.Sprintf("%.0f", f.FPS)
+		}
+		if f.Kbps > 0 {
+			kbps = fmt.Sprintf("%.0f", f.Kbps)
+		}
+		if f.Bytes > 0 {
+			size = util.HumanizeBytes(f.Bytes)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			mark, f.ID, f.Ext, res, fps, orDash(f.VCodec), orDash(f.ACodec), kbps, size, f.Note)
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// planSource describes the source format, e.g. "1920x1080 30fps h264/mp4a ~45 MB".
+func planSource(p pipeline.Plan) string {
+	var parts []string
+	if p.SourceWidth > 0 && p.SourceHeight > 0 {
+		parts = append(parts, fmt.Sprintf("%dx%d", p.SourceWidth, p.SourceHeight))
+	}
+	if p.SourceFPS > 0 {
+		parts = append(parts, fmt.Sprintf("%.0ffps", p.SourceFPS))
+	}
+	if p.SourceVCodec != "" || p.SourceACodec != "" {
+		parts = append(parts, shortCodec(p.SourceVCodec)+"/"+shortCodec(p.SourceACodec))
+	}
+	if p.SourceBytes > 0 {
+		parts = append(parts, "~"+util.HumanizeBytes(p.SourceBytes))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+// shortCodec trims a codec string to its family ("avc1.64001F" -> "avc1"),
+// or "-" when there's no such stream.
+func shortCodec(c string) string {
+	if c == "" {
+		return "-"
+	}
+	if i := strings.IndexByte(c, '.'); i > 0 {
+		return c[:i]
+	}
+	return c
+}
+
+func planMode(p pipeline.Plan) string {
+	switch p.Mode {
+	case "audio":
+		return fmt.Sprintf("audio only, AAC %d kbps", p.AudioKbps)
+	case "crf":
+		return fmt.Sprintf("%dp, CRF %d", p.LongSidePx, p.CRF)
+	default:
+		if p.VideoKbps > 0 {
+			return fmt.Sprintf("%dp, ≤%d MB (~%d kbps)", p.LongSidePx, p.TargetMB, p.VideoKbps)
+		}
+		return fmt.Sprintf("%dp, ≤%d MB", p.LongSidePx, p.TargetMB)
+	}
+}
+
+// planEstimate shows the sample-encode range when there is one, otherwise
+// the size implied by the bitrates.
+func planEstimate(p pipeline.Plan) string {
+	if e := p.Estimate; e != nil {
+		return fmt.Sprintf("%s – %s (sampled)", util.HumanizeBytes(e.LowBytes), util.HumanizeBytes(e.HighBytes))
+	}
+	if p.Mode == "crf" || p.DurationSec <= 0 {
+		return "-"
+	}
+	kbps := p.AudioKbps
+	if p.Mode == "size" {
+		kbps += p.VideoKbps
+	}
+	return "~" + util.HumanizeBytes(int64(float64(kbps)*1000/8*p.DurationSec))
+}
+
+func planDuration(sec float64) string {
+	if sec <= 0 {
+		return "-"
+	}
+	return util.FormatTimestamp(sec)
+}
+
+// planTrim describes a plan's trim range, or "" when the whole video is used.
+func planTrim(p pipeline.Plan) string {
+	if p.TrimStartSec <= 0 && p.TrimEndSec <= 0 && p.Chapter == "" {
+		return ""
+	}
+	end := "end"
+	if p.TrimEndSec > 0 {
+		end = util.FormatTimestamp(p.TrimEndSec)
+	}
+	if p.Chapter != "" {
+		return fmt.Sprintf("%q, %s-%s", p.Chapter, util.FormatTimestamp(p.TrimStartSec), end)
+	}
+	return util.FormatTimestamp(p.TrimStartSec) + "-" + end
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/queue"
+	"ig2wa/internal/util"
+)
+
+// maxQueueTries is how many runs a queued URL may fail before it is dropped.
+const maxQueueTries = 3
+
+func newQueueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Collect URLs now and snip them later in one run",
+		Long:  "Queues are kept in the state directory, so URLs can be added from any shell during the day and processed together with `sniplette queue run`, e.g. at night from cron. Use --queue to keep several named queues (default: \"default\").",
+	}
+	cmd.PersistentFlags().String("queue", queue.DefaultName, "Queue name")
+
+	add := &cobra.Command{
+		Use:           "add <url>...",
+		Short:         "Add URLs to the queue",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          runQueueAdd,
+	}
+	list := &cobra.Command{
+		Use:           "list",
+		Short:         "Show the queued URLs (every queue unless --queue is given)",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE:          runQueueList,
+	}
+	run := &cobra.Command{
+		Use:           "run",
+		Short:         "Snip every queued URL, continuing past failures",
+		Long:          fmt.Sprintf("Takes every URL off the queue and snips them as one run with the given flags, continuing past failures. Failed URLs go back on the queue for the next run and are dropped after %d failed runs. URLs added while the run is going wait for the next one.", maxQueueTries),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE:          runQueueRun,
+	}
+	bindRunFlags(run.Flags())
+	clear := &cobra.Command{
+		Use:           "clear",
+		Short:         "Empty the queue",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			name, err := queueName(cmd)
+			if err == nil {
+				err = queue.Clear(name)
+			}
+			if err != nil {
+				return &ExitError{Code: ExitCLIError, Err: err}
+			}
+			return nil
+		},
+	}
+	cmd.AddCommand(add, list, run, clear)
+	return cmd
+}
+
+// resumeQueue keeps the URLs a drain (SIGTERM, SIGHUP) left unfinished.
+const resumeQueue = "interrupted"
+
+// drained ends a run stopped by a drain with unfinished URLs, saving them to
+// resumeQueue when save is set. The exit code is the signal's (128 + its
+// number).
+func drained(d *util.Drain, unfinished []string, save bool) error {
+	msg := i18n.Sprintf("stopped with %d URL(s) unfinished", len(unfinished))
+	if save {
+		items := make([]queue.Item, len(unfinished))
+		for i, u := range unfinished {
+			items[i] = queue.Item{URL: u}
+		}
+		if err := queue.Add(resumeQueue, items...); err != nil {
+			slog.Error("could not save unfinished URLs", "err", err, "urls", strings.Join(unfinished, " "))
+		} else {
+			msg += i18n.Sprintf("; saved to queue %q (resume with: sniplette queue run --queue %s)", resumeQueue, resumeQueue)
+		}
+	}
+	return &ExitError{Code: d.ExitCode(), Err: errors.New(msg)}
+}
+
+func queueName(cmd *cobra.Command) (string, error) {
+	name, _ := cmd.Flags().GetString("queue")
+	return name, queue.CheckName(name)
+}
+
+func runQueueAdd(cmd *cobra.Command, args []string) error {
+	name, err := queueName(cmd)
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	queued, err := queue.List(name)
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	seen := make(map[string]bool, len(queued))
+	for _, it := range queued {
+		seen[util.SameVideoKey(it.URL)] = true
+	}
+	items := make([]queue.Item, 0, len(args))
+	for _, raw := range args {
+		raw = strings.TrimSpace(raw)
+		if util.IsShortLink(raw) {
+			if resolved, err := util.ResolveShortLink(cmd.Context(), raw); err == nil {
+				raw = resolved
+			} else {
+				slog.Warn("keeping the short link; it is resolved again when the queue runs", "err", err)
+			}
+		}
+		raw = util.CleanURL(raw)
+		if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("not a URL: %q", raw)}
+		}
+		key := util.SameVideoKey(raw)
+		if seen[key] {
+			if !getPersistentBool(cmd, "quiet", false) {
+				fmt.Fprintln(cmd.ErrOrStderr(), i18n.Sprintf("Skipping %s: already queued", raw))
+			}
+			continue
+		}
+		seen[key] = true
+		items = append(items, queue.Item{URL: raw})
+	}
+	if err := queue.Add(name, items...); err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	if !getPersistentBool(cmd, "quiet", false) {
+		n, _ := queue.List(name)
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Queued %d URL(s); %q now holds %d.", len(items), name, len(n)))
+	}
+	return nil
+}
+
+func runQueueList(cmd *cobra.Command, _ []string) error {
+	names := []string{}
+	if cmd.Flags().Changed("queue") {
+		name, err := queueName(cmd)
+		if err != nil {
+			return &ExitError{Code: ExitCLIError, Err: err}
+		}
+		names = append(names, name)
+	} else {
+		var err error
+		if names, err = queue.Names(); err != nil {
+			return &ExitError{Code: ExitCLIError, Err: err}
+		}
+	}
+	w := cmd.OutOrStdout()
+	shown := 0
+	for _, name := range names {
+		items, err := queue.List(name)
+		if err != nil {
+			return &ExitError{Code: ExitCLIError, Err: err}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		if shown > 0 {
+			fmt.Fprintln(w)
+		}
+		shown++
+		fmt.Fprintf(w, "%s (%d)\n", name, len(items))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, it := range items {
+			tries := ""
+			if it.Tries > 0 {
+				tries = i18n.Sprintf("failed %dx", it.Tries)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", it.Added.Local().Format("2006-01-02 15:04"), it.URL, tries)
+		}
+		tw.Flush()
+	}
+	if shown == 0 {
+		fmt.Fprintln(w, i18n.String("Nothing queued."))
+	}
+	return nil
+}
+
+// runQueueRun claims the queue, runs its URLs as a --keep-going run, and puts
+// the failed ones back.
+func runQueueRun(cmd *cobra.Command, _ []string) error {
+	name, err := queueName(cmd)
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	c, err := queue.Claim(name)
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	if len(c.Items) == 0 {
+		if !getPersistentBool(cmd, "quiet", false) {
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Queue %q is empty.", name))
+		}
+		return c.Done(nil, nil)
+	}
+	// Short links that couldn't be resolved when queued are tried again; one
+	// that still fails counts as a failed job.
+	status := make(map[string]string)
+	jobURL := make([]string, len(c.Items))
+	var urls []string
+	for i, it := range c.Items {
+		jobURL[i] = it.URL
+		if util.IsShortLink(it.URL) {
+			resolved, err := util.ResolveShortLink(cmd.Context(), it.URL)
+			if err != nil {
+				slog.Error("queued URL failed", "err", err)
+				status[it.URL] = "failure"
+				continue
+			}
+			jobURL[i] = resolved
+		}
+		urls = append(urls, jobURL[i])
+	}
+	_, opts, presetCRF, err := assembleRunInputs(cmd, urls)
+	if err != nil {
+		_ = c.Done(nil, c.Items)
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	in := runInputs{URLs: urls, Options: opts, PresetCRF: presetCRF, Report: pipeline.NewReport()}
+	in.Options.KeepGoing = true
+	cmd.SetContext(context.WithValue(cmd.Context(), runInputsKey, in))
+	var runErr error
+	if len(urls) > 0 {
+		runErr = runExecute(cmd, urls, runMode{Local: true, CallerResumes: true})
+	}
+
+	// URLs without a job were skipped (archive, --latest) unless the run
+	// stopped early.
+	for _, j := range in.Report.Jobs() {
+		status[j.URL] = j.Status
+	}
+	interrupted := cmd.Context().Err() != nil || util.DrainFrom(cmd.Context()).Started()
+	var ee *ExitError
+	if errors.As(runErr, &ee) && (ee.Code == ExitMissingDep || ee.Code == ExitCLIError) && len(in.Report.Jobs()) == 0 {
+		interrupted = true // nothing ran; keep the queue as it was
+	}
+	var failed, unfinished []queue.Item
+	for i, it := range c.Items {
+		switch st, ok := status[jobURL[i]]; {
+		case ok && st != "success" && it.Tries+1 >= maxQueueTries:
+			slog.Warn("dropping queued URL after repeated failures", "url", it.URL, "tries", it.Tries+1)
+		case ok && st != "success":
+			failed = append(failed, it)
+		case !ok && interrupted:
+			unfinished = append(unfinished, it)
+		}
+	}
+	if err := c.Done(failed, unfinished); err != nil {
+		slog.Error("could not update queue", "queue", name, "err", err)
+	}
+	return runErr
+}
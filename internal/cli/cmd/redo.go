@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"ig2wa/internal/history"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/util"
+)
+
+// redoListLen is how many recent entries `redo` lists without an ID.
+const redoListLen = 20
+
+func newRedoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "redo [history-id]",
+		Short:         "Snip a video from the history again with new settings",
+		Long:          "Runs a job from the history file again with the options given now, e.g. `sniplette redo 3f9c2a1b --quality-preset high --max-size-mb 16`. The stored URL and tags are used, and so is the downloaded source if the job kept it (--keep-temp) and it is still there; otherwise the video is downloaded again. Without an ID, lists the most recent entries and their IDs (only those with every --tag given).",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          runRedo,
+	}
+	bindRunFlags(cmd.Flags())
+	return cmd
+}
+
+func runRedo(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		entries, err := history.Load()
+		if err != nil {
+			return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("read history: %w", err)}
+		}
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		for _, t := range tags {
+			entries = history.WithTag(entries, t)
+		}
+		printRecentHistory(cmd.OutOrStdout(), entries)
+		return nil
+	}
+	e, err := history.Find(args[0])
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+
+	urls, opts, presetCRF, err := assembleRunInputs(cmd, []string{e.URL})
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	if e.SourcePath != "" {
+		if util.FileSize(e.SourcePath) > 0 {
+			opts.Source = e.SourcePath
+			slog.Info("reusing the kept source", "path", e.SourcePath)
+		} else {
+			slog.Info("kept source is gone; downloading again", "path", e.SourcePath)
+		}
+	}
+	if !cmd.Flags().Changed("tag") {
+		opts.Tags = e.Tags
+	}
+	opts.Latest = 0 // the entry is a single video
+	in := runInputs{URLs: urls, Options: opts, PresetCRF: presetCRF}
+	cmd.SetContext(context.WithValue(cmd.Context(), runInputsKey, in))
+	return runExecute(cmd, urls, runMode{})
+}
+
+// printRecentHistory lists the last redoListLen entries, newest first.
+func printRecentHistory(w io.Writer, entries []history.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, i18n.String("The history is empty."))
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, i18n.String("ID\tTIME\tSTATUS\tSOURCE\tTAGS\tVIDEO"))
+	for i := len(entries) - 1; i >= 0 && i >= len(entries)-redoListLen; i-- {
+		e := entries[i]
+		src := "-"
+		if e.SourcePath != "" && util.FileSize(e.SourcePath) > 0 {
+			src = "kept"
+		}
+		video := e.Title
+		if video == "" {
+			video = e.URL
+		}
+		tags := strings.Join(e.Tags, ",")
+		if tags == "" {
+			tags = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.ID(), e.Time.Local().Format("2006-01-02 15:04"), e.Status, src, tags, video)
+	}
+	tw.Flush()
+}
@@ -2,15 +2,22 @@ package cmd
 
 import (
 	"context"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"ig2wa/internal/buildinfo"
 	"ig2wa/internal/config"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/logging"
+	"ig2wa/internal/pipeline"
 )
 
 const (
@@ -19,6 +26,9 @@ const (
 	ExitMissingDep     = 2
 	ExitDownloadError  = 3
 	ExitTranscodeError = 4
+	ExitTimeout        = 5
+	ExitPartialFailure = 6
+	ExitUploadError    = 7
 )
 
 // ExitError wraps an error with a process exit code.
@@ -36,13 +46,18 @@ func (e *ExitError) Error() string {
 
 func newRootCmd() *cobra.Command {
 	root := &cobra.Command{
-		Use:           "sniplette [urls...]",
-		Short:         "Tiny video helper for snack-sized clips",
-		Long:          "Sniplette is a tiny video helper that turns large Instagram and YouTube videos into small, shareable clips. Give it a link, and Sniplette will fetch → transcode → compress → and hand you a neat little 'snip' perfect for messaging apps, chats, and social platforms.",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		Args:          cobra.MinimumNArgs(1), // preserve current behavior: requires at least one URL
+		Use:               "sniplette [urls...]",
+		Version:           buildinfo.Get().Version,
+		Short:             "Tiny video helper for snack-sized clips",
+		Long:              "Sniplette is a tiny video helper that turns large Instagram, YouTube, Facebook, and Reddit videos into small, shareable clips. Give it a link, and Sniplette will fetch → transcode → compress → and hand you a neat little 'snip' perfect for messaging apps, chats, and social platforms.",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		Args:              rootArgs,
+		PersistentPreRunE: persistentPreRun,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !batchFileGiven(cmd) {
+				return runWizard(cmd, "")
+			}
 			// Default to the same behavior as the old CLI when no subcommand is specified.
 			return runExecute(cmd, args, runMode{
 				ForceTUI:   false,
@@ -51,14 +66,8 @@ func newRootCmd() *cobra.Command {
 		},
 	}
 
-	// Default output directory is current directory for better UX
-	defaultOut := "."
-
 	// Persistent flags available to all subcommands
-	root.PersistentFlags().StringP("out-dir", "o", defaultOut, "Output directory")
-	root.PersistentFlags().BoolP("verbose", "v", false, "Show full subprocess commands/output")
-	root.PersistentFlags().String("dl-binary", "", "Path to yt-dlp or youtube-dl")
-	root.PersistentFlags().Int("jobs", 2, "Max concurrent jobs in TUI")
+	bindPersistentFlags(root.PersistentFlags())
 
 	// Also bind run-specific flags on root, so `sniplette <url>` continues to work.
 	bindRunFlags(root.Flags())
@@ -70,9 +79,24 @@ func newRootCmd() *cobra.Command {
 	// Subcommands
 	root.AddCommand(newRunCmd())
 	root.AddCommand(newPlanCmd())
+	root.AddCommand(newInfoCmd())
 	root.AddCommand(newTuiCmd())
+	root.AddCommand(newWizardCmd())
+	root.AddCommand(newScheduleCmd())
+	root.AddCommand(newRedoCmd())
+	root.AddCommand(newStatsCmd())
+	root.AddCommand(newQueueCmd())
+	root.AddCommand(newDaemonCmd())
+	root.AddCommand(newAddCmd())
 	root.AddCommand(newDoctorCmd())
+	root.AddCommand(newVersionCmd())
+	root.AddCommand(newDepsCmd())
+	root.AddCommand(newConfigCmd())
+	root.AddCommand(newCleanCmd())
+	root.AddCommand(newMigrateCmd())
 	root.AddCommand(newCompletionCmd())
+	root.AddCommand(newDocsCmd())
+	root.AddCommand(helpTopics()...)
 
 	// Initialize Viper configuration (env, config file, and defaults)
 	_ = config.Init(root)
@@ -80,23 +104,153 @@ func newRootCmd() *cobra.Command {
 	return root
 }
 
+// rootArgs requires at least one URL, except in a terminal, where running
+// without any starts the wizard.
+func rootArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && interactive() {
+		return nil
+	}
+	return urlArgs(cmd, args)
+}
+
+func bindPersistentFlags(fs *pflag.FlagSet) {
+	fs.StringP("out-dir", "o", "", "Output directory (default: "+config.DefaultOutDir()+")")
+	fs.BoolP("verbose", "v", false, "Show full subprocess commands/output")
+	fs.String("dl-binary", "", "Path to yt-dlp or youtube-dl")
+	fs.Int("jobs", 2, "Max concurrent jobs in TUI; 0 adapts to CPU load and download throughput")
+	fs.Int("max-jobs", runtime.NumCPU(), "Upper bound on concurrent jobs when --jobs 0")
+	fs.Duration("shutdown-grace", time.Minute, "On SIGTERM/SIGHUP, let running jobs finish for this long before canceling them (0 = cancel at once)")
+	fs.BoolP("quiet", "q", false, "Only print errors")
+	fs.String("log-level", "warn", "Log level: error, warn, info, debug")
+	fs.String("log-file", "", "Also write debug-level logs to this file")
+	fs.Bool("skip-version-check", false, "Skip yt-dlp/ffmpeg version checks at startup")
+	fs.Bool("auto-update", false, "Update yt-dlp before running when it is stale or below the minimum version")
+	fs.String("profile", "", "Named option profile from the config file (profiles.<name>)")
+	fs.String("lang", "", "Language of messages, e.g. en or ms (default: from LC_ALL/LC_MESSAGES/LANG)")
+}
+
 func bindRunFlags(fs *pflag.FlagSet) {
+	fs.StringP("file", "f", "", "Run the batch described in a YAML or JSON manifest (jobs with their own options)")
+	fs.String("input", "", "Read URLs from a file (- = stdin), one per line, each optionally followed by its own flags")
 	fs.Int("max-size-mb", 50, "Target max size per video (MB). Set 0 to use CRF mode.")
+	fs.Bool("cbr", false, "In size mode, encode at a constant bitrate (strictest size control, some quality cost)")
 	fs.String("quality-preset", "medium", "Quality preset: low, medium, high")
 	fs.Int("resolution", 0, "Override long-side resolution in px (e.g., 540, 720, 1080); 0 uses preset default")
+	fs.String("denoise", "off", "Denoise before scaling: off, light, medium, strong (strong is slow)")
+	fs.String("sharpen", "off", "Sharpen after scaling: off, light, medium")
+	fs.String("x264-preset", pipeline.DefaultX264Preset, "x264 speed preset, ultrafast to veryslow (slower = smaller at the same quality)")
+	fs.String("h264-profile", pipeline.DefaultH264Profile, "H.264 profile: baseline, main, high (high10/high422/high444 for --pix-fmt)")
+	fs.Int("keyint", pipeline.DefaultKeyInt, "Frames between keyframes (GOP size)")
+	fs.String("pix-fmt", pipeline.DefaultPixFmt, "Output pixel format: yuv420p, yuv420p10le, yuv422p, yuv444p (only yuv420p plays everywhere)")
+	fs.Int("audio-kbps", pipeline.DefaultAudioKbps, "AAC audio bitrate in kbps (32-320)")
 	fs.Bool("audio-only", false, "Extract audio only (M4A)")
-	fs.String("caption", "txt", "Caption output: txt, none")
+	fs.StringSlice("emit", nil, "Outputs to make from each download, main one first (mp4, audio, gif, thumb); default: mp4")
+	fs.String("caption", "txt", "Caption output: txt (sidecar file), embed (into the output's metadata), both, none")
 	fs.Bool("keep-temp", false, "Keep intermediate downloads")
+	fs.String("trim", "", "Only encode part of the video: START-END, e.g. 1:05-1:30, or 2:00- to run to the end")
+	fs.String("chapter", "", "Only encode one chapter, by number (1 = first) or title")
+	fs.Float64("fade", 0, "Fade video and audio in and out over this many seconds, e.g. 0.5 (0 = none)")
+	fs.String("poster-at", "", "Use the frame at this clip position (e.g. 0:03) as the preview image chat apps show")
+	fs.String("intro", "", "Video clip to put before every snip (scaled to fit; e.g. a branded bumper)")
+	fs.String("outro", "", "Video clip to put after every snip")
 	fs.Bool("dry-run", false, "Show plan without executing") // deprecated in favor of 'plan'
 	fs.Bool("no-ui", false, "Disable TUI; use plain textual output")
+	fs.Bool("no-thumbnails", false, "Disable inline thumbnails in the TUI")
+	fs.Bool("accessible", false, "Screen-reader friendly output: no spinners or progress bars, status as plain lines")
+	fs.StringSlice("post-process", nil, "After-encode steps to run in order (caption, thumbnail); default: caption")
+	fs.Bool("keep-going", false, "Continue with the remaining URLs after a failure and summarize at the end")
+	fs.String("on-duplicate", "wait", "When another sniplette job is already snipping the same video: wait for it, or skip this one")
+	fs.String("priority", "normal", "Queue priority: low, normal, high (high jobs start before the rest of the batch)")
+	fs.StringSlice("tag", nil, "Label the jobs (repeatable, e.g. --tag familia); stored in the history and report")
+	fs.String("report", "", "Write a report of every job when the run ends: json, csv, or md (to the output dir), or a file path")
+	fs.String("progress-file", "", "Append every job's progress events to this file as JSON lines")
+	fs.String("progress-webhook", "", "POST job progress updates and results to this URL as JSON")
+	fs.Bool("fail-fast", false, "Stop at the first failed URL (the default)")
+	fs.Bool("pick-format", false, "Pick the source format per job in the TUI before downloading")
+	fs.String("temp-dir", "auto", "Where job workdirs go: auto, cache, output (next to the outputs), or a path")
+	fs.String("organize", "", "Nest outputs in subfolders: platform ({platform}/{uploader}), date ({year}/{month}), or a template")
+	fs.Bool("name-date", false, "Start output file names with the video's upload date (YYYYMMDD)")
+	fs.String("backend", "", "Downloader backend for all URLs (yt-dlp, gallery-dl, http); overrides per-platform config")
+	fs.String("cookies", "", "Cookies file (Netscape format) for yt-dlp, for videos that need a login, such as most Facebook videos")
+	fs.String("cookies-from-browser", "", "Load yt-dlp's cookies from a browser (firefox, chrome, safari, ...), for videos that need a login")
+	fs.StringArray("dl-args", nil, "Extra yt-dlp arguments, appended after generated ones and before the URL (repeatable)")
+	fs.StringArray("ffmpeg-args", nil, "Extra ffmpeg output arguments, appended just before the output file (repeatable)")
+	fs.Int("nice", 0, "Run ffmpeg at lower CPU priority: niceness 1-19 (0 = normal)")
+	fs.Int("threads", 0, "Limit ffmpeg to this many threads (0 = ffmpeg default)")
+	fs.StringSlice("encode-remote", nil, "Encode on other machines' 'sniplette daemon --listen', e.g. http://server:8765; several (and local) share the batch (token: remote_token)")
+	fs.StringSlice("remote-workers", nil, "Run whole jobs on other machines' 'sniplette daemon --listen', e.g. http://server:8765; several (and local) share the batch (token: remote_token)")
+	fs.Bool("force-encode", false, "Always re-encode, even when the source is already H.264/AAC within the size and resolution targets")
+	fs.Duration("metadata-timeout", 2*time.Minute, "Give up on a metadata fetch after this long (0 = no limit)")
+	fs.Duration("download-timeout", 0, "Give up on a download after this long (0 = no limit)")
+	fs.Duration("encode-timeout", 0, "Give up on an encode after this long (0 = no limit)")
+	fs.Duration("metadata-cache-ttl", time.Hour, "Reuse video metadata fetched within this long instead of asking the site again (0 = always fetch)")
+	fs.Duration("wait-live", 0, "Wait up to this long for a live or upcoming YouTube stream to end instead of failing (0 = don't wait)")
+	fs.Int("source-cache-mb", 0, "Keep downloaded originals in a cache of this size (MB) so re-encoding a video skips the download (0 = off)")
+	fs.Duration("job-timeout", 0, "Give up on a whole job (all stages) after this long (0 = no limit)")
+	fs.String("upload", "", "Upload each output with rclone: s3://bucket/prefix or an rclone remote (name:path)")
+	fs.String("on-success", "", "Command to run after each successful job, e.g. 'mv {output} /mnt/nas/' (details also in SNIPLETTE_* env vars)")
+	fs.String("on-failure", "", "Command to run after each failed job, e.g. 'notify-send failed {url}'")
+	fs.Duration("hook-timeout", 5*time.Minute, "Stop an --on-success/--on-failure command after this long (0 = no limit)")
+	fs.Int("latest", 0, "Snip the newest N videos of channel, profile, or playlist URLs, skipping ones already in the history")
+	fs.String("since", "", "With --latest or schedule: only videos published on or after a date (2024-01-31) or within a period (7d, 48h)")
+	fs.Duration("min-duration", 0, "With --latest or schedule: skip videos shorter than this, e.g. 30s")
+	fs.Duration("max-duration", 0, "With --latest or schedule: skip videos longer than this, e.g. 10m")
+	fs.String("match-title", "", "With --latest or schedule: only videos whose title matches this regular expression ((?i) for any case)")
+	fs.String("download-archive", "", "yt-dlp download archive file: skip videos listed in it and add the ones snipped")
+	fs.Bool("no-history", false, "Don't record finished jobs in the history file")
+	fs.Bool("no-daemon", false, "Run here even when 'sniplette daemon' is running")
+	fs.Bool("single-instance", false, "Let the TUI take the URLs of later launches, and hand this launch's URLs to a TUI already running")
 }
 
 // Execute runs the CLI with the provided context.
 func Execute(ctx context.Context) error {
+	// Sensible console logging until flags/config are parsed.
+	_ = logging.Setup(logging.Options{Level: slog.LevelWarn})
+	defer logging.Close()
 	root := newRootCmd()
 	return root.ExecuteContext(ctx)
 }
 
+// persistentPreRun applies the selected config profile, then sets up logging
+// (so a profile can also choose the log level) and the message language, and
+// prints startup notices.
+func persistentPreRun(cmd *cobra.Command, args []string) error {
+	if name := getPersistentString(cmd, "profile", ""); name != "" {
+		if err := config.ApplyProfile(name); err != nil {
+			return &ExitError{Code: ExitCLIError, Err: err}
+		}
+	}
+	if err := setupLogging(cmd, args); err != nil {
+		return err
+	}
+	if _, err := i18n.Setup(getPersistentString(cmd, "lang", "")); err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	startupNotices(cmd)
+	return nil
+}
+
+// setupLogging configures slog from --quiet/--log-level/--log-file (and config).
+// --verbose implies debug unless a level is given explicitly.
+func setupLogging(cmd *cobra.Command, _ []string) error {
+	levelName := getPersistentString(cmd, "log-level", "warn")
+	level, err := logging.ParseLevel(levelName)
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	if getPersistentBool(cmd, "verbose", false) && !persistentSet(cmd, "log-level", "log_level") {
+		level = slog.LevelDebug
+	}
+	if err := logging.Setup(logging.Options{
+		Level:   level,
+		Quiet:   getPersistentBool(cmd, "quiet", false),
+		LogFile: getPersistentString(cmd, "log-file", ""),
+	}); err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	return nil
+}
+
 // Helpers
 func getPersistentString(cmd *cobra.Command, name, def string) string {
 	// Flag value takes precedence if explicitly set
@@ -114,6 +268,18 @@ func getPersistentString(cmd *cobra.Command, name, def string) string {
 	return def
 }
 
+// persistentSet reports whether a persistent option was given by flag, env, or config.
+func persistentSet(cmd *cobra.Command, flagName, key string) bool {
+	if f := cmd.Flags().Lookup(flagName); f != nil && f.Changed {
+		return true
+	}
+	if f := cmd.InheritedFlags().Lookup(flagName); f != nil && f.Changed {
+		return true
+	}
+	_, inEnv := os.LookupEnv("SNIPLETTE_" + strings.ToUpper(key))
+	return inEnv || viper.InConfig(key)
+}
+
 func getPersistentBool(cmd *cobra.Command, name string, def bool) bool {
 	if f := cmd.InheritedFlags().Lookup(name); f != nil && f.Changed {
 		v, _ := cmd.InheritedFlags().GetBool(name)
@@ -138,9 +304,21 @@ func getPersistentInt(cmd *cobra.Command, name string, def int) int {
 	return def
 }
 
+func getPersistentDuration(cmd *cobra.Command, name string, def time.Duration) time.Duration {
+	if f := cmd.InheritedFlags().Lookup(name); f != nil && f.Changed {
+		v, _ := cmd.InheritedFlags().GetDuration(name)
+		return v
+	}
+	key := strings.ReplaceAll(name, "-", "_")
+	if viper.IsSet(key) {
+		return viper.GetDuration(key)
+	}
+	return def
+}
+
 func ensureDir(path string) error {
 	if path == "" {
 		path = "."
 	}
 	return os.MkdirAll(filepath.Clean(path), 0o755)
-}
\ No newline at end of file
+}
@@ -4,18 +4,35 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"golang.org/x/term"
 
+	"ig2wa/internal/config"
+	"ig2wa/internal/dirs"
 	"ig2wa/internal/downloader"
 	"ig2wa/internal/encoder"
+	"ig2wa/internal/history"
+	"ig2wa/internal/i18n"
 	"ig2wa/internal/model"
 	"ig2wa/internal/pipeline"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/remote"
 	"ig2wa/internal/ui"
+	"ig2wa/internal/uploader"
 	"ig2wa/internal/util"
 	"ig2wa/internal/util/deps"
 	"ig2wa/internal/util/media"
@@ -24,6 +41,10 @@ import (
 type runMode struct {
 	ForceTUI   bool
 	DryRunOnly bool
+	Local      bool // Never hand the run to a daemon (see useDaemon)
+	// The caller resumes the URLs a drain leaves unfinished itself (queue
+	// run, schedule) rather than having them saved to resumeQueue
+	CallerResumes bool
 }
 
 func newRunCmd() *cobra.Command {
@@ -32,7 +53,7 @@ func newRunCmd() *cobra.Command {
 		Short:         "Run fetch/encode pipeline for tiny snips",
 		SilenceUsage:  true,
 		SilenceErrors: true,
-		Args:          cobra.MinimumNArgs(1),
+		Args:          urlArgs,
 		PreRunE:       runPreRun,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runExecute(cmd, args, runMode{
@@ -56,72 +77,292 @@ type runInputs struct {
 	URLs      []string
 	Options   model.CLIOptions
 	PresetCRF int
+	Report    *pipeline.Report // Collects finished jobs (for --report or the caller); nil = none
+	TagOutput bool             // Several jobs run at once: start their output lines with the job ID
+
+	// Options of batch entries with flags of their own, by index in URLs;
+	// nil = the batch's (see forEntry). Nil when no entry has flags.
+	Entries []*jobOptions
 }
 
 func runPreRun(cmd *cobra.Command, args []string) error {
-	urls, opts, presetCRF, err := assembleRunInputs(cmd, args)
+	in, err := assembleBatch(cmd, args)
 	if err != nil {
 		return &ExitError{Code: ExitCLIError, Err: err}
 	}
-	ctx := context.WithValue(cmd.Context(), runInputsKey, runInputs{
-		URLs:      urls,
-		Options:   opts,
-		PresetCRF: presetCRF,
-	})
-	cmd.SetContext(ctx)
+	cmd.SetContext(context.WithValue(cmd.Context(), runInputsKey, in))
 	return nil
 }
 
 func assembleRunInputs(cmd *cobra.Command, args []string) ([]string, model.CLIOptions, int, error) {
 	// Persistent flags with precedence: flag > env/config > default
-	defaultOut := "."
-	outDir := getPersistentString(cmd, "out-dir", defaultOut)
+	outDir := getPersistentString(cmd, "out-dir", "")
+	if outDir == "" {
+		outDir = config.DefaultOutDir()
+	}
 	verbose := getPersistentBool(cmd, "verbose", false)
+	quiet := getPersistentBool(cmd, "quiet", false)
 	dlBinary := getPersistentString(cmd, "dl-binary", "")
 	jobs := getPersistentInt(cmd, "jobs", 2)
-	if jobs <= 0 {
+	if jobs < 0 {
 		jobs = 2
 	}
+	shutdownGrace := getPersistentDuration(cmd, "shutdown-grace", time.Minute)
+	maxJobs := getPersistentInt(cmd, "max-jobs", runtime.NumCPU())
+	if maxJobs < 1 {
+		maxJobs = 1
+	}
 
 	// Run flags
-	maxSizeMB, _ := cmd.Flags().GetInt("max-size-mb")
-	quality, _ := cmd.Flags().GetString("quality-preset")
-	resolution, _ := cmd.Flags().GetInt("resolution")
-	audioOnly, _ := cmd.Flags().GetBool("audio-only")
-	caption, _ := cmd.Flags().GetString("caption")
-	keepTemp, _ := cmd.Flags().GetBool("keep-temp")
+	maxSizeMB := runFlagInt(cmd, "max-size-mb")
+	quality := runFlagString(cmd, "quality-preset")
+	resolution := runFlagInt(cmd, "resolution")
+	audioOnly := runFlagBool(cmd, "audio-only")
+	caption := runFlagString(cmd, "caption")
+	keepTemp := runFlagBool(cmd, "keep-temp")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	noUI, _ := cmd.Flags().GetBool("no-ui")
+	noThumbs := runFlagBool(cmd, "no-thumbnails")
+	accessible := runFlagBool(cmd, "accessible")
+	pickFormat := runFlagBool(cmd, "pick-format")
+	keepGoing := runFlagBool(cmd, "keep-going")
+	singleInstance := runFlagBool(cmd, "single-instance")
+	sampleEncode, _ := cmd.Flags().GetBool("sample") // plan only
+	showCommands, _ := cmd.Flags().GetBool("show-commands")
+	showFormats, _ := cmd.Flags().GetBool("formats") // plan only
+	if failFast, _ := cmd.Flags().GetBool("fail-fast"); failFast {
+		if cmd.Flags().Changed("keep-going") && keepGoing {
+			return nil, model.CLIOptions{}, 0, errors.New(i18n.String("--keep-going and --fail-fast are mutually exclusive"))
+		}
+		keepGoing = false // overrides keep_going from config
+	}
+	postProcess, _ := cmd.Flags().GetStringSlice("post-process")
+	if !cmd.Flags().Changed("post-process") && viper.IsSet("post_process") {
+		postProcess = viper.GetStringSlice("post_process")
+	}
+	if _, err := pipeline.PostProcessorsFor(postProcess); err != nil {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --post-process: %v (valid: %s)", err, strings.Join(pipeline.PostProcessorNames(), "|"))
+	}
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	if !cmd.Flags().Changed("tag") && viper.IsSet("tag") {
+		tags = viper.GetStringSlice("tag")
+	}
+	tags = cleanTags(tags)
+	report := runFlagString(cmd, "report")
+	if report != "" {
+		if _, _, err := pipeline.ReportPath(report, "", time.Now()); err != nil {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --report: %v", err)
+		}
+	}
+	progressFile := runFlagString(cmd, "progress-file")
+	progressWebhook := runFlagString(cmd, "progress-webhook")
+	if progressWebhook != "" {
+		if u, err := url.Parse(progressWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --progress-webhook %q: want an http(s) URL", progressWebhook)
+		}
+	}
+	emit, _ := cmd.Flags().GetStringSlice("emit")
+	if !cmd.Flags().Changed("emit") && viper.IsSet("emit") {
+		emit = viper.GetStringSlice("emit")
+	}
+	if len(emit) > 0 {
+		if audioOnly {
+			return nil, model.CLIOptions{}, 0, errors.New(i18n.String("--emit and --audio-only are mutually exclusive; use --emit audio"))
+		}
+		var eerr error
+		if emit, eerr = pipeline.ParseEmit(emit); eerr != nil {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --emit: %v (valid: %s)", eerr, strings.Join(pipeline.EmitKinds(), "|"))
+		}
+		audioOnly = len(emit) > 0 && emit[0] == pipeline.EmitAudio // the main output decides
+	}
+	backend, _ := cmd.Flags().GetString("backend")
+	backends := viper.GetStringMapString("backends")
+	if cmd.Flags().Changed("backend") {
+		backends = nil // an explicit flag applies to every URL
+	} else if viper.IsSet("backend") {
+		backend = viper.GetString("backend")
+	}
+	for _, name := range append([]string{backend}, mapValues(backends)...) {
+		if _, err := downloader.LookupBackend(name); err != nil {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid backend: %v (valid: %s)", err, strings.Join(downloader.BackendNames(), "|"))
+		}
+	}
 
 	quality = strings.ToLower(quality)
 	switch quality {
 	case string(model.PresetLow), string(model.PresetMedium), string(model.PresetHigh):
 	default:
-		return nil, model.CLIOptions{}, 0, fmt.Errorf("invalid --quality-preset: %q (valid: low|medium|high)", quality)
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --quality-preset: %q (valid: low|medium|high)", quality)
+	}
+
+	priority, err := model.ParsePriority(strings.ToLower(runFlagString(cmd, "priority")))
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --priority: %v", err)
+	}
+
+	denoise, err := filterPreset(cmd, "denoise", encoder.DenoisePresets())
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	sharpen, err := filterPreset(cmd, "sharpen", encoder.SharpenPresets())
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+
+	onDuplicate, err := oneOf(cmd, "on-duplicate", []string{pipeline.DuplicateWait, pipeline.DuplicateSkip})
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	x264Preset, err := oneOf(cmd, "x264-preset", encoder.X264Presets())
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	h264Profile, err := oneOf(cmd, "h264-profile", encoder.H264Profiles())
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	pixFmt, err := oneOf(cmd, "pix-fmt", encoder.PixFmts())
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	if err := encoder.CheckH264(h264Profile, pixFmt); err != nil {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --h264-profile: %v", err)
+	}
+	encoderName := viper.GetString("encoder")
+	encodeRemotes, err := remoteEndpoints(cmd, "encode-remote", "encode_remote")
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	remoteWorkers, err := remoteEndpoints(cmd, "remote-workers", "remote_workers")
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	if len(encodeRemotes) > 0 {
+		encoderName = encoder.RemoteBackend
+	}
+	if _, err := encoder.LookupBackend(encoderName); err != nil {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid encoder: %v (valid: %s)", err, strings.Join(encoder.BackendNames(), "|"))
+	}
+	if encoderName == encoder.RemoteBackend && len(encodeRemotes) == 0 {
+		return nil, model.CLIOptions{}, 0, errors.New(i18n.String("the remote encoder needs --encode-remote"))
+	}
+	keyInt := runFlagInt(cmd, "keyint")
+	if keyInt < 1 {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --keyint: %d (must be at least 1)", keyInt)
+	}
+	audioKbps := runFlagInt(cmd, "audio-kbps")
+	if audioKbps < 32 || audioKbps > 320 {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --audio-kbps: %d (valid: 32-320)", audioKbps)
 	}
 
 	caption = strings.ToLower(caption)
-	if caption != string(model.CaptionTxt) && caption != string(model.CaptionNone) {
-		return nil, model.CLIOptions{}, 0, fmt.Errorf("invalid --caption: %q (valid: txt|none)", caption)
+	switch model.CaptionMode(caption) {
+	case model.CaptionTxt, model.CaptionEmbed, model.CaptionBoth, model.CaptionNone:
+	default:
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --caption: %q (valid: txt|embed|both|none)", caption)
+	}
+
+	organize, err := media.OrganizeTemplate(runFlagString(cmd, "organize"))
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --organize: %v", err)
+	}
+	dlArgs, err := rawArgs(cmd, "dl-args", "dl_args")
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	ffmpegArgs, err := rawArgs(cmd, "ffmpeg-args", "ffmpeg_args")
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	nice := runFlagInt(cmd, "nice")
+	if nice < 0 || nice > 19 {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --nice: %d (valid: 0-19)", nice)
+	}
+	threads := runFlagInt(cmd, "threads")
+	if threads < 0 {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --threads: %d", threads)
+	}
+	upload := runFlagString(cmd, "upload")
+	if upload != "" {
+		if err := uploader.Validate(upload); err != nil {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --upload: %v", err)
+		}
+	}
+	onSuccess, err := util.SplitArgs(runFlagString(cmd, "on-success"))
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --on-success: %v", err)
+	}
+	onFailure, err := util.SplitArgs(runFlagString(cmd, "on-failure"))
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --on-failure: %v", err)
+	}
+	latest, _ := cmd.Flags().GetInt("latest")
+	if latest < 0 {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --latest: %d", latest)
+	}
+	filter, err := sourceFilter(cmd)
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
+	chapter, _ := cmd.Flags().GetString("chapter")
+	if chapter = strings.TrimSpace(chapter); chapter != "" && cmd.Flags().Changed("trim") {
+		return nil, model.CLIOptions{}, 0, errors.New(i18n.String("--chapter and --trim are mutually exclusive"))
+	}
+	fade := runFlagFloat64(cmd, "fade")
+	if fade < 0 {
+		return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --fade: %g", fade)
+	}
+	var posterAt float64
+	if s := runFlagString(cmd, "poster-at"); s != "" {
+		if posterAt, err = util.ParseTimestamp(s); err != nil {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --poster-at: %v", err)
+		}
+		if audioOnly {
+			return nil, model.CLIOptions{}, 0, errors.New(i18n.String("--poster-at needs a video output; the main output is audio-only"))
+		}
+	}
+	intro, outro := runFlagString(cmd, "intro"), runFlagString(cmd, "outro")
+	for _, b := range []string{intro, outro} {
+		if b == "" {
+			continue
+		}
+		if audioOnly {
+			return nil, model.CLIOptions{}, 0, errors.New(i18n.String("--intro/--outro need a video output; the main output is audio-only"))
+		}
+		if _, err := os.Stat(b); err != nil {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("bumper clip: %w", err)
+		}
+	}
+	var trimStart, trimEnd float64
+	if trim, _ := cmd.Flags().GetString("trim"); trim != "" {
+		if trimStart, trimEnd, err = util.ParseTimeRange(trim); err != nil {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("invalid --trim: %v", err)
+		}
 	}
 
 	// URL validation
 	var urls []string
 	for _, raw := range args {
-		if _, _, err := util.DetectPlatform(raw); err != nil {
+		if _, _, err := util.DetectPlatform(raw); err != nil && backend != "http" {
 			return nil, model.CLIOptions{}, 0, err
 		}
+		if latest == 0 && util.IsStoryURL(raw) && util.IsCollectionURL(raw) {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("%s is a story or highlight; pass --latest N to snip its newest N items", raw)
+		}
+		if latest == 0 && util.IsCollectionURL(raw) {
+			return nil, model.CLIOptions{}, 0, i18n.Errorf("%s is a channel, profile, or playlist; pass --latest N to snip its newest N videos", raw)
+		}
 		urls = append(urls, raw)
 	}
 
 	// Defaults based on preset
 	preset := model.QualityPreset(quality)
-	presetRes, presetMaxMB, presetCRF := presetDefaults(preset)
+	presetRes, presetMaxMB, presetCRF := pipeline.PresetDefaults(preset)
 
 	if resolution <= 0 {
 		resolution = presetRes
 	}
-	changedMax := cmd.Flags().Changed("max-size-mb")
+	changedMax := cmd.Flags().Changed("max-size-mb") || viper.IsSet("max_size_mb")
 	if !changedMax {
 		maxSizeMB = presetMaxMB
 	} else if maxSizeMB < 0 {
@@ -137,47 +378,291 @@ func assembleRunInputs(cmd *cobra.Command, args []string) ([]string, model.CLIOp
 	}
 
 	outDir = filepath.Clean(outDir)
+	tempBase, err := resolveTempBase(runFlagString(cmd, "temp-dir"), outDir)
+	if err != nil {
+		return nil, model.CLIOptions{}, 0, err
+	}
 
 	opts := model.CLIOptions{
-		OutDir:     outDir,
-		MaxSizeMB:  maxSizeMB,
-		Quality:    preset,
-		Resolution: resolution,
-		AudioOnly:  audioOnly,
-		Caption:    model.CaptionMode(caption),
-		KeepTemp:   keepTemp,
-		DLBinary:   dlBinary,
-		DryRun:     dryRun,
-		Verbose:    verbose,
-		NoUI:       noUI,
-		Jobs:       jobs,
+		OutDir:          outDir,
+		Organize:        organize,
+		NameDate:        runFlagBool(cmd, "name-date"),
+		TempBase:        tempBase,
+		MaxSizeMB:       maxSizeMB,
+		CBR:             runFlagBool(cmd, "cbr"),
+		Quality:         preset,
+		Resolution:      resolution,
+		Denoise:         denoise,
+		Sharpen:         sharpen,
+		FadeSec:         fade,
+		PosterAt:        posterAt,
+		Intro:           intro,
+		Outro:           outro,
+		AudioOnly:       audioOnly,
+		Emit:            emit,
+		Report:          report,
+		ProgressFile:    progressFile,
+		ProgressWebhook: progressWebhook,
+		Tags:            tags,
+		Caption:         model.CaptionMode(caption),
+		KeepTemp:        keepTemp,
+		DLBinary:        dlBinary,
+		DryRun:          dryRun,
+		Verbose:         verbose,
+		Quiet:           quiet,
+		NoUI:            noUI,
+		Jobs:            jobs,
+		MaxJobs:         maxJobs,
+		ShutdownGrace:   shutdownGrace,
+		NoThumbnails:    noThumbs,
+		Accessible:      accessible,
+		PickFormat:      pickFormat,
+		KeepGoing:       keepGoing,
+		OnDuplicate:     onDuplicate,
+		SingleInstance:  singleInstance,
+		Priority:        priority,
+		KeyBindings:     viper.GetStringMapStringSlice("keys"),
+		PostProcessors:  postProcess,
+		Backend:         backend,
+		Backends:        backends,
+		BackendPaths:    viper.GetStringMapString("backend_paths"),
+		DLArgs:          dlArgs,
+		FFmpegArgs:      ffmpegArgs,
+		Platforms:       platformOverrides(cmd),
+		GeoBypass:       viper.GetString("geo_bypass"),
+		SourceAddress:   viper.GetString("source_address"),
+
+		Cookies:            runFlagString(cmd, "cookies"),
+		CookiesFromBrowser: runFlagString(cmd, "cookies-from-browser"),
+
+		MetadataTimeout: runFlagDuration(cmd, "metadata-timeout"),
+		DownloadTimeout: runFlagDuration(cmd, "download-timeout"),
+		EncodeTimeout:   runFlagDuration(cmd, "encode-timeout"),
+		JobTimeout:      runFlagDuration(cmd, "job-timeout"),
+
+		MetadataCacheTTL: runFlagDuration(cmd, "metadata-cache-ttl"),
+		SourceCacheMB:    max(runFlagInt(cmd, "source-cache-mb"), 0),
+
+		WaitLive: runFlagDuration(cmd, "wait-live"),
+
+		Nice:    nice,
+		Threads: threads,
+
+		Encoder:       encoderName,
+		EncodeRemotes: encodeRemotes,
+		RemoteWorkers: remoteWorkers,
+		RemoteToken:   viper.GetString("remote_token"),
+		X264Preset:    x264Preset,
+		H264Profile:   h264Profile,
+		KeyInt:        keyInt,
+		PixFmt:        pixFmt,
+		AudioKbps:     audioKbps,
+
+		ForceEncode: runFlagBool(cmd, "force-encode"),
+
+		SampleEncode: sampleEncode,
+		ShowCommands: showCommands,
+		ShowFormats:  showFormats,
+
+		TrimStartSec: trimStart,
+		TrimEndSec:   trimEnd,
+		Chapter:      chapter,
+
+		Upload: upload,
+
+		OnSuccess:   onSuccess,
+		OnFailure:   onFailure,
+		HookTimeout: runFlagDuration(cmd, "hook-timeout"),
+
+		NoHistory: runFlagBool(cmd, "no-history"),
+		Latest:    latest,
+		Filter:    filter,
+
+		DownloadArchive: runFlagString(cmd, "download-archive"),
+	}
+	if err := validateNetworkOptions(opts); err != nil {
+		return nil, model.CLIOptions{}, 0, err
 	}
 	return urls, opts, presetCRF, nil
 }
 
+// filterPreset reads the --denoise or --sharpen preset, returning "" for off.
+func filterPreset(cmd *cobra.Command, name string, valid []string) (string, error) {
+	v := strings.ToLower(runFlagString(cmd, name))
+	if v == "" || v == "off" {
+		return "", nil
+	}
+	for _, p := range valid {
+		if v == p {
+			return v, nil
+		}
+	}
+	return "", i18n.Errorf("invalid --%s: %q (valid: %s)", name, v, strings.Join(valid, "|"))
+}
+
+// oneOf reads a flag that must be one of valid, ignoring case.
+func oneOf(cmd *cobra.Command, name string, valid []string) (string, error) {
+	v := strings.ToLower(runFlagString(cmd, name))
+	for _, p := range valid {
+		if v == p {
+			return v, nil
+		}
+	}
+	return "", i18n.Errorf("invalid --%s: %q (valid: %s)", name, v, strings.Join(valid, "|"))
+}
+
+// remoteEndpoints reads a list of `daemon --listen` base URLs (or
+// remote.Local) from the flag name, or the config key when the flag isn't set.
+func remoteEndpoints(cmd *cobra.Command, name, key string) ([]string, error) {
+	list, _ := cmd.Flags().GetStringSlice(name)
+	if !cmd.Flags().Changed(name) && viper.IsSet(key) {
+		list = viper.GetStringSlice(key)
+	}
+	for _, r := range list {
+		if r == remote.Local {
+			continue
+		}
+		if u, err := url.Parse(r); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, i18n.Errorf("invalid --%s: %q (want http(s)://host:port or local)", name, r)
+		}
+	}
+	return list, nil
+}
+
+// validateNetworkOptions checks geo_bypass and source_address, run-wide and
+// per platform.
+func validateNetworkOptions(opts model.CLIOptions) error {
+	check := func(where, geo, addr string) error {
+		if _, err := downloader.GeoBypassArgs(geo); err != nil {
+			return fmt.Errorf("%sgeo_bypass: %v", where, err)
+		}
+		if addr != "" && net.ParseIP(addr) == nil {
+			return i18n.Errorf("%ssource_address: %q is not an IP address", where, addr)
+		}
+		return nil
+	}
+	if err := check("", opts.GeoBypass, opts.SourceAddress); err != nil {
+		return err
+	}
+	for name, po := range opts.Platforms {
+		if err := check("platforms."+name+".", po.GeoBypass, po.SourceAddress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func runExecute(cmd *cobra.Command, args []string, mode runMode) error {
 	// Grab inputs from context; if not present (root directly called without PreRunE), assemble now.
 	var in runInputs
 	if v := cmd.Context().Value(runInputsKey); v != nil {
 		in = v.(runInputs)
 	} else {
-		urls, opts, presetCRF, err := assembleRunInputs(cmd, args)
-		if err != nil {
+		var err error
+		if in, err = assembleBatch(cmd, args); err != nil {
 			return &ExitError{Code: ExitCLIError, Err: err}
 		}
-		in = runInputs{URLs: urls, Options: opts, PresetCRF: presetCRF}
 	}
 
 	// Ensure output directory exists early when using TUI
 	if err := ensureDir(in.Options.OutDir); err != nil {
-		return &ExitError{Code: ExitCLIError, Err: fmt.Errorf("failed to create output dir: %v", err)}
+		return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("failed to create output dir: %v", err)}
+	}
+
+	if useDaemon(cmd, in, mode) {
+		return sendToDaemon(cmd, in)
+	}
+
+	if err := checkToolVersions(cmd, in.Options.DLBinary); err != nil {
+		return &ExitError{Code: ExitMissingDep, Err: err}
+	}
+	if in.Options.Upload != "" && !in.Options.DryRun && !mode.DryRunOnly {
+		if _, err := deps.FindRclone(); err != nil {
+			return &ExitError{Code: ExitMissingDep, Err: err}
+		}
+	}
+
+	if in.usesLatest() {
+		var err error
+		if in, err = expandLatest(cmd.Context(), in); err != nil {
+			return err
+		}
+		if len(in.URLs) == 0 {
+			if !in.Options.Quiet {
+				msg := "Nothing new: the latest videos are all in the history."
+				if in.Options.Filter.Active() {
+					msg = "Nothing new: the latest videos are in the history or filtered out."
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.String(msg))
+			}
+			return nil
+		}
+	}
+
+	if in.Options.DownloadArchive != "" {
+		var err error
+		if in, err = skipArchived(cmd, in); err != nil {
+			return err
+		}
+		if len(in.URLs) == 0 {
+			return nil
+		}
+	}
+
+	// Batch report (--report), written once every job has finished
+	if in.Options.Report != "" && !in.Options.DryRun && !mode.DryRunOnly {
+		path, format, err := pipeline.ReportPath(in.Options.Report, in.Options.OutDir, time.Now())
+		if err != nil {
+			return &ExitError{Code: ExitCLIError, Err: err}
+		}
+		if in.Report == nil {
+			in.Report = pipeline.NewReport()
+		}
+		defer func() {
+			if err := in.Report.Write(path, format); err != nil {
+				slog.Error("could not write report", "path", path, "err", err)
+			} else if !in.Options.Quiet {
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Report: %s", path))
+			}
+		}()
+	}
+
+	// Progress sinks next to the TUI or console (--progress-file, --progress-webhook)
+	var sinks progress.Reporter
+	if !in.Options.DryRun && !mode.DryRunOnly {
+		var err error
+		if sinks, err = progressSinks(cmd.Context(), in.Options); err != nil {
+			return &ExitError{Code: ExitCLIError, Err: err}
+		}
+		defer func() { _ = progress.Close(sinks) }()
 	}
 
 	// TUI path (forced or auto if TTY and not disabled)
 	useTUI := mode.ForceTUI || (!in.Options.NoUI && isTerminal())
 	if useTUI && !mode.DryRunOnly {
-		if err := ui.Run(cmd.Context(), in.URLs, in.Options); err != nil {
-			return &ExitError{Code: ExitCLIError, Err: err}
+		perJob := make([]*model.CLIOptions, len(in.URLs))
+		for i := range perJob {
+			if in.entry(i) != nil {
+				opts := in.forEntry(i).Options
+				perJob[i] = &opts
+			}
+		}
+		if err := ui.Run(cmd.Context(), in.URLs, in.Options, perJob, in.Report, sinks); err != nil {
+			var de *ui.DrainedError
+			if errors.As(err, &de) {
+				return drained(util.DrainFrom(cmd.Context()), de.Unfinished, !mode.CallerResumes)
+			}
+			// As without the TUI, every job failing keeps the specific
+			// code of the first failure.
+			code := ExitCLIError
+			var fj *ui.FailedJobsError
+			if errors.As(err, &fj) {
+				code = ExitPartialFailure
+				if fj.Failed == fj.Total {
+					code = jobExitError(fj.First).Code
+				}
+			}
+			return &ExitError{Code: code, Err: err}
 		}
 		return nil
 	}
@@ -194,7 +679,7 @@ func runExecute(cmd *cobra.Command, args []string, mode runMode) error {
 
 	// Ensure output directory exists (again, for non-UI-only invocations)
 	if err := ensureDir(in.Options.OutDir); err != nil {
-		return &ExitError{Code: ExitCLIError, Err: fmt.Errorf("failed to create output dir: %v", err)}
+		return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("failed to create output dir: %v", err)}
 	}
 
 	// Dry-run-only mode forces metadata-only planning
@@ -203,162 +688,509 @@ func runExecute(cmd *cobra.Command, args []string, mode runMode) error {
 		in.Options.NoUI = true
 	}
 
-	for _, rawURL := range in.URLs {
-		if err := processOne(cmd.Context(), rawURL, in, downloaderPath, ffmpegPath); err != nil {
-			var ee *ExitError
-			if errors.As(err, &ee) {
-				return ee
-			}
-			return &ExitError{Code: ExitCLIError, Err: err}
+	// Jobs run on a pool of --jobs workers; with several, output lines start
+	// with the job ID.
+	workers := min(max(in.Options.Jobs, 1), len(in.URLs))
+	in.TagOutput = workers > 1
+
+	// Plain-text progress on stderr (in-place when attached to a terminal and
+	// one job runs at a time, unless --accessible asks for whole lines)
+	var rep progress.Reporter
+	if !in.Options.DryRun && !in.Options.Quiet {
+		console := progress.NewConsole(os.Stderr, workers == 1 && !in.Options.Accessible && term.IsTerminal(int(os.Stderr.Fd())))
+		rep = console
+		if len(in.URLs) > 1 {
+			defer func() {
+				if dl := console.Downloaded(); dl > 0 {
+					fmt.Fprintln(os.Stderr, i18n.Sprintf("Downloaded %s in total", util.HumanizeBytes(dl)))
+				}
+			}()
 		}
 	}
-	return nil
-}
+	rep = progress.NewMulti(rep, sinks)
 
-func isTerminal() bool {
-	return term.IsTerminal(int(os.Stdout.Fd()))
-}
+	// Plans are collected and rendered together once planning stops.
+	var plans []pipeline.Plan
+	if in.Options.DryRun {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		defer func() {
+			if err := renderPlans(os.Stdout, plans, asJSON); err != nil {
+				slog.Error("render plan", "err", err)
+			}
+		}()
+	}
 
-var (
-	errDownload = errors.New("download failed")
-	errEncode   = errors.New("encode failed")
-)
+	// Fail fast by default: the first failure cancels the jobs still running
+	// and queued. With --keep-going, collect failures and report them
+	// together once every URL has been tried.
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	type outcome struct {
+		plan     *pipeline.Plan
+		err      *ExitError
+		ran      bool
+		canceled bool // Failed once a drain's grace period was over
+	}
+	outcomes := make([]outcome, len(in.URLs))
+	var (
+		mu       sync.Mutex
+		firstErr *ExitError // Of the job that failed first, when failing fast
+	)
 
-func processOne(ctx context.Context, rawURL string, in runInputs, dlPath, ffmpegPath string) error {
-	metaOnly := in.Options.DryRun
-	dv, tempDir, derr := downloader.Download(ctx, rawURL, downloader.Options{
-		DownloaderPath: dlPath,
-		Verbose:        in.Options.Verbose,
-		KeepTemp:       in.Options.KeepTemp,
-		MetadataOnly:   metaOnly,
-	})
-	defer func() {
-		if !in.Options.KeepTemp && tempDir != "" {
-			_ = os.RemoveAll(tempDir)
+	// On SIGTERM/SIGHUP, start no more jobs and give the running ones
+	// --shutdown-grace to finish before canceling them.
+	drain := util.DrainFrom(cmd.Context())
+	poolDone := make(chan struct{})
+	var graceOver atomic.Bool
+	go func() {
+		select {
+		case <-drain.Done():
+		case <-poolDone:
+			return
+		}
+		select {
+		case <-time.After(in.Options.ShutdownGrace):
+			graceOver.Store(true)
+			cancel()
+		case <-poolDone:
 		}
 	}()
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				jobID := fmt.Sprintf("%d/%d", i+1, len(in.URLs))
+				plan, err := processOne(ctx, i, jobID, in, downloaderPath, ffmpegPath, rep)
+				o := outcome{plan: plan, ran: true, canceled: err != nil && graceOver.Load()}
+				if err != nil {
+					if !errors.As(err, &o.err) {
+						o.err = &ExitError{Code: ExitCLIError, Err: err}
+					}
+					mu.Lock()
+					if !in.Options.KeepGoing && firstErr == nil {
+						firstErr = o.err
+						cancel()
+					}
+					mu.Unlock()
+				}
+				outcomes[i] = o
+			}
+		}()
+	}
+	// Higher --priority entries start first; the rest keep their order
+	order := make([]int, len(in.URLs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return in.forEntry(order[a]).Options.Priority > in.forEntry(order[b]).Options.Priority
+	})
+feed:
+	for _, i := range order {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		case <-drain.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	close(poolDone)
 
-	if derr != nil {
-		return &ExitError{Code: ExitDownloadError, Err: fmt.Errorf("%w: %v", errDownload, derr)}
+	if drain.Started() {
+		var unfinished []string
+		for i, o := range outcomes {
+			if !o.ran || o.canceled {
+				unfinished = append(unfinished, in.URLs[i])
+			}
+		}
+		if len(unfinished) > 0 {
+			return drained(drain, unfinished, !mode.CallerResumes)
+		}
 	}
 
-	// Plan encoding
-	targetLongSide, crf := pipeline.PlanResolutionAndCRF(in.Options, dv, in.PresetCRF)
-	encOpts := model.EncodeOptions{
-		LongSidePx:       targetLongSide,
-		ModeCRF:          in.Options.MaxSizeMB == 0 || dv.DurationSec <= 0 || in.Options.AudioOnly,
-		CRF:              crf,
-		MaxSizeMB:        in.Options.MaxSizeMB,
-		AudioBitrateKbps: 96,
-		VideoMinKbps:     500,
-		VideoMaxKbps:     8000,
-		Preset:           "veryfast",
-		Profile:          "main",
-		AudioOnly:        in.Options.AudioOnly,
-		KeyInt:           48,
+	var failed []string
+	firstCode := ExitOK
+	for i, o := range outcomes {
+		if o.plan != nil {
+			plans = append(plans, *o.plan)
+		}
+		if o.err == nil {
+			continue
+		}
+		if cmd.Context().Err() != nil {
+			return o.err
+		}
+		if in.Options.DryRun {
+			plans = append(plans, pipeline.Plan{URL: in.URLs[i], Error: o.err.Err.Error()})
+		}
+		failed = append(failed, fmt.Sprintf("- %s: %v", in.URLs[i], o.err.Err))
+		if firstCode == ExitOK {
+			firstCode = o.err.Code
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	if len(failed) > 0 {
+		// Every job failing keeps the specific code of the first failure.
+		code := ExitPartialFailure
+		if len(failed) == len(in.URLs) {
+			code = firstCode
+		}
+		return &ExitError{Code: code, Err: i18n.Errorf("%d of %d job(s) failed:\n%s", len(failed), len(in.URLs), strings.Join(failed, "\n"))}
 	}
+	return nil
+}
 
-	// Output filename
-	base := media.OutputBasename(dv, targetLongSide, in.Options.MaxSizeMB, encOpts)
-	ext := ".mp4"
-	if in.Options.AudioOnly {
-		ext = ".m4a"
+// webhookInterval spaces out the percentages --progress-webhook posts; stage
+// changes and results are posted at once.
+const webhookInterval = 5 * time.Second
+
+// progressSinks opens the reporters --progress-file and --progress-webhook
+// ask for, or returns nil for none. Close the result when the run ends.
+func progressSinks(ctx context.Context, opts model.CLIOptions) (progress.Reporter, error) {
+	var sinks []progress.Reporter
+	if opts.ProgressFile != "" {
+		j, err := progress.CreateJSONL(opts.ProgressFile)
+		if err != nil {
+			return nil, i18n.Errorf("open --progress-file: %w", err)
+		}
+		sinks = append(sinks, j)
+	}
+	if opts.ProgressWebhook != "" {
+		sinks = append(sinks, progress.Chain(progress.NewWebhook(ctx, opts.ProgressWebhook), progress.Throttled(webhookInterval)))
 	}
-	outputPath := filepath.Join(in.Options.OutDir, base+ext)
+	return progress.NewMulti(sinks...), nil
+}
 
-	if in.Options.DryRun {
-		printPlan(rawURL, dlPath, ffmpegPath, tempDir, outputPath, dv, encOpts, in.Options)
-		return nil
+// cleanTags trims --tag values and drops empty and repeated ones.
+func cleanTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		out = append(out, t)
 	}
+	return out
+}
 
-	// Encode
-	out, eerr := encoder.Encode(ctx, dv, encOpts, encoder.Options{
-		FFmpegPath: ffmpegPath,
-		Verbose:    in.Options.Verbose,
-		OutputPath: outputPath,
-	})
-	if eerr != nil {
-		return &ExitError{Code: ExitTranscodeError, Err: fmt.Errorf("%w: %v", errEncode, eerr)}
+// sourceFilter reads --since, --min-duration, --max-duration, and
+// --match-title (or their config keys).
+func sourceFilter(cmd *cobra.Command) (model.SourceFilter, error) {
+	f := model.SourceFilter{
+		MinDuration: runFlagDuration(cmd, "min-duration"),
+		MaxDuration: runFlagDuration(cmd, "max-duration"),
+	}
+	if f.MinDuration < 0 || f.MaxDuration < 0 || (f.MaxDuration > 0 && f.MaxDuration < f.MinDuration) {
+		return f, i18n.Errorf("invalid --min-duration/--max-duration: %s-%s", f.MinDuration, f.MaxDuration)
 	}
+	if s := runFlagString(cmd, "since"); s != "" {
+		t, err := util.ParseSince(s, time.Now())
+		if err != nil {
+			return f, i18n.Errorf("invalid --since: %v", err)
+		}
+		f.Since = t
+	}
+	if s := runFlagString(cmd, "match-title"); s != "" {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return f, i18n.Errorf("invalid --match-title: %v", err)
+		}
+		f.MatchTitle = re
+	}
+	return f, nil
+}
 
-	// Caption output
-	if in.Options.Caption == model.CaptionTxt {
-		caption := media.CaptionText(dv)
-		if _, werr := util.WriteCaptionFile(out.OutputPath, caption); werr != nil {
-			fmt.Fprintf(os.Stderr, "warning: failed to write caption: %v\n", werr)
+// expandLatest replaces each channel, profile, or playlist URL with its
+// newest opts.Latest videos that have not been snipped before (per the
+// history file). Other URLs are kept as they are. The videos of a batch
+// entry with flags of its own get the entry's options.
+func expandLatest(ctx context.Context, in runInputs) (runInputs, error) {
+	dl, err := deps.FindDownloader(in.Options.DLBinary)
+	if err != nil {
+		return in, &ExitError{Code: ExitMissingDep, Err: err}
+	}
+	seen, err := history.LoadIndex()
+	if err != nil {
+		slog.Warn("could not read history; nothing will be skipped", "err", err)
+	}
+	out := in
+	out.URLs, out.Entries = nil, nil
+	for i, raw := range in.URLs {
+		opts := in.forEntry(i).Options
+		if opts.Latest == 0 || !util.IsCollectionURL(raw) {
+			out.addURL(raw, in.entry(i))
+			continue
+		}
+		fresh, err := pipeline.LatestNew(ctx, opts, dl, raw, opts.Latest, seen)
+		if err != nil {
+			return in, &ExitError{Code: exitCodeFor(err, ExitDownloadError), Err: err}
+		}
+		slog.Info("expanded source", "url", raw, "new", len(fresh))
+		for _, u := range fresh {
+			out.addURL(u, in.entry(i))
 		}
 	}
+	return out, nil
+}
 
-	// Size overshoot warning (best-effort)
-	if !encOpts.ModeCRF && in.Options.MaxSizeMB > 0 {
-		maxBytes := int64(in.Options.MaxSizeMB) * 1024 * 1024
-		if out.Bytes > int64(float64(maxBytes)*1.10) {
-			fmt.Fprintf(os.Stderr, "warning: output size (%0.2f MB) exceeds target (%d MB). Consider lowering bitrate or preset.\n",
-				float64(out.Bytes)/(1024*1024), in.Options.MaxSizeMB)
+// usesLatest reports whether any URL of the run is to be expanded (--latest).
+func (in runInputs) usesLatest() bool {
+	if in.Options.Latest > 0 {
+		return true
+	}
+	for _, jo := range in.Entries {
+		if jo != nil && jo.Options.Latest > 0 {
+			return true
 		}
 	}
+	return false
+}
 
-	fmt.Printf("Saved: %s (%0.2f MB)\n", out.OutputPath, float64(out.Bytes)/(1024*1024))
-	return nil
+// skipArchived drops the URLs whose videos are in the --download-archive
+// file. Only IDs that are part of the URL can be checked up front; other
+// videos are downloaded and then added to the archive.
+func skipArchived(cmd *cobra.Command, in runInputs) (runInputs, error) {
+	a, err := downloader.OpenArchive(in.Options.DownloadArchive)
+	if err != nil {
+		return in, &ExitError{Code: ExitCLIError, Err: i18n.Errorf("read download archive: %w", err)}
+	}
+	out := in
+	out.URLs, out.Entries = nil, nil
+	for i, raw := range in.URLs {
+		if a.Has(raw, util.VideoIDFromURL(raw)) {
+			if !in.Options.Quiet {
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("Skipping %s: already in the download archive", raw))
+			}
+			continue
+		}
+		out.addURL(raw, in.entry(i))
+	}
+	return out, nil
 }
 
-func presetDefaults(p model.QualityPreset) (resolution int, maxSizeMB int, crf int) {
-	switch p {
-	case model.PresetLow:
-		return 540, 20, 26
-	case model.PresetHigh:
-		return 1080, 100, 19
-	case model.PresetMedium:
-		fallthrough
+// resolveTempBase maps --temp-dir to a workdir parent ("" = cache temp dir).
+// "auto" keeps the cache unless it is on a different filesystem than the
+// output dir, in which case workdirs go next to the outputs so finalizing is
+// a rename and a small tmpfs-backed cache cannot run out of space mid-job.
+func resolveTempBase(choice, outDir string) (string, error) {
+	nextToOutput := filepath.Join(outDir, util.OutputTempDirName)
+	switch strings.ToLower(choice) {
+	case "", "auto":
+		cache, err := dirs.TempBaseDir()
+		if err != nil || util.SameFilesystem(cache, outDir) {
+			return "", nil
+		}
+		return nextToOutput, nil
+	case "cache":
+		return "", nil
+	case "output":
+		return nextToOutput, nil
 	default:
-		return 720, 50, 22
+		abs, err := filepath.Abs(choice)
+		if err != nil {
+			return "", i18n.Errorf("invalid --temp-dir %q: %v", choice, err)
+		}
+		return abs, nil
 	}
 }
 
-// printPlan outputs a dry-run plan of actions without executing them.
-func printPlan(rawURL, dlPath, ffmpegPath, tempDir, outputPath string, dv model.DownloadedVideo, enc model.EncodeOptions, opts model.CLIOptions) {
-	fmt.Println("Dry-run plan:")
-	fmt.Printf("- URL:            %s\n", rawURL)
-	fmt.Printf("- Downloader:     %s\n", dlPath)
-	fmt.Printf("- FFmpeg:         %s\n", ffmpegPath)
-	fmt.Printf("- Temp dir:       %s\n", tempDir)
-	fmt.Printf("- Output dir:     %s\n", opts.OutDir)
-	fmt.Printf("- Output path:    %s\n", outputPath)
-	fmt.Printf("- Audio only:     %v\n", enc.AudioOnly)
-	if !enc.AudioOnly {
-		fmt.Printf("- Resolution:     %dp (long side)\n", enc.LongSidePx)
-		if enc.ModeCRF {
-			fmt.Printf("- Mode:           CRF %d\n", enc.CRF)
-		} else {
-			kbps := 0
-			if dv.DurationSec > 0 && opts.MaxSizeMB > 0 {
-				kbps = bitrateForPreview(opts.MaxSizeMB, dv.DurationSec, enc.AudioBitrateKbps, enc.VideoMinKbps, enc.VideoMaxKbps)
+// runFlagString, runFlagInt, runFlagBool, and runFlagDuration read a run flag with precedence
+// flag > env/config (including the active profile) > flag default. The config
+// key is the flag name with underscores (max-size-mb -> max_size_mb).
+func runFlagString(cmd *cobra.Command, name string) string {
+	v, _ := cmd.Flags().GetString(name)
+	if key := strings.ReplaceAll(name, "-", "_"); !cmd.Flags().Changed(name) && viper.IsSet(key) {
+		return viper.GetString(key)
+	}
+	return v
+}
+
+func runFlagInt(cmd *cobra.Command, name string) int {
+	v, _ := cmd.Flags().GetInt(name)
+	if key := strings.ReplaceAll(name, "-", "_"); !cmd.Flags().Changed(name) && viper.IsSet(key) {
+		return viper.GetInt(key)
+	}
+	return v
+}
+
+func runFlagFloat64(cmd *cobra.Command, name string) float64 {
+	v, _ := cmd.Flags().GetFloat64(name)
+	if key := strings.ReplaceAll(name, "-", "_"); !cmd.Flags().Changed(name) && viper.IsSet(key) {
+		return viper.GetFloat64(key)
+	}
+	return v
+}
+
+func runFlagBool(cmd *cobra.Command, name string) bool {
+	v, _ := cmd.Flags().GetBool(name)
+	if key := strings.ReplaceAll(name, "-", "_"); !cmd.Flags().Changed(name) && viper.IsSet(key) {
+		return viper.GetBool(key)
+	}
+	return v
+}
+
+func runFlagDuration(cmd *cobra.Command, name string) time.Duration {
+	v, _ := cmd.Flags().GetDuration(name)
+	if key := strings.ReplaceAll(name, "-", "_"); !cmd.Flags().Changed(name) && viper.IsSet(key) {
+		return viper.GetDuration(key)
+	}
+	return v
+}
+
+// platformOverrides reads platforms.<name> sections from config. Explicit
+// --resolution/--max-size-mb flags apply to every URL, so they drop the
+// matching per-platform values.
+func platformOverrides(cmd *cobra.Command) map[string]model.PlatformOptions {
+	out := make(map[string]model.PlatformOptions)
+	for name := range viper.GetStringMap("platforms") {
+		sub := viper.Sub("platforms." + name)
+		if sub == nil {
+			continue
+		}
+		po := model.PlatformOptions{
+			Resolution:         sub.GetInt("resolution"),
+			Format:             sub.GetString("format"),
+			Cookies:            sub.GetString("cookies"),
+			CookiesFromBrowser: sub.GetString("cookies_from_browser"),
+			RateLimit:          sub.GetString("rate_limit"),
+			GeoBypass:          sub.GetString("geo_bypass"),
+			SourceAddress:      sub.GetString("source_address"),
+		}
+		if sub.IsSet("max_size_mb") {
+			mb := sub.GetInt("max_size_mb")
+			if mb < 0 {
+				mb = 0
 			}
-			fmt.Printf("- Mode:           Size-constrained (target %d MB), est video bitrate ~ %d kbps\n", opts.MaxSizeMB, kbps)
+			po.MaxSizeMB = &mb
 		}
-	} else {
-		fmt.Printf("- Audio bitrate:  %d kbps (AAC)\n", enc.AudioBitrateKbps)
+		if cmd.Flags().Changed("resolution") {
+			po.Resolution = 0
+		}
+		if cmd.Flags().Changed("max-size-mb") {
+			po.MaxSizeMB = nil
+		}
+		if cmd.Flags().Changed("cookies") || cmd.Flags().Changed("cookies-from-browser") {
+			po.Cookies, po.CookiesFromBrowser = "", ""
+		}
+		out[strings.ToLower(name)] = po
 	}
-	fmt.Printf("- Caption:        %s\n", strings.ToUpper(string(opts.Caption)))
+	return out
 }
 
-func bitrateForPreview(maxSizeMB int, durationSec float64, audioKbps, vMin, vMax int) int {
-	if durationSec <= 0 {
-		return clamp(2000, vMin, vMax)
+// rawArgs collects pass-through arguments from a repeatable flag, or from the
+// config key (a string or a list of strings) when the flag is absent. Each
+// value is split shell-style, so "--proxy 'socks5://host:1080'" works.
+func rawArgs(cmd *cobra.Command, flagName, key string) ([]string, error) {
+	values, _ := cmd.Flags().GetStringArray(flagName)
+	if !cmd.Flags().Changed(flagName) && viper.IsSet(key) {
+		switch v := viper.Get(key).(type) {
+		case string:
+			values = []string{v}
+		default:
+			values = viper.GetStringSlice(key)
+		}
+	}
+	var out []string
+	for _, v := range values {
+		words, err := util.SplitArgs(v)
+		if err != nil {
+			return nil, i18n.Errorf("invalid --%s: %v", flagName, err)
+		}
+		out = append(out, words...)
 	}
-	targetSizeBytes := int64(maxSizeMB) * 1024 * 1024
-	totalBitrateBps := float64(targetSizeBytes*8) / durationSec
-	videoBitrateBps := totalBitrateBps - float64(audioKbps*1000)
-	kbps := int(videoBitrateBps / 1000.0)
-	return clamp(kbps, vMin, vMax)
+	return out, nil
 }
 
-func clamp(v, min, max int) int {
-	if min != 0 && v < min {
-		return min
+// mapValues returns m's values (order unspecified).
+func mapValues(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
 	}
-	if max != 0 && v > max {
-		return max
+	return out
+}
+
+func isTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// exitCodeFor returns ExitTimeout for errors caused by a time limit, else code.
+func exitCodeFor(err error, code int) int {
+	if errors.Is(err, util.ErrTimeout) {
+		return ExitTimeout
 	}
-	return v
-}
\ No newline at end of file
+	return code
+}
+
+// processOne runs in's i-th URL through pipeline.Service. In dry-run mode it
+// stops after planning and returns the plan instead of encoding.
+func processOne(ctx context.Context, i int, jobID string, in runInputs, dlPath, ffmpegPath string, rep progress.Reporter) (*pipeline.Plan, error) {
+	rawURL := in.URLs[i]
+	in = in.forEntry(i)
+	svc := pipeline.Service{DownloaderPath: dlPath, FFmpegPath: ffmpegPath}
+	res, err := svc.RunJob(ctx, pipeline.Job{
+		ID:        jobID,
+		URL:       rawURL,
+		Options:   pipeline.OptionsForURL(in.Options, rawURL),
+		PresetCRF: in.PresetCRF,
+		Reporter:  rep,
+		Report:    in.Report,
+	})
+	if err != nil {
+		return nil, jobExitError(err)
+	}
+	if res.Plan != nil {
+		return res.Plan, nil
+	}
+
+	if !in.Options.Quiet {
+		prefix := ""
+		if in.TagOutput {
+			prefix = "[" + jobID + "] "
+		}
+		if res.Skipped != "" {
+			fmt.Println(prefix + i18n.Sprintf("Skipping %s: %s", rawURL, res.Skipped))
+			return nil, nil
+		}
+		for _, o := range res.Outputs {
+			note := ""
+			if o.Copied {
+				note = i18n.String(", not re-encoded")
+			}
+			fmt.Println(prefix + i18n.Sprintf("Saved: %s (%0.2f MB%s)", o.OutputPath, float64(o.Bytes)/(1024*1024), note))
+			if o.RemoteURL != "" {
+				fmt.Println(prefix + i18n.Sprintf("Uploaded: %s", o.RemoteURL))
+			}
+		}
+		fmt.Println(prefix + i18n.Sprintf("Took: %s", res.Times))
+	}
+	return nil, nil
+}
+
+// jobExitError maps a failed job to the exit code of the step it failed in.
+func jobExitError(err error) *ExitError {
+	var je *pipeline.JobError
+	if !errors.As(err, &je) {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	switch je.Step {
+	case pipeline.StepDownload:
+		return &ExitError{Code: exitCodeFor(je.Err, ExitDownloadError), Err: i18n.Errorf("download failed: %v", je.Err)}
+	case pipeline.StepEncode:
+		return &ExitError{Code: exitCodeFor(je.Err, ExitTranscodeError), Err: i18n.Errorf("encode failed: %v", je.Err)}
+	case pipeline.StepUpload:
+		return &ExitError{Code: exitCodeFor(je.Err, ExitUploadError), Err: i18n.Errorf("upload failed: %v", je.Err)}
+	}
+	return &ExitError{Code: ExitCLIError, Err: je.Err}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ig2wa/internal/history"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/util"
+	"ig2wa/internal/util/deps"
+)
+
+func newScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "schedule",
+		Short:         "Watch channels and profiles and snip new videos as they appear",
+		Long:          "Checks the configured sources (channel, profile, or playlist URLs under `sources` in the config, or --source) every --interval and snips the newest videos that are not yet in the history file. Use --once to run a single check, e.g. from cron.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE:          runSchedule,
+	}
+	cmd.Flags().StringArray("source", nil, "Channel/profile/playlist URL to watch (repeatable; replaces the configured sources)")
+	cmd.Flags().Duration("interval", 30*time.Minute, "Time between checks (config key schedule_interval)")
+	cmd.Flags().Bool("once", false, "Check once and exit")
+	// Run flags apply to every snipped video; --latest defaults to
+	// schedule_latest (5).
+	bindRunFlags(cmd.Flags())
+	return cmd
+}
+
+func runSchedule(cmd *cobra.Command, _ []string) error {
+	sources, _ := cmd.Flags().GetStringArray("source")
+	if !cmd.Flags().Changed("source") {
+		sources = viper.GetStringSlice("sources")
+	}
+	if len(sources) == 0 {
+		return &ExitError{Code: ExitCLIError, Err: errors.New(i18n.String("no sources to watch: add `sources:` to the config or pass --source"))}
+	}
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if !cmd.Flags().Changed("interval") && viper.IsSet("schedule_interval") {
+		interval = viper.GetDuration("schedule_interval")
+	}
+	latest, _ := cmd.Flags().GetInt("latest")
+	if !cmd.Flags().Changed("latest") {
+		latest = 5
+		if viper.IsSet("schedule_latest") {
+			latest = viper.GetInt("schedule_latest")
+		}
+	}
+	once, _ := cmd.Flags().GetBool("once")
+	if !once && interval < time.Minute {
+		return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("invalid --interval: %s (minimum 1m)", interval)}
+	}
+
+	base := cmd.Context()
+	drain := util.DrainFrom(base)
+	for {
+		err := schedulePass(cmd, base, sources, latest)
+		if once || drain.Started() {
+			return err // videos a drain left unfinished are picked up by the next run
+		}
+		if err != nil {
+			var ee *ExitError
+			if errors.As(err, &ee) && ee.Code == ExitMissingDep {
+				return err // won't fix itself
+			}
+			slog.Error("scheduled check failed", "err", err)
+		}
+		slog.Info("next check", "in", interval)
+		select {
+		case <-base.Done():
+			return nil
+		case <-drain.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// schedulePass lists every source, then snips the videos not yet in the
+// history, oldest first, without the TUI and continuing past failures.
+// Failed videos stay out of the history's successes and are retried on the
+// next pass.
+func schedulePass(cmd *cobra.Command, ctx context.Context, sources []string, latest int) error {
+	_, opts, _, err := assembleRunInputs(cmd, nil)
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	dl, err := deps.FindDownloader(opts.DLBinary)
+	if err != nil {
+		return &ExitError{Code: ExitMissingDep, Err: err}
+	}
+	seen, err := history.LoadIndex()
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("read history: %w", err)}
+	}
+
+	var urls []string
+	for _, src := range sources {
+		fresh, err := pipeline.LatestNew(ctx, opts, dl, src, latest, seen)
+		if err != nil {
+			slog.Warn("could not list source", "source", src, "err", err)
+			continue
+		}
+		urls = append(urls, fresh...)
+	}
+	if len(urls) == 0 {
+		slog.Info("no new videos", "sources", len(sources))
+		return nil
+	}
+	if !opts.Quiet {
+		fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("%s: %d new video(s)", time.Now().Format("2006-01-02 15:04"), len(urls)))
+	}
+
+	in := runInputs{URLs: urls, Options: opts}
+	_, _, in.PresetCRF = pipeline.PresetDefaults(opts.Quality)
+	in.Options.NoUI = true
+	in.Options.KeepGoing = true
+	in.Options.NoHistory = false // the history is what keeps videos from being snipped twice
+	in.Options.Latest = 0        // urls are single videos already
+	cmd.SetContext(context.WithValue(ctx, runInputsKey, in))
+	return runExecute(cmd, urls, runMode{Local: true, CallerResumes: true})
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"ig2wa/internal/history"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+)
+
+func newStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "stats",
+		Short:         "Summarize the history: clips made, space saved, platforms, and uploaders",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			entries, err := history.Load()
+			if err != nil {
+				return &ExitError{Code: ExitCLIError, Err: i18n.Errorf("read history: %w", err)}
+			}
+			tag, _ := cmd.Flags().GetString("tag")
+			s := history.Summarize(history.WithTag(entries, tag))
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(s)
+			}
+			if tag != "" && s.Clips+s.Failed == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.Sprintf("No jobs tagged %q.", tag))
+				return nil
+			}
+			printStats(cmd.OutOrStdout(), s)
+			return nil
+		},
+	}
+	cmd.Flags().String("tag", "", "Only count jobs with this tag")
+	cmd.Flags().Bool("json", false, "Print machine-readable JSON")
+	return cmd
+}
+
+func printStats(w io.Writer, s history.Stats) {
+	if s.Clips+s.Failed == 0 {
+		fmt.Fprintln(w, i18n.String("The history is empty."))
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, i18n.Sprintf("Period:\t%s to %s", s.First.Local().Format("2006-01-02"), s.Last.Local().Format("2006-01-02")))
+	fmt.Fprintln(tw, i18n.Sprintf("Clips:\t%d (%d failed)", s.Clips, s.Failed))
+	fmt.Fprintln(tw, i18n.Sprintf("Video time:\t%s", (time.Duration(s.DurationSec)*time.Second).String()))
+	fmt.Fprintln(tw, i18n.Sprintf("Output:\t%s", util.HumanizeBytes(s.OutputBytes)))
+	if s.SourceBytes > 0 {
+		fmt.Fprintln(tw, i18n.Sprintf("Sources:\t%s (%s saved)", util.HumanizeBytes(s.SourceBytes), util.HumanizeBytes(s.SavedBytes)))
+		fmt.Fprintln(tw, i18n.Sprintf("Avg. compression:\t%.1fx", s.AvgRatio))
+	}
+	if s.ElapsedSec > 0 {
+		fmt.Fprintln(tw, i18n.Sprintf("Time spent:\t%s", progress.StageTimes{
+			Metadata: util.SecondsDuration(s.MetadataSec),
+			Download: util.SecondsDuration(s.DownloadSec),
+			Encode:   util.SecondsDuration(s.EncodeSec),
+			Upload:   util.SecondsDuration(s.UploadSec),
+			Total:    util.SecondsDuration(s.ElapsedSec),
+		}))
+	}
+	fmt.Fprintln(tw, i18n.Sprintf("Platforms:\t%s", joinCounts(s.Platforms)))
+	if len(s.Uploaders) > 0 {
+		fmt.Fprintln(tw, i18n.Sprintf("Top uploaders:\t%s", joinCounts(s.Uploaders)))
+	}
+	if len(s.Tags) > 0 {
+		fmt.Fprintln(tw, i18n.Sprintf("Tags:\t%s", joinCounts(s.Tags)))
+	}
+	tw.Flush()
+}
+
+// joinCounts renders counts as "youtube 12, instagram 3".
+func joinCounts(cs []history.Count) string {
+	if len(cs) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = fmt.Sprintf("%s %d", c.Name, c.Clips)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -10,7 +10,7 @@ func newTuiCmd() *cobra.Command {
 		Short:         "Force TUI mode for interactive snips",
 		SilenceUsage:  true,
 		SilenceErrors: true,
-		Args:          cobra.MinimumNArgs(1),
+		Args:          urlArgs,
 		PreRunE:       runPreRun,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Force TUI; if stdout is not a terminal, ui.Run will error appropriately.
@@ -26,4 +26,4 @@ func newTuiCmd() *cobra.Command {
 		f.Hidden = true
 	}
 	return cmd
-}
\ No newline at end of file
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"ig2wa/internal/buildinfo"
+	"ig2wa/internal/util/deps"
+)
+
+// componentVersion is an external tool as `version` found it.
+type componentVersion struct {
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func newVersionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "version",
+		Short:         "Print the sniplette build and the yt-dlp and ffmpeg it would use",
+		Long:          "Prints the version, commit, and build date of this binary and the versions of the downloader (yt-dlp/youtube-dl) and ffmpeg it finds. Include the output in bug reports.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			bi := buildinfo.Get()
+			components := map[string]componentVersion{}
+			if dl, err := deps.FindDownloader(getPersistentString(cmd, "dl-binary", "")); err != nil {
+				components["downloader"] = componentVersion{Error: err.Error()}
+			} else {
+				cv := componentVersion{Path: dl}
+				if cv.Version, err = deps.ToolVersion(cmd.Context(), dl, "--version"); err != nil {
+					cv.Error = err.Error()
+				}
+				components["downloader"] = cv
+			}
+			if ff, err := deps.FindFFmpeg(); err != nil {
+				components["ffmpeg"] = componentVersion{Error: err.Error()}
+			} else {
+				cv := componentVersion{Path: ff}
+				line, err := deps.ToolVersion(cmd.Context(), ff, "-version")
+				if err != nil {
+					cv.Error = err.Error()
+				}
+				cv.Version = deps.ParseFFmpegVersion(line)
+				components["ffmpeg"] = cv
+			}
+
+			w := cmd.OutOrStdout()
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"sniplette": bi, "downloader": components["downloader"], "ffmpeg": components["ffmpeg"]})
+			}
+			var details []string
+			if bi.Commit != "" {
+				c := "commit " + bi.Commit
+				if bi.Modified {
+					c += ", modified"
+				}
+				details = append(details, c)
+			}
+			if bi.Date != "" {
+				details = append(details, "built "+bi.Date)
+			}
+			details = append(details, bi.GoVersion, bi.Platform)
+			fmt.Fprintf(w, "sniplette %s (%s)\n", bi.Version, strings.Join(details, ", "))
+			for _, name := range []string{"downloader", "ffmpeg"} {
+				cv := components[name]
+				label := name
+				if name == "downloader" && cv.Path != "" {
+					label = strings.TrimSuffix(filepath.Base(cv.Path), ".exe")
+				}
+				if cv.Error != "" {
+					fmt.Fprintf(w, "%s: %s\n", label, cv.Error)
+					continue
+				}
+				fmt.Fprintf(w, "%s: %s (%s)\n", label, cv.Version, cv.Path)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("json", false, "Print as JSON")
+	return cmd
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/ui"
+	"ig2wa/internal/util/deps"
+)
+
+func newWizardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "wizard [url]",
+		Short:         "Choose app, quality, trim, and caption step by step, then snip",
+		Long:          "Fetches the video's details and walks through the choices interactively (target app, quality, part of the video, caption) with a live size estimate, then runs the job. Running sniplette with no arguments in a terminal starts the wizard too.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var url string
+			if len(args) == 1 {
+				url = args[0]
+			}
+			return runWizard(cmd, url)
+		},
+	}
+	// Other run flags (e.g. --out-dir, --organize) still apply to the job.
+	bindRunFlags(cmd.Flags())
+	return cmd
+}
+
+// interactive reports whether both stdin and stdout are terminals.
+func interactive() bool {
+	return isTerminal() && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// runWizard asks for the job's settings, turns them into run flags, and runs
+// the job in the TUI as if they had been given on the command line.
+func runWizard(cmd *cobra.Command, url string) error {
+	if !interactive() {
+		return &ExitError{Code: ExitCLIError, Err: errors.New(i18n.String("the wizard needs an interactive terminal; pass flags instead (see sniplette run --help)"))}
+	}
+	_, defaults, _, err := assembleRunInputs(cmd, nil)
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	dl, err := deps.FindDownloader(defaults.DLBinary)
+	if err != nil {
+		return &ExitError{Code: ExitMissingDep, Err: err}
+	}
+	fetch := func(ctx context.Context, rawURL string) (downloader.YTDLPInfo, error) {
+		return fetchInfo(cmd, dl, rawURL)
+	}
+
+	res, ok, err := ui.RunWizard(cmd.Context(), url, defaults, fetch)
+	if err != nil {
+		return &ExitError{Code: ExitCLIError, Err: err}
+	}
+	if !ok {
+		return nil
+	}
+
+	flags := map[string]string{
+		"max-size-mb":    strconv.Itoa(res.MaxSizeMB),
+		"quality-preset": string(res.Quality),
+		"caption":        string(res.Caption),
+	}
+	if res.TrimStartSec > 0 || res.TrimEndSec > 0 {
+		trim := strconv.FormatFloat(res.TrimStartSec, 'f', -1, 64) + "-"
+		if res.TrimEndSec > 0 {
+			trim += strconv.FormatFloat(res.TrimEndSec, 'f', -1, 64)
+		}
+		flags["trim"] = trim
+	}
+	for name, v := range flags {
+		if err := cmd.Flags().Set(name, v); err != nil {
+			return &ExitError{Code: ExitCLIError, Err: err}
+		}
+	}
+	return runExecute(cmd, []string{res.URL}, runMode{ForceTUI: true})
+}
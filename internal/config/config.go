@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -32,9 +34,53 @@ func Init(root *cobra.Command) error {
 	_ = viper.BindPFlag("verbose", root.PersistentFlags().Lookup("verbose"))
 	_ = viper.BindPFlag("dl_binary", root.PersistentFlags().Lookup("dl-binary"))
 	_ = viper.BindPFlag("jobs", root.PersistentFlags().Lookup("jobs"))
+	_ = viper.BindPFlag("max_jobs", root.PersistentFlags().Lookup("max-jobs"))
+	_ = viper.BindPFlag("shutdown_grace", root.PersistentFlags().Lookup("shutdown-grace"))
+	_ = viper.BindPFlag("quiet", root.PersistentFlags().Lookup("quiet"))
+	_ = viper.BindPFlag("log_level", root.PersistentFlags().Lookup("log-level"))
+	_ = viper.BindPFlag("log_file", root.PersistentFlags().Lookup("log-file"))
+	_ = viper.BindPFlag("skip_version_check", root.PersistentFlags().Lookup("skip-version-check"))
+	_ = viper.BindPFlag("auto_update", root.PersistentFlags().Lookup("auto-update"))
+	_ = viper.BindPFlag("profile", root.PersistentFlags().Lookup("profile"))
+	_ = viper.BindPFlag("lang", root.PersistentFlags().Lookup("lang"))
 
 	// Read config file if present (ignore not found)
 	_ = viper.ReadInConfig()
 
 	return nil
-}
\ No newline at end of file
+}
+
+// ProfileNames lists the profiles defined under `profiles` in the config file.
+func ProfileNames() []string {
+	var names []string
+	for name := range viper.GetStringMap("profiles") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Active profile and the top-level keys it sets, for Source.
+var (
+	activeProfile string
+	profileKeys   map[string]bool
+)
+
+// ApplyProfile layers profiles.<name> over the top-level config file values.
+// Flags and environment variables still take precedence over the profile.
+func ApplyProfile(name string) error {
+	sub := viper.Sub("profiles." + strings.ToLower(name))
+	if sub == nil {
+		if names := ProfileNames(); len(names) > 0 {
+			return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+		}
+		return fmt.Errorf("unknown profile %q: no profiles defined in the config file", name)
+	}
+	settings := sub.AllSettings()
+	activeProfile = strings.ToLower(name)
+	profileKeys = make(map[string]bool, len(settings))
+	for k := range settings {
+		profileKeys[k] = true
+	}
+	return viper.MergeConfigMap(settings)
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"ig2wa/internal/dirs"
+	"ig2wa/internal/pipeline"
+)
+
+// Kind is the value type of a configuration key.
+type Kind string
+
+const (
+	KindString   Kind = "string"
+	KindInt      Kind = "int"
+	KindFloat    Kind = "float"
+	KindBool     Kind = "bool"
+	KindDuration Kind = "duration"
+	KindList     Kind = "list"
+	KindMap      Kind = "map"
+)
+
+// Key describes one supported configuration key.
+type Key struct {
+	Name    string
+	Kind    Kind
+	Default any
+	Help    string
+}
+
+// Keys lists the supported top-level configuration keys.
+var Keys = []Key{
+	{"out_dir", KindString, DefaultOutDir(), "Output directory"},
+	{"organize", KindString, "", "Output subfolders: platform, date, or a template"},
+	{"name_date", KindBool, false, "Start output names with the upload date (YYYYMMDD)"},
+	{"temp_dir", KindString, "auto", "Job workdir location: auto, cache, output, or a path"},
+	{"verbose", KindBool, false, "Show full subprocess commands/output"},
+	{"quiet", KindBool, false, "Only print errors"},
+	{"log_level", KindString, "warn", "Console log level: error, warn, info, debug"},
+	{"log_file", KindString, "", "Also write debug-level logs to this file"},
+	{"dl_binary", KindString, "", "Path or name of yt-dlp/youtube-dl"},
+	{"jobs", KindInt, 2, "Max concurrent jobs; 0 = adaptive"},
+	{"max_jobs", KindInt, runtime.NumCPU(), "Upper bound for adaptive concurrency"},
+	{"shutdown_grace", KindDuration, "1m0s", "On SIGTERM/SIGHUP, time running jobs get to finish; 0 = cancel at once"},
+	{"skip_version_check", KindBool, false, "Skip yt-dlp/ffmpeg version checks at startup"},
+	{"auto_update", KindBool, false, "Update a stale yt-dlp before running"},
+	{"update_check", KindBool, true, "Check daily for a new sniplette release and a broken yt-dlp"},
+	{"profile", KindString, "", "Profile (profiles.<name>) applied by default"},
+	{"lang", KindString, "", "Language of messages (en, ms); empty = from the locale"},
+	{"max_size_mb", KindInt, 50, "Target max size per video in MB; 0 = CRF mode"},
+	{"cbr", KindBool, false, "Size mode: constant bitrate instead of capped VBR"},
+	{"quality_preset", KindString, "medium", "Quality preset: low, medium, high"},
+	{"resolution", KindInt, 0, "Long-side resolution in px; 0 = preset default"},
+	{"denoise", KindString, "off", "Denoise preset: off, light, medium, strong"},
+	{"sharpen", KindString, "off", "Sharpen preset: off, light, medium"},
+	{"encoder", KindString, "ffmpeg", "Encoder backend: ffmpeg, or remote (see encode_remote)"},
+	{"encode_remote", KindList, nil, "URLs of 'daemon --listen' instances (or local) to spread encodes over"},
+	{"remote_workers", KindList, nil, "URLs of 'daemon --listen' instances (or local) to spread whole jobs over"},
+	{"remote_token", KindString, "", "Shared secret of remote encodes and jobs (client and 'daemon --listen')"},
+	{"x264_preset", KindString, pipeline.DefaultX264Preset, "x264 speed preset, ultrafast to veryslow"},
+	{"h264_profile", KindString, pipeline.DefaultH264Profile, "H.264 profile: baseline, main, high, high10, high422, high444"},
+	{"keyint", KindInt, pipeline.DefaultKeyInt, "Frames between keyframes (GOP size)"},
+	{"pix_fmt", KindString, pipeline.DefaultPixFmt, "Output pixel format: yuv420p, yuv420p10le, yuv422p, yuv444p"},
+	{"audio_kbps", KindInt, pipeline.DefaultAudioKbps, "AAC audio bitrate in kbps (32-320)"},
+	{"fade", KindFloat, 0.0, "Fade video and audio in and out over this many seconds; 0 = none"},
+	{"poster_at", KindString, "", "Clip position of the preview frame, e.g. 0:03"},
+	{"intro", KindString, "", "Video clip joined before every snip"},
+	{"outro", KindString, "", "Video clip joined after every snip"},
+	{"audio_only", KindBool, false, "Extract audio only (M4A)"},
+	{"emit", KindList, []string{"mp4"}, "Outputs per URL, main one first"},
+	{"caption", KindString, "txt", "Caption output: txt, embed, both, none"},
+	{"keep_temp", KindBool, false, "Keep intermediate downloads"},
+	{"no_thumbnails", KindBool, false, "Disable inline thumbnails in the TUI"},
+	{"accessible", KindBool, false, "Screen-reader friendly TUI: no animations, status as plain lines"},
+	{"keep_going", KindBool, false, "Continue after a failed URL (non-UI) and summarize at the end"},
+	{"single_instance", KindBool, false, "TUI takes later launches' URLs instead of them opening another TUI"},
+	{"on_duplicate", KindString, "wait", "When another job is snipping the same video: wait or skip"},
+	{"priority", KindString, "normal", "Queue priority of jobs: low, normal, high"},
+	{"tag", KindList, nil, "Labels stored with each job in the history and report"},
+	{"report", KindString, "", "Job report after each run: json, csv, md, or a file path"},
+	{"progress_file", KindString, "", "File to append progress events to as JSON lines"},
+	{"progress_webhook", KindString, "", "URL to POST progress updates and results to as JSON"},
+	{"pick_format", KindBool, false, "Pick the source format per job in the TUI"},
+	{"post_process", KindList, []string{"caption"}, "After-encode steps in order"},
+	{"backend", KindString, "yt-dlp", "Downloader backend when no per-platform entry applies"},
+	{"nice", KindInt, 0, "ffmpeg CPU niceness 1-19; 0 = normal priority"},
+	{"threads", KindInt, 0, "ffmpeg thread limit; 0 = ffmpeg default"},
+	{"force_encode", KindBool, false, "Re-encode even sources that could be remuxed as they are"},
+	{"metadata_timeout", KindDuration, "2m0s", "Metadata fetch time limit, e.g. 30s; 0 = none"},
+	{"download_timeout", KindDuration, "0s", "Download time limit; 0 = none"},
+	{"encode_timeout", KindDuration, "0s", "Encode time limit; 0 = none"},
+	{"job_timeout", KindDuration, "0s", "Whole-job time limit; 0 = none"},
+	{"metadata_cache_ttl", KindDuration, "1h0m0s", "Reuse fetched metadata for this long; 0 = always fetch"},
+	{"source_cache_mb", KindInt, 0, "Source cache size limit in MB; 0 = off"},
+	{"wait_live", KindDuration, "0s", "Wait this long for a live YouTube stream to end; 0 = fail at once"},
+	{"upload", KindString, "", "Upload destination: s3://bucket/prefix or an rclone remote"},
+	{"on_success", KindString, "", "Command run after each successful job"},
+	{"on_failure", KindString, "", "Command run after each failed job"},
+	{"hook_timeout", KindDuration, "5m0s", "Time limit for on_success/on_failure commands; 0 = none"},
+	{"no_history", KindBool, false, "Don't record finished jobs in the history file"},
+	{"no_daemon", KindBool, false, "Run jobs in-process even when a daemon is running"},
+	{"download_archive", KindString, "", "yt-dlp download archive file shared with other yt-dlp runs"},
+	{"sources", KindList, nil, "Channel/profile URLs watched by schedule"},
+	{"schedule_interval", KindDuration, "30m0s", "Time between schedule checks"},
+	{"schedule_latest", KindInt, 5, "Newest videos per source considered by schedule"},
+	{"since", KindString, "", "Only snip listed videos published on/after a date (2024-01-31) or period (7d)"},
+	{"min_duration", KindDuration, "0s", "Skip listed videos shorter than this; 0 = no minimum"},
+	{"max_duration", KindDuration, "0s", "Skip listed videos longer than this; 0 = no maximum"},
+	{"match_title", KindString, "", "Only snip listed videos whose title matches this regexp"},
+	{"dl_args", KindList, nil, "Extra yt-dlp arguments"},
+	{"ffmpeg_args", KindList, nil, "Extra ffmpeg output arguments"},
+	{"keys", KindMap, nil, "TUI keybinding overrides (action -> keys)"},
+	{"backends", KindMap, nil, "Platform -> downloader backend"},
+	{"backend_paths", KindMap, nil, "Backend -> binary path"},
+	{"cookies", KindString, "", "Cookies file for yt-dlp, for videos that need a login"},
+	{"cookies_from_browser", KindString, "", "Browser to load yt-dlp cookies from (firefox, chrome, ...)"},
+	{"geo_bypass", KindString, "", "yt-dlp geo bypass: never, a country code (US), or an IP block (CIDR)"},
+	{"source_address", KindString, "", "Local IP to download from, to pick a network interface"},
+	{"platforms", KindMap, nil, "Per-platform overrides"},
+	{"profiles", KindMap, nil, "Named option profiles"},
+}
+
+// DefaultOutDir is where snips go without --out-dir: the data dir's output
+// folder, or the current directory if that cannot be determined.
+func DefaultOutDir() string {
+	if d, err := dirs.DefaultOutputDir(); err == nil {
+		return d
+	}
+	return "."
+}
+
+// LookupKey finds a supported key by name (hyphens are accepted for underscores).
+func LookupKey(name string) (Key, bool) {
+	name = strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	for _, k := range Keys {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// Source reports where the effective value of key comes from: "flag", "env",
+// "profile <name>", "file", or "default". flags may be nil.
+func Source(key string, flags *pflag.FlagSet) string {
+	if flags != nil {
+		if f := flags.Lookup(strings.ReplaceAll(key, "_", "-")); f != nil && f.Changed {
+			return "flag"
+		}
+	}
+	if _, ok := os.LookupEnv("SNIPLETTE_" + strings.ToUpper(key)); ok {
+		return "env"
+	}
+	if activeProfile != "" && profileKeys[strings.SplitN(key, ".", 2)[0]] {
+		return "profile " + activeProfile
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	return "default"
+}
+
+// FilePath returns the config file in use, or where a new one should be created.
+func FilePath() (string, error) {
+	if p := viper.ConfigFileUsed(); p != "" {
+		return p, nil
+	}
+	dir, err := dirs.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// SetInFile parses raw according to the key's kind and writes key = value to
+// the config file (creating it if needed). Only the file's own values are
+// rewritten; env vars, flags, and profiles in effect are not persisted.
+// Comments in the file are not preserved.
+func SetInFile(key, raw string) (string, error) {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	top := strings.SplitN(key, ".", 2)[0]
+	k, ok := LookupKey(top)
+	if !ok {
+		return "", fmt.Errorf("unknown config key %q (see 'sniplette config list')", key)
+	}
+	kind := k.Kind
+	if strings.Contains(key, ".") {
+		kind = "" // nested value: infer the type
+	} else if kind == KindMap {
+		return "", fmt.Errorf("%s is a section; set one entry, e.g. %s.<name>", key, key)
+	}
+	val, err := parseValue(kind, raw)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+
+	path, err := FilePath()
+	if err != nil {
+		return "", err
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if _, statErr := os.Stat(path); statErr == nil {
+		if err := v.ReadInConfig(); err != nil {
+			return "", fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+	v.Set(key, val)
+	if err := dirs.Ensure(filepath.Dir(path)); err != nil {
+		return "", err
+	}
+	if err := v.WriteConfigAs(path); err != nil {
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func parseValue(kind Kind, raw string) (any, error) {
+	switch kind {
+	case KindString:
+		return raw, nil
+	case KindInt:
+		return strconv.Atoi(raw)
+	case KindFloat:
+		return strconv.ParseFloat(raw, 64)
+	case KindBool:
+		return strconv.ParseBool(raw)
+	case KindDuration:
+		if _, err := time.ParseDuration(raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	case KindList:
+		var out []string
+		for _, p := range strings.Split(raw, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				out = append(out, p)
+			}
+		}
+		return out, nil
+	default:
+		if n, err := strconv.Atoi(raw); err == nil {
+			return n, nil
+		}
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b, nil
+		}
+		return raw, nil
+	}
+}
+
+// UnknownKeys returns config keys that are not in Keys, sorted; useful to flag typos.
+func UnknownKeys() []string {
+	var out []string
+	for _, k := range viper.AllKeys() {
+		if _, ok := LookupKey(strings.SplitN(k, ".", 2)[0]); !ok {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ig2wa/internal/dirs"
+)
+
+// What Migrate did, or would do, with a leftover.
+const (
+	ActionMoved   = "moved"
+	ActionRemoved = "removed"
+	ActionKept    = "kept"   // The sniplette side already has it, or it may be in use
+	ActionManual  = "manual" // Env vars: only the user's shell can rename them
+)
+
+// legacyTempMinAge keeps Migrate away from ig2wa temp dirs that might still
+// be in use by an old binary.
+const legacyTempMinAge = time.Hour
+
+// Migration is an ig2wa-era leftover found by Migrate.
+type Migration struct {
+	Kind   string // env, config, data, state, cache, temp
+	From   string
+	To     string // New name or path; "" for removals
+	Action string
+	Err    error // Set when the action failed
+}
+
+// Migrate moves what the app left under its old name to the sniplette
+// names: the ig2wa config, data, state, and cache directories are merged
+// into sniplette's, entry by entry, keeping sniplette's copy where both
+// exist; ig2wa temp dirs are removed; and IG2WA_* env vars are reported with
+// the SNIPLETTE_* name to use. With dryRun it only reports.
+func Migrate(dryRun bool) []Migration {
+	out := legacyEnv()
+	var done []string
+	for _, d := range []struct {
+		kind string
+		dir  func() (string, error)
+	}{{"config", dirs.ConfigDir}, {"data", dirs.DataDir}, {"state", dirs.StateDir}, {"cache", dirs.CacheDir}} {
+		to, err := d.dir()
+		if err != nil {
+			continue
+		}
+		from := dirs.Legacy(to)
+		if from == to || within(from, done) {
+			continue // on macOS, state lives inside the config dir
+		}
+		done = append(done, from)
+		out = append(out, mergeDir(d.kind, from, to, dryRun)...)
+	}
+	return append(out, legacyTemp(dryRun)...)
+}
+
+// legacyEnv lists the IG2WA_* variables in the environment.
+func legacyEnv() []Migration {
+	var out []Migration
+	prefix := strings.ToUpper(dirs.LegacyName) + "_"
+	for _, kv := range os.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, prefix) {
+			out = append(out, Migration{Kind: "env", From: k, To: "SNIPLETTE_" + strings.TrimPrefix(k, prefix), Action: ActionManual})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].From < out[j].From })
+	return out
+}
+
+// mergeDir moves the entries of from into to, descending into directories
+// both have, and removes from once it is empty.
+func mergeDir(kind, from, to string, dryRun bool) []Migration {
+	entries, err := os.ReadDir(from)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return []Migration{{Kind: kind, From: from, To: to, Action: ActionMoved, Err: err}}
+	}
+	var out []Migration
+	for _, e := range entries {
+		src, dst := filepath.Join(from, e.Name()), filepath.Join(to, e.Name())
+		if info, err := os.Lstat(dst); err == nil {
+			if e.IsDir() && info.IsDir() {
+				out = append(out, mergeDir(kind, src, dst, dryRun)...)
+			} else {
+				out = append(out, Migration{Kind: kind, From: src, To: dst, Action: ActionKept})
+			}
+			continue
+		}
+		m := Migration{Kind: kind, From: src, To: dst, Action: ActionMoved}
+		if !dryRun {
+			if m.Err = dirs.Ensure(to); m.Err == nil {
+				m.Err = os.Rename(src, dst)
+			}
+		}
+		out = append(out, m)
+	}
+	if !dryRun {
+		_ = os.Remove(from) // only succeeds once emptied
+	}
+	return out
+}
+
+// legacyTemp removes the os.TempDir()/ig2wa* dirs of older versions.
+func legacyTemp(dryRun bool) []Migration {
+	paths, _ := filepath.Glob(filepath.Join(os.TempDir(), dirs.LegacyName+"*"))
+	var out []Migration
+	for _, p := range paths {
+		m := Migration{Kind: "temp", From: p, Action: ActionRemoved}
+		if info, err := os.Stat(p); err == nil && time.Since(info.ModTime()) < legacyTempMinAge {
+			m.Action = ActionKept
+		} else if !dryRun {
+			m.Err = os.RemoveAll(p)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// within reports whether path is one of roots or inside one.
+func within(path string, roots []string) bool {
+	for _, d := range roots {
+		if path == d || strings.HasPrefix(path, d+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
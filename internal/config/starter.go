@@ -0,0 +1,168 @@
+package config
+
+// Starter is the commented config written by `sniplette config init`.
+const Starter = `# Sniplette configuration.
+# Precedence: flags > SNIPLETTE_* environment variables > this file > defaults.
+# Inspect the effective values with: sniplette config list
+
+# Where snips are written.
+# out_dir: "/home/user/Videos/sniplette"
+
+# Start output names with the upload date (YYYYMMDD).
+# name_date: true
+
+# Quality preset: low (540p, 20 MB), medium (720p, 50 MB), high (1080p, 100 MB).
+# quality_preset: medium
+
+# Target max size per video in MB; 0 switches to quality-based (CRF) encoding.
+# max_size_mb: 50
+
+# Size mode caps bitrate peaks; cbr: true holds the bitrate constant instead,
+# for apps that reject files with any spike over their limit.
+# cbr: false
+
+# Long-side resolution in px; 0 uses the preset's default.
+# resolution: 0
+
+# Encoder settings. A slower x264 preset gives better quality at the same
+# size; only yuv420p with the main (or baseline) profile plays everywhere.
+# x264_preset: veryfast
+# h264_profile: main
+# keyint: 48
+# pix_fmt: yuv420p
+# audio_kbps: 96
+
+# Clean up noisy (e.g. low-light) footage, which compresses badly in size
+# mode: denoise off, light, medium, or strong (slow); sharpen off, light, or
+# medium.
+# denoise: light
+# sharpen: off
+
+# Fade video and audio in and out at the clip's ends, in seconds; softens
+# trims that cut mid-sentence.
+# fade: 0.5
+
+# Preview frame chat apps show instead of the (often black) first frame,
+# as a position in the clip.
+# poster_at: "0:03"
+
+# Bumper clips joined before/after every snip (fitted to its size).
+# intro: "/home/user/Videos/bumper.mp4"
+# outro: "/home/user/Videos/bumper.mp4"
+
+# Caption output: txt (sidecar file), embed (the output's comment and
+# description tags), both, or none.
+# caption: txt
+
+# Max concurrent jobs; 0 adapts to CPU load and download throughput, up to max_jobs.
+# jobs: 2
+# max_jobs: 8
+
+# Path or name of yt-dlp (or youtube-dl).
+# dl_binary: "yt-dlp"
+
+# Console log level (error, warn, info, debug) and an optional debug log file.
+# log_level: warn
+# log_file: "/tmp/sniplette.log"
+
+# Update a stale yt-dlp automatically before runs.
+# auto_update: false
+
+# Outputs to make from each download, main one first: mp4, audio, gif, thumb.
+# The others are saved next to the main one with the same name.
+# emit: ["mp4"]
+
+# After-encode steps, in order: caption, thumbnail.
+# post_process: ["caption"]
+
+# Login cookies for yt-dlp, needed for most Facebook videos and Instagram
+# stories: a cookies file, or the browser to read them from. Both can also be
+# set per platform.
+# cookies: "~/cookies.txt"
+# cookies_from_browser: "firefox"
+
+# Extra arguments passed through to yt-dlp and ffmpeg.
+# dl_args: ["--cookies-from-browser firefox"]
+# ffmpeg_args: ["-tune film"]
+
+# Keep batches in the background: lower ffmpeg's CPU priority and thread count.
+# nice: 10
+# threads: 2
+
+# Sources that are already H.264/AAC within the size and resolution targets
+# are remuxed without re-encoding, and AAC audio within the audio bitrate is
+# kept as it is; force_encode: true always re-encodes.
+# force_encode: false
+
+# Time limits (0 = none); a stage that runs out of time fails with exit code 5.
+# metadata_timeout: 2m
+# download_timeout: 30m
+# encode_timeout: 0
+# job_timeout: 0
+
+# Metadata is cached so plan-then-run or a retry asks the site only once
+# (Instagram rate-limits these requests); 0 turns the cache off.
+# metadata_cache_ttl: 1h
+
+# Keep downloaded originals (up to this many MB, least recently used evicted
+# first) so encoding a video again with other settings skips the download.
+# source_cache_mb: 2000
+
+# Live and upcoming YouTube streams fail with a clear message; set this to
+# wait that long for the stream to end and its replay to become available.
+# wait_live: 3h
+
+# Copy every snip to S3 or any rclone remote after encoding (needs rclone).
+# upload: "s3://my-bucket/snips"
+
+# Commands run after each job; {output}, {url}, {title}, {uploader}, {id},
+# {status}, and {error} are filled in (also as SNIPLETTE_* env vars).
+# on_success: "rsync {output} nas:/videos/"
+# on_failure: "notify-send 'snip failed' {url}"
+# hook_timeout: 5m
+
+# Channels and profiles for "sniplette schedule" to watch; new videos are
+# snipped every schedule_interval. Finished jobs are kept in history.jsonl in
+# the data dir (no_history: true turns that off for normal runs).
+# sources:
+#   - "https://www.youtube.com/@somechannel/videos"
+#   - "https://www.instagram.com/someprofile/reels/"
+# schedule_interval: 30m
+# schedule_latest: 5
+# While "sniplette daemon" runs, runs hand their jobs to it; this keeps them
+# in-process instead.
+# no_daemon: true
+# Share yt-dlp's "already downloaded" list with your own yt-dlp jobs.
+# download_archive: "/home/me/videos/yt-dlp-archive.txt"
+# Skip listed videos that don't fit (also --since, --min-duration, ...).
+# since: 14d
+# min_duration: 15s
+# max_duration: 3m
+# match_title: "(?i)recipe"
+
+# Network route for yt-dlp: geo bypass (never, a country code, or an IP
+# block) and the local IP to download from, e.g. to keep downloads off a VPN
+# interface. Both can also be set per platform.
+# geo_bypass: "US"
+# source_address: "192.168.1.20"
+
+# Per-platform overrides (instagram, youtube, facebook, reddit).
+# platforms:
+#   instagram:
+#     max_size_mb: 16
+#   facebook:
+#     cookies_from_browser: "firefox"
+#   youtube:
+#     resolution: 480
+#     rate_limit: "4M"
+#     geo_bypass: "DE"
+
+# Named profiles, selected with --profile or SNIPLETTE_PROFILE.
+# profiles:
+#   family-whatsapp:
+#     quality_preset: low
+#     max_size_mb: 16
+#   archive:
+#     quality_preset: high
+#     max_size_mb: 0
+`
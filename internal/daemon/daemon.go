@@ -0,0 +1,384 @@
+// Package daemon lets a long-running `sniplette daemon` take jobs from other
+// sniplette processes over a unix socket in the state directory, so a new
+// job starts without the per-run dependency checks and UI startup. A TUI run
+// with --single-instance takes them on the same socket (see ServeInstance).
+//
+// The protocol is one JSON Request per connection, answered by one JSON
+// Response.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ig2wa/internal/dirs"
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// Request operations.
+const (
+	OpAdd    = "add"
+	OpStatus = "status"
+	OpStop   = "stop"
+)
+
+// Job states.
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+// Request is sent by a client.
+type Request struct {
+	Op        string           `json:"op"`
+	URLs      []string         `json:"urls,omitempty"`
+	Options   model.CLIOptions `json:"options"` // Fully resolved, with absolute paths
+	PresetCRF int              `json:"preset_crf,omitempty"`
+}
+
+// Response answers a Request. Jobs are the jobs an add created, or every job
+// the daemon knows of for a status request.
+type Response struct {
+	Error    string `json:"error,omitempty"`
+	Jobs     []Job  `json:"jobs,omitempty"`
+	Instance string `json:"instance,omitempty"` // InstanceTUI for a single-instance TUI; "" = the daemon
+}
+
+// InstanceTUI marks the responses of a single-instance TUI (see
+// ServeInstance).
+const InstanceTUI = "tui"
+
+// Job is one URL handed to the daemon.
+type Job struct {
+	ID       int       `json:"id"`
+	URL      string    `json:"url"`
+	State    string    `json:"state"`
+	Added    time.Time `json:"added"`
+	Finished time.Time `json:"finished,omitempty"`
+	Error    string    `json:"error,omitempty"`
+
+	opts      model.CLIOptions
+	presetCRF int
+	canceled  bool // Failed after a drain's grace period ran out
+}
+
+// RunFunc processes one job's URL with the options it was submitted with.
+type RunFunc func(ctx context.Context, url string, opts model.CLIOptions, presetCRF int, jobID string) error
+
+// SocketPath returns where the daemon listens.
+func SocketPath() (string, error) {
+	d, err := dirs.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "daemon.sock"), nil
+}
+
+// Running reports whether a daemon answers on the socket.
+func Running() bool {
+	p, err := SocketPath()
+	if err != nil {
+		return false
+	}
+	conn, err := net.DialTimeout("unix", p, 200*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// ErrNotRunning is returned by Send when no daemon is listening.
+var ErrNotRunning = errors.New("no daemon is running (start one with 'sniplette daemon')")
+
+// Send delivers req to the running daemon and returns its answer. An error
+// the daemon reports comes back as an error too.
+func Send(req Request) (Response, error) {
+	p, err := SocketPath()
+	if err != nil {
+		return Response{}, err
+	}
+	conn, err := net.DialTimeout("unix", p, time.Second)
+	if err != nil {
+		return Response{}, ErrNotRunning
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("send to daemon: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("read daemon reply: %w", err)
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Server runs submitted jobs on a fixed pool of workers.
+type Server struct {
+	Workers int // At least 1
+	Run     RunFunc
+	// On a drain (see util.Drain), how long running jobs may go on before
+	// they are canceled; 0 = cancel at once
+	Grace time.Duration
+
+	mu     sync.Mutex
+	jobs   []*Job
+	nextID int
+	queue  chan *Job
+	stop   context.CancelFunc
+}
+
+// keepFinished caps how many finished jobs status still lists.
+const keepFinished = 100
+
+// Serve listens on the socket until ctx is done or a client asks the daemon
+// to stop; running jobs are then cancelled. A drain attached to ctx (see
+// util.DrainFrom) stops it gracefully instead: no more jobs are taken, and
+// running ones get Grace to finish (see Unfinished). It fails if another
+// daemon is already listening.
+func (s *Server) Serve(ctx context.Context) error {
+	ln, p, err := listen()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(p)
+
+	ctx, s.stop = context.WithCancel(ctx)
+	defer s.stop()
+	s.queue = make(chan *Job, 1024)
+	quit := make(chan struct{}) // Closed when no more jobs are to start
+	drain := util.DrainFrom(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-drain.Done():
+			slog.Info("daemon draining: finishing running jobs", "grace", s.Grace)
+			time.AfterFunc(s.Grace, s.stop)
+		}
+		close(quit)
+		ln.Close()
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < max(s.Workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx, quit)
+		}()
+	}
+	slog.Info("daemon listening", "socket", p, "workers", max(s.Workers, 1))
+	accept(ln, quit, s.handle)
+	wg.Wait()
+	return nil
+}
+
+// listen opens the socket, failing if another instance is already
+// listening on it.
+func listen() (net.Listener, string, error) {
+	p, err := SocketPath()
+	if err != nil {
+		return nil, "", err
+	}
+	if Running() {
+		return nil, "", fmt.Errorf("another sniplette instance is already listening on %s", p)
+	}
+	if err := dirs.Ensure(filepath.Dir(p)); err != nil {
+		return nil, "", err
+	}
+	_ = os.Remove(p) // left behind by an instance that died
+	ln, err := net.Listen("unix", p)
+	if err != nil {
+		return nil, "", err
+	}
+	return ln, p, nil
+}
+
+// accept answers each connection to ln with handle until ln is closed after
+// quit is.
+func accept(ln net.Listener, quit <-chan struct{}, handle func(Request) Response) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if isClosed(quit) {
+				return
+			}
+			slog.Warn("daemon accept", "err", err)
+			continue
+		}
+		go func() {
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+			var req Request
+			if err := json.NewDecoder(conn).Decode(&req); err != nil {
+				_ = json.NewEncoder(conn).Encode(Response{Error: "bad request: " + err.Error()})
+				return
+			}
+			_ = json.NewEncoder(conn).Encode(handle(req))
+		}()
+	}
+}
+
+// ServeInstance answers requests on the socket with handle until ctx is
+// done, for a process other than the daemon (a single-instance TUI) that
+// takes jobs itself. It fails if another instance is already listening.
+func ServeInstance(ctx context.Context, handle func(Request) Response) error {
+	ln, p, err := listen()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(p)
+	quit := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(quit)
+		ln.Close()
+	}()
+	accept(ln, quit, handle)
+	return nil
+}
+
+func (s *Server) handle(req Request) Response {
+	var resp Response
+	switch req.Op {
+	case OpAdd:
+		resp.Jobs = s.add(req)
+	case OpStatus:
+		resp.Jobs = s.snapshot()
+	case OpStop:
+		slog.Info("daemon stopping on request")
+		s.stop()
+	default:
+		resp.Error = fmt.Sprintf("unknown operation %q", req.Op)
+	}
+	return resp
+}
+
+// add queues one job per URL and returns them.
+func (s *Server) add(req Request) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Job
+	for _, u := range req.URLs {
+		s.nextID++
+		j := &Job{ID: s.nextID, URL: u, State: StateQueued, Added: time.Now(), opts: req.Options, presetCRF: req.PresetCRF}
+		s.jobs = append(s.jobs, j)
+		select {
+		case s.queue <- j:
+		default:
+			j.State, j.Error, j.Finished = StateFailed, "daemon queue is full", time.Now()
+		}
+		// Workers change j only under s.mu, so the copy is of j as added
+		out = append(out, *j)
+	}
+	s.pruneLocked()
+	return out
+}
+
+func (s *Server) work(ctx context.Context, quit <-chan struct{}) {
+	for {
+		select {
+		case <-quit:
+			return
+		case j := <-s.queue:
+			if isClosed(quit) {
+				return // j stays queued (see Unfinished)
+			}
+			s.setState(j, StateRunning, nil)
+			slog.Info("job started", "id", j.ID, "url", j.URL)
+			err := s.Run(ctx, j.URL, j.opts, j.presetCRF, fmt.Sprintf("d%d", j.ID))
+			if err != nil {
+				slog.Error("job failed", "id", j.ID, "url", j.URL, "err", err)
+				s.mu.Lock()
+				j.canceled = ctx.Err() != nil
+				s.mu.Unlock()
+				s.setState(j, StateFailed, err)
+			} else {
+				slog.Info("job done", "id", j.ID, "url", j.URL)
+				s.setState(j, StateDone, nil)
+			}
+		}
+	}
+}
+
+// Unfinished lists the URLs of the jobs a drain left: still queued, or
+// canceled when the grace period ran out. Call it once Serve has returned.
+func (s *Server) Unfinished() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for _, j := range s.jobs {
+		if j.State == StateQueued || j.canceled {
+			out = append(out, j.URL)
+		}
+	}
+	return out
+}
+
+// isClosed reports whether ch is closed.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) setState(j *Job, state string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j.State = state
+	if err != nil {
+		j.Error = err.Error()
+	}
+	if state == StateDone || state == StateFailed {
+		j.Finished = time.Now()
+	}
+}
+
+func (s *Server) snapshot() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, len(s.jobs))
+	for i, j := range s.jobs {
+		out[i] = *j
+	}
+	return out
+}
+
+// pruneLocked forgets the oldest finished jobs beyond keepFinished.
+func (s *Server) pruneLocked() {
+	finished := 0
+	for _, j := range s.jobs {
+		if !j.Finished.IsZero() {
+			finished++
+		}
+	}
+	if finished <= keepFinished {
+		return
+	}
+	kept := s.jobs[:0]
+	for _, j := range s.jobs {
+		if !j.Finished.IsZero() && finished > keepFinished {
+			finished--
+			continue
+		}
+		kept = append(kept, j)
+	}
+	s.jobs = kept
+}
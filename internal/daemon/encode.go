@@ -0,0 +1,337 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ig2wa/internal/encoder"
+	"ig2wa/internal/model"
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/remote"
+	"ig2wa/internal/util"
+)
+
+// outputTTL is how long a remote encode's or job's output waits to be
+// fetched.
+const outputTTL = 30 * time.Minute
+
+// RemoteServer takes work from other machines over HTTP: encodes from their
+// remote encoder backend (see encoder.RemoteBackend) and whole jobs from
+// their --remote-workers (see pipeline.RemoteJobRequest). POST /v1/encode
+// uploads a source and streams the encode's progress back, POST /v1/jobs
+// downloads and encodes a URL the same way, GET /v1/outputs/<name> fetches a
+// result once, and GET /v1/load reports how busy the server is, so clients
+// share it fairly. Every request needs the bearer Token.
+type RemoteServer struct {
+	DownloaderPath string
+	FFmpegPath     string
+	Token          string
+	Workers        int // Encodes and jobs run at the same time; at least 1
+
+	once    sync.Once
+	sem     chan struct{}
+	busy    atomic.Int32 // Encodes and jobs being handled
+	mu      sync.Mutex
+	outputs map[string]servedOutput // By name
+}
+
+// servedOutput is an output waiting to be fetched; done is called once it is
+// fetched or expires.
+type servedOutput struct {
+	path string
+	done func()
+}
+
+// ListenAndServe serves on addr until ctx is done.
+func (e *RemoteServer) ListenAndServe(ctx context.Context, addr string) error {
+	if e.Token == "" {
+		return errors.New("a token is required")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(encoder.RemoteEncodePath, e.handleEncode)
+	mux.HandleFunc(pipeline.RemoteJobPath, e.handleJob)
+	mux.HandleFunc(remote.OutputsPath, e.handleOutput)
+	mux.HandleFunc(remote.LoadPath, e.handleLoad)
+	srv := &http.Server{Handler: e.authorize(mux), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	slog.Info("accepting remote encodes and jobs", "addr", ln.Addr().String(), "workers", max(e.Workers, 1))
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (e *RemoteServer) init() {
+	e.once.Do(func() {
+		e.sem = make(chan struct{}, max(e.Workers, 1))
+		e.outputs = map[string]servedOutput{}
+	})
+}
+
+func (e *RemoteServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(e.Token)) != 1 {
+			http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleEncode saves the upload to a workdir, waits for a free worker, and
+// encodes it, writing progress and then the output (or an error) as
+// encoder.RemoteEvent lines. The client going away cancels the encode.
+func (e *RemoteServer) handleEncode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	e.init()
+	e.busy.Add(1)
+	defer e.busy.Add(-1)
+	dir, err := os.MkdirTemp("", "sniplette-remote-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+	rr, err := receiveEncode(r, dir)
+	if err == nil {
+		err = encoder.CheckOptions(rr.Encode)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	ev := &eventWriter[encoder.RemoteEvent]{w: w, update: func(u progress.Update) encoder.RemoteEvent { return encoder.RemoteEvent{Update: &u} }}
+	select {
+	case e.sem <- struct{}{}:
+		defer func() { <-e.sem }()
+	case <-r.Context().Done():
+		return
+	}
+	slog.Info("remote encode started", "from", r.RemoteAddr, "id", rr.Video.ID)
+	out, err := encoder.Encode(r.Context(), rr.Video, rr.Encode, encoder.Options{
+		FFmpegPath: e.FFmpegPath,
+		OutputPath: filepath.Join(dir, "output"+rr.Ext),
+		Threads:    rr.Threads,
+		Reporter:   ev,
+	})
+	if err != nil {
+		slog.Warn("remote encode failed", "from", r.RemoteAddr, "id", rr.Video.ID, "err", err)
+		ev.write(encoder.RemoteEvent{Error: err.Error()})
+		return
+	}
+	name, err := e.addOutput(out.OutputPath, func() { _ = os.RemoveAll(dir) })
+	if err != nil {
+		ev.write(encoder.RemoteEvent{Error: err.Error()})
+		return
+	}
+	keep = true
+	slog.Info("remote encode done", "from", r.RemoteAddr, "id", rr.Video.ID, "bytes", out.Bytes)
+	out.OutputPath = name
+	ev.write(encoder.RemoteEvent{Output: &out})
+}
+
+// handleLoad reports the encodes and jobs being handled and the workers, as
+// a remote.Load.
+func (e *RemoteServer) handleLoad(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(remote.Load{Encodes: int(e.busy.Load()), Workers: max(e.Workers, 1)})
+}
+
+// receiveEncode reads a remote encode's parts into dir and points the
+// request's paths at the saved files.
+func receiveEncode(r *http.Request, dir string) (encoder.RemoteRequest, error) {
+	var rr encoder.RemoteRequest
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return rr, err
+	}
+	var gotRequest, gotSource bool
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rr, err
+		}
+		name := part.FormName()
+		if name == encoder.RemotePartRequest {
+			if err := json.NewDecoder(part).Decode(&rr); err != nil {
+				return rr, fmt.Errorf("bad request part: %w", err)
+			}
+			gotRequest = true
+			continue
+		}
+		if !gotRequest {
+			return rr, errors.New("the request part must come first")
+		}
+		var target *string
+		switch name {
+		case encoder.RemotePartSource:
+			target, gotSource = &rr.Video.InputPath, true
+		case encoder.RemotePartIntro:
+			if rr.Encode.Intro != nil {
+				target = &rr.Encode.Intro.Path
+			}
+		case encoder.RemotePartOutro:
+			if rr.Encode.Outro != nil {
+				target = &rr.Encode.Outro.Path
+			}
+		}
+		if target == nil {
+			return rr, fmt.Errorf("unexpected part %q", name)
+		}
+		path := filepath.Join(dir, name+filepath.Ext(part.FileName()))
+		if err := saveFile(part, path); err != nil {
+			return rr, err
+		}
+		*target = path
+	}
+	if !gotSource {
+		return rr, errors.New("no source uploaded")
+	}
+	if rr.Ext == "" || strings.ContainsAny(rr.Ext, `/\`) {
+		return rr, fmt.Errorf("bad output extension %q", rr.Ext)
+	}
+	for _, b := range []*model.Bumper{rr.Encode.Intro, rr.Encode.Outro} {
+		if b != nil && !strings.HasPrefix(b.Path, dir) {
+			return rr, errors.New("a bumper was not uploaded")
+		}
+	}
+	return rr, nil
+}
+
+func saveFile(r io.Reader, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// addOutput makes path fetchable under a new random name for outputTTL;
+// done is called once it is fetched or expires.
+func (e *RemoteServer) addOutput(path string, done func()) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(b) + filepath.Ext(path)
+	e.mu.Lock()
+	e.outputs[name] = servedOutput{path: path, done: done}
+	e.mu.Unlock()
+	time.AfterFunc(outputTTL, func() {
+		if o, ok := e.takeOutput(name); ok {
+			o.done()
+		}
+	})
+	return name, nil
+}
+
+// takeOutput removes name from the outputs and returns it.
+func (e *RemoteServer) takeOutput(name string) (servedOutput, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	o, ok := e.outputs[name]
+	delete(e.outputs, name)
+	return o, ok
+}
+
+// handleOutput sends a finished output and deletes it.
+func (e *RemoteServer) handleOutput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	e.init()
+	o, ok := e.takeOutput(strings.TrimPrefix(r.URL.Path, remote.OutputsPath))
+	if !ok {
+		http.Error(w, "no such output (already fetched or expired)", http.StatusNotFound)
+		return
+	}
+	defer o.done()
+	path := o.path
+	f, err := os.Open(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(util.FileSize(path), 10))
+	if _, err := io.Copy(w, f); err != nil {
+		slog.Warn("sending remote output", "to", r.RemoteAddr, "err", err)
+	}
+}
+
+// eventWriter is the progress.Reporter of a remote encode or job: updates
+// (and, when log is set, log lines) go to the client as events E as they
+// come.
+type eventWriter[E any] struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	update func(progress.Update) E
+	log    func(progress.Log) E
+}
+
+func (ew *eventWriter[E]) write(ev E) {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	_ = json.NewEncoder(ew.w).Encode(ev)
+	if f, ok := ew.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (ew *eventWriter[E]) Update(u progress.Update) { ew.write(ew.update(u)) }
+func (ew *eventWriter[E]) Result(progress.Result)   {}
+
+func (ew *eventWriter[E]) Log(l progress.Log) {
+	if ew.log != nil {
+		ew.write(ew.log(l))
+	}
+}
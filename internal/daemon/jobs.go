@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+)
+
+// maxJobRequest bounds the JSON body of a remote job.
+const maxJobRequest = 1 << 20
+
+// handleJob waits for a free worker and downloads and encodes the requested
+// URL into a workdir, writing progress and log lines and then the result (or
+// an error) as pipeline.RemoteJobEvent lines. The outputs wait to be fetched
+// like those of encodes. The client going away cancels the job.
+func (e *RemoteServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	e.init()
+	e.busy.Add(1)
+	defer e.busy.Add(-1)
+	var req pipeline.RemoteJobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJobRequest)).Decode(&req); err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, _, err := util.DetectPlatform(req.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !pipeline.RemoteWorkable(req.Options) {
+		http.Error(w, "the job needs files or arguments of the client", http.StatusBadRequest)
+		return
+	}
+	dir, err := os.MkdirTemp("", "sniplette-remote-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	ev := &eventWriter[pipeline.RemoteJobEvent]{
+		w:      w,
+		update: func(u progress.Update) pipeline.RemoteJobEvent { return pipeline.RemoteJobEvent{Update: &u} },
+		log:    func(l progress.Log) pipeline.RemoteJobEvent { return pipeline.RemoteJobEvent{Log: &l} },
+	}
+	select {
+	case e.sem <- struct{}{}:
+		defer func() { <-e.sem }()
+	case <-r.Context().Done():
+		return
+	}
+	slog.Info("remote job started", "from", r.RemoteAddr, "url", req.URL)
+	svc := pipeline.Service{DownloaderPath: e.DownloaderPath, FFmpegPath: e.FFmpegPath}
+	res, err := svc.RunWorkerJob(r.Context(), req, dir, ev)
+	if err != nil {
+		slog.Warn("remote job failed", "from", r.RemoteAddr, "url", req.URL, "err", err)
+		fail := pipeline.RemoteJobEvent{Error: err.Error()}
+		var je *pipeline.JobError
+		if errors.As(err, &je) {
+			fail.Error, fail.Step = je.Err.Error(), je.Step // The client adds the step back
+		}
+		ev.write(fail)
+		return
+	}
+
+	// The job's directory goes once all its outputs are fetched or expire
+	var left atomic.Int32
+	left.Store(int32(len(res.Outputs)))
+	done := func() {
+		if left.Add(-1) == 0 {
+			_ = os.RemoveAll(dir)
+		}
+	}
+	for i, o := range res.Outputs {
+		name, err := e.addOutput(filepath.Join(dir, filepath.FromSlash(o.Path)), done)
+		if err != nil {
+			for range res.Outputs[i:] {
+				done()
+			}
+			keep = true // Removed by the last done
+			ev.write(pipeline.RemoteJobEvent{Error: err.Error()})
+			return
+		}
+		res.Outputs[i].Name = name
+		res.Outputs[i].Output.OutputPath = ""
+	}
+	keep = true
+	slog.Info("remote job done", "from", r.RemoteAddr, "url", req.URL, "outputs", len(res.Outputs))
+	ev.write(pipeline.RemoteJobEvent{Result: &res})
+}
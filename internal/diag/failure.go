@@ -0,0 +1,61 @@
+package diag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ig2wa/internal/dirs"
+	"ig2wa/internal/util"
+)
+
+const lastFailureFile = "last-failure.txt"
+
+// maxStderrBytes bounds how much tool stderr is kept per failure.
+const maxStderrBytes = 64 * 1024
+
+// RecordFailure stores details about a failed job (URL, error, and the failing
+// tool's command line and stderr when available), with secrets masked (see
+// RedactText), in the state dir so that `doctor --bundle` can attach it.
+// Best-effort: errors are ignored.
+func RecordFailure(url string, jobErr error) {
+	if jobErr == nil {
+		return
+	}
+	dir, err := dirs.StateDir()
+	if err != nil {
+		return
+	}
+	if err := dirs.Ensure(dir); err != nil {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "url: %s\n", url)
+	fmt.Fprintf(&b, "error: %v\n", jobErr)
+	var ce *util.CmdError
+	if errors.As(jobErr, &ce) {
+		fmt.Fprintf(&b, "command: %s\n", ce.Command)
+		fmt.Fprintf(&b, "exit: %d\n", ce.Code)
+		stderr := ce.Stderr
+		if len(stderr) > maxStderrBytes {
+			stderr = stderr[len(stderr)-maxStderrBytes:]
+		}
+		b.WriteString("\n--- stderr ---\n")
+		b.Write(stderr)
+	}
+	_ = os.WriteFile(filepath.Join(dir, lastFailureFile), []byte(RedactText(b.String())), 0o644)
+}
+
+// LastFailurePath returns the path of the most recent failure record.
+func LastFailurePath() (string, error) {
+	dir, err := dirs.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, lastFailureFile), nil
+}
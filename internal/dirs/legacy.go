@@ -0,0 +1,24 @@
+package dirs
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// LegacyName is the app's name before it became sniplette; its directories,
+// temp dirs, and IG2WA_* env vars are what `sniplette migrate` moves over.
+const LegacyName = "ig2wa"
+
+// Legacy returns where dir, one of this package's directories, was under
+// LegacyName: the path with its app-name element renamed. It returns dir
+// itself when there is no such element.
+func Legacy(dir string) string {
+	parts := strings.Split(filepath.Clean(dir), string(filepath.Separator))
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] == appName {
+			parts[i] = LegacyName
+			return strings.Join(parts, string(filepath.Separator))
+		}
+	}
+	return dir
+}
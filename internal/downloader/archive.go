@@ -0,0 +1,91 @@
+package downloader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"ig2wa/internal/util"
+)
+
+// Archive is a yt-dlp --download-archive file: one "<extractor> <id>" line
+// per downloaded video. Sharing one file with yt-dlp's own runs means a video
+// fetched by either is skipped by both.
+type Archive struct {
+	path string
+	mu   sync.Mutex
+	ids  map[string]bool
+}
+
+// OpenArchive reads the archive at path; a missing file is an empty archive.
+func OpenArchive(path string) (*Archive, error) {
+	a := &Archive{path: path, ids: map[string]bool{}}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			a.ids[line] = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// archiveKey is yt-dlp's archive line for a video: the lowercased extractor
+// name and the ID. It is "" when the URL's platform is unknown or the URL is
+// a direct media link, whose ID is only a file name.
+func archiveKey(url, id string) string {
+	pl, _, err := util.DetectPlatform(url)
+	if err != nil || pl == util.PlatformDirect || id == "" {
+		return ""
+	}
+	// yt-dlp's extractor keys for our platforms match the platform names
+	// ("Youtube", "Instagram", "Facebook").
+	return string(pl) + " " + id
+}
+
+// Has reports whether the video is in the archive.
+func (a *Archive) Has(url, id string) bool {
+	key := archiveKey(url, id)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return key != "" && a.ids[key]
+}
+
+// Add appends the video to the archive file unless it is already listed.
+func (a *Archive) Add(url, id string) error {
+	key := archiveKey(url, id)
+	if key == "" {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ids[key] {
+		return nil
+	}
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(key + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	a.ids[key] = true
+	return nil
+}
@@ -0,0 +1,111 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// DefaultBackend is used when no backend is configured for a URL.
+const DefaultBackend = "yt-dlp"
+
+// Backend fetches metadata (and, unless Options.MetadataOnly, the media) for a
+// URL into a temp workdir. Implementations report progress through
+// Options.Reporter and return the workdir even on failure so callers can clean up.
+type Backend interface {
+	Name() string
+	Download(ctx context.Context, url string, opts Options) (model.DownloadedVideo, string, error)
+}
+
+var backends = map[string]Backend{}
+
+// RegisterBackend makes a backend selectable by name. Registering an existing
+// name replaces it.
+func RegisterBackend(b Backend) {
+	backends[b.Name()] = b
+}
+
+func init() {
+	RegisterBackend(ytdlpBackend{})
+	RegisterBackend(galleryDLBackend{})
+	RegisterBackend(httpBackend{})
+}
+
+// BackendNames returns the registered backend names, sorted.
+func BackendNames() []string {
+	names := make([]string, 0, len(backends))
+	for n := range backends {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupBackend resolves a backend by name; empty selects DefaultBackend.
+func LookupBackend(name string) (Backend, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+	b, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown downloader backend %q", name)
+	}
+	return b, nil
+}
+
+// SelectBackend picks the backend name for url: the entry for its platform in
+// byPlatform if present, else fallback (which may be empty for DefaultBackend).
+// Direct media links use the http backend unless byPlatform["direct"] or a
+// fallback other than DefaultBackend names another; yt-dlp would only run
+// its generic extractor on them.
+func SelectBackend(url string, byPlatform map[string]string, fallback string) string {
+	pl, _, err := util.DetectPlatform(url)
+	if err != nil {
+		return fallback
+	}
+	if name, ok := byPlatform[string(pl)]; ok && name != "" {
+		return name
+	}
+	if pl == util.PlatformDirect && (fallback == "" || fallback == DefaultBackend) {
+		return "http"
+	}
+	return fallback
+}
+
+// Download fetches metadata (and optionally downloads the media) for a given URL
+// using the backend named in opts.Backend.
+// With opts.Source set, or a hit in the source cache, that file stands in for
+// the download.
+// Returns the DownloadedVideo and the temp workdir used (for caller to cleanup).
+func Download(ctx context.Context, url string, opts Options) (model.DownloadedVideo, string, error) {
+	b, err := LookupBackend(opts.Backend)
+	if err != nil {
+		return model.DownloadedVideo{}, "", err
+	}
+	cache := useSourceCache(opts)
+	if opts.Source == "" && cache {
+		if opts.Source = cachedSource(url, opts.Format); opts.Source != "" {
+			slog.Info("using cached source", "url", url, "path", opts.Source)
+		}
+	}
+	if opts.Source == "" || opts.MetadataOnly {
+		dv, workdir, err := b.Download(ctx, url, opts)
+		if err == nil && cache {
+			dv = storeSource(url, dv, opts.Format, opts.SourceCacheMB)
+		}
+		return dv, workdir, err
+	}
+	// Reuse a file downloaded earlier; the metadata still comes from the
+	// backend (or its cache).
+	opts.MetadataOnly = true
+	dv, workdir, err := b.Download(ctx, url, opts)
+	if err != nil {
+		return dv, workdir, err
+	}
+	dv.InputPath = opts.Source
+	return dv, workdir, nil
+}
@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"ig2wa/internal/model"
@@ -19,6 +23,8 @@ import (
 
 var ErrThreadsUnsupported = errors.New("threads not supported (yt-dlp has no extractor)")
 
+const defaultFormat = "bestvideo+bestaudio/best"
+
 func isThreadsURL(raw string) bool {
 	s := strings.ToLower(strings.TrimSpace(raw))
 	if i := strings.Index(s, "://"); i != -1 {
@@ -30,24 +36,63 @@ func isThreadsURL(raw string) bool {
 
 // Options controls downloader behavior.
 type Options struct {
+	Backend        string // Backend name (see RegisterBackend); empty uses DefaultBackend
 	DownloaderPath string // Path to yt-dlp or youtube-dl
+	FFmpegPath     string // ffmpeg yt-dlp merges separate video and audio streams with; empty searches PATH
+	BackendPath    string // Binary for non-yt-dlp backends; empty searches PATH
 	Verbose        bool
-	KeepTemp       bool // Reserved for future; cleanup handled by caller
-	MetadataOnly   bool // If true, only fetch metadata; do not download the media file
+	KeepTemp       bool     // Reserved for future; cleanup handled by caller
+	TempBase       string   // Parent dir for the job workdir; empty uses the cache temp dir
+	MetadataOnly   bool     // If true, only fetch metadata; do not download the media file
+	Source         string   // Already-downloaded media file to use; only metadata is fetched
+	SourceCacheMB  int      // Keep downloads in the source cache, up to this size; 0 = off
+	Format         string   // yt-dlp format selector; empty uses defaultFormat
+	ExtraArgs      []string // Raw yt-dlp options placed after generated ones, before the URL
+
+	Cookies            string // yt-dlp --cookies file
+	CookiesFromBrowser string // yt-dlp --cookies-from-browser value
+	RateLimit          string // yt-dlp --limit-rate value
+	GeoBypass          string // See GeoBypassArgs; "" = yt-dlp default
+	SourceAddress      string // yt-dlp --source-address value
+
+	MetadataTimeout time.Duration // Limit for the metadata fetch; 0 = none
+	DownloadTimeout time.Duration // Limit for the media download; 0 = none
+
+	MetadataCacheTTL time.Duration // Reuse metadata fetched this recently; 0 = always fetch
+
+	WaitLive time.Duration // Wait this long for a live or upcoming stream to end; 0 = fail at once
+
+	// SelectFormat, when set, is called after metadata arrives with the formats
+	// yt-dlp reports. A non-empty return overrides Format for the download.
+	SelectFormat func(ctx context.Context, formats []Format) (string, error)
+
+	// Section, when set, is called before the download. A non-zero range
+	// (end 0 = to the end) downloads only that part with yt-dlp
+	// --download-sections; if that fails, the whole video is downloaded.
+	// Section gets the metadata only if SectionNeedsInfo (e.g. to look up a
+	// chapter) or it is cached; otherwise only dv.URL is set.
+	Section          func(dv model.DownloadedVideo) (start, end float64)
+	SectionNeedsInfo bool
 
 	// Progress reporting (optional)
 	Reporter progress.Reporter
 	JobID    string
 }
 
-// Download fetches metadata (and optionally downloads the media) for a given URL.
-// Returns the DownloadedVideo and the temp workdir used (for caller to cleanup).
-func Download(ctx context.Context, url string, opts Options) (model.DownloadedVideo, string, error) {
+type ytdlpBackend struct{}
+
+func (ytdlpBackend) Name() string { return DefaultBackend }
+
+// Download downloads the selected format into a fresh temp workdir. When
+// nothing has to be decided from the metadata up front (no format picking,
+// no chapter lookup) and it isn't cached, metadata and media come from one
+// yt-dlp call via --write-info-json; otherwise a --dump-json call runs first.
+func (ytdlpBackend) Download(ctx context.Context, url string, opts Options) (model.DownloadedVideo, string, error) {
 	if opts.DownloaderPath == "" {
 		return model.DownloadedVideo{}, "", errors.New("downloader path is required")
 	}
 
-	workdir, err := util.MakeTempWorkdir("job")
+	workdir, err := util.MakeTempWorkdirIn(opts.TempBase, "job")
 	if err != nil {
 		return model.DownloadedVideo{}, "", fmt.Errorf("create temp dir: %w", err)
 	}
@@ -72,39 +117,55 @@ func Download(ctx context.Context, url string, opts Options) (model.DownloadedVi
 		normURL = util.NormalizeURL(url, pl)
 	}
 
-	// First: get metadata as JSON
-	info, err := fetchMetadata(ctx, opts, normURL)
-	if err != nil {
-		return model.DownloadedVideo{}, workdir, err
-	}
+	// Each yt-dlp call counts against the site's rate limits, so fetch
+	// metadata separately only when it is needed before downloading.
+	_, cached := cachedMetadata(normURL, opts.MetadataCacheTTL)
+	// Waiting for a stream to end (WaitLive) needs its status up front.
+	oneCall := !cached && !opts.MetadataOnly && opts.SelectFormat == nil && !(opts.Section != nil && opts.SectionNeedsInfo) &&
+		!(opts.WaitLive > 0 && liveArgs(normURL) != nil)
 
+	var info YTDLPInfo
+	var metaTime time.Duration
+	if !oneCall {
+		metaStart := time.Now()
+		info, err = fetchMetadata(ctx, opts, normURL)
+		if err != nil {
+			return model.DownloadedVideo{}, workdir, err
+		}
+		if info, err = checkLive(ctx, opts, url, info); err != nil {
+			return model.DownloadedVideo{}, workdir, err
+		}
+		metaTime = time.Since(metaStart)
+		slog.Debug("metadata fetched", "url", normURL, "id", info.ID, "duration", info.Duration,
+			"width", info.Width, "height", info.Height, "formats", len(info.Formats))
+	}
+	dv := info.downloadedVideo(url)
+	dv.MetadataTime = metaTime
+	dv.Formats = info.sourceFormats(formatOrDefault(opts.Format))
 	// If only metadata is needed (dry-run), return early with no InputPath
 	if opts.MetadataOnly {
-		return model.DownloadedVideo{
-			InputPath:   "",
-			DurationSec: info.Duration,
-			Title:       info.Title,
-			Uploader:    info.Uploader,
-			ID:          info.ID,
-			Description: info.Description,
-			Width:       info.Width,
-			Height:      info.Height,
-			URL:         url,
-		}, workdir, nil
+		return dv, workdir, nil
 	}
 
-	// Download best available file into workdir
-	// Use a fixed template based on ID to know where the file lands.
-	outTemplate := filepath.Join(workdir, "%(id)s.%(ext)s")
-	args := []string{
-		"-f", "bestvideo+bestaudio/best",
-		"-o", outTemplate,
-		"--no-playlist",
+	format := formatOrDefault(opts.Format)
+	if opts.SelectFormat != nil && len(info.Formats) > 0 {
+		sel, serr := opts.SelectFormat(ctx, info.Formats)
+		if serr != nil {
+			return model.DownloadedVideo{}, workdir, fmt.Errorf("select format: %w", serr)
+		}
+		if sel != "" {
+			format = sel
+		}
 	}
-	if opts.Reporter != nil {
-		args = append(args, "--newline")
+
+	var sectionStart float64
+	var section []string
+	if opts.Section != nil {
+		start, end := opts.Section(dv)
+		sectionStart, section = start, sectionArgs(start, end)
 	}
-	args = append(args, normURL)
+
+	slog.Debug("downloading", "url", normURL, "format", format, "workdir", workdir, "section", section)
 
 	if opts.Reporter != nil {
 		opts.Reporter.Update(progress.Update{
@@ -115,11 +176,77 @@ func Download(ctx context.Context, url string, opts Options) (model.DownloadedVi
 		})
 	}
 
-	_, runErr := util.Run(ctx, util.CmdSpec{
+	var infoArgs []string
+	if oneCall {
+		infoArgs = []string{"--write-info-json"}
+	}
+
+	// Download best available file into workdir
+	runErr := runDownload(ctx, opts, downloadArgs(opts, format, workdir, normURL, append(section, infoArgs...)...), workdir)
+	if runErr != nil && section != nil && ctx.Err() == nil {
+		// Not every extractor/protocol can be cut while downloading.
+		slog.Info("section download failed; downloading the whole video", "url", normURL, "err", runErr)
+		removePartial(workdir)
+		sectionStart, section = 0, nil
+		runErr = runDownload(ctx, opts, downloadArgs(opts, format, workdir, normURL, infoArgs...), workdir)
+	}
+	if runErr != nil {
+		return model.DownloadedVideo{}, workdir, fmt.Errorf("downloader failed: %w", loginHint(url, opts, runErr))
+	}
+
+	if oneCall {
+		if info, err = readInfoJSON(workdir); err != nil {
+			// yt-dlp skips live streams (liveArgs) without an error.
+			if live, lerr := fetchMetadata(ctx, opts, normURL); lerr == nil && live.notReady() {
+				_, err = checkLive(ctx, opts, url, live)
+			}
+			return model.DownloadedVideo{}, workdir, err
+		}
+		storeMetadata(normURL, info, opts.MetadataCacheTTL)
+		dv = info.downloadedVideo(url)
+	}
+	input, err := resolveDownload(workdir, info.ID)
+	if err != nil {
+		return model.DownloadedVideo{}, workdir, err
+	}
+	dv.InputPath = input
+	if section != nil {
+		dv.Sectioned, dv.SectionStartSec = true, sectionStart
+	}
+	return dv, workdir, nil
+}
+
+// readInfoJSON reads and removes the metadata file --write-info-json left in
+// workdir, so only the media file remains there.
+func readInfoJSON(workdir string) (YTDLPInfo, error) {
+	files, _ := filepath.Glob(filepath.Join(workdir, "*.info.json"))
+	if len(files) == 0 {
+		return YTDLPInfo{}, errors.New("download succeeded but yt-dlp wrote no metadata")
+	}
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		return YTDLPInfo{}, err
+	}
+	for _, f := range files {
+		_ = os.Remove(f)
+	}
+	var info YTDLPInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return YTDLPInfo{}, fmt.Errorf("parse metadata JSON: %w", err)
+	}
+	return info, nil
+}
+
+// runDownload runs the yt-dlp download call, forwarding its progress.
+func runDownload(ctx context.Context, opts Options, args []string, workdir string) error {
+	var tc transferCounter
+	_, err := util.Run(ctx, util.CmdSpec{
 		Path:    opts.DownloaderPath,
 		Args:    args,
 		Dir:     workdir,
 		Verbose: opts.Verbose && opts.Reporter == nil,
+		Timeout: opts.DownloadTimeout,
+		Stage:   "download",
 		StdoutLine: func(line string) {
 			if opts.Reporter == nil {
 				return
@@ -130,6 +257,7 @@ func Download(ctx context.Context, url string, opts Options) (model.DownloadedVi
 			}
 			// Try to parse progress lines (yt-dlp --newline commonly writes progress to stdout)
 			if u, ok := parseYTDLPProgress(line, opts.JobID); ok {
+				tc.count(&u, line)
 				opts.Reporter.Update(u)
 			}
 		},
@@ -143,50 +271,67 @@ func Download(ctx context.Context, url string, opts Options) (model.DownloadedVi
 			}
 			// Try to parse progress lines
 			if u, ok := parseYTDLPProgress(line, opts.JobID); ok {
+				tc.count(&u, line)
 				opts.Reporter.Update(u)
 			}
 		},
 	})
-	if runErr != nil {
-		return model.DownloadedVideo{}, workdir, fmt.Errorf("downloader failed: %w", runErr)
+	return err
+}
+
+// transferCounter turns yt-dlp's per-file byte counts into a running total
+// for the job: video and audio are separate files, each counted from zero.
+type transferCounter struct {
+	mu   sync.Mutex
+	done int64 // Bytes of the files already finished
+	cur  int64 // Bytes of the file in progress
+}
+
+// count sets u.Bytes to the total so far, if the line carried a count.
+func (t *transferCounter) count(u *progress.Update, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if strings.Contains(line, "Destination:") {
+		t.done, t.cur = t.done+t.cur, 0
 	}
+	if u.Bytes == nil {
+		return
+	}
+	t.cur = *u.Bytes
+	total := t.done + t.cur
+	u.Bytes = &total
+}
 
-	// Resolve actual downloaded path(s)
-	candidates, globErr := filepath.Glob(filepath.Join(workdir, info.ID+".*"))
-	if globErr != nil {
-		return model.DownloadedVideo{}, workdir, fmt.Errorf("resolve download: %w", globErr)
+// sectionArgs returns yt-dlp options that download only start-end (end 0 =
+// to the end), or nil for the whole video. The cut is made exactly at start,
+// not at the keyframe before it, as the encode takes the file to begin there
+// (see model.DownloadedVideo.SectionStartSec).
+func sectionArgs(start, end float64) []string {
+	if start <= 0 && end <= 0 {
+		return nil
 	}
-	if len(candidates) == 0 {
-		// fallback: try find any file in workdir
-		all, _ := filepath.Glob(filepath.Join(workdir, "*"))
-		if len(all) == 0 {
-			return model.DownloadedVideo{}, workdir, errors.New("download succeeded but no output file found")
-		}
-		candidates = all
+	to := "inf"
+	if end > 0 {
+		to = strconv.FormatFloat(end, 'f', -1, 64)
 	}
+	return []string{"--download-sections", "*" + strconv.FormatFloat(start, 'f', -1, 64) + "-" + to, "--force-keyframes-at-cuts"}
+}
 
-	// Prefer common playable containers/extensions
-	sort.SliceStable(candidates, func(i, j int) bool {
-		pri := extPriority(filepath.Ext(candidates[i]))
-		prj := extPriority(filepath.Ext(candidates[j]))
-		if pri == prj {
-			return candidates[i] < candidates[j]
-		}
-		return pri < prj
-	})
-	input := candidates[0]
-
-	return model.DownloadedVideo{
-		InputPath:   input,
-		DurationSec: info.Duration,
-		Title:       info.Title,
-		Uploader:    info.Uploader,
-		ID:          info.ID,
-		Description: info.Description,
-		Width:       info.Width,
-		Height:      info.Height,
-		URL:         url,
-	}, workdir, nil
+// removePartial clears what a failed download left in workdir.
+func removePartial(workdir string) {
+	files, _ := filepath.Glob(filepath.Join(workdir, "*"))
+	for _, f := range files {
+		_ = os.RemoveAll(f)
+	}
+}
+
+// FetchInfo returns yt-dlp's metadata for url, including the available
+// formats, without downloading anything.
+func FetchInfo(ctx context.Context, url string, opts Options) (YTDLPInfo, error) {
+	if opts.DownloaderPath == "" {
+		return YTDLPInfo{}, errors.New("downloader path is required")
+	}
+	return fetchMetadata(ctx, opts, url)
 }
 
 func fetchMetadata(ctx context.Context, opts Options, url string) (YTDLPInfo, error) {
@@ -200,17 +345,26 @@ func fetchMetadata(ctx context.Context, opts Options, url string) (YTDLPInfo, er
 	if isThreadsURL(url) || isThreadsURL(normURL) {
 		return YTDLPInfo{}, ErrThreadsUnsupported
 	}
+	if info, ok := cachedMetadata(normURL, opts.MetadataCacheTTL); ok {
+		slog.Debug("metadata from cache", "url", normURL, "id", info.ID)
+		return info, nil
+	}
 
 	args := []string{
 		"--dump-json",
-		"-f", "bestvideo+bestaudio/best",
+		"-f", formatOrDefault(opts.Format),
 		"--no-playlist",
-		normURL,
 	}
+	args = append(args, itemArgs(normURL)...)
+	args = append(args, opts.sourceArgs()...)
+	args = append(args, opts.ExtraArgs...)
+	args = append(args, normURL)
 	res, runErr := util.Run(ctx, util.CmdSpec{
 		Path:    opts.DownloaderPath,
 		Args:    args,
 		Verbose: opts.Verbose && opts.Reporter == nil,
+		Timeout: opts.MetadataTimeout,
+		Stage:   "metadata",
 		// Forward stderr lines to Reporter logs in verbose UI mode (optional)
 		StderrLine: func(line string) {
 			if opts.Reporter != nil && opts.Verbose {
@@ -223,12 +377,15 @@ func fetchMetadata(ctx context.Context, opts Options, url string) (YTDLPInfo, er
 		if strings.Contains(msg, "unsupported url") && (strings.Contains(msg, "threads.net") || strings.Contains(msg, "threads.com")) {
 			return YTDLPInfo{}, ErrThreadsUnsupported
 		}
-		return YTDLPInfo{}, fmt.Errorf("metadata fetch failed: %w", runErr)
+		return YTDLPInfo{}, fmt.Errorf("metadata fetch failed: %w", loginHint(url, opts, runErr))
 	}
 
 	// yt-dlp sometimes prints progress/info to stderr but JSON to stdout
 	// Parse the last JSON object if multiple lines exist.
 	data := strings.TrimSpace(string(res.Stdout))
+	if data == "" && util.StoryItemID(normURL) != "" {
+		return YTDLPInfo{}, errors.New("story item not found: it may have expired or been removed from the highlight")
+	}
 	dec := json.NewDecoder(strings.NewReader(data))
 	var info YTDLPInfo
 	if err := dec.Decode(&info); err != nil {
@@ -251,9 +408,247 @@ func fetchMetadata(ctx context.Context, opts Options, url string) (YTDLPInfo, er
 			return YTDLPInfo{}, fmt.Errorf("parse metadata JSON: %w", lastErr)
 		}
 	}
+	info.Selector = formatOrDefault(opts.Format)
+	if !info.notReady() {
+		storeMetadata(normURL, info, opts.MetadataCacheTTL) // a stream's status changes
+	}
 	return info, nil
 }
 
+// resolveDownload finds the media file a backend left in workdir, preferring
+// files named after id and common playable containers. Leftovers of a merge
+// (the separate video and audio streams, <id>.f<format>.<ext>) and partial
+// downloads are skipped.
+func resolveDownload(workdir, id string) (string, error) {
+	var candidates []string
+	if id != "" {
+		m, err := filepath.Glob(filepath.Join(workdir, id+".*"))
+		if err != nil {
+			return "", fmt.Errorf("resolve download: %w", err)
+		}
+		candidates = mediaFiles(m)
+	}
+	if len(candidates) == 0 {
+		// fallback: try find any file in workdir
+		all, _ := filepath.Glob(filepath.Join(workdir, "*"))
+		candidates = mediaFiles(all)
+		if len(candidates) == 0 {
+			return "", errors.New("download succeeded but no output file found")
+		}
+	}
+
+	var merged []string
+	for _, c := range candidates {
+		if !formatPartRe.MatchString(filepath.Base(c)) {
+			merged = append(merged, c)
+		}
+	}
+	switch {
+	case len(merged) > 0:
+		candidates = merged
+	case len(candidates) > 1:
+		// Only separate streams: yt-dlp couldn't merge them.
+		return "", errors.New("download left separate video and audio streams: yt-dlp could not merge them (is ffmpeg installed? try 'sniplette deps install --ffmpeg')")
+	}
+
+	// Prefer common playable containers/extensions
+	sort.SliceStable(candidates, func(i, j int) bool {
+		pri := extPriority(filepath.Ext(candidates[i]))
+		prj := extPriority(filepath.Ext(candidates[j]))
+		if pri == prj {
+			return candidates[i] < candidates[j]
+		}
+		return pri < prj
+	})
+	slog.Debug("download resolved", "path", candidates[0], "candidates", len(candidates))
+	return candidates[0], nil
+}
+
+// formatPartRe matches the streams yt-dlp downloads before merging them:
+// <id>.f<format id>.<ext>.
+var formatPartRe = regexp.MustCompile(`\.f[0-9A-Za-z_-]+\.[0-9A-Za-z]+$`)
+
+// mediaFiles drops partial downloads and sidecar files from paths.
+func mediaFiles(paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		name := strings.ToLower(filepath.Base(p))
+		switch {
+		case strings.HasSuffix(name, ".part"), strings.HasSuffix(name, ".ytdl"),
+			strings.HasSuffix(name, ".temp"), strings.Contains(name, ".part-frag"),
+			strings.HasSuffix(name, ".info.json"):
+			continue
+		}
+		if fi, err := os.Stat(p); err != nil || fi.IsDir() {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// BuildDownloadArgs returns the yt-dlp arguments (after the binary) used to
+// download url into workdir, for display and copy-paste. A non-zero start or
+// end downloads only that section, as Download does with Options.Section.
+func BuildDownloadArgs(url string, opts Options, workdir string, start, end float64) ([]string, error) {
+	if name := opts.Backend; name != "" && name != DefaultBackend {
+		return nil, fmt.Errorf("the %s backend does not run yt-dlp", name)
+	}
+	normURL := url
+	if pl, _, err := util.DetectPlatform(url); err == nil {
+		normURL = util.NormalizeURL(url, pl)
+	}
+	return downloadArgs(opts, formatOrDefault(opts.Format), workdir, normURL, sectionArgs(start, end)...), nil
+}
+
+// downloadArgs builds the yt-dlp download call. A fixed output template based
+// on the ID tells us where the file lands.
+func downloadArgs(opts Options, format, workdir, url string, extra ...string) []string {
+	args := []string{
+		"-f", format,
+		"-o", filepath.Join(workdir, "%(id)s.%(ext)s"),
+		"--no-playlist",
+	}
+	if opts.Reporter != nil {
+		args = append(args, "--newline")
+	}
+	if opts.RateLimit != "" {
+		args = append(args, "--limit-rate", opts.RateLimit)
+	}
+	if opts.FFmpegPath != "" {
+		args = append(args, "--ffmpeg-location", opts.FFmpegPath)
+	}
+	args = append(args, extra...)
+	args = append(args, itemArgs(url)...)
+	args = append(args, liveArgs(url)...)
+	args = append(args, opts.sourceArgs()...)
+	args = append(args, opts.ExtraArgs...)
+	return append(args, url)
+}
+
+// StreamURLs asks yt-dlp for the direct media URLs of the selected format
+// without downloading it: one URL, or video then audio for merged formats.
+// Only the yt-dlp backend supports this.
+func StreamURLs(ctx context.Context, url string, opts Options) ([]string, error) {
+	if name := opts.Backend; name != "" && name != DefaultBackend {
+		return nil, fmt.Errorf("stream URLs need the %s backend, not %s", DefaultBackend, name)
+	}
+	if opts.DownloaderPath == "" {
+		return nil, errors.New("downloader path is required")
+	}
+	normURL := url
+	if pl, _, err := util.DetectPlatform(url); err == nil {
+		normURL = util.NormalizeURL(url, pl)
+	}
+	args := []string{"-g", "-f", formatOrDefault(opts.Format), "--no-playlist"}
+	args = append(args, itemArgs(normURL)...)
+	args = append(args, opts.sourceArgs()...)
+	args = append(args, opts.ExtraArgs...)
+	args = append(args, normURL)
+	res, err := util.Run(ctx, util.CmdSpec{
+		Path:    opts.DownloaderPath,
+		Args:    args,
+		Timeout: opts.MetadataTimeout,
+		Stage:   "metadata",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resolve stream URLs: %w", err)
+	}
+	var urls []string
+	for _, line := range strings.Split(string(res.Stdout), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "http") {
+			urls = append(urls, line)
+		}
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("resolve stream URLs: yt-dlp returned none")
+	}
+	return urls, nil
+}
+
+// loginMarkers are phrases yt-dlp uses for videos that need a login.
+var loginMarkers = []string{"login", "log in", "logged-in", "cookies", "registered users", "private"}
+
+// loginHint adds how to pass cookies to a yt-dlp failure that says the video
+// needs a login, unless cookies were given. Most Facebook videos do.
+func loginHint(url string, opts Options, err error) error {
+	var ce *util.CmdError
+	if opts.Cookies != "" || opts.CookiesFromBrowser != "" || !errors.As(err, &ce) {
+		return err
+	}
+	stderr := strings.ToLower(string(ce.Stderr))
+	for _, m := range loginMarkers {
+		if strings.Contains(stderr, m) {
+			name := "<platform>"
+			if pl, _, perr := util.DetectPlatform(url); perr == nil {
+				name = string(pl)
+			}
+			return fmt.Errorf("%w (the video needs a login: pass --cookies-from-browser firefox or --cookies <file>, or set platforms.%s.cookies_from_browser)", err, name)
+		}
+	}
+	return err
+}
+
+// itemArgs narrows yt-dlp to the story item a link is pinned to, since it
+// extracts stories and highlights as a whole (see util.StoryItemID).
+func itemArgs(url string) []string {
+	if id := util.StoryItemID(url); id != "" {
+		return []string{"--match-filters", "id=" + id}
+	}
+	return nil
+}
+
+// sourceArgs returns the yt-dlp options both the metadata and download calls need.
+func (o Options) sourceArgs() []string {
+	var args []string
+	if o.Cookies != "" {
+		args = append(args, "--cookies", o.Cookies)
+	}
+	if o.CookiesFromBrowser != "" {
+		args = append(args, "--cookies-from-browser", o.CookiesFromBrowser)
+	}
+	geo, _ := GeoBypassArgs(o.GeoBypass) // validated with the rest of the run options
+	args = append(args, geo...)
+	if o.SourceAddress != "" {
+		args = append(args, "--source-address", o.SourceAddress)
+	}
+	return args
+}
+
+// GeoBypassArgs maps a geo_bypass setting to yt-dlp options: "never" turns
+// the bypass off, a two-letter country code fakes an address from that
+// country, and an IP block (CIDR) fakes one from the block.
+func GeoBypassArgs(v string) ([]string, error) {
+	switch {
+	case v == "":
+		return nil, nil
+	case strings.EqualFold(v, "never"):
+		return []string{"--no-geo-bypass"}, nil
+	case len(v) == 2 && isLetters(v):
+		return []string{"--geo-bypass-country", strings.ToUpper(v)}, nil
+	}
+	if _, _, err := net.ParseCIDR(v); err == nil {
+		return []string{"--geo-bypass-ip-block", v}, nil
+	}
+	return nil, fmt.Errorf("%q is not never, a country code (US), or an IP block (1.2.3.0/24)", v)
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func formatOrDefault(f string) string {
+	if strings.TrimSpace(f) == "" {
+		return defaultFormat
+	}
+	return f
+}
+
 func extPriority(ext string) int {
 	e := strings.ToLower(strings.TrimPrefix(ext, "."))
 	switch e {
@@ -273,7 +668,7 @@ func extPriority(ext string) int {
 // CleanupWorkdir removes the given temp workdir (best-effort).
 // Not strictly required but useful if a caller wants explicit cleanup here.
 func CleanupWorkdir(dir string) {
-	_ = os.RemoveAll(dir)
+	_ = util.RemoveTempWorkdir(dir)
 }
 
 func parseYTDLPProgress(line, jobID string) (u progress.Update, ok bool) {
@@ -309,6 +704,16 @@ func parseYTDLPProgress(line, jobID string) (u progress.Update, ok bool) {
 				}
 			}
 		}
+		// size: " of <size>", or " of ~ <size>" while yt-dlp estimates it
+		if i := strings.Index(line, " of "); i != -1 && u.Percent >= 0 {
+			rest := strings.TrimLeft(line[i+4:], " ~")
+			if sz := strings.Fields(rest); len(sz) > 0 {
+				if total, ok := progress.ParseSize(sz[0]); ok {
+					b := int64(total * u.Percent / 100)
+					u.Bytes = &b
+				}
+			}
+		}
 		// ETA parsing
 		if j := strings.Index(line, " ETA "); j != -1 {
 			rest := strings.TrimSpace(line[j+5:])
@@ -323,15 +728,35 @@ func parseYTDLPProgress(line, jobID string) (u progress.Update, ok bool) {
 		}
 		return u, true
 	}
-	if strings.Contains(line, "Merging formats") || strings.Contains(line, "[Merger]") {
+	if msg := postprocessorStep(line); msg != "" {
 		u.Stage = progress.StageMerging
-		u.Message = "Merging"
+		u.Message = msg
 		u.Percent = -1
 		return u, true
 	}
 	return u, false
 }
 
+// postprocessorStep describes the step of a line yt-dlp prints while it runs
+// ffmpeg on the finished download (merging streams, fixing the container),
+// or returns "" for other lines. These steps report no progress of their own.
+func postprocessorStep(line string) string {
+	tag, _, ok := strings.Cut(strings.TrimSpace(line), "]")
+	if !ok || !strings.HasPrefix(tag, "[") {
+		return ""
+	}
+	switch tag = tag[1:]; {
+	case tag == "Merger" || strings.Contains(line, "Merging formats"):
+		return "Merging video and audio"
+	case strings.HasPrefix(tag, "Fixup"):
+		return "Fixing container"
+	case tag == "ffmpeg" || tag == "VideoRemuxer" || tag == "VideoConvertor" || tag == "Metadata" ||
+		tag == "EmbedThumbnail" || tag == "ModifyChapters":
+		return "Post-processing"
+	}
+	return ""
+}
+
 func parseETA(s string) (time.Duration, error) {
 	parts := strings.Split(s, ":")
 	if len(parts) == 2 {
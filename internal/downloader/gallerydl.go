@@ -0,0 +1,184 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+)
+
+// galleryDLBackend uses gallery-dl, which often keeps working for Instagram
+// when yt-dlp's extractor is broken. Only the first video of a post is used.
+type galleryDLBackend struct{}
+
+func (galleryDLBackend) Name() string { return "gallery-dl" }
+
+const galleryDLVideoFilter = "extension in ('mp4', 'webm', 'mov', 'mkv', 'm4v')"
+
+func (galleryDLBackend) Download(ctx context.Context, url string, opts Options) (model.DownloadedVideo, string, error) {
+	bin := opts.BackendPath
+	if bin == "" {
+		p, err := exec.LookPath("gallery-dl")
+		if err != nil {
+			return model.DownloadedVideo{}, "", errors.New("gallery-dl backend selected but gallery-dl was not found in PATH")
+		}
+		bin = p
+	}
+
+	workdir, err := util.MakeTempWorkdirIn(opts.TempBase, "job")
+	if err != nil {
+		return model.DownloadedVideo{}, "", fmt.Errorf("create temp dir: %w", err)
+	}
+	reportStage(opts, progress.StageMetadata, -1, "Fetching metadata")
+
+	metaStart := time.Now()
+	res, runErr := util.Run(ctx, util.CmdSpec{
+		Path:    bin,
+		Args:    []string{"--dump-json", "--filter", galleryDLVideoFilter, url},
+		Verbose: opts.Verbose && opts.Reporter == nil,
+		Timeout: opts.MetadataTimeout,
+		Stage:   "metadata",
+	})
+	if runErr != nil && len(res.Stdout) == 0 {
+		return model.DownloadedVideo{}, workdir, fmt.Errorf("metadata fetch failed: %w", runErr)
+	}
+	dv, err := parseGalleryDLJSON(res.Stdout)
+	if err != nil {
+		return model.DownloadedVideo{}, workdir, err
+	}
+	dv.URL, dv.MetadataTime = url, time.Since(metaStart)
+	slog.Debug("metadata fetched", "backend", "gallery-dl", "url", url, "id", dv.ID)
+	if opts.MetadataOnly {
+		return dv, workdir, nil
+	}
+
+	reportStage(opts, progress.StageDownloading, -1, "Downloading")
+	if _, err := util.Run(ctx, util.CmdSpec{
+		Path:    bin,
+		Args:    []string{"--directory", workdir, "--filter", galleryDLVideoFilter, url},
+		Dir:     workdir,
+		Verbose: opts.Verbose && opts.Reporter == nil,
+		Timeout: opts.DownloadTimeout,
+		Stage:   "download",
+		StdoutLine: func(line string) {
+			if opts.Reporter != nil && opts.Verbose {
+				opts.Reporter.Log(progress.Log{JobID: opts.JobID, Stream: progress.StreamStdout, Line: line})
+			}
+		},
+	}); err != nil {
+		return model.DownloadedVideo{}, workdir, fmt.Errorf("downloader failed: %w", err)
+	}
+	input, err := resolveDownload(workdir, "")
+	if err != nil {
+		return model.DownloadedVideo{}, workdir, err
+	}
+	dv.InputPath = input
+	return dv, workdir, nil
+}
+
+// parseGalleryDLJSON reads `gallery-dl --dump-json` output: an array of
+// messages whose last element is the metadata dict. The first URL message
+// (type 3) describes the video; directory messages (type 2) fill gaps.
+func parseGalleryDLJSON(data []byte) (model.DownloadedVideo, error) {
+	var msgs [][]json.RawMessage
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return model.DownloadedVideo{}, fmt.Errorf("parse metadata JSON: %w", err)
+	}
+	var dv model.DownloadedVideo
+	found := false
+	for _, msg := range msgs {
+		if len(msg) < 2 {
+			continue
+		}
+		var typ int
+		if json.Unmarshal(msg[0], &typ) != nil || (typ != 2 && typ != 3) {
+			continue
+		}
+		var kw map[string]any
+		if json.Unmarshal(msg[len(msg)-1], &kw) != nil {
+			continue
+		}
+		fillFromKwdict(&dv, kw)
+		if typ == 3 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return model.DownloadedVideo{}, errors.New("gallery-dl found no video at this URL")
+	}
+	return dv, nil
+}
+
+func fillFromKwdict(dv *model.DownloadedVideo, kw map[string]any) {
+	str := func(keys ...string) string {
+		for _, k := range keys {
+			switch v := kw[k].(type) {
+			case string:
+				if v != "" {
+					return v
+				}
+			case float64:
+				return fmt.Sprintf("%.0f", v)
+			}
+		}
+		return ""
+	}
+	num := func(keys ...string) float64 {
+		for _, k := range keys {
+			if v, ok := kw[k].(float64); ok && v > 0 {
+				return v
+			}
+		}
+		return 0
+	}
+	if dv.ID == "" {
+		dv.ID = str("post_shortcode", "shortcode", "id", "media_id")
+	}
+	if dv.Uploader == "" {
+		dv.Uploader = str("username", "uploader", "author", "owner")
+	}
+	if dv.Description == "" {
+		dv.Description = str("description", "caption", "content")
+	}
+	if dv.Title == "" {
+		title := str("title")
+		if title == "" {
+			// Instagram has no titles; use the caption's first line like yt-dlp does.
+			title, _, _ = strings.Cut(dv.Description, "\n")
+		}
+		dv.Title = title
+	}
+	if dv.Width == 0 {
+		dv.Width = int(num("width"))
+	}
+	if dv.Height == 0 {
+		dv.Height = int(num("height"))
+	}
+	if dv.DurationSec == 0 {
+		dv.DurationSec = num("video_duration", "duration")
+	}
+	if dv.Thumbnail == "" {
+		dv.Thumbnail = str("display_url", "thumbnail")
+	}
+	if dv.Published.IsZero() {
+		if t, err := time.Parse("2006-01-02 15:04:05", str("date", "post_date")); err == nil {
+			dv.Published = t
+		}
+	}
+}
+
+func reportStage(opts Options, stage progress.Stage, pct float64, msg string) {
+	if opts.Reporter == nil {
+		return
+	}
+	opts.Reporter.Update(progress.Update{JobID: opts.JobID, Stage: stage, Percent: pct, Message: msg})
+}
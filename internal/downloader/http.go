@@ -0,0 +1,149 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+)
+
+// httpBackend fetches a direct media link (e.g., an .mp4 on a CDN) with a plain
+// GET, or an HLS/DASH manifest (.m3u8, .mpd) with ffmpeg. There is no metadata
+// beyond what the URL itself reveals; ffprobe fills in the rest after the
+// download.
+type httpBackend struct{}
+
+func (httpBackend) Name() string { return "http" }
+
+func (httpBackend) Download(ctx context.Context, rawURL string, opts Options) (model.DownloadedVideo, string, error) {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return model.DownloadedVideo{}, "", fmt.Errorf("invalid URL %q", rawURL)
+	}
+	workdir, err := util.MakeTempWorkdirIn(opts.TempBase, "job")
+	if err != nil {
+		return model.DownloadedVideo{}, "", fmt.Errorf("create temp dir: %w", err)
+	}
+
+	id, host := util.DirectMediaName(rawURL)
+	ext := strings.ToLower(path.Ext(u.Path))
+	if ext == "" {
+		ext = ".mp4"
+	}
+	dv := model.DownloadedVideo{
+		ID:       id,
+		Title:    id,
+		Uploader: host,
+		URL:      rawURL,
+	}
+	if opts.MetadataOnly {
+		return dv, workdir, nil
+	}
+
+	reportStage(opts, progress.StageDownloading, 0, "Starting download")
+	if util.IsStreamManifest(rawURL) {
+		dst, err := fetchManifest(ctx, rawURL, workdir, id, opts)
+		if err != nil {
+			return model.DownloadedVideo{}, workdir, err
+		}
+		dv.InputPath = dst
+		return dv, workdir, nil
+	}
+	ctx, cancel := util.StageContext(ctx, "download", opts.DownloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return model.DownloadedVideo{}, workdir, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return model.DownloadedVideo{}, workdir, fmt.Errorf("downloader failed: %w", util.TimeoutCause(ctx, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return model.DownloadedVideo{}, workdir, fmt.Errorf("downloader failed: GET %s: %s", rawURL, resp.Status)
+	}
+
+	dst := filepath.Join(workdir, util.SanitizeFilename(id)+ext)
+	f, err := os.Create(dst)
+	if err != nil {
+		return model.DownloadedVideo{}, workdir, err
+	}
+	_, copyErr := io.Copy(f, &progressReader{r: resp.Body, total: resp.ContentLength, opts: opts})
+	if cerr := f.Close(); copyErr == nil {
+		copyErr = cerr
+	}
+	if copyErr != nil {
+		return model.DownloadedVideo{}, workdir, fmt.Errorf("downloader failed: %w", util.TimeoutCause(ctx, copyErr))
+	}
+	dv.InputPath = dst
+	return dv, workdir, nil
+}
+
+// fetchManifest has ffmpeg read the HLS or DASH stream at rawURL and copy it,
+// without re-encoding, into an MP4 in workdir. ffmpeg picks the best video
+// and audio variants of a master playlist.
+func fetchManifest(ctx context.Context, rawURL, workdir, id string, opts Options) (string, error) {
+	if opts.FFmpegPath == "" {
+		return "", fmt.Errorf("downloader failed: %s is a stream manifest, which needs ffmpeg", rawURL)
+	}
+	reportStage(opts, progress.StageDownloading, -1, "Fetching stream with ffmpeg")
+	dst := filepath.Join(workdir, util.SanitizeFilename(id)+".mp4")
+	if _, err := util.Run(ctx, util.CmdSpec{
+		Path:    opts.FFmpegPath,
+		Args:    []string{"-hide_banner", "-loglevel", "error", "-nostdin", "-y", "-i", rawURL, "-sn", "-dn", "-c", "copy", dst},
+		Verbose: opts.Verbose && opts.Reporter == nil,
+		Timeout: opts.DownloadTimeout,
+		Stage:   "download",
+	}); err != nil {
+		return "", fmt.Errorf("downloader failed: %w", err)
+	}
+	return dst, nil
+}
+
+// progressReader reports download progress whenever another whole percent
+// (or, with an unknown length, another MiB) has been read.
+type progressReader struct {
+	r     io.Reader
+	total int64
+	read  int64
+	last  int64
+	opts  Options
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.opts.Reporter == nil {
+		return n, err
+	}
+	step := int64(1 << 20)
+	if p.total > 0 {
+		step = p.total / 100
+	}
+	if p.read-p.last >= step || err == io.EOF {
+		p.last = p.read
+		pct := -1.0
+		if p.total > 0 {
+			pct = float64(p.read) * 100 / float64(p.total)
+		}
+		read := p.read
+		p.opts.Reporter.Update(progress.Update{
+			JobID:   p.opts.JobID,
+			Stage:   progress.StageDownloading,
+			Percent: pct,
+			Bytes:   &read,
+			Message: "Downloading",
+		})
+	}
+	return n, err
+}
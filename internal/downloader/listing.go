@@ -0,0 +1,87 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"ig2wa/internal/util"
+)
+
+// Entry is one item of a channel, profile, or playlist listing. Flat
+// listings often leave out the duration and date; zero means unknown.
+type Entry struct {
+	ID         string  `json:"id"`
+	URL        string  `json:"url"`
+	Title      string  `json:"title"`
+	Type       string  `json:"_type"` // "url" for videos; nested playlists (e.g. channel tabs) are skipped
+	Duration   float64 `json:"duration"`
+	UploadDate string  `json:"upload_date"` // YYYYMMDD
+	Timestamp  float64 `json:"timestamp"`   // Unix seconds
+}
+
+// Published returns when the video was published, or the zero time if
+// unknown.
+func (e Entry) Published() time.Time {
+	return published(e.UploadDate, e.Timestamp)
+}
+
+func published(uploadDate string, timestamp float64) time.Time {
+	if timestamp > 0 {
+		return time.Unix(int64(timestamp), 0).UTC()
+	}
+	if t, err := time.Parse("20060102", uploadDate); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// ListLatest returns up to n (0 = all) of the newest videos of a channel,
+// profile, or playlist URL, newest first. It uses yt-dlp's flat playlist
+// extraction, so no per-video requests are made.
+func ListLatest(ctx context.Context, url string, n int, opts Options) ([]Entry, error) {
+	if opts.DownloaderPath == "" {
+		return nil, errors.New("downloader path is required")
+	}
+	args := []string{"--flat-playlist", "--dump-single-json"}
+	if n > 0 {
+		args = append(args, "--playlist-end", strconv.Itoa(n))
+	}
+	args = append(args, opts.sourceArgs()...)
+	args = append(args, opts.ExtraArgs...)
+	args = append(args, url)
+	res, err := util.Run(ctx, util.CmdSpec{
+		Path:    opts.DownloaderPath,
+		Args:    args,
+		Verbose: opts.Verbose,
+		Timeout: opts.MetadataTimeout,
+		Stage:   "metadata",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", url, err)
+	}
+
+	var listing struct {
+		Entries []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(res.Stdout, &listing); err != nil {
+		return nil, fmt.Errorf("parse listing of %s: %w", url, err)
+	}
+	story := util.IsStoryURL(url)
+	var out []Entry
+	for _, e := range listing.Entries {
+		if story && e.ID != "" && e.Type != "playlist" {
+			// Story items come fully extracted, without links of their own;
+			// pin each in the source's link.
+			e.URL, e.Type = util.StoryItemURL(url, e.ID), "url"
+		}
+		if e.URL == "" || (e.Type != "" && e.Type != "url") {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
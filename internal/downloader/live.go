@@ -0,0 +1,100 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+)
+
+// liveWaitInterval is how often WaitLive checks a stream again.
+const liveWaitInterval = time.Minute
+
+// liveFilter keeps yt-dlp from recording streams that are live or not yet
+// downloadable; videos without a live_status pass.
+const liveFilter = "live_status!=?is_live & live_status!=?is_upcoming & live_status!=?post_live"
+
+// LiveError reports a YouTube stream that can't be snipped yet: it is live,
+// scheduled, or just ended and its replay is still being processed.
+type LiveError struct {
+	URL    string
+	Status string        // yt-dlp live_status: is_live, is_upcoming, or post_live
+	Waited time.Duration // How long WaitLive waited; 0 = didn't
+}
+
+func (e *LiveError) Error() string {
+	if e.Waited > 0 {
+		return fmt.Sprintf("%s: the stream's replay is still not available after waiting %s (%s)", e.URL, e.Waited.Round(time.Second), e.Status)
+	}
+	switch e.Status {
+	case "is_upcoming":
+		return fmt.Sprintf("%s is a stream or premiere that hasn't started yet; snip it once it has ended (or pass --wait-live)", e.URL)
+	case "post_live":
+		return fmt.Sprintf("%s has just ended and YouTube is still processing the replay; try again later (or pass --wait-live)", e.URL)
+	default:
+		return fmt.Sprintf("%s is live now; snip it once the stream has ended (or pass --wait-live)", e.URL)
+	}
+}
+
+// notReady reports whether the video is a stream without a finished replay.
+func (i YTDLPInfo) notReady() bool {
+	switch i.LiveStatus {
+	case "is_live", "is_upcoming", "post_live":
+		return true
+	case "":
+		return i.IsLive
+	}
+	return false
+}
+
+// liveArgs adds liveFilter to yt-dlp calls that download YouTube videos
+// without checking their metadata first.
+func liveArgs(url string) []string {
+	if pl, _, err := util.DetectPlatform(url); err != nil || pl != util.PlatformYouTube {
+		return nil
+	}
+	return []string{"--match-filters", liveFilter}
+}
+
+// checkLive returns info if the video can be downloaded. For a stream that
+// can't be yet, it waits up to opts.WaitLive for the replay, checking every
+// liveWaitInterval (and at the deadline), or returns a *LiveError.
+func checkLive(ctx context.Context, opts Options, url string, info YTDLPInfo) (YTDLPInfo, error) {
+	if !info.notReady() {
+		return info, nil
+	}
+	if opts.WaitLive <= 0 {
+		return info, &LiveError{URL: url, Status: info.LiveStatus}
+	}
+	start := time.Now()
+	deadline := start.Add(opts.WaitLive)
+	for info.notReady() {
+		wait := min(liveWaitInterval, time.Until(deadline))
+		if wait <= 0 {
+			return info, &LiveError{URL: url, Status: info.LiveStatus, Waited: time.Since(start)}
+		}
+		slog.Info("waiting for the stream to end", "url", url, "status", info.LiveStatus)
+		if opts.Reporter != nil {
+			opts.Reporter.Update(progress.Update{
+				JobID:   opts.JobID,
+				Stage:   progress.StageMetadata,
+				Percent: -1,
+				Message: "Waiting for the stream to end",
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return info, ctx.Err()
+		case <-time.After(wait):
+		}
+		next, err := fetchMetadata(ctx, opts, url)
+		if err != nil {
+			return info, err
+		}
+		info = next
+	}
+	return info, nil
+}
@@ -0,0 +1,101 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ig2wa/internal/dirs"
+)
+
+// Fetched metadata is cached on disk per normalized URL, so a plan followed
+// by a run, or a retried job, makes one --dump-json call instead of several.
+// Platforms such as Instagram rate-limit those calls.
+
+type cachedInfo struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Info      YTDLPInfo `json:"info"`
+}
+
+func metadataCacheDir() (string, error) {
+	d, err := dirs.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "metadata"), nil
+}
+
+func metadataCachePath(url string) (string, error) {
+	dir, err := metadataCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// cachedMetadata returns url's metadata if it was fetched less than ttl ago.
+func cachedMetadata(url string, ttl time.Duration) (YTDLPInfo, bool) {
+	if ttl <= 0 {
+		return YTDLPInfo{}, false
+	}
+	path, err := metadataCachePath(url)
+	if err != nil {
+		return YTDLPInfo{}, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return YTDLPInfo{}, false
+	}
+	var c cachedInfo
+	if json.Unmarshal(b, &c) != nil || c.Info.ID == "" || time.Since(c.FetchedAt) > ttl {
+		return YTDLPInfo{}, false
+	}
+	return c.Info, true
+}
+
+// storeMetadata caches info for url and drops entries older than ttl.
+// Best-effort: failures only cost a later refetch.
+func storeMetadata(url string, info YTDLPInfo, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	path, err := metadataCachePath(url)
+	if err != nil {
+		return
+	}
+	dir := filepath.Dir(path)
+	if err := dirs.Ensure(dir); err != nil {
+		slog.Debug("metadata cache", "err", err)
+		return
+	}
+	b, err := json.Marshal(cachedInfo{FetchedAt: time.Now(), Info: info})
+	if err != nil {
+		return
+	}
+	// Write then rename so a concurrent reader never sees half a file.
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(b)
+	if cerr := tmp.Close(); werr != nil || cerr != nil || os.Rename(tmp.Name(), path) != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	pruneMetadataCache(dir, ttl)
+}
+
+func pruneMetadataCache(dir string, ttl time.Duration) {
+	entries, _ := os.ReadDir(dir)
+	cutoff := time.Now().Add(-ttl)
+	for _, e := range entries {
+		if fi, err := e.Info(); err == nil && fi.ModTime().Before(cutoff) {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
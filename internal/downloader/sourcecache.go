@@ -0,0 +1,184 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ig2wa/internal/dirs"
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// Downloaded originals can be kept in an opt-in cache (Options.SourceCacheMB)
+// keyed by platform and video ID, so encoding the same video again with
+// another preset or size skips the download. Using an entry refreshes its
+// modification time, and the least recently used entries are evicted when
+// the cache outgrows its limit.
+
+// SourceCacheDir returns where cached sources are kept.
+func SourceCacheDir() (string, error) {
+	d, err := dirs.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "sources"), nil
+}
+
+// useSourceCache reports whether a download may be served from, or stored in,
+// the source cache. Picked formats aren't part of the key, so they skip it.
+func useSourceCache(opts Options) bool {
+	return opts.SourceCacheMB > 0 && !opts.MetadataOnly && opts.SelectFormat == nil
+}
+
+// sourceCacheName is the file name, without extension, of a video's cached
+// source: platform and ID, plus a hash of a non-default format selector. It
+// is "" when the video can't be keyed.
+func sourceCacheName(url, id, format string) string {
+	pl, _, err := util.DetectPlatform(url)
+	if err != nil || id == "" {
+		return ""
+	}
+	name := string(pl) + "-" + util.SanitizeFilename(id)
+	if format != "" {
+		sum := sha256.Sum256([]byte(format))
+		name += "-" + hex.EncodeToString(sum[:4])
+	}
+	return name
+}
+
+// cachedSource returns the cached source for url, or "" if there is none. The
+// ID comes from the URL, so links that don't carry one always miss.
+func cachedSource(url, format string) string {
+	name := sourceCacheName(url, util.VideoIDFromURL(url), format)
+	dir, err := SourceCacheDir()
+	if name == "" || err != nil {
+		return ""
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, name+".*"))
+	for _, m := range matches {
+		if strings.HasSuffix(m, ".part") || util.FileSize(m) == 0 {
+			continue
+		}
+		now := time.Now()
+		_ = os.Chtimes(m, now, now) // most recently used
+		return m
+	}
+	return ""
+}
+
+// storeSource moves a finished download into the source cache and points dv
+// at it, then evicts old entries down to limitMB. Partial (sectioned)
+// downloads aren't stored. Best-effort: on failure dv is returned unchanged.
+func storeSource(url string, dv model.DownloadedVideo, format string, limitMB int) model.DownloadedVideo {
+	name := sourceCacheName(url, dv.ID, format)
+	dir, err := SourceCacheDir()
+	if name == "" || err != nil || dv.Sectioned || dv.InputPath == "" {
+		return dv
+	}
+	if err := dirs.Ensure(dir); err != nil {
+		slog.Debug("source cache", "err", err)
+		return dv
+	}
+	dst := filepath.Join(dir, name+filepath.Ext(dv.InputPath))
+	if err := util.MoveFile(dv.InputPath, dst); err != nil {
+		slog.Warn("could not cache source", "path", dv.InputPath, "err", err)
+		return dv
+	}
+	now := time.Now()
+	_ = os.Chtimes(dst, now, now)
+	dv.InputPath = dst
+	pruneSourceCache(dir, int64(limitMB)*1024*1024, dst)
+	return dv
+}
+
+// pruneSourceCache removes the least recently used sources until the cache
+// fits in limit bytes. keep, the source just stored, always stays.
+func pruneSourceCache(dir string, limit int64, keep string) {
+	items, err := scanSourceCache(dir)
+	if err != nil {
+		return
+	}
+	var total int64
+	for _, it := range items {
+		total += it.Bytes
+	}
+	inUse := sourcesInUse()
+	for _, it := range items {
+		if total <= limit {
+			break
+		}
+		if it.Path == keep || inUse(it.Path) {
+			continue
+		}
+		if err := os.Remove(it.Path); err == nil {
+			total -= it.Bytes
+			slog.Debug("evicted cached source", "path", it.Path, "bytes", it.Bytes)
+		}
+	}
+}
+
+// SourceCacheEntries lists the cached sources, least recently used first,
+// leaving out the ones of videos a running job is snipping.
+func SourceCacheEntries() ([]util.StaleTemp, error) {
+	dir, err := SourceCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	items, err := scanSourceCache(dir)
+	if err != nil {
+		return nil, err
+	}
+	inUse := sourcesInUse()
+	var out []util.StaleTemp
+	for _, it := range items {
+		if !inUse(it.Path) {
+			out = append(out, it)
+		}
+	}
+	return out, nil
+}
+
+// sourcesInUse returns a test for whether a cached source belongs to a video
+// that a job, in this process or another, holds the lock of (see
+// util.LockVideo) and so may be encoding from. It errs on the side of in use:
+// an ID that is a prefix of another's up to a "-" shields both.
+func sourcesInUse() func(path string) bool {
+	var prefixes []string
+	for key := range util.LockedVideos() {
+		if pl, id, ok := strings.Cut(key, ":"); ok && id != "" {
+			prefixes = append(prefixes, pl+"-"+util.SanitizeFilename(id))
+		}
+	}
+	return func(path string) bool {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		for _, p := range prefixes {
+			if name == p || strings.HasPrefix(name, p+"-") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func scanSourceCache(dir string) ([]util.StaleTemp, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var out []util.StaleTemp
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() {
+			continue
+		}
+		out = append(out, util.StaleTemp{Path: filepath.Join(dir, e.Name()), Bytes: info.Size(), ModTime: info.ModTime(), Reason: "cached source"})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModTime.Before(out[j].ModTime) })
+	return out, nil
+}
@@ -1,12 +1,234 @@
 package downloader
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
 // YTDLPInfo mirrors fields from yt-dlp --dump-json output that we care about.
 type YTDLPInfo struct {
-	ID          string  `json:"id"`
-	Title       string  `json:"title"`
-	Uploader    string  `json:"uploader"`
-	Duration    float64 `json:"duration"`
-	Description string  `json:"description"`
-	Width       int     `json:"width"`
-	Height      int     `json:"height"`
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Uploader    string   `json:"uploader"`
+	Channel     string   `json:"channel"`    // Facebook page name when uploader is missing
+	ChannelID   string   `json:"channel_id"` // Subreddit for Reddit posts
+	Duration    float64  `json:"duration"`
+	Description string   `json:"description"`
+	Width       int      `json:"width"`
+	Height      int      `json:"height"`
+	Thumbnail   string   `json:"thumbnail"`
+	UploadDate  string   `json:"upload_date"` // YYYYMMDD
+	Timestamp   float64  `json:"timestamp"`   // Unix seconds
+	WebpageURL  string   `json:"webpage_url"`
+	ViewCount   int64    `json:"view_count"`
+	LikeCount   int64    `json:"like_count"`
+	Formats     []Format `json:"formats"`
+	FormatID    string   `json:"format_id"` // Picked by -f; "137+140" when merging
+	// The -f selector the metadata was fetched with (ours, kept in the
+	// metadata cache), so FormatID isn't taken for another selector's pick
+	Selector string `json:"sniplette_selector,omitempty"`
+
+	// Of the format -f picks (video and audio combined when merging)
+	FPS            float64 `json:"fps"`
+	VCodec         string  `json:"vcodec"`
+	ACodec         string  `json:"acodec"`
+	Filesize       int64   `json:"filesize"`
+	FilesizeApprox int64   `json:"filesize_approx"`
+
+	LiveStatus string `json:"live_status"` // not_live, is_live, is_upcoming, was_live, post_live
+	IsLive     bool   `json:"is_live"`     // Set by older yt-dlp versions without live_status
+
+	Chapters []Chapter `json:"chapters"` // Empty if the video has none
+}
+
+// Chapter mirrors an entry of yt-dlp's chapters array.
+type Chapter struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// downloadedVideo converts the metadata of url, without a media file yet.
+func (i YTDLPInfo) downloadedVideo(url string) model.DownloadedVideo {
+	return model.DownloadedVideo{
+		DurationSec: i.Duration,
+		Title:       i.Title,
+		Uploader:    i.uploader(url),
+		ID:          i.ID,
+		Description: i.Description,
+		Width:       i.Width,
+		Height:      i.Height,
+		URL:         url,
+		Thumbnail:   i.Thumbnail,
+		Chapters:    i.chapters(),
+		Subreddit:   i.subreddit(url),
+		Published:   i.Published(),
+		WebpageURL:  i.WebpageURL,
+		ChannelID:   i.ChannelID,
+		ViewCount:   i.ViewCount,
+		LikeCount:   i.LikeCount,
+		FPS:         i.FPS,
+		VCodec:      codecOrEmpty(i.VCodec),
+		ACodec:      codecOrEmpty(i.ACodec),
+		SizeApprox:  Format{Filesize: i.Filesize, FilesizeApprox: i.FilesizeApprox}.Size(),
+	}
+}
+
+// codecOrEmpty maps yt-dlp's "none" (no such stream) to "".
+func codecOrEmpty(c string) string {
+	if c == "none" {
+		return ""
+	}
+	return c
+}
+
+// sourceFormats converts the formats list for model.DownloadedVideo, marking
+// the ones selector picks when the metadata was fetched with it.
+func (i YTDLPInfo) sourceFormats(selector string) []model.SourceFormat {
+	picked := map[string]bool{}
+	if i.Selector == selector {
+		for _, id := range strings.Split(i.FormatID, "+") {
+			picked[id] = true
+		}
+	}
+	var out []model.SourceFormat
+	for _, f := range i.Formats {
+		sf := model.SourceFormat{
+			ID: f.FormatID, Ext: f.Ext, Width: f.Width, Height: f.Height, FPS: f.FPS,
+			Kbps: f.TBR, Bytes: f.Size(), Note: f.FormatNote, Selected: picked[f.FormatID],
+		}
+		if f.HasVideo() {
+			sf.VCodec = f.VCodec
+		}
+		if f.HasAudio() {
+			sf.ACodec = f.ACodec
+		}
+		out = append(out, sf)
+	}
+	return out
+}
+
+// subreddit returns the subreddit of a Reddit post: yt-dlp's channel_id, or
+// the subreddit in the link.
+func (i YTDLPInfo) subreddit(url string) string {
+	if pl, _, err := util.DetectPlatform(url); err != nil || pl != util.PlatformReddit {
+		return ""
+	}
+	if i.ChannelID != "" {
+		return i.ChannelID
+	}
+	return util.Subreddit(url)
+}
+
+// uploader returns the uploader's name. Facebook metadata often leaves it
+// out; then the channel (page) name or the page in the link is used.
+func (i YTDLPInfo) uploader(url string) string {
+	if i.Uploader != "" {
+		return i.Uploader
+	}
+	if i.Channel != "" {
+		return i.Channel
+	}
+	return util.FacebookPage(url)
+}
+
+// chapters converts the chapter list for model.DownloadedVideo.
+func (i YTDLPInfo) chapters() []model.Chapter {
+	var out []model.Chapter
+	for _, c := range i.Chapters {
+		out = append(out, model.Chapter{Title: c.Title, StartSec: c.StartTime, EndSec: c.EndTime})
+	}
+	return out
+}
+
+// Published returns when the video was published, or the zero time if
+// unknown.
+func (i YTDLPInfo) Published() time.Time {
+	return published(i.UploadDate, i.Timestamp)
+}
+
+// Format mirrors a single entry of the yt-dlp formats array (the data behind -F).
+type Format struct {
+	FormatID       string  `json:"format_id"`
+	Ext            string  `json:"ext"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	FPS            float64 `json:"fps"`
+	VCodec         string  `json:"vcodec"`
+	ACodec         string  `json:"acodec"`
+	TBR            float64 `json:"tbr"`
+	Filesize       int64   `json:"filesize"`
+	FilesizeApprox int64   `json:"filesize_approx"`
+	FormatNote     string  `json:"format_note"`
+}
+
+// HasVideo reports whether the format carries a video stream.
+func (f Format) HasVideo() bool {
+	return f.VCodec != "" && f.VCodec != "none"
+}
+
+// HasAudio reports whether the format carries an audio stream.
+func (f Format) HasAudio() bool {
+	return f.ACodec != "" && f.ACodec != "none"
+}
+
+// Size returns the exact or approximate file size in bytes (0 if unknown).
+func (f Format) Size() int64 {
+	if f.Filesize > 0 {
+		return f.Filesize
+	}
+	return f.FilesizeApprox
+}
+
+// Selector returns a yt-dlp format selector that downloads this format,
+// pairing video-only formats with the best available audio.
+func (f Format) Selector() string {
+	if f.HasVideo() && !f.HasAudio() {
+		return f.FormatID + "+bestaudio/" + f.FormatID
+	}
+	return f.FormatID
+}
+
+// Label renders a compact one-line description similar to yt-dlp -F.
+func (f Format) Label() string {
+	parts := []string{f.FormatID, f.Ext}
+	switch {
+	case f.Width > 0 && f.Height > 0:
+		parts = append(parts, fmt.Sprintf("%dx%d", f.Width, f.Height))
+	case !f.HasVideo():
+		parts = append(parts, "audio only")
+	}
+	if f.FPS > 0 {
+		parts = append(parts, fmt.Sprintf("%.0ffps", f.FPS))
+	}
+	if f.HasVideo() {
+		parts = append(parts, shortCodec(f.VCodec))
+		if !f.HasAudio() {
+			parts = append(parts, "video only")
+		}
+	}
+	if f.HasAudio() {
+		parts = append(parts, shortCodec(f.ACodec))
+	}
+	if f.TBR > 0 {
+		parts = append(parts, fmt.Sprintf("%.0fk", f.TBR))
+	}
+	if sz := f.Size(); sz > 0 {
+		parts = append(parts, fmt.Sprintf("%.1fMiB", float64(sz)/(1024*1024)))
+	}
+	if f.FormatNote != "" {
+		parts = append(parts, f.FormatNote)
+	}
+	return strings.Join(parts, "  ")
+}
+
+func shortCodec(c string) string {
+	if i := strings.Index(c, "."); i != -1 {
+		return c[:i]
+	}
+	return c
 }
@@ -0,0 +1,79 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"ig2wa/internal/model"
+)
+
+// DefaultBackend is used when no encoder backend is configured.
+const DefaultBackend = "ffmpeg"
+
+// Backend turns a downloaded video into an output. Implementations report
+// progress through Options.Reporter and, with Options.WorkDir, must not leave
+// a partial file at Options.OutputPath.
+type Backend interface {
+	Name() string
+	// Probe records the streams of in.InputPath in in.Streams and fills in
+	// the size and duration the metadata left out. When probing isn't
+	// possible, in is returned unchanged.
+	Probe(ctx context.Context, in model.DownloadedVideo) model.DownloadedVideo
+	// Command returns the full command line Encode would run, for plans; nil
+	// when the backend doesn't run one.
+	Command(in model.DownloadedVideo, enc model.EncodeOptions, opts Options) ([]string, error)
+	Encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptions, opts Options) (model.OutputVideo, error)
+}
+
+var backends = map[string]Backend{}
+
+// RegisterBackend makes a backend selectable by name. Registering an existing
+// name replaces it.
+func RegisterBackend(b Backend) {
+	backends[b.Name()] = b
+}
+
+func init() {
+	RegisterBackend(ffmpegBackend{})
+}
+
+// BackendNames lists the registered backends, sorted.
+func BackendNames() []string {
+	names := make([]string, 0, len(backends))
+	for n := range backends {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupBackend resolves a backend by name; empty selects DefaultBackend.
+func LookupBackend(name string) (Backend, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+	b, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoder backend %q", name)
+	}
+	return b, nil
+}
+
+// ffmpegBackend encodes with ffmpeg (Options.FFmpegPath) and probes with
+// ffprobe.
+type ffmpegBackend struct{}
+
+func (ffmpegBackend) Name() string { return DefaultBackend }
+
+func (ffmpegBackend) Command(in model.DownloadedVideo, enc model.EncodeOptions, opts Options) ([]string, error) {
+	args, err := BuildArgs(in, enc, opts)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{valueOr(opts.FFmpegPath, "ffmpeg")}, args...), nil
+}
+
+func (ffmpegBackend) Encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptions, opts Options) (model.OutputVideo, error) {
+	return Encode(ctx, in, enc, opts)
+}
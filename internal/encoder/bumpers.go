@@ -0,0 +1,109 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"ig2wa/internal/model"
+)
+
+// bumpers returns the clips to join around the video, intro first. Audio-only
+// outputs have none.
+func bumpers(enc model.EncodeOptions) []*model.Bumper {
+	if enc.AudioOnly {
+		return nil
+	}
+	var out []*model.Bumper
+	for _, b := range []*model.Bumper{enc.Intro, enc.Outro} {
+		if b != nil {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// OutputDuration returns the length of what enc produces from in: the
+// (trimmed) video plus any intro and outro.
+func OutputDuration(in model.DownloadedVideo, enc model.EncodeOptions) float64 {
+	d := in.DurationSec
+	if d <= 0 {
+		return 0
+	}
+	for _, b := range bumpers(enc) {
+		d += b.DurationSec
+	}
+	return d
+}
+
+// bumperGraph returns a -filter_complex graph that scales the video as usual
+// and concatenates it with the intro and outro, producing
+// [v] and [a]. Bumpers are fitted into the video's output size with padding,
+// and segments without audio get silence, since concat needs every segment
+// to match.
+func bumperGraph(in model.DownloadedVideo, enc model.EncodeOptions) (string, error) {
+	if in.Width <= 0 || in.Height <= 0 {
+		return "", errors.New("--intro/--outro need the video's size, which is unknown (is ffprobe installed?)")
+	}
+	w, h := scaledSize(enc.LongSidePx, in.Width, in.Height)
+	size := fmt.Sprintf("%d:%d", w, h)
+
+	// Main video first in the graph; concat order is set below. Fades apply
+	// to the video alone, not the bumpers.
+	main := clipFilters(in, enc, "scale="+size)
+	chains := []string{fmt.Sprintf("[0:v]%s,setsar=1,format=%s[vmain]", strings.Join(main, ","), pixFmt(enc))}
+	mainAudio := in.Streams == nil || in.Streams.AudioCodec != ""
+	chains = append(chains, audioChain("0", "amain", mainAudio, in.DurationSec, fades("afade", enc.FadeSec, in.DurationSec)...))
+
+	// Bumpers are inputs 1 and 2, in the order inputArgs adds them.
+	input := 0
+	bumper := func(b *model.Bumper, name string) {
+		input++
+		idx := strconv.Itoa(input)
+		chains = append(chains,
+			fmt.Sprintf("[%s:v]scale=%s:force_original_aspect_ratio=decrease,pad=%s:(ow-iw)/2:(oh-ih)/2,setsar=1,format=%s[v%s]", idx, size, size, pixFmt(enc), name),
+			audioChain(idx, "a"+name, b.HasAudio, b.DurationSec))
+	}
+	var order []string
+	if enc.Intro != nil {
+		bumper(enc.Intro, "intro")
+		order = append(order, "intro")
+	}
+	order = append(order, "main")
+	if enc.Outro != nil {
+		bumper(enc.Outro, "outro")
+		order = append(order, "outro")
+	}
+
+	var concat strings.Builder
+	for _, name := range order {
+		fmt.Fprintf(&concat, "[v%s][a%s]", name, name)
+	}
+	fmt.Fprintf(&concat, "concat=n=%d:v=1:a=1[v][a]", len(order))
+	return strings.Join(append(chains, concat.String()), ";"), nil
+}
+
+// audioChain converts input idx's audio to a common format as [label], after
+// any extra filters, or generates durSec of silence when it has none.
+func audioChain(idx, label string, hasAudio bool, durSec float64, extra ...string) string {
+	const format = "aformat=sample_rates=48000:channel_layouts=stereo"
+	if hasAudio {
+		return fmt.Sprintf("[%s:a]%s[%s]", idx, strings.Join(append(extra, format), ","), label)
+	}
+	return fmt.Sprintf("anullsrc=r=48000:cl=stereo,atrim=duration=%s,%s[%s]", strconv.FormatFloat(durSec, 'f', 3, 64), format, label)
+}
+
+// scaledSize returns the even output size scaleFilter gives a width x height
+// video.
+func scaledSize(longSide, width, height int) (int, int) {
+	if longSide <= 0 {
+		longSide = 720
+	}
+	even := func(v float64) int { return int(math.Round(v/2)) * 2 }
+	if height > width {
+		return even(float64(longSide) * float64(width) / float64(height)), longSide
+	}
+	return longSide, even(float64(longSide) * float64(height) / float64(width))
+}
@@ -6,19 +6,35 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"ig2wa/internal/model"
 	"ig2wa/internal/progress"
 	"ig2wa/internal/util"
 )
 
+// gifFPS is the frame rate of GIF outputs.
+const gifFPS = 10
+
 // Options control ffmpeg execution.
 type Options struct {
 	FFmpegPath string
 	Verbose    bool
-	OutputPath string // Full path of desired output file (including extension)
+	OutputPath string        // Full path of desired output file (including extension)
+	ExtraArgs  []string      // Raw output options placed after all generated ones, just before OutputPath
+	WorkDir    string        // If set, encode here first and move into OutputPath when done
+	Timeout    time.Duration // Limit for the ffmpeg run; 0 = none
+	Nice       int           // Lower ffmpeg's scheduling priority (see util.CmdSpec.Nice); 0 = normal
+	Threads    int           // ffmpeg -threads; 0 lets ffmpeg decide
+
+	// Remote backend: base URLs of the encoding daemons (remote.Local for
+	// this machine) and their token
+	Remotes     []string
+	RemoteToken string
 
 	// Progress reporting (optional)
 	Reporter progress.Reporter
@@ -26,8 +42,30 @@ type Options struct {
 }
 
 // Encode performs the transcoding according to the provided options.
-// It returns metadata about the resulting file on success.
+// It returns metadata about the resulting file on success. With opts.WorkDir,
+// ffmpeg writes into the workdir and the finished file is moved into place, so
+// a partial output never appears at OutputPath.
 func Encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptions, opts Options) (model.OutputVideo, error) {
+	if opts.WorkDir == "" || opts.OutputPath == "" {
+		return encode(ctx, in, enc, opts)
+	}
+	final := opts.OutputPath
+	opts.OutputPath = filepath.Join(opts.WorkDir, "encoded"+filepath.Ext(final))
+	out, err := encode(ctx, in, enc, opts)
+	if err != nil {
+		return out, err
+	}
+	if err := util.EnsureDir(filepath.Dir(final)); err != nil {
+		return model.OutputVideo{}, fmt.Errorf("ensure output dir: %w", err)
+	}
+	if err := util.MoveFile(out.OutputPath, final); err != nil {
+		return model.OutputVideo{}, fmt.Errorf("finalize output: %w", err)
+	}
+	out.OutputPath = final
+	return out, nil
+}
+
+func encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptions, opts Options) (model.OutputVideo, error) {
 	if opts.FFmpegPath == "" {
 		return model.OutputVideo{}, errors.New("ffmpeg path is required")
 	}
@@ -35,74 +73,49 @@ func Encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptio
 		if opts.OutputPath == "" {
 			return model.OutputVideo{}, errors.New("output path is required")
 		}
-		return encodeAudioOnly(ctx, in.InputPath, opts, enc)
-	}
-
-	vf, _ := scaleFilter(enc.LongSidePx, in.Width, in.Height)
-	args := []string{
-		"-y",
-		"-i", in.InputPath,
-		"-vf", vf,
-		"-c:v", "libx264",
-		"-preset", valueOr(enc.Preset, "veryfast"),
-		"-profile:v", valueOr(enc.Profile, "main"),
-		"-pix_fmt", "yuv420p",
-		"-c:a", "aac",
-		"-b:a", fmt.Sprintf("%dk", safeAudioKbps(enc.AudioBitrateKbps)),
-		"-movflags", "+faststart",
-	}
-	if enc.KeyInt > 0 {
-		args = append(args, "-g", strconv.Itoa(enc.KeyInt), "-keyint_min", strconv.Itoa(enc.KeyInt))
-	}
-
-	usedCRF := 0
-	usedVBR := 0
-	if enc.ModeCRF {
-		usedCRF = nonZero(enc.CRF, 22)
-		args = append(args, "-crf", strconv.Itoa(usedCRF))
-	} else {
-		// bitrate mode
-		if in.DurationSec <= 0 || enc.MaxSizeMB <= 0 {
-			return model.OutputVideo{}, errors.New("invalid bitrate mode inputs: missing duration or max size")
-		}
-		kbps := computeVideoBitrateKbps(enc.MaxSizeMB, in.DurationSec, safeAudioKbps(enc.AudioBitrateKbps), enc.VideoMinKbps, enc.VideoMaxKbps)
-		usedVBR = kbps
-		args = append(args, "-b:v", fmt.Sprintf("%dk", kbps))
+		return encodeAudioOnly(ctx, in, opts, enc)
 	}
 
 	if opts.OutputPath == "" {
 		return model.OutputVideo{}, errors.New("output path is required")
 	}
-
-	// Add ffmpeg machine-readable progress if reporting and not verbose passthrough
-	if opts.Reporter != nil && !opts.Verbose {
-		args = append(args, "-progress", "pipe:1", "-nostats")
+	codec, usedCRF, usedVBR, err := codecArgs(in, enc)
+	if err != nil {
+		return model.OutputVideo{}, err
 	}
-
-	args = append(args, opts.OutputPath)
+	// Add ffmpeg machine-readable progress if reporting and not verbose passthrough
+	args := assembleArgs(inputArgs(in.InputPath, enc), codec, opts, opts.Reporter != nil && !opts.Verbose)
 
 	// Ensure output dir exists
 	if err := util.EnsureDir(filepath.Dir(opts.OutputPath)); err != nil {
 		return model.OutputVideo{}, fmt.Errorf("ensure output dir: %w", err)
 	}
 
+	stageMsg := "Encoding"
+	if enc.StreamCopy {
+		stageMsg = "Remuxing"
+	}
 	if opts.Reporter != nil {
 		opts.Reporter.Update(progress.Update{
 			JobID:   opts.JobID,
 			Stage:   progress.StageEncoding,
 			Percent: 0,
-			Message: "Encoding",
+			Message: stageMsg,
 		})
 	}
 
 	var outTimeMs int64
 	var speedStr string
 	var totalSize int64
+	var stats encodeStats
 
 	_, runErr := util.Run(ctx, util.CmdSpec{
 		Path:    opts.FFmpegPath,
 		Args:    args,
 		Verbose: opts.Verbose && opts.Reporter == nil,
+		Timeout: opts.Timeout,
+		Stage:   "encode",
+		Nice:    opts.Nice,
 		// ffmpeg -progress writes to stdout; avoid large capture when reporting
 		CaptureStdout: opts.Reporter == nil,
 		StdoutLine: func(line string) {
@@ -124,11 +137,13 @@ func Encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptio
 					if v, err := strconv.ParseInt(val, 10, 64); err == nil {
 						totalSize = v
 					}
+				case "frame", "fps", "bitrate":
+					stats.parse(key, val)
 				case "progress":
 					// Emit on progress markers for smoother UI
 					percent := -1.0
-					if in.DurationSec > 0 {
-						den := in.DurationSec * 1_000_000 // out_time_ms uses microseconds
+					if dur := OutputDuration(in, enc); dur > 0 {
+						den := dur * 1_000_000 // out_time_ms uses microseconds
 						if den > 0 {
 							percent = (float64(outTimeMs) / (den)) * 100.0
 							if percent > 100 {
@@ -146,14 +161,16 @@ func Encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptio
 						ts := totalSize
 						bptr = &ts
 					}
-					opts.Reporter.Update(progress.Update{
+					u := progress.Update{
 						JobID:   opts.JobID,
 						Stage:   progress.StageEncoding,
 						Percent: percent,
 						Speed:   sptr,
 						Bytes:   bptr,
-						Message: "Encoding",
-					})
+						Message: stageMsg,
+					}
+					stats.apply(&u)
+					opts.Reporter.Update(u)
 				}
 			}
 			// In verbose mode, also forward logs into UI
@@ -186,9 +203,251 @@ func Encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptio
 		UsedBitrateKbps: usedVBR,
 		LongSidePx:      enc.LongSidePx,
 		AudioOnly:       false,
+		Copied:          enc.StreamCopy,
+		PosterSec:       posterSec(enc),
 	}, nil
 }
 
+// BuildArgs returns the ffmpeg arguments (after the binary) that Encode runs
+// for in, minus the progress flags it adds when reporting.
+func BuildArgs(in model.DownloadedVideo, enc model.EncodeOptions, opts Options) ([]string, error) {
+	codec, _, _, err := codecArgs(in, enc)
+	if err != nil {
+		return nil, err
+	}
+	return assembleArgs(inputArgs(in.InputPath, enc), codec, opts, false), nil
+}
+
+// inputArgs returns the input options for path, seeking to the trim range
+// (enc.StartSec/EndSec) when one is set, followed by any intro and outro and
+// the poster frame's input.
+func inputArgs(path string, enc model.EncodeOptions) []string {
+	var args []string
+	if enc.StartSec > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(enc.StartSec, 'f', 3, 64))
+	}
+	if enc.EndSec > 0 {
+		args = append(args, "-t", strconv.FormatFloat(enc.EndSec-enc.StartSec, 'f', 3, 64))
+	}
+	args = append(args, "-i", path)
+	for _, b := range bumpers(enc) {
+		args = append(args, "-i", util.LongPath(b.Path))
+	}
+	if posterSec(enc) > 0 {
+		args = append(args, posterInput(path, enc)...)
+	}
+	return args
+}
+
+// codecArgs returns the encoding options shared by full and sample encodes,
+// plus the CRF or video bitrate (kbps) they select.
+func codecArgs(in model.DownloadedVideo, enc model.EncodeOptions) (args []string, usedCRF, usedVBR int, err error) {
+	if enc.AudioOnly {
+		args = append([]string{"-vn"}, audioArgs(in, enc, nonZero(enc.AudioBitrateKbps, 128))...)
+		args = append(args, "-movflags", "+faststart")
+		return append(args, metadataArgs(enc)...), 0, 0, nil
+	}
+
+	if enc.GIF {
+		// One palette for the whole clip keeps colors stable and the file small.
+		scale, _ := scaleFilter(enc.LongSidePx, in.Width, in.Height)
+		graph := fmt.Sprintf("[0:v]fps=%d,%s:flags=lanczos,split[a][b];[a]palettegen[p];[b][p]paletteuse", gifFPS, scale)
+		return []string{"-filter_complex", graph, "-an", "-loop", "0"}, 0, 0, nil
+	}
+
+	if enc.StreamCopy {
+		// Subtitle and data streams often can't go into MP4 as they are.
+		args = []string{"-c", "copy", "-sn", "-dn", "-movflags", "+faststart"}
+		return append(args, metadataArgs(enc)...), 0, 0, nil
+	}
+
+	if len(bumpers(enc)) > 0 {
+		graph, err := bumperGraph(in, enc)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		args = []string{"-filter_complex", graph, "-map", "[v]", "-map", "[a]"}
+	} else if posterSec(enc) > 0 {
+		// The poster is a second video stream; keep the filters off it.
+		args = []string{"-map", "0:v:0", "-map", "0:a:0?", "-filter:v:0", videoFilters(in, enc)}
+	} else {
+		args = []string{"-vf", videoFilters(in, enc)}
+	}
+	profileOpt := "-profile:v"
+	if posterSec(enc) > 0 {
+		profileOpt = "-profile:v:0" // mjpeg rejects x264 profile names
+	}
+	args = append(args,
+		"-c:v", "libx264",
+		"-preset", valueOr(enc.Preset, "veryfast"),
+		profileOpt, valueOr(enc.Profile, "main"),
+		"-pix_fmt", pixFmt(enc),
+	)
+	if in.Streams != nil && in.Streams.Rotation != 0 {
+		// The decoder already turned the frames upright; don't let a stale
+		// tag rotate them again.
+		args = append(args, "-metadata:s:v:0", "rotate=0")
+	}
+	args = append(args, audioArgs(in, enc, safeAudioKbps(enc.AudioBitrateKbps))...)
+	args = append(args, "-movflags", "+faststart")
+	if enc.KeyInt > 0 {
+		args = append(args, "-g", strconv.Itoa(enc.KeyInt), "-keyint_min", strconv.Itoa(enc.KeyInt))
+	}
+
+	if enc.ModeCRF {
+		usedCRF = nonZero(enc.CRF, 22)
+		args = append(args, "-crf", strconv.Itoa(usedCRF))
+	} else {
+		// bitrate mode
+		dur := OutputDuration(in, enc)
+		if dur <= 0 || enc.MaxSizeMB <= 0 {
+			return nil, 0, 0, errors.New("invalid bitrate mode inputs: missing duration or max size")
+		}
+		usedVBR = computeVideoBitrateKbps(enc.MaxSizeMB, dur, safeAudioKbps(enc.AudioBitrateKbps), enc.VideoMinKbps, enc.VideoMaxKbps)
+		args = append(args, "-b:v", fmt.Sprintf("%dk", usedVBR))
+		args = append(args, rateControlArgs(enc, usedVBR)...)
+	}
+	if posterSec(enc) > 0 {
+		// Later options win, so these override the libx264 settings above
+		// for the poster stream.
+		poster, err := posterArgs(in, enc, 1+len(bumpers(enc)))
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		args = append(args, poster...)
+	}
+	return append(args, metadataArgs(enc)...), usedCRF, usedVBR, nil
+}
+
+// metadataArgs tags the output with enc.Comment as its comment and
+// description (the MP4 ©cmt and desc atoms), so the caption travels with
+// the file when it is forwarded.
+func metadataArgs(enc model.EncodeOptions) []string {
+	if enc.Comment == "" {
+		return nil
+	}
+	return []string{"-metadata", "comment=" + enc.Comment, "-metadata", "description=" + enc.Comment}
+}
+
+// CanStreamCopy reports whether in can be remuxed into the output as it is:
+// probed, unrotated H.264 (8-bit 4:2:0) video with no audio or audio listed
+// in audioCopyMatrix, no larger than enc.LongSidePx, no trim range, and, in
+// size mode, already under enc.MaxSizeMB.
+func CanStreamCopy(in model.DownloadedVideo, enc model.EncodeOptions) bool {
+	s := in.Streams
+	switch {
+	case s == nil || enc.AudioOnly:
+		return false
+	case s.VideoCodec != "h264" || !samePixFmt(s.PixFmt, pixFmt(enc)):
+		return false
+	case s.Rotation != 0:
+		return false // a copy keeps the rotation tag, which some players ignore
+	case s.AudioCodec != "" && !audioCompatible(s):
+		return false
+	case enc.StartSec > 0 || enc.EndSec > 0:
+		return false // stream copy can only cut at keyframes
+	case enc.Denoise != "" || enc.Sharpen != "" || enc.FadeSec > 0 || enc.PosterAtSec > 0 || len(bumpers(enc)) > 0:
+		return false
+	case in.Width <= 0 || in.Height <= 0 || max(in.Width, in.Height) > nonZero(enc.LongSidePx, 720):
+		return false
+	}
+	if !enc.ModeCRF && enc.MaxSizeMB > 0 {
+		size := util.FileSize(in.InputPath)
+		return size > 0 && size <= int64(enc.MaxSizeMB)*1024*1024
+	}
+	return true
+}
+
+// rateControlArgs bounds the momentary bitrate of a size-mode encode at kbps,
+// so spikes stay within what messaging apps accept even when the average is
+// right. By default peaks may reach 1.25x kbps over a 2 s buffer; with
+// enc.CBR the rate is held constant (x264 nal-hrd=cbr).
+func rateControlArgs(enc model.EncodeOptions, kbps int) []string {
+	if enc.CBR {
+		rate := fmt.Sprintf("%dk", kbps)
+		return []string{"-minrate", rate, "-maxrate", rate, "-bufsize", fmt.Sprintf("%dk", nonZero(enc.BufSizeKbps, kbps)), "-x264-params", "nal-hrd=cbr"}
+	}
+	maxRate := nonZero(enc.MaxRateKbps, kbps*5/4)
+	bufSize := nonZero(enc.BufSizeKbps, 2*kbps)
+	return []string{"-maxrate", fmt.Sprintf("%dk", maxRate), "-bufsize", fmt.Sprintf("%dk", bufSize)}
+}
+
+// audioArgs returns the audio codec options: a copy when enc.AudioCopy is
+// set, otherwise AAC at kbps, faded in and out when enc.FadeSec is set (the
+// bumper graph fades the audio itself).
+func audioArgs(in model.DownloadedVideo, enc model.EncodeOptions, kbps int) []string {
+	if enc.AudioCopy {
+		return []string{"-c:a", "copy"}
+	}
+	var args []string
+	if f := fades("afade", enc.FadeSec, in.DurationSec); len(f) > 0 && len(bumpers(enc)) == 0 {
+		args = append(args, "-af", strings.Join(f, ","))
+	}
+	return append(args, "-c:a", "aac", "-b:a", fmt.Sprintf("%dk", kbps))
+}
+
+// audioCopyMatrix lists the source audio an MP4/M4A output can carry as it is
+// and still play on phones: codec -> accepted profiles and channel limit.
+var audioCopyMatrix = map[string]struct {
+	profiles    []string
+	maxChannels int
+}{
+	"aac": {profiles: []string{"LC"}, maxChannels: 2},
+}
+
+// audioCompatible reports whether the probed source audio is in
+// audioCopyMatrix.
+func audioCompatible(s *model.SourceStreams) bool {
+	c, ok := audioCopyMatrix[s.AudioCodec]
+	if !ok || s.AudioChannels <= 0 || s.AudioChannels > c.maxChannels {
+		return false
+	}
+	for _, p := range c.profiles {
+		if strings.EqualFold(p, s.AudioProfile) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanCopyAudio reports whether in's audio can be kept as it is: it is in
+// audioCopyMatrix and its bitrate is known and no higher than the one the
+// encode would use.
+func CanCopyAudio(in model.DownloadedVideo, enc model.EncodeOptions) bool {
+	s := in.Streams
+	if s == nil || s.AudioCodec == "" || !audioCompatible(s) || enc.FadeSec > 0 || len(bumpers(enc)) > 0 {
+		return false
+	}
+	target := safeAudioKbps(enc.AudioBitrateKbps)
+	if enc.AudioOnly {
+		target = nonZero(enc.AudioBitrateKbps, 128)
+	}
+	return s.AudioKbps > 0 && s.AudioKbps <= target
+}
+
+// assembleArgs puts an ffmpeg command line together: inputs and codec
+// options, then optional machine-readable progress, the thread limit, the
+// user's extra arguments, and the output path last, so extras override
+// anything generated before them.
+func assembleArgs(inputs, codec []string, opts Options, withProgress bool) []string {
+	args := append([]string{"-y"}, inputs...)
+	args = append(args, codec...)
+	if withProgress {
+		args = append(args, "-progress", "pipe:1", "-nostats")
+	}
+	if opts.Threads > 0 {
+		args = append(args, "-threads", strconv.Itoa(opts.Threads))
+	}
+	args = append(args, opts.ExtraArgs...)
+	return append(args, util.LongPath(opts.OutputPath))
+}
+
+// VideoBitrateKbps returns the video bitrate a size-constrained encode of a
+// video with the given duration will use.
+func VideoBitrateKbps(enc model.EncodeOptions, durationSec float64) int {
+	return computeVideoBitrateKbps(enc.MaxSizeMB, durationSec, safeAudioKbps(enc.AudioBitrateKbps), enc.VideoMinKbps, enc.VideoMaxKbps)
+}
+
 // computeVideoBitrateKbps calculates a video bitrate to fit within a target size.
 func computeVideoBitrateKbps(maxSizeMB int, durationSec float64, audioKbps, vMinKbps, vMaxKbps int) int {
 	if durationSec <= 0 {
@@ -207,6 +466,149 @@ func computeVideoBitrateKbps(maxSizeMB int, durationSec float64, audioKbps, vMin
 	return kbps
 }
 
+// x264Presets are the valid --x264-preset values, fastest first.
+var x264Presets = []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow"}
+
+// pixFmtProfiles maps the valid --pix-fmt values to the H.264 profiles that
+// can carry them.
+var pixFmtProfiles = map[string][]string{
+	"yuv420p":     {"baseline", "main", "high", "high10", "high422", "high444"},
+	"yuv420p10le": {"high10", "high422", "high444"},
+	"yuv422p":     {"high422", "high444"},
+	"yuv444p":     {"high444"},
+}
+
+// X264Presets returns the valid --x264-preset values.
+func X264Presets() []string { return x264Presets }
+
+// H264Profiles returns the valid --h264-profile values.
+func H264Profiles() []string { return pixFmtProfiles["yuv420p"] }
+
+// PixFmts returns the valid --pix-fmt values.
+func PixFmts() []string {
+	names := make([]string, 0, len(pixFmtProfiles))
+	for n := range pixFmtProfiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckH264 reports whether the H.264 profile can carry the pixel format;
+// libx264 refuses e.g. main with 10-bit or 4:4:4 video.
+func CheckH264(profile, pixFmt string) error {
+	profiles, ok := pixFmtProfiles[pixFmt]
+	if !ok {
+		return fmt.Errorf("unknown pixel format %q", pixFmt)
+	}
+	for _, p := range profiles {
+		if p == profile {
+			return nil
+		}
+	}
+	return fmt.Errorf("profile %s can't carry %s video (use %s)", profile, pixFmt, strings.Join(profiles, "|"))
+}
+
+// CheckOptions validates the settings of enc that reach the ffmpeg command
+// as they are: the x264 preset, H.264 profile and pixel format, the filter
+// presets, and the ranges of CRF and audio bitrate. pipeline.NewEncodeOptions
+// makes sure of these for local runs; the remote encode server checks what
+// clients send with it.
+func CheckOptions(enc model.EncodeOptions) error {
+	switch {
+	case enc.CRF < 0 || enc.CRF > 51:
+		return fmt.Errorf("invalid CRF: %d (valid: 0-51)", enc.CRF)
+	case enc.AudioBitrateKbps < 0 || enc.AudioBitrateKbps > 320:
+		return fmt.Errorf("invalid audio bitrate: %d kbps (valid: 32-320)", enc.AudioBitrateKbps)
+	case enc.KeyInt < 0:
+		return fmt.Errorf("invalid keyframe interval: %d", enc.KeyInt)
+	case enc.Preset != "" && !slices.Contains(x264Presets, enc.Preset):
+		return fmt.Errorf("unknown x264 preset %q (valid: %s)", enc.Preset, strings.Join(x264Presets, "|"))
+	case enc.Denoise != "" && !slices.Contains(DenoisePresets(), enc.Denoise):
+		return fmt.Errorf("unknown denoise preset %q (valid: %s)", enc.Denoise, strings.Join(DenoisePresets(), "|"))
+	case enc.Sharpen != "" && !slices.Contains(SharpenPresets(), enc.Sharpen):
+		return fmt.Errorf("unknown sharpen preset %q (valid: %s)", enc.Sharpen, strings.Join(SharpenPresets(), "|"))
+	}
+	return CheckH264(valueOr(enc.Profile, "main"), pixFmt(enc))
+}
+
+// pixFmt is the output's pixel format: enc.PixFmt, or 8-bit 4:2:0.
+func pixFmt(enc model.EncodeOptions) string { return valueOr(enc.PixFmt, "yuv420p") }
+
+// samePixFmt reports whether a source in pixel format src can be copied into
+// an output of format out; full-range yuvj420p passes for yuv420p.
+func samePixFmt(src, out string) bool {
+	return src == out || (out == "yuv420p" && src == "yuvj420p")
+}
+
+// denoiseFilters and sharpenFilters map the --denoise and --sharpen presets
+// to ffmpeg filters.
+var (
+	denoiseFilters = map[string]string{
+		"light":  "hqdn3d=2:1.5:3:2.25",
+		"medium": "hqdn3d=4:3:6:4.5",
+		"strong": "nlmeans=s=3:p=7:r=9", // much slower than hqdn3d
+	}
+	sharpenFilters = map[string]string{
+		"light":  "unsharp=5:5:0.5:5:5:0",
+		"medium": "unsharp=5:5:1.0:5:5:0",
+	}
+)
+
+// DenoisePresets returns the valid --denoise values.
+func DenoisePresets() []string { return presetNames(denoiseFilters) }
+
+// SharpenPresets returns the valid --sharpen values.
+func SharpenPresets() []string { return presetNames(sharpenFilters) }
+
+func presetNames(m map[string]string) []string {
+	names := []string{"off"}
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// videoFilters returns the -vf chain for in (see clipFilters).
+func videoFilters(in model.DownloadedVideo, enc model.EncodeOptions) string {
+	scale, _ := scaleFilter(enc.LongSidePx, in.Width, in.Height)
+	return strings.Join(clipFilters(in, enc, scale), ",")
+}
+
+// clipFilters returns the video's filters around scale: denoise at the source
+// size, where the noise is, then scale, then sharpen the scaled result and
+// fade.
+func clipFilters(in model.DownloadedVideo, enc model.EncodeOptions, scale string) []string {
+	var chain []string
+	if f := denoiseFilters[enc.Denoise]; f != "" {
+		chain = append(chain, f)
+	}
+	chain = append(chain, scale)
+	if f := sharpenFilters[enc.Sharpen]; f != "" {
+		chain = append(chain, f)
+	}
+	return append(chain, fades("fade", enc.FadeSec, in.DurationSec)...)
+}
+
+// fades returns fade (or afade) filters that fade in over sec at the start of
+// a durSec clip and out at its end. The clip is already trimmed, so it starts
+// at 0. Without a known duration only the fade-in is applied.
+func fades(filter string, sec, durSec float64) []string {
+	if sec <= 0 {
+		return nil
+	}
+	if durSec > 0 && sec > durSec/2 {
+		sec = durSec / 2
+	}
+	d := strconv.FormatFloat(sec, 'f', 3, 64)
+	out := []string{fmt.Sprintf("%s=t=in:st=0:d=%s", filter, d)}
+	if durSec > 0 {
+		out = append(out, fmt.Sprintf("%s=t=out:st=%s:d=%s", filter, strconv.FormatFloat(durSec-sec, 'f', 3, 64), d))
+	}
+	return out
+}
+
 // scaleFilter returns the ffmpeg scale filter and whether the input is vertical.
 func scaleFilter(longSide int, width, height int) (string, bool) {
 	if longSide <= 0 {
@@ -219,22 +621,12 @@ func scaleFilter(longSide int, width, height int) (string, bool) {
 	return fmt.Sprintf("scale=%d:-2", longSide), false
 }
 
-func encodeAudioOnly(ctx context.Context, inputPath string, opts Options, enc model.EncodeOptions) (model.OutputVideo, error) {
-	if inputPath == "" {
+func encodeAudioOnly(ctx context.Context, in model.DownloadedVideo, opts Options, enc model.EncodeOptions) (model.OutputVideo, error) {
+	if in.InputPath == "" {
 		return model.OutputVideo{}, errors.New("input path is required")
 	}
-	args := []string{
-		"-y",
-		"-i", inputPath,
-		"-vn",
-		"-c:a", "aac",
-		"-b:a", fmt.Sprintf("%dk", nonZero(enc.AudioBitrateKbps, 128)),
-		"-movflags", "+faststart",
-	}
-	if opts.Reporter != nil && !opts.Verbose {
-		args = append(args, "-progress", "pipe:1", "-nostats")
-	}
-	args = append(args, opts.OutputPath)
+	codec, _, _, _ := codecArgs(in, enc)
+	args := assembleArgs(inputArgs(in.InputPath, enc), codec, opts, opts.Reporter != nil && !opts.Verbose)
 
 	if err := util.EnsureDir(filepath.Dir(opts.OutputPath)); err != nil {
 		return model.OutputVideo{}, fmt.Errorf("ensure output dir: %w", err)
@@ -251,11 +643,15 @@ func encodeAudioOnly(ctx context.Context, inputPath string, opts Options, enc mo
 
 	var speedStr string
 	var totalSize int64
+	var stats encodeStats
 
 	_, runErr := util.Run(ctx, util.CmdSpec{
 		Path:          opts.FFmpegPath,
 		Args:          args,
 		Verbose:       opts.Verbose && opts.Reporter == nil,
+		Timeout:       opts.Timeout,
+		Stage:         "encode",
+		Nice:          opts.Nice,
 		CaptureStdout: opts.Reporter == nil,
 		StdoutLine: func(line string) {
 			if opts.Reporter == nil {
@@ -271,6 +667,8 @@ func encodeAudioOnly(ctx context.Context, inputPath string, opts Options, enc mo
 					if v, err := strconv.ParseInt(val, 10, 64); err == nil {
 						totalSize = v
 					}
+				case "bitrate":
+					stats.parse(key, val)
 				case "progress":
 					var sptr *string
 					if speedStr != "" {
@@ -283,14 +681,16 @@ func encodeAudioOnly(ctx context.Context, inputPath string, opts Options, enc mo
 						bptr = &ts
 					}
 					// No known duration for audio-only path; percent may be unknown
-					opts.Reporter.Update(progress.Update{
+					u := progress.Update{
 						JobID:   opts.JobID,
 						Stage:   progress.StageEncoding,
 						Percent: -1,
 						Speed:   sptr,
 						Bytes:   bptr,
 						Message: "Encoding (audio)",
-					})
+					}
+					stats.apply(&u)
+					opts.Reporter.Update(u)
 				}
 			}
 			if opts.Verbose {
@@ -331,6 +731,47 @@ func clamp(v, min, max int) int {
 	return v
 }
 
+// encodeStats holds the frame=, fps=, and bitrate= values of ffmpeg's
+// -progress output until the next progress= marker reports them.
+type encodeStats struct {
+	frame   int64
+	fps     float64
+	bitrate string
+}
+
+func (st *encodeStats) parse(key, val string) {
+	switch key {
+	case "frame":
+		if v, err := strconv.ParseInt(val, 10, 64); err == nil {
+			st.frame = v
+		}
+	case "fps":
+		if v, err := strconv.ParseFloat(val, 64); err == nil {
+			st.fps = v
+		}
+	case "bitrate":
+		if val != "N/A" {
+			st.bitrate = val
+		}
+	}
+}
+
+// apply copies the stats known so far into u.
+func (st encodeStats) apply(u *progress.Update) {
+	if st.frame > 0 {
+		frame := st.frame
+		u.Frame = &frame
+	}
+	if st.fps > 0 {
+		fps := st.fps
+		u.FPS = &fps
+	}
+	if st.bitrate != "" {
+		br := st.bitrate
+		u.Bitrate = &br
+	}
+}
+
 func valueOr(s, def string) string {
 	if s == "" {
 		return def
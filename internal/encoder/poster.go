@@ -0,0 +1,50 @@
+package encoder
+
+import (
+	"fmt"
+	"strconv"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// posterSec returns where the poster frame lands in the output: the
+// --poster-at position in the clip, after any intro. 0 means no poster.
+func posterSec(enc model.EncodeOptions) float64 {
+	if enc.PosterAtSec <= 0 || enc.AudioOnly || enc.StreamCopy {
+		return 0
+	}
+	if enc.Intro != nil {
+		return enc.Intro.DurationSec + enc.PosterAtSec
+	}
+	return enc.PosterAtSec
+}
+
+// posterInput returns the input that supplies the poster frame: the source
+// again, seeked to the --poster-at position in the clip.
+func posterInput(path string, enc model.EncodeOptions) []string {
+	at := enc.StartSec + enc.PosterAtSec
+	return []string{"-ss", strconv.FormatFloat(at, 'f', 3, 64), "-i", path}
+}
+
+// posterArgs maps the poster input's first frame into the output as cover
+// art (an MP4 attached picture, which chat apps and players use as the
+// preview) and forces a keyframe at the same spot in the video, for apps
+// that preview the nearest keyframe instead. input is the poster input's
+// index.
+func posterArgs(in model.DownloadedVideo, enc model.EncodeOptions, input int) ([]string, error) {
+	if in.DurationSec > 0 && enc.PosterAtSec >= in.DurationSec {
+		return nil, fmt.Errorf("--poster-at %s is past the end of the clip (%s)", util.FormatTimestamp(enc.PosterAtSec), util.FormatTimestamp(in.DurationSec))
+	}
+	scale, _ := scaleFilter(enc.LongSidePx, in.Width, in.Height)
+	return []string{
+		"-map", fmt.Sprintf("%d:v:0", input),
+		"-filter:v:1", scale,
+		"-c:v:1", "mjpeg",
+		"-pix_fmt:v:1", "yuvj420p",
+		"-q:v:1", "2",
+		"-frames:v:1", "1",
+		"-disposition:v:1", "attached_pic",
+		"-force_key_frames:v:0", strconv.FormatFloat(posterSec(enc), 'f', 3, 64),
+	}, nil
+}
@@ -0,0 +1,62 @@
+package encoder
+
+import (
+	"context"
+	"log/slog"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/util/deps"
+	"ig2wa/internal/util/media"
+)
+
+// Probe runs ffprobe on a downloaded video and records its streams in
+// dv.Streams. It also completes width, height, and duration when the
+// downloader's metadata left them out (common for some Instagram formats), so
+// size mode and the no-upscale rule still apply. Without ffprobe, or if
+// probing fails, dv is returned unchanged.
+func (ffmpegBackend) Probe(ctx context.Context, dv model.DownloadedVideo) model.DownloadedVideo {
+	if dv.InputPath == "" {
+		return dv
+	}
+	incomplete := dv.Width <= 0 || dv.Height <= 0 || dv.DurationSec <= 0
+	ffprobe, err := deps.FindFFprobe()
+	if err != nil {
+		if incomplete {
+			slog.Warn("metadata lacks size or duration and ffprobe is unavailable", "id", dv.ID, "err", err)
+		} else {
+			slog.Debug("ffprobe unavailable; not probing download", "err", err)
+		}
+		return dv
+	}
+	p, err := media.Probe(ctx, ffprobe, dv.InputPath)
+	if err != nil {
+		slog.Warn("could not probe download", "path", dv.InputPath, "err", err)
+		return dv
+	}
+	streams := &model.SourceStreams{}
+	if v, ok := p.Video(); ok {
+		streams.VideoCodec, streams.PixFmt, streams.Rotation = v.Codec, v.PixFmt, v.Rotation
+		if dv.Width <= 0 || dv.Height <= 0 {
+			dv.Width, dv.Height = v.Width, v.Height
+		}
+		// ffmpeg rotates the frames while decoding, so scaling must go by the
+		// displayed shape; metadata often reports the stored one.
+		if (v.Rotation == 90 || v.Rotation == 270) && dv.Width == v.Width && dv.Height == v.Height {
+			dv.Width, dv.Height = dv.Height, dv.Width
+		}
+	}
+	if a, ok := p.Audio(); ok {
+		streams.AudioCodec, streams.AudioProfile, streams.AudioChannels = a.Codec, a.Profile, a.Channels
+		streams.AudioKbps = int(a.BitRate / 1000)
+	}
+	dv.Streams = streams
+	if dv.DurationSec <= 0 && p.DurationSec > 0 {
+		dv.DurationSec = p.DurationSec
+		if dv.Sectioned {
+			dv.DurationSec += dv.SectionStartSec // the file starts at the cut
+		}
+	}
+	slog.Debug("probed download", "path", dv.InputPath, "width", dv.Width, "height", dv.Height, "duration", dv.DurationSec,
+		"video", streams.VideoCodec, "pix_fmt", streams.PixFmt, "rotation", streams.Rotation, "audio", streams.AudioCodec, "audio_kbps", streams.AudioKbps)
+	return dv
+}
@@ -0,0 +1,276 @@
+package encoder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/remote"
+	"ig2wa/internal/util"
+)
+
+// RemoteBackend is the name of the backend that offloads encodes to other
+// machines' `sniplette daemon --listen` (Options.Remotes). Only the encode is
+// sent out: the download, probe, and everything after the encode stay local.
+const RemoteBackend = "remote"
+
+// RemoteEncodePath is where a server takes encodes, relative to its base URL;
+// their outputs are fetched from remote.OutputsPath.
+const RemoteEncodePath = "/v1/encode"
+
+// Parts of a remote encode request, in this order: the JSON RemoteRequest,
+// then the source and the bumpers it uses.
+const (
+	RemotePartRequest = "request"
+	RemotePartSource  = "source"
+	RemotePartIntro   = "intro"
+	RemotePartOutro   = "outro"
+)
+
+// RemoteRequest is the settings of a remote encode. The file paths in Video
+// and Encode are the client's; the server swaps in its copies of the parts.
+// Raw ffmpeg arguments (--ffmpeg-args) are never sent: they could make the
+// server write anywhere.
+type RemoteRequest struct {
+	Video   model.DownloadedVideo `json:"video"`
+	Encode  model.EncodeOptions   `json:"encode"`
+	Ext     string                `json:"ext"` // Of the output, e.g. ".mp4"
+	Threads int                   `json:"threads,omitempty"`
+}
+
+// RemoteEvent is one JSON line of the response to a remote encode: progress
+// while it runs, then the output or an error.
+type RemoteEvent struct {
+	Update *progress.Update   `json:"update,omitempty"`
+	Output *model.OutputVideo `json:"output,omitempty"` // OutputPath is the name to fetch it by
+	Error  string             `json:"error,omitempty"`
+}
+
+func init() {
+	RegisterBackend(remoteBackend{})
+}
+
+type remoteBackend struct{}
+
+func (remoteBackend) Name() string { return RemoteBackend }
+
+// Probe runs ffprobe locally, as the ffmpeg backend does.
+func (remoteBackend) Probe(ctx context.Context, in model.DownloadedVideo) model.DownloadedVideo {
+	return ffmpegBackend{}.Probe(ctx, in)
+}
+
+// Command is nil: the server builds the ffmpeg command.
+func (remoteBackend) Command(model.DownloadedVideo, model.EncodeOptions, Options) ([]string, error) {
+	return nil, nil
+}
+
+// Encode runs the encode on the least loaded of opts.Remotes (remote.Local is
+// this machine's ffmpeg). When an endpoint can't be reached or fails
+// mid-transfer, it is passed over for a while and the encode moves to the
+// next one; errors of the encode itself are final.
+func (remoteBackend) Encode(ctx context.Context, in model.DownloadedVideo, enc model.EncodeOptions, opts Options) (model.OutputVideo, error) {
+	if len(opts.Remotes) == 0 {
+		return model.OutputVideo{}, errors.New("remote encoder URL is required (--encode-remote)")
+	}
+	if opts.OutputPath == "" {
+		return model.OutputVideo{}, errors.New("output path is required")
+	}
+	if len(opts.ExtraArgs) > 0 {
+		return model.OutputVideo{}, errors.New("--ffmpeg-args can't be used with remote encoding")
+	}
+	ctx, cancel := util.StageContext(ctx, "encode", opts.Timeout)
+	defer cancel()
+	tried := map[string]bool{}
+	var errs []error
+	for {
+		ep, ok := endpoints.Acquire(ctx, opts.Remotes, tried, opts.RemoteToken)
+		if !ok {
+			return model.OutputVideo{}, fmt.Errorf("no encoder endpoint could take the encode: %w", errors.Join(errs...))
+		}
+		tried[ep] = true
+		var out model.OutputVideo
+		var err error
+		if ep == remote.Local {
+			o := opts
+			o.Timeout = 0 // already applied to ctx
+			out, err = Encode(ctx, in, enc, o)
+		} else {
+			out, err = encodeOn(ctx, ep, in, enc, opts)
+		}
+		var ee *remote.EndpointError
+		failed := errors.As(err, &ee) && ctx.Err() == nil
+		endpoints.Release(ep, failed)
+		if !failed {
+			return out, err
+		}
+		slog.Warn("encoder endpoint failed; moving the encode", "endpoint", ep, "err", err)
+		errs = append(errs, err)
+	}
+}
+
+// endpoints are the encode endpoints of this process's jobs.
+var endpoints = remote.NewPool()
+
+// encodeOn uploads in's source (and bumpers) to the daemon at base, relays
+// its progress, and fetches the output into opts.OutputPath.
+func encodeOn(ctx context.Context, base string, in model.DownloadedVideo, enc model.EncodeOptions, opts Options) (model.OutputVideo, error) {
+	base = strings.TrimRight(base, "/")
+	host := remote.Host(base)
+	report := func(percent float64, msg string) {
+		if opts.Reporter != nil {
+			opts.Reporter.Update(progress.Update{JobID: opts.JobID, Stage: progress.StageEncoding, Percent: percent, Message: msg + " (" + host + ")"})
+		}
+	}
+
+	// Upload and encode: the response streams progress until the output is ready
+	body, contentType := remoteRequestBody(in, enc, opts, report)
+	defer body.Close()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+RemoteEncodePath, body)
+	if err != nil {
+		return model.OutputVideo{}, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	remote.SetAuth(req, opts.RemoteToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return model.OutputVideo{}, &remote.EndpointError{Err: fmt.Errorf("remote encoder %s: %w", host, err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("remote encoder %s: %w", host, remote.StatusError(resp))
+		if remote.FailedStatus(resp.StatusCode) {
+			err = &remote.EndpointError{Err: err}
+		}
+		return model.OutputVideo{}, err
+	}
+	var out *model.OutputVideo
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var ev RemoteEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			return model.OutputVideo{}, &remote.EndpointError{Err: fmt.Errorf("remote encoder %s: bad reply: %w", host, err)}
+		}
+		switch {
+		case ev.Error != "":
+			return model.OutputVideo{}, fmt.Errorf("remote encoder %s: %s", host, ev.Error)
+		case ev.Output != nil:
+			out = ev.Output
+		case ev.Update != nil && opts.Reporter != nil:
+			u := *ev.Update
+			u.JobID = opts.JobID
+			u.Message += " (" + host + ")"
+			opts.Reporter.Update(u)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return model.OutputVideo{}, &remote.EndpointError{Err: fmt.Errorf("remote encoder %s: %w", host, util.TimeoutCause(ctx, err))}
+	}
+	if out == nil {
+		return model.OutputVideo{}, &remote.EndpointError{Err: fmt.Errorf("remote encoder %s: connection closed before the output was ready", host)}
+	}
+
+	// Fetch the output, through the workdir so a partial file never shows
+	report(-1, "Fetching from remote encoder")
+	tmp := opts.OutputPath + ".part"
+	if opts.WorkDir != "" {
+		tmp = filepath.Join(opts.WorkDir, "encoded"+filepath.Ext(opts.OutputPath))
+	}
+	if err := remote.Fetch(ctx, base, out.OutputPath, opts.RemoteToken, tmp); err != nil {
+		_ = util.RemoveIfExists(tmp)
+		return model.OutputVideo{}, &remote.EndpointError{Err: err}
+	}
+	if err := util.EnsureDir(filepath.Dir(opts.OutputPath)); err != nil {
+		return model.OutputVideo{}, fmt.Errorf("ensure output dir: %w", err)
+	}
+	if err := util.MoveFile(tmp, opts.OutputPath); err != nil {
+		return model.OutputVideo{}, fmt.Errorf("finalize output: %w", err)
+	}
+	out.OutputPath, out.Bytes = opts.OutputPath, util.FileSize(opts.OutputPath)
+	return *out, nil
+}
+
+// remoteRequestBody streams the multipart upload of a remote encode,
+// reporting how much of the source has been sent.
+func remoteRequestBody(in model.DownloadedVideo, enc model.EncodeOptions, opts Options, report func(float64, string)) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(func() error {
+			part, err := mw.CreateFormField(RemotePartRequest)
+			if err != nil {
+				return err
+			}
+			rr := RemoteRequest{Video: in, Encode: enc, Ext: filepath.Ext(opts.OutputPath), Threads: opts.Threads}
+			if err := json.NewEncoder(part).Encode(rr); err != nil {
+				return err
+			}
+			total := util.FileSize(in.InputPath)
+			err = addRemoteFile(mw, RemotePartSource, in.InputPath, func(sent int64) {
+				if total > 0 {
+					report(float64(sent)*100/float64(total), "Sending to remote encoder")
+				}
+			})
+			if err != nil {
+				return err
+			}
+			for _, b := range []struct {
+				part   string
+				bumper *model.Bumper
+			}{{RemotePartIntro, enc.Intro}, {RemotePartOutro, enc.Outro}} {
+				if b.bumper != nil {
+					if err := addRemoteFile(mw, b.part, b.bumper.Path, nil); err != nil {
+						return err
+					}
+				}
+			}
+			return mw.Close()
+		}())
+	}()
+	return pr, mw.FormDataContentType()
+}
+
+// addRemoteFile copies the file at path into a part named name, calling
+// sent (if non-nil) with the bytes copied so far.
+func addRemoteFile(mw *multipart.Writer, name, path string, sent func(int64)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	part, err := mw.CreateFormFile(name, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	var n int64
+	buf := make([]byte, 1024*1024)
+	for {
+		k, rerr := f.Read(buf)
+		if k > 0 {
+			if _, err := part.Write(buf[:k]); err != nil {
+				return err
+			}
+			n += int64(k)
+			if sent != nil {
+				sent(n)
+			}
+		}
+		if rerr == io.EOF {
+			return nil
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+}
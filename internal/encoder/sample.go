@@ -0,0 +1,82 @@
+package encoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// Sample is the result of one short test encode.
+type Sample struct {
+	StartSec float64
+	DurSec   float64
+	Bytes    int64
+}
+
+// SampleEncode encodes durSec seconds at each start offset with the same
+// settings Encode would use and reports the size of each piece. inputs are
+// local paths or direct media URLs; when a second input is given it supplies
+// the audio (yt-dlp's separate video+audio formats). Samples are written to
+// opts.WorkDir and removed afterwards. in.DurationSec must be the full
+// duration so bitrate mode targets the same rate as the real encode.
+func SampleEncode(ctx context.Context, inputs []string, in model.DownloadedVideo, enc model.EncodeOptions, opts Options, starts []float64, durSec float64) ([]Sample, error) {
+	if opts.FFmpegPath == "" {
+		return nil, errors.New("ffmpeg path is required")
+	}
+	if len(inputs) == 0 || len(inputs) > 2 {
+		return nil, fmt.Errorf("expected 1 or 2 inputs, got %d", len(inputs))
+	}
+	// Samples cover the video only; bumpers count just toward the bitrate,
+	// and the poster frame is left out.
+	in.DurationSec = OutputDuration(in, enc)
+	enc.Intro, enc.Outro, enc.PosterAtSec = nil, nil, 0
+	codec, _, _, err := codecArgs(in, enc)
+	if err != nil {
+		return nil, err
+	}
+	ext := ".mp4"
+	if enc.AudioOnly {
+		ext = ".m4a"
+	}
+
+	var out []Sample
+	for i, start := range starts {
+		var inArgs []string
+		for _, src := range inputs {
+			inArgs = append(inArgs,
+				"-ss", strconv.FormatFloat(start, 'f', 3, 64),
+				"-t", strconv.FormatFloat(durSec, 'f', 3, 64),
+				"-i", src)
+		}
+		if len(inputs) == 2 {
+			inArgs = append(inArgs, "-map", "0:v:0", "-map", "1:a:0")
+		}
+		sopts := opts
+		sopts.OutputPath = filepath.Join(opts.WorkDir, fmt.Sprintf("sample-%d%s", i, ext))
+		_, runErr := util.Run(ctx, util.CmdSpec{
+			Path:    opts.FFmpegPath,
+			Args:    assembleArgs(inArgs, codec, sopts, false),
+			Verbose: opts.Verbose,
+			Timeout: opts.Timeout,
+			Stage:   "sample encode",
+			Nice:    opts.Nice,
+		})
+		if runErr != nil {
+			_ = util.RemoveIfExists(sopts.OutputPath)
+			return out, fmt.Errorf("sample encode at %.0fs: %w", start, runErr)
+		}
+		fi, err := os.Stat(sopts.OutputPath)
+		if err != nil {
+			return out, fmt.Errorf("stat sample: %w", err)
+		}
+		_ = util.RemoveIfExists(sopts.OutputPath)
+		out = append(out, Sample{StartSec: start, DurSec: durSec, Bytes: fi.Size()})
+	}
+	return out, nil
+}
@@ -0,0 +1,230 @@
+package history
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ig2wa/internal/dirs"
+	"ig2wa/internal/util"
+)
+
+const fileName = "history.jsonl"
+
+// Job outcomes.
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Entry records one finished job. The history file holds one JSON entry per
+// line, oldest first.
+type Entry struct {
+	Time        time.Time `json:"time"`
+	URL         string    `json:"url"`
+	Platform    string    `json:"platform,omitempty"`
+	VideoID     string    `json:"video_id,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Uploader    string    `json:"uploader,omitempty"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	OutputPath  string    `json:"output_path,omitempty"`
+	RemoteURL   string    `json:"remote_url,omitempty"`
+	Bytes       int64     `json:"bytes,omitempty"`
+	SourceBytes int64     `json:"source_bytes,omitempty"`
+	DurationSec float64   `json:"duration_sec,omitempty"`
+	SourcePath  string    `json:"source_path,omitempty"` // Downloaded source, when it was kept
+	Tags        []string  `json:"tags,omitempty"`
+
+	// Wall-clock seconds spent per stage and on the whole job; 0 if the
+	// stage didn't run.
+	MetadataSec float64 `json:"metadata_sec,omitempty"`
+	DownloadSec float64 `json:"download_sec,omitempty"`
+	EncodeSec   float64 `json:"encode_sec,omitempty"`
+	UploadSec   float64 `json:"upload_sec,omitempty"`
+	ElapsedSec  float64 `json:"elapsed_sec,omitempty"`
+}
+
+// HasTag reports whether the entry carries tag (case-insensitively).
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithTag returns the entries carrying tag; an empty tag keeps them all.
+func WithTag(entries []Entry, tag string) []Entry {
+	if tag == "" {
+		return entries
+	}
+	var out []Entry
+	for _, e := range entries {
+		if e.HasTag(tag) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// ID is a short, stable identifier for the entry, derived from its time and
+// URL (see Find).
+func (e Entry) ID() string {
+	sum := sha1.Sum([]byte(e.Time.UTC().Format(time.RFC3339Nano) + "\n" + e.URL))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Key identifies the video an entry is about: platform and video ID when
+// known, else the URL.
+func (e Entry) Key() string {
+	return Key(e.URL, e.VideoID)
+}
+
+// Key identifies a video by platform and ID when both are known, else by URL.
+// Direct media links are always keyed by URL: their IDs are only file names.
+func Key(url, videoID string) string {
+	if pl, _, err := util.DetectPlatform(url); err == nil && pl != util.PlatformDirect && videoID != "" {
+		return string(pl) + ":" + videoID
+	}
+	return url
+}
+
+// Path returns the history file location in the data dir.
+func Path() (string, error) {
+	dir, err := dirs.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+var mu sync.Mutex // serializes appends from concurrent TUI jobs
+
+// Append adds e to the history file.
+func Append(e Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Platform == "" {
+		if pl, _, err := util.DetectPlatform(e.URL); err == nil {
+			e.Platform = string(pl)
+		}
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := dirs.Ensure(filepath.Dir(path)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads every entry, oldest first. A missing file is an empty history;
+// malformed lines are skipped.
+func Load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			out = append(out, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return out, fmt.Errorf("read %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// Find returns the entry whose ID is id, or starts with it.
+func Find(id string) (Entry, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return Entry{}, errors.New("empty history ID")
+	}
+	entries, err := Load()
+	if err != nil {
+		return Entry{}, err
+	}
+	var found []Entry
+	for _, e := range entries {
+		if strings.HasPrefix(e.ID(), id) {
+			found = append(found, e)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Entry{}, fmt.Errorf("no history entry %q", id)
+	case 1:
+		return found[0], nil
+	default:
+		return Entry{}, fmt.Errorf("history ID %q is ambiguous (%d entries); give more of it", id, len(found))
+	}
+}
+
+// Index answers "has this video been snipped before?".
+type Index map[string]bool
+
+// LoadIndex indexes the successful entries by URL and by Key, so a video is
+// recognised whichever form of its link was used.
+func LoadIndex() (Index, error) {
+	entries, err := Load()
+	ix := Index{}
+	for _, e := range entries {
+		if e.Status == StatusSuccess {
+			ix.Add(e.URL, e.VideoID)
+		}
+	}
+	return ix, err
+}
+
+// Add marks a video as done.
+func (ix Index) Add(url, videoID string) {
+	ix[url] = true
+	ix[Key(url, videoID)] = true
+}
+
+// Seen reports whether the video was snipped successfully before.
+func (ix Index) Seen(url, videoID string) bool {
+	return ix[url] || ix[Key(url, videoID)]
+}
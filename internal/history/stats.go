@@ -0,0 +1,115 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats aggregates history entries (see Summarize).
+type Stats struct {
+	Clips       int       `json:"clips"`  // Successful jobs
+	Failed      int       `json:"failed"` // Failed jobs
+	First       time.Time `json:"first,omitempty"`
+	Last        time.Time `json:"last,omitempty"`
+	OutputBytes int64     `json:"output_bytes"`
+	SourceBytes int64     `json:"source_bytes"` // Of the clips whose source size is known
+	SavedBytes  int64     `json:"saved_bytes"`  // Source minus output, over those same clips
+	// AvgRatio is the mean source/output size ratio of the clips whose source
+	// size is known; 0 if there are none.
+	AvgRatio    float64 `json:"avg_compression_ratio"`
+	DurationSec float64 `json:"duration_sec"` // Total video duration of the clips
+
+	// Wall-clock seconds spent per stage, over all jobs that recorded them.
+	MetadataSec float64 `json:"metadata_sec"`
+	DownloadSec float64 `json:"download_sec"`
+	EncodeSec   float64 `json:"encode_sec"`
+	UploadSec   float64 `json:"upload_sec"`
+	ElapsedSec  float64 `json:"elapsed_sec"`
+
+	Platforms []Count `json:"platforms"`
+	Uploaders []Count `json:"uploaders"` // Busiest first, at most TopUploaders
+	Tags      []Count `json:"tags,omitempty"`
+}
+
+// Count is a name with its number of clips.
+type Count struct {
+	Name  string `json:"name"`
+	Clips int    `json:"clips"`
+}
+
+// TopUploaders caps Stats.Uploaders.
+const TopUploaders = 5
+
+// Summarize aggregates entries. Sizes, ratios, platforms, uploaders, and tags
+// count successful jobs only; stage times count failed jobs too.
+func Summarize(entries []Entry) Stats {
+	var s Stats
+	platforms := map[string]int{}
+	uploaders := map[string]int{}
+	tags := map[string]int{}
+	var ratios float64
+	var withSource int
+	for _, e := range entries {
+		if s.First.IsZero() || e.Time.Before(s.First) {
+			s.First = e.Time
+		}
+		if e.Time.After(s.Last) {
+			s.Last = e.Time
+		}
+		s.MetadataSec += e.MetadataSec
+		s.DownloadSec += e.DownloadSec
+		s.EncodeSec += e.EncodeSec
+		s.UploadSec += e.UploadSec
+		s.ElapsedSec += e.ElapsedSec
+		if e.Status != StatusSuccess {
+			s.Failed++
+			continue
+		}
+		s.Clips++
+		s.OutputBytes += e.Bytes
+		s.DurationSec += e.DurationSec
+		if e.SourceBytes > 0 && e.Bytes > 0 {
+			s.SourceBytes += e.SourceBytes
+			s.SavedBytes += e.SourceBytes - e.Bytes
+			ratios += float64(e.SourceBytes) / float64(e.Bytes)
+			withSource++
+		}
+		pl := e.Platform
+		if pl == "" {
+			pl = "other"
+		}
+		platforms[pl]++
+		if e.Uploader != "" {
+			uploaders[e.Uploader]++
+		}
+		for _, t := range e.Tags {
+			tags[t]++
+		}
+	}
+	if withSource > 0 {
+		s.AvgRatio = ratios / float64(withSource)
+	}
+	s.Platforms = sortedCounts(platforms, 0)
+	s.Uploaders = sortedCounts(uploaders, TopUploaders)
+	s.Tags = sortedCounts(tags, 0)
+	return s
+}
+
+// sortedCounts orders m by count, then name, keeping at most limit entries
+// (0 = all).
+func sortedCounts(m map[string]int, limit int) []Count {
+	out := make([]Count, 0, len(m))
+	for name, n := range m {
+		out = append(out, Count{Name: name, Clips: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Clips != out[j].Clips {
+			return out[i].Clips > out[j].Clips
+		}
+		return out[i].Name < out[j].Name
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
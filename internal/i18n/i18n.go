@@ -0,0 +1,142 @@
+// Package i18n translates the CLI's and TUI's user-facing text. Messages are
+// keyed by their English text, the fmt format string the code passes to
+// Sprintf, so English needs no catalog entries and a missing translation
+// falls back to it. Translations live in one file per language (ms.go, ...),
+// which add their strings to the catalog with add.
+//
+// Only the format string is translated; arguments are formatted with fmt as
+// before, so numbers, sizes, and paths read the same in every language.
+//
+// What's translated is what a user reads while using sniplette: the TUI, the
+// wizard, command output, errors, and the --no-ui progress lines. Command and
+// flag help, the help topics, and log lines stay in English, as do the JSON
+// and machine-readable outputs.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+)
+
+var (
+	cat = catalog.NewBuilder(catalog.Fallback(language.English))
+
+	// current is set once at startup by Setup, before any goroutine reads it.
+	current = language.English
+)
+
+// verbRe matches the fmt verbs of a format string, for add's check.
+var verbRe = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]*)?[a-zA-Z]`)
+
+// add registers the translations of one language: English text to translated
+// text. A translation must use the same verbs, in order, as its English text.
+func add(tag language.Tag, msgs map[string]string) {
+	for key, msg := range msgs {
+		if want, got := verbRe.FindAllString(key, -1), verbRe.FindAllString(msg, -1); strings.Join(want, "") != strings.Join(got, "") {
+			panic(fmt.Sprintf("i18n: %s %q: verbs %v, want %v", tag, key, got, want))
+		}
+		if err := cat.SetString(tag, key, msg); err != nil {
+			panic(fmt.Sprintf("i18n: %s %q: %v", tag, key, err))
+		}
+	}
+}
+
+// Languages lists the languages with a catalog, English first.
+func Languages() []language.Tag {
+	tags := []language.Tag{language.English}
+	for _, t := range cat.Languages() {
+		if t != language.English {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// Setup selects the language of later messages: lang when set (a BCP 47 tag
+// such as "ms" or "ms-MY", from --lang or the lang config key), or else the
+// locale in LC_ALL, LC_MESSAGES, or LANG. Languages without a catalog fall
+// back to the closest one that has, or English. It returns the language
+// chosen, and an error only when lang can't be parsed.
+func Setup(lang string) (language.Tag, error) {
+	want := language.English
+	if lang = strings.TrimSpace(lang); lang != "" {
+		t, err := language.Parse(lang)
+		if err != nil {
+			return language.English, fmt.Errorf("invalid language %q: use a tag such as en or ms", lang)
+		}
+		want = t
+	} else if t, ok := envLocale(); ok {
+		want = t
+	}
+	supported := Languages()
+	_, i, conf := language.NewMatcher(supported).Match(want)
+	current = language.English
+	if conf != language.No {
+		current = supported[i]
+	}
+	return current, nil
+}
+
+// envLocale reads the POSIX locale, such as "ms_MY.UTF-8", from the first of
+// LC_ALL, LC_MESSAGES, and LANG that is set. "C" and "POSIX" mean English.
+func envLocale() (language.Tag, bool) {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		v, _, _ = strings.Cut(v, ".")
+		v, _, _ = strings.Cut(v, "@")
+		if v == "C" || v == "POSIX" {
+			return language.English, true
+		}
+		t, err := language.Parse(strings.ReplaceAll(v, "_", "-"))
+		return t, err == nil
+	}
+	return language.Und, false
+}
+
+// Current returns the language selected by Setup.
+func Current() language.Tag {
+	return current
+}
+
+// Sprintf formats the translation of format, or format itself when the
+// current language has none, with fmt.Sprintf.
+func Sprintf(format string, a ...any) string {
+	return fmt.Sprintf(lookup(format), a...)
+}
+
+// Errorf is fmt.Errorf with the translation of format, so %w wraps as usual.
+func Errorf(format string, a ...any) error {
+	return fmt.Errorf(lookup(format), a...)
+}
+
+// String returns the translation of s, or s. Unlike Sprintf it is safe for
+// text that isn't a format string, such as progress messages.
+func String(s string) string {
+	return lookup(s)
+}
+
+func lookup(key string) string {
+	if current == language.English {
+		return key
+	}
+	var r textRenderer
+	if err := cat.Context(current, &r).Execute(key); err != nil {
+		return key
+	}
+	return r.String()
+}
+
+// textRenderer collects the text of a catalog message.
+type textRenderer struct{ strings.Builder }
+
+func (r *textRenderer) Render(s string) { r.WriteString(s) }
+
+func (r *textRenderer) Arg(int) interface{} { return nil }
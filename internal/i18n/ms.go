@@ -0,0 +1,286 @@
+package i18n
+
+import "golang.org/x/text/language"
+
+// Malay (Bahasa Melayu).
+func init() {
+	add(language.Malay, map[string]string{
+		// TUI
+		"ig2wa — Instagram/YouTube to WhatsApp": "ig2wa — Instagram/YouTube ke WhatsApp",
+		"Jobs: %d/%d done • ":                   "Kerja: %d/%d siap • ",
+		"%s downloaded • ":                      "%s dimuat turun • ",
+		"%d at a time (auto) • ":                "%d serentak (auto) • ",
+		"Shutting down… finishing %d running job(s) first (press q to stop them now)":   "Menutup… menyiapkan %d kerja yang sedang berjalan dahulu (tekan q untuk menghentikannya sekarang)",
+		"Shutting down… stopping %d job(s) and cleaning up (press q again to exit now)": "Menutup… menghentikan %d kerja dan membersihkan (tekan q sekali lagi untuk keluar sekarang)",
+		"stopping":           "menghentikan",
+		"✓ done":             "✓ siap",
+		"✗ error":            "✗ ralat",
+		"waiting":            "menunggu",
+		"↑ high priority":    "↑ keutamaan tinggi",
+		"↓ low priority":     "↓ keutamaan rendah",
+		"elapsed %s":         "berlalu %s",
+		" • ETA %s":          " • anggaran siap %s",
+		"frame %d":           "bingkai %d",
+		"✓ Completed Files:": "✓ Fail Siap:",
+		"Keybindings":        "Kekunci",
+		"Remap keys in the config file under \"keys\" (e.g., quit: [\"x\"]).": "Tukar kekunci dalam fail konfigurasi di bawah \"keys\" (cth., quit: [\"x\"]).",
+		"Pick source format: %s":         "Pilih format sumber: %s",
+		"auto (best video + best audio)": "auto (video terbaik + audio terbaik)",
+		"%d formats • ":                  "%d format • ",
+		"Dependency error: %v":           "Ralat kebergantungan: %v",
+		"Waiting for format choice":      "Menunggu pilihan format",
+		"Starting":                       "Bermula",
+		"Planned: %s (%s)":               "Dirancang: %s (%s)",
+		"Saved: %s (%s) → %s":            "Disimpan: %s (%s) → %s",
+		"Saved: %s (%s)":                 "Disimpan: %s (%s)",
+		"Completed":                      "Selesai",
+		"Skipped: %s":                    "Dilangkau: %s",
+
+		// Accessible mode
+		"Job %d of %d: %s":               "Kerja %d daripada %d: %s",
+		"started, %s":                    "bermula, %s",
+		"done. %s":                       "siap. %s",
+		"failed. %s":                     "gagal. %s",
+		"%s, %d percent":                 "%s, %d peratus",
+		"Press q to quit or ? for help.": "Tekan q untuk keluar atau ? untuk bantuan.",
+
+		// Keybinding help
+		"quit":                         "keluar",
+		"toggle help":                  "tunjuk/sembunyi bantuan",
+		"move up":                      "naik",
+		"move down":                    "turun",
+		"select format":                "pilih format",
+		"use automatic format":         "guna format automatik",
+		"raise priority of queued job": "naikkan keutamaan kerja yang menunggu",
+		"lower priority of queued job": "turunkan keutamaan kerja yang menunggu",
+
+		// Job stages and progress messages
+		"deps":                          "kebergantungan",
+		"metadata":                      "metadata",
+		"downloading":                   "memuat turun",
+		"merging":                       "menggabung",
+		"encoding":                      "mengekod",
+		"uploading":                     "memuat naik",
+		"completed":                     "selesai",
+		"error":                         "ralat",
+		"Fetching metadata":             "Mendapatkan metadata",
+		"Starting download":             "Memulakan muat turun",
+		"Downloading":                   "Memuat turun",
+		"Waiting for the stream to end": "Menunggu siaran tamat",
+		"Encoding":                      "Mengekod",
+		"Remuxing":                      "Mengemas semula",
+		"Encoding (audio)":              "Mengekod (audio)",
+		"Extracting frame":              "Mengekstrak bingkai",
+		"Uploading":                     "Memuat naik",
+		"Uploaded":                      "Dimuat naik",
+
+		// Wizard
+		"Sniplette wizard":             "Panduan Sniplette",
+		"Paste the link to the video:": "Tampal pautan video:",
+		"Fetching details for %s…":     "Mendapatkan butiran untuk %s…",
+		"Where will you send it?":      "Ke mana anda akan menghantarnya?",
+		"Email":                        "E-mel",
+		"Keep quality (no size limit)": "Kekalkan kualiti (tiada had saiz)",
+		"%s (up to %d MB)":             "%s (sehingga %d MB)",
+		"Picture quality:":             "Kualiti gambar:",
+		"low":                          "rendah",
+		"medium":                       "sederhana",
+		"high":                         "tinggi",
+		"Keep only part of the video? Leave both empty for all of it.": "Simpan sebahagian video sahaja? Biarkan kedua-duanya kosong untuk keseluruhan video.",
+		"start, e.g. 0:05 (empty = beginning)":                         "mula, cth. 0:05 (kosong = dari awal)",
+		"end, e.g. 0:35 (empty = end)":                                 "tamat, cth. 0:35 (kosong = hingga akhir)",
+		"start is past the end of the video (%s)":                      "masa mula melepasi penghujung video (%s)",
+		"Save the caption with the video?":                             "Simpan kapsyen bersama video?",
+		"Yes, as a .txt file":                                          "Ya, sebagai fail .txt",
+		"Yes, inside the video file":                                   "Ya, di dalam fail video",
+		"Yes, both":                                                    "Ya, kedua-duanya",
+		"No":                                                           "Tidak",
+		"yes (.txt)":                                                   "ya (.txt)",
+		"yes (in the file)":                                            "ya (dalam fail)",
+		"yes (.txt and in the file)":                                   "ya (.txt dan dalam fail)",
+		"no":                                                           "tidak",
+		"Ready:":                                                       "Sedia:",
+		"whole video":                                                  "keseluruhan video",
+		"end":                                                          "akhir",
+		"%s to %s":                                                     "%s hingga %s",
+		"  For:      %s\n  Quality:  %s\n  Part:     %s\n  Caption:  %s\n": "  Untuk:    %s\n  Kualiti:  %s\n  Bahagian: %s\n  Kapsyen:  %s\n",
+		"%s long":                             "panjang %s",
+		"CRF %d, size depends on the content": "CRF %d, saiz bergantung pada kandungan",
+		"at most %d MB":                       "paling banyak %d MB",
+		"~%s (limit %d MB)":                   "~%s (had %d MB)",
+		"Estimate: %s":                        "Anggaran: %s",
+		"enter continue • esc/ctrl+c quit":    "enter teruskan • esc/ctrl+c keluar",
+		"esc/ctrl+c quit":                     "esc/ctrl+c keluar",
+		"tab switch field • enter continue • esc back": "tab tukar medan • enter teruskan • esc kembali",
+		"enter start • esc back • ctrl+c quit":         "enter mula • esc kembali • ctrl+c keluar",
+		"↑/↓ choose • enter continue • esc back":       "↑/↓ pilih • enter teruskan • esc kembali",
+
+		// CLI
+		"Saved: %s (%0.2f MB%s)": "Disimpan: %s (%0.2f MB%s)",
+		", not re-encoded":       ", tidak dikod semula",
+		"Uploaded: %s":           "Dimuat naik: %s",
+		"Took: %s":               "Masa diambil: %s",
+		"Report: %s":             "Laporan: %s",
+		"Downloaded %s in total": "Jumlah dimuat turun: %s",
+		"Skipping %s: already in the download archive": "Melangkau %s: sudah ada dalam arkib muat turun",
+		"Skipping %s: %s":                                                    "Melangkau %s: %s",
+		"Skipping duplicate %s":                                              "Melangkau pendua %s",
+		"Skipping %s: same video as %s":                                      "Melangkau %s: video yang sama dengan %s",
+		"Nothing new: the latest videos are all in the history.":             "Tiada yang baharu: video terkini semuanya sudah ada dalam sejarah.",
+		"Nothing new: the latest videos are in the history or filtered out.": "Tiada yang baharu: video terkini sudah ada dalam sejarah atau ditapis keluar.",
+		"the daemon":                 "daemon",
+		"the running TUI":            "TUI yang sedang berjalan",
+		"Not queued: %s (%s)":        "Tidak dimasukkan ke baris gilir: %s (%s)",
+		"Queued on %s as job %d: %s": "Dimasukkan ke baris gilir pada %s sebagai kerja %d: %s",
+		"A new sniplette release is available: %s (you have %s) %s":                                    "Keluaran baharu sniplette tersedia: %s (anda mempunyai %s) %s",
+		"WARNING: %s %s is known to be broken for Instagram (minimum %s); run 'sniplette deps update'": "AMARAN: %s %s diketahui rosak untuk Instagram (minimum %s); jalankan 'sniplette deps update'",
+
+		// Command output
+		"Nothing to clean.":                        "Tiada apa untuk dibersihkan.",
+		"Removed":                                  "Dibuang",
+		"Would remove":                             "Akan dibuang",
+		"Failed to remove %s: %v":                  "Gagal membuang %s: %v",
+		"%s %s (%s, %s, modified %s)":              "%s %s (%s, %s, diubah suai %s)",
+		"%s %s in total.":                          "%s %s kesemuanya.",
+		"# config file: %s":                        "# fail konfigurasi: %s",
+		"! unknown key %q is ignored":              "! kunci %q tidak dikenali dan diabaikan",
+		"%s is not set":                            "%s tidak ditetapkan",
+		"Set %s in %s":                             "%s ditetapkan dalam %s",
+		"Wrote %s":                                 "Ditulis: %s",
+		"The daemon has no jobs.":                  "Daemon tiada kerja.",
+		"ID\tSTATE\tADDED\tURL\tERROR":             "ID\tKEADAAN\tDITAMBAH\tURL\tRALAT",
+		"yt-dlp is up to date (%s)":                "yt-dlp sudah terkini (%s)",
+		"Debug bundle: %s":                         "Berkas nyahpepijat: %s",
+		"Downloader:":                              "Pemuat turun:",
+		"FFmpeg:    ":                              "FFmpeg:       ",
+		"%s (not found)":                           "%s (tidak ditemui)",
+		"FFmpeg features:":                         "Ciri FFmpeg:",
+		" (missing: %s)":                           " (tiada: %s)",
+		" [required]":                              " [wajib]",
+		"  Hardware acceleration: %s":              "  Pecutan perkakasan: %s",
+		"  Hardware acceleration: none":            "  Pecutan perkakasan: tiada",
+		"Title:     %s":                            "Tajuk:     %s",
+		"Uploader:  %s":                            "Pemuat naik: %s",
+		"ID:        %s":                            "ID:        %s",
+		"URL:       %s":                            "URL:       %s",
+		"Duration:  %s":                            "Tempoh:    %s",
+		"Size:      %dx%d":                         "Saiz:      %dx%d",
+		"Live:      %s":                            "Langsung:  %s",
+		"Formats (%d):":                            "Format (%d):",
+		"Estimated output per preset (size mode):": "Anggaran output bagi setiap praset (mod saiz):",
+		"Nothing to migrate.":                      "Tiada apa untuk dipindahkan.",
+		"Moved":                                    "Dipindahkan",
+		"Would move":                               "Akan dipindahkan",
+		"Failed to migrate %s: %v":                 "Gagal memindahkan %s: %v",
+		"%s is set: sniplette reads %s instead; rename it where you set it (shell profile, service file)": "%s ditetapkan: sniplette membaca %s sebagai gantinya; namakan semula di tempat anda menetapkannya (profil shell, fail servis)",
+		"%s old temp dir %s": "%s direktori sementara lama %s",
+		"Kept %s: modified within the last hour, so it may still be in use": "%s dikekalkan: diubah suai dalam sejam yang lalu, jadi mungkin masih digunakan",
+		"Kept %s: %s already exists; compare them and merge by hand":        "%s dikekalkan: %s sudah wujud; bandingkan dan gabungkan secara manual",
+		"Dry-run plan (1 URL)":                     "Pelan larian kering (1 URL)",
+		"Dry-run plan (%d URLs)":                   "Pelan larian kering (%d URL)",
+		"Downloader: %s":                           "Pemuat turun: %s",
+		"FFmpeg:     %s":                           "FFmpeg:       %s",
+		"Commands for %s:":                         "Arahan untuk %s:",
+		"Formats for %s (* = picked by --format):": "Format untuk %s (* = dipilih oleh --format):",
+		"Skipping %s: already queued":              "Melangkau %s: sudah dalam baris gilir",
+		"Queued %d URL(s); %q now holds %d.":       "%d URL dimasukkan ke baris gilir; %q kini mengandungi %d.",
+		"failed %dx":                               "gagal %dx",
+		"Nothing queued.":                          "Tiada apa dalam baris gilir.",
+		"Queue %q is empty.":                       "Baris gilir %q kosong.",
+		"The history is empty.":                    "Sejarah kosong.",
+		"ID\tTIME\tSTATUS\tSOURCE\tTAGS\tVIDEO":    "ID\tMASA\tSTATUS\tSUMBER\tTAG\tVIDEO",
+		"%s: %d new video(s)":                      "%s: %d video baharu",
+		"No jobs tagged %q.":                       "Tiada kerja bertag %q.",
+		"Period:\t%s to %s":                        "Tempoh:\t%s hingga %s",
+		"Clips:\t%d (%d failed)":                   "Klip:\t%d (%d gagal)",
+		"Video time:\t%s":                          "Masa video:\t%s",
+		"Output:\t%s":                              "Output:\t%s",
+		"Sources:\t%s (%s saved)":                  "Sumber:\t%s (%s dijimatkan)",
+		"Avg. compression:\t%.1fx":                 "Purata mampatan:\t%.1fx",
+		"Time spent:\t%s":                          "Masa diambil:\t%s",
+		"Platforms:\t%s":                           "Platform:\t%s",
+		"Top uploaders:\t%s":                       "Pemuat naik teratas:\t%s",
+		"Tags:\t%s":                                "Tag:\t%s",
+		"  %.0f fps":                               "  %.0f fps",
+		"  ETA %s":                                 "  anggaran siap %s",
+
+		// Errors
+		"invalid entry %q: %v":                               "entri tidak sah %q: %v",
+		"invalid entry %q: the URL comes first":              "entri tidak sah %q: URL mesti didahulukan",
+		"read --input: %w":                                   "baca --input: %w",
+		"no URLs given":                                      "tiada URL diberikan",
+		"%s: unexpected argument %q (one URL per entry)":     "%s: hujah tidak dijangka %q (satu URL bagi setiap entri)",
+		"%s: %s can't be set per URL":                        "%s: %s tidak boleh ditetapkan bagi setiap URL",
+		"unknown config key %q":                              "kunci konfigurasi tidak dikenali %q",
+		"invalid editor %q":                                  "editor tidak sah %q",
+		"editor: %w":                                         "editor: %w",
+		"%s already exists (use --force to overwrite)":       "%s sudah wujud (guna --force untuk menulis ganti)",
+		"a daemon or single-instance TUI is already running": "daemon atau TUI satu-instans sudah berjalan",
+		"--listen needs a token: set remote_token in the config or SNIPLETTE_REMOTE_TOKEN": "--listen memerlukan token: tetapkan remote_token dalam konfigurasi atau SNIPLETTE_REMOTE_TOKEN",
+		"remote encoding: %w":       "pengekodan jauh: %w",
+		"read download archive: %w": "baca arkib muat turun: %w",
+		"%s: %s (run 'sniplette doctor' for details, or pass --skip-version-check)": "%s: %s (jalankan 'sniplette doctor' untuk butiran, atau beri --skip-version-check)",
+		"read manifest: %w":                                                      "baca manifest: %w",
+		"manifest defaults: %w":                                                  "lalai manifest: %w",
+		"manifest %s: no jobs":                                                   "manifest %s: tiada kerja",
+		"manifest job %d: want a URL or a map of options":                        "kerja manifest %d: perlukan URL atau peta pilihan",
+		"manifest job %d: no url":                                                "kerja manifest %d: tiada url",
+		"manifest job %d (%s): %w":                                               "kerja manifest %d (%s): %w",
+		"%s can't be set in a manifest":                                          "%s tidak boleh ditetapkan dalam manifest",
+		"unknown option %q":                                                      "pilihan tidak dikenali %q",
+		"option %q: want a value or a list":                                      "pilihan %q: perlukan nilai atau senarai",
+		"%d item(s) could not be migrated":                                       "%d item tidak dapat dipindahkan",
+		"stopped with %d URL(s) unfinished":                                      "dihentikan dengan %d URL belum selesai",
+		"; saved to queue %q (resume with: sniplette queue run --queue %s)":      "; disimpan ke baris gilir %q (sambung dengan: sniplette queue run --queue %s)",
+		"not a URL: %q":                                                          "bukan URL: %q",
+		"read history: %w":                                                       "baca sejarah: %w",
+		"--keep-going and --fail-fast are mutually exclusive":                    "--keep-going dan --fail-fast tidak boleh digunakan bersama",
+		"invalid --post-process: %v (valid: %s)":                                 "--post-process tidak sah: %v (sah: %s)",
+		"invalid --report: %v":                                                   "--report tidak sah: %v",
+		"invalid --progress-webhook %q: want an http(s) URL":                     "--progress-webhook tidak sah %q: perlukan URL http(s)",
+		"--emit and --audio-only are mutually exclusive; use --emit audio":       "--emit dan --audio-only tidak boleh digunakan bersama; guna --emit audio",
+		"invalid --emit: %v (valid: %s)":                                         "--emit tidak sah: %v (sah: %s)",
+		"invalid backend: %v (valid: %s)":                                        "backend tidak sah: %v (sah: %s)",
+		"invalid --quality-preset: %q (valid: low|medium|high)":                  "--quality-preset tidak sah: %q (sah: low|medium|high)",
+		"invalid --priority: %v":                                                 "--priority tidak sah: %v",
+		"invalid --h264-profile: %v":                                             "--h264-profile tidak sah: %v",
+		"invalid --%s: %q (want http(s)://host:port or local)":                   "--%s tidak sah: %q (perlukan http(s)://host:port atau local)",
+		"invalid encoder: %v (valid: %s)":                                        "pengekod tidak sah: %v (sah: %s)",
+		"the remote encoder needs --encode-remote":                               "pengekod jauh memerlukan --encode-remote",
+		"invalid --keyint: %d (must be at least 1)":                              "--keyint tidak sah: %d (mesti sekurang-kurangnya 1)",
+		"invalid --audio-kbps: %d (valid: 32-320)":                               "--audio-kbps tidak sah: %d (sah: 32-320)",
+		"invalid --caption: %q (valid: txt|embed|both|none)":                     "--caption tidak sah: %q (sah: txt|embed|both|none)",
+		"invalid --organize: %v":                                                 "--organize tidak sah: %v",
+		"invalid --nice: %d (valid: 0-19)":                                       "--nice tidak sah: %d (sah: 0-19)",
+		"invalid --threads: %d":                                                  "--threads tidak sah: %d",
+		"invalid --upload: %v":                                                   "--upload tidak sah: %v",
+		"invalid --on-success: %v":                                               "--on-success tidak sah: %v",
+		"invalid --on-failure: %v":                                               "--on-failure tidak sah: %v",
+		"invalid --latest: %d":                                                   "--latest tidak sah: %d",
+		"--chapter and --trim are mutually exclusive":                            "--chapter dan --trim tidak boleh digunakan bersama",
+		"invalid --fade: %g":                                                     "--fade tidak sah: %g",
+		"invalid --poster-at: %v":                                                "--poster-at tidak sah: %v",
+		"--poster-at needs a video output; the main output is audio-only":        "--poster-at memerlukan output video; output utama hanya audio",
+		"--intro/--outro need a video output; the main output is audio-only":     "--intro/--outro memerlukan output video; output utama hanya audio",
+		"bumper clip: %w":                                                        "klip pembuka/penutup: %w",
+		"invalid --trim: %v":                                                     "--trim tidak sah: %v",
+		"%s is a story or highlight; pass --latest N to snip its newest N items": "%s ialah story atau highlight; beri --latest N untuk memotong N item terbaharunya",
+		"%s is a channel, profile, or playlist; pass --latest N to snip its newest N videos": "%s ialah saluran, profil, atau senarai main; beri --latest N untuk memotong N video terbaharunya",
+		"invalid --%s: %q (valid: %s)":                 "--%s tidak sah: %q (sah: %s)",
+		"%ssource_address: %q is not an IP address":    "%ssource_address: %q bukan alamat IP",
+		"failed to create output dir: %v":              "gagal mencipta direktori output: %v",
+		"%d of %d job(s) failed:\n%s":                  "%d daripada %d kerja gagal:\n%s",
+		"open --progress-file: %w":                     "buka --progress-file: %w",
+		"invalid --min-duration/--max-duration: %s-%s": "--min-duration/--max-duration tidak sah: %s-%s",
+		"invalid --since: %v":                          "--since tidak sah: %v",
+		"invalid --match-title: %v":                    "--match-title tidak sah: %v",
+		"invalid --temp-dir %q: %v":                    "--temp-dir tidak sah %q: %v",
+		"invalid --%s: %v":                             "--%s tidak sah: %v",
+		"download failed: %v":                          "muat turun gagal: %v",
+		"encode failed: %v":                            "pengekodan gagal: %v",
+		"upload failed: %v":                            "muat naik gagal: %v",
+		"no sources to watch: add `sources:` to the config or pass --source":                      "tiada sumber untuk dipantau: tambah `sources:` dalam konfigurasi atau beri --source",
+		"invalid --interval: %s (minimum 1m)":                                                     "--interval tidak sah: %s (minimum 1m)",
+		"the wizard needs an interactive terminal; pass flags instead (see sniplette run --help)": "panduan memerlukan terminal interaktif; beri bendera sebagai gantinya (lihat sniplette run --help)",
+	})
+}
@@ -1,5 +1,11 @@
 package model
 
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
 // QualityPreset represents a named quality configuration.
 type QualityPreset string
 
@@ -9,29 +15,212 @@ const (
 	PresetHigh   QualityPreset = "high"
 )
 
-// CaptionMode controls writing of captions next to the output file.
+// CaptionMode controls where captions are written: next to the output file,
+// into it, or both.
 type CaptionMode string
 
 const (
-	CaptionTxt  CaptionMode = "txt"
-	CaptionNone CaptionMode = "none"
+	CaptionTxt   CaptionMode = "txt"   // .txt sidecar
+	CaptionEmbed CaptionMode = "embed" // Comment/description tags in the output
+	CaptionBoth  CaptionMode = "both"  // Sidecar and tags
+	CaptionNone  CaptionMode = "none"
+)
+
+// Priority orders the jobs of a run that wait for a slot: higher ones start
+// first, and jobs of equal priority start in the order given.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
 )
 
+// ParsePriority reads "low", "normal", or "high" ("" = normal).
+func ParsePriority(s string) (Priority, error) {
+	switch s {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return PriorityNormal, fmt.Errorf("unknown priority %q (valid: low|normal|high)", s)
+}
+
+func (p Priority) String() string {
+	switch {
+	case p < PriorityNormal:
+		return "low"
+	case p > PriorityNormal:
+		return "high"
+	}
+	return "normal"
+}
+
+// Sidecar reports whether the caption goes into a .txt next to the output.
+func (c CaptionMode) Sidecar() bool { return c == CaptionTxt || c == CaptionBoth }
+
+// Embedded reports whether the caption goes into the output's metadata.
+func (c CaptionMode) Embedded() bool { return c == CaptionEmbed || c == CaptionBoth }
+
 // CLIOptions holds user-configurable runtime options as parsed from flags.
 type CLIOptions struct {
 	OutDir     string
+	Organize   string        // Subfolder template under OutDir (see media.OrganizedSubdir); empty = flat
+	NameDate   bool          // Start output names with the upload date (YYYYMMDD) when known
+	TempBase   string        // Parent dir for job workdirs; empty = cache temp dir
 	MaxSizeMB  int           // 0 disables size mode and forces CRF mode.
+	CBR        bool          // Size mode: constant bitrate instead of capped VBR
 	Quality    QualityPreset // low | medium | high
 	Resolution int           // Desired long-side resolution. 0 = use preset default.
+	Denoise    string        // Denoise preset (see encoder.DenoisePresets); "" = off
+	Sharpen    string        // Sharpen preset (see encoder.SharpenPresets); "" = off
+	FadeSec    float64       // Fade in/out length at the clip's ends; 0 = none
+	PosterAt   float64       // Clip position of the preview frame (--poster-at); 0 = none
+	Emit       []string      // Outputs per URL, main one first (see pipeline.EmitKinds)
+	Intro      string        // Clip to put before every output (--intro); "" = none
+	Outro      string        // Clip to put after every output (--outro); "" = none
 	AudioOnly  bool
-	Caption    CaptionMode // txt | none
+	Caption    CaptionMode // txt | embed | both | none
 	KeepTemp   bool
 	DLBinary   string // Optional explicit path to yt-dlp/youtube-dl
 	DryRun     bool
 	Verbose    bool
+	Quiet      bool // Only report errors
+
+	Source        string // Already-downloaded source to encode instead of downloading (redo)
+	SourceCacheMB int    // Source cache size limit (--source-cache-mb); 0 = no cache
+
+	NoUI    bool // Disable TUI when true
+	Jobs    int  // Max concurrent jobs for TUI; 0 = adaptive (see MaxJobs)
+	MaxJobs int  // Upper bound for adaptive concurrency
+	// On SIGTERM/SIGHUP, how long running jobs may go on before they are
+	// canceled; 0 = cancel at once
+	ShutdownGrace  time.Duration
+	NoThumbnails   bool     // Disable inline thumbnails in the TUI
+	Accessible     bool     // TUI: no animations; status as plain lines, for screen readers
+	PickFormat     bool     // Ask for the source format per job in the TUI
+	KeepGoing      bool     // Non-UI: continue after a failed URL and summarize at the end
+	OnDuplicate    string   // When another job has the same video: wait or skip (see pipeline.DuplicateWait)
+	SingleInstance bool     // TUI: take the URLs of later launches instead of them opening a TUI of their own
+	Priority       Priority // Queue order within the run (--priority)
+
+	Report string   // --report: a format (json, csv, md) or a file path; "" = none
+	Tags   []string // Labels stored with each job in the history and report
+
+	ProgressFile    string // Append progress events as JSON lines to this file; "" = none
+	ProgressWebhook string // POST progress updates and results to this URL as JSON; "" = none
+
+	KeyBindings    map[string][]string // TUI action -> keys overrides from config
+	PostProcessors []string            // After-encode steps by name; empty uses pipeline defaults
+
+	Backend      string            // Downloader backend when no per-platform entry applies; empty = yt-dlp
+	Backends     map[string]string // Platform -> downloader backend from config
+	BackendPaths map[string]string // Backend -> binary path from config
+
+	DLArgs     []string // Extra raw yt-dlp arguments, appended before the URL
+	FFmpegArgs []string // Extra raw ffmpeg output arguments, appended before the output path
+
+	// Source options; usually set per URL from Platforms (see pipeline.OptionsForURL).
+	Format             string // yt-dlp format selector; empty uses the downloader default
+	Cookies            string // Cookies file passed to yt-dlp --cookies
+	CookiesFromBrowser string // Browser passed to yt-dlp --cookies-from-browser
+	RateLimit          string // yt-dlp --limit-rate value, e.g. "2M"
+	GeoBypass          string // "never", a country code, or an IP block (CIDR); "" = yt-dlp default
+	SourceAddress      string // Local IP that downloads are sent from (picks the network interface)
+
+	Platforms map[string]PlatformOptions // Per-platform overrides from config (platforms.<name>)
+
+	// Time limits; 0 = none. A stage or job that runs past its limit fails
+	// with an error matching util.ErrTimeout.
+	MetadataTimeout time.Duration
+	DownloadTimeout time.Duration
+	EncodeTimeout   time.Duration
+	JobTimeout      time.Duration
+
+	MetadataCacheTTL time.Duration // Reuse metadata fetched this recently; 0 = always fetch
+
+	WaitLive time.Duration // Wait this long for a live or upcoming YouTube stream to end; 0 = fail at once
+
+	Nice    int // Scheduling niceness for ffmpeg (1..19); 0 = normal priority
+	Threads int // ffmpeg -threads; 0 lets ffmpeg decide
+
+	Encoder string // Encoder backend (see encoder.BackendNames); "" = ffmpeg
+
+	// Remote encoder backend: base URLs of the `daemon --listen` instances to
+	// spread encodes over ("local" = this machine), and their token (never
+	// serialized; each process reads its own config)
+	EncodeRemotes []string
+	RemoteToken   string `json:"-"`
+
+	// Base URLs of the `daemon --listen` instances to spread whole jobs over
+	// ("local" = this machine; see pipeline.RemoteWorkable)
+	RemoteWorkers []string
+
+	// Encoder settings (see pipeline.NewEncodeOptions); zero values use the
+	// defaults there
+	X264Preset  string
+	H264Profile string
+	KeyInt      int // GOP size in frames
+	PixFmt      string
+	AudioKbps   int
+
+	ForceEncode bool // Re-encode even when the source could be remuxed as it is
+
+	SampleEncode bool // plan: predict the output size from short sample encodes
+	ShowCommands bool // plan: include the full yt-dlp and ffmpeg command lines
+	ShowFormats  bool // plan: list the source formats and mark the ones --format picks
+
+	// Part of the source to encode, in seconds (--trim); 0 = from the start /
+	// to the end.
+	TrimStartSec float64
+	TrimEndSec   float64
+	Chapter      string // Chapter to snip instead (--chapter): 1-based index or title
 
-	NoUI bool // Disable TUI when true
-	Jobs int  // Max concurrent jobs for TUI
+	Upload string // Upload destination: s3://bucket/prefix or an rclone remote; "" = none
+
+	// Commands (argv) run after each job succeeds or fails; see
+	// pipeline.RunHook. HookTimeout limits each run; 0 = none.
+	OnSuccess   []string
+	OnFailure   []string
+	HookTimeout time.Duration
+
+	NoHistory       bool   // Don't record finished jobs in the history file
+	DownloadArchive string // yt-dlp archive file: skip the videos it lists and add snipped ones; "" = none
+
+	// Latest > 0 expands channel/profile/playlist URLs to their newest
+	// Latest videos that are not in the history yet.
+	Latest int
+	Filter SourceFilter
+}
+
+// SourceFilter narrows the videos taken from channel, profile, and playlist
+// listings. Zero values don't filter.
+type SourceFilter struct {
+	Since       time.Time      // Published on or after this day
+	MinDuration time.Duration  // At least this long
+	MaxDuration time.Duration  // At most this long
+	MatchTitle  *regexp.Regexp // Title matches
+}
+
+// Active reports whether any filter is set.
+func (f SourceFilter) Active() bool {
+	return !f.Since.IsZero() || f.MinDuration > 0 || f.MaxDuration > 0 || f.MatchTitle != nil
+}
+
+// PlatformOptions overrides run options for URLs of one platform. Zero values
+// leave the run-wide option unchanged.
+type PlatformOptions struct {
+	Resolution         int
+	MaxSizeMB          *int // nil = no override; 0 switches to CRF mode
+	Format             string
+	Cookies            string
+	CookiesFromBrowser string
+	RateLimit          string
+	GeoBypass          string
+	SourceAddress      string
 }
 
 // DownloadedVideo represents the media and metadata returned by the downloader.
@@ -45,6 +234,76 @@ type DownloadedVideo struct {
 	Width       int // 0 if unknown
 	Height      int // 0 if unknown
 	URL         string
+	Thumbnail   string    // Remote thumbnail URL, empty if unknown
+	Subreddit   string    // Reddit posts only, without "r/"
+	Published   time.Time // Upload date; zero if unknown
+	WebpageURL  string    // Canonical page of the video per the metadata; may differ from URL
+	ChannelID   string    // Channel, account, or subreddit ID; empty if unknown
+
+	// Counts when the metadata was fetched; 0 if unknown
+	ViewCount int64
+	LikeCount int64
+
+	// The source format the downloader picks, per the metadata; zero values
+	// are unknown. VCodec/ACodec are empty when the format has no such stream.
+	FPS        float64
+	VCodec     string
+	ACodec     string
+	SizeApprox int64 // Bytes to download
+
+	// MetadataTime is how long the backend spent fetching metadata before
+	// downloading; 0 when it came with the download or from the cache.
+	MetadataTime time.Duration
+
+	Chapters []Chapter // From the metadata; empty if the video has none
+	Chapter  string    // Title of the chapter being snipped (--chapter)
+
+	// Sectioned is set when InputPath holds only the trimmed part of the
+	// video, starting SectionStartSec into the original.
+	Sectioned       bool
+	SectionStartSec float64
+
+	// Streams describes InputPath as probed by ffprobe; nil if it was not
+	// probed.
+	Streams *SourceStreams
+
+	// Formats the site offers, as yt-dlp lists them; empty for other
+	// backends
+	Formats []SourceFormat
+}
+
+// SourceFormat is one format a site offers for a video.
+type SourceFormat struct {
+	ID            string
+	Ext           string
+	Width, Height int     // 0 for audio-only formats
+	FPS           float64 // 0 if unknown
+	VCodec        string  // "" = no video
+	ACodec        string  // "" = no audio
+	Kbps          float64 // Total bitrate; 0 if unknown
+	Bytes         int64   // Exact or approximate size; 0 if unknown
+	Note          string  // yt-dlp's format note, e.g. "720p" or "DASH audio"
+	// Picked by the --format selector (one of two when video and audio are
+	// merged)
+	Selected bool
+}
+
+// SourceStreams is what ffprobe found in a downloaded file.
+type SourceStreams struct {
+	VideoCodec    string // e.g. "h264"; empty if there is no video stream
+	PixFmt        string // e.g. "yuv420p"
+	Rotation      int    // Clockwise display rotation in degrees (0, 90, 180, 270)
+	AudioCodec    string // e.g. "aac"; empty if there is no audio stream
+	AudioProfile  string // e.g. "LC" for AAC
+	AudioChannels int
+	AudioKbps     int // 0 if unknown
+}
+
+// Chapter is a named section of a video.
+type Chapter struct {
+	Title    string
+	StartSec float64
+	EndSec   float64
 }
 
 // EncodeOptions controls ffmpeg encoding strategy.
@@ -58,8 +317,41 @@ type EncodeOptions struct {
 	VideoMaxKbps     int    // Clamp upper bound for video bitrate.
 	Preset           string // x264 preset, e.g., "veryfast".
 	Profile          string // H.264 profile, e.g., "main".
+	PixFmt           string // Output pixel format, e.g., "yuv420p"; "" = yuv420p.
 	AudioOnly        bool   // Extract audio only.
+	GIF              bool   // Animated GIF (no audio) instead of MP4.
 	KeyInt           int    // GOP size; 0 to omit.
+
+	StartSec float64 // Source position to start encoding at; 0 = the beginning.
+	EndSec   float64 // Source position to stop at; 0 = the end.
+
+	StreamCopy bool // Remux the source's streams as they are instead of re-encoding.
+	AudioCopy  bool // Keep the source's audio stream as it is (video is still encoded).
+
+	// Rate control in size mode: peaks are capped at MaxRateKbps with a
+	// BufSizeKbps VBV buffer (0 = derived from the target bitrate), or CBR
+	// holds the bitrate constant.
+	MaxRateKbps int
+	BufSizeKbps int
+	CBR         bool
+
+	Denoise string // Denoise preset applied before scaling; "" = none
+	Sharpen string // Sharpen preset applied after scaling; "" = none
+
+	FadeSec     float64 // Fade the video and audio in and out over this long; 0 = none
+	PosterAtSec float64 // Clip position of the preview frame (--poster-at); 0 = none
+
+	Intro *Bumper // Clip joined before the video (--intro); nil = none
+	Outro *Bumper // Clip joined after the video (--outro); nil = none
+
+	Comment string // Caption embedded as the comment and description tags; "" = none
+}
+
+// Bumper is a clip joined onto the start or end of every output.
+type Bumper struct {
+	Path        string
+	DurationSec float64
+	HasAudio    bool
 }
 
 // OutputVideo captures encoding results.
@@ -70,6 +362,10 @@ type OutputVideo struct {
 	UsedBitrateKbps int // 0 if CRF mode
 	LongSidePx      int
 	AudioOnly       bool
+	RemoteURL       string // Set once uploaded (--upload)
+	Copied          bool   // Streams were remuxed without re-encoding
+
+	PosterSec float64 // Output position of the embedded preview frame; 0 = none
 }
 
 // VideoJob represents a single URL processing job with runtime-resolved paths.
@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/util/deps"
+	"ig2wa/internal/util/media"
+)
+
+// Bumpers probes the --intro and --outro clips for encoding; either is nil
+// when not set.
+func Bumpers(ctx context.Context, opts model.CLIOptions) (intro, outro *model.Bumper, err error) {
+	if opts.AudioOnly || (opts.Intro == "" && opts.Outro == "") {
+		return nil, nil, nil
+	}
+	ffprobe, err := deps.FindFFprobe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("--intro/--outro: %w", err)
+	}
+	load := func(flag, path string) (*model.Bumper, error) {
+		if path == "" {
+			return nil, nil
+		}
+		p, err := media.Probe(ctx, ffprobe, path)
+		if err != nil {
+			return nil, fmt.Errorf("--%s %s: %w", flag, path, err)
+		}
+		if _, ok := p.Video(); !ok {
+			return nil, fmt.Errorf("--%s %s: no video stream", flag, path)
+		}
+		if p.DurationSec <= 0 {
+			return nil, fmt.Errorf("--%s %s: unknown duration", flag, path)
+		}
+		_, hasAudio := p.Audio()
+		return &model.Bumper{Path: path, DurationSec: p.DurationSec, HasAudio: hasAudio}, nil
+	}
+	if intro, err = load("intro", opts.Intro); err != nil {
+		return nil, nil, err
+	}
+	if outro, err = load("outro", opts.Outro); err != nil {
+		return nil, nil, err
+	}
+	return intro, outro, nil
+}
@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"ig2wa/internal/encoder"
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+	"ig2wa/internal/util/media"
+)
+
+// Output kinds for --emit.
+const (
+	EmitMP4   = "mp4"
+	EmitAudio = "audio"
+	EmitGIF   = "gif"
+	EmitThumb = "thumb"
+)
+
+var emitExt = map[string]string{
+	EmitMP4:   ".mp4",
+	EmitAudio: ".m4a",
+	EmitGIF:   ".gif",
+	EmitThumb: ".jpg",
+}
+
+// gifLongSide caps GIF outputs, which grow quickly with size.
+const gifLongSide = 480
+
+// EmitKinds lists the valid --emit values.
+func EmitKinds() []string {
+	return []string{EmitMP4, EmitAudio, EmitGIF, EmitThumb}
+}
+
+// ParseEmit validates --emit values, keeping their order and dropping
+// repeats.
+func ParseEmit(kinds []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, k := range kinds {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" || seen[k] {
+			continue
+		}
+		if _, ok := emitExt[k]; !ok {
+			return nil, fmt.Errorf("unknown output %q", k)
+		}
+		seen[k] = true
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+// Emits returns the outputs to produce for a job, the main one first. Without
+// --emit that is the MP4, or the audio with --audio-only.
+func Emits(opts model.CLIOptions) []string {
+	if len(opts.Emit) > 0 {
+		return opts.Emit
+	}
+	if opts.AudioOnly {
+		return []string{EmitAudio}
+	}
+	return []string{EmitMP4}
+}
+
+// EmitExt returns the file extension of an output kind.
+func EmitExt(kind string) string {
+	return emitExt[kind]
+}
+
+// OutputExts returns the extensions of every file a job may write under its
+// output name: the emitted kinds, the caption (.txt), and the thumbnail
+// (.jpg). Naming budgets for the longest.
+func OutputExts(opts model.CLIOptions) []string {
+	exts := []string{".txt", ".jpg"}
+	for _, kind := range Emits(opts) {
+		exts = append(exts, emitExt[kind])
+	}
+	return exts
+}
+
+// EmitPath returns where an extra output goes: next to the main output, with
+// the same name and the kind's extension.
+func EmitPath(outputPath, kind string) string {
+	return util.SidecarPath(outputPath, emitExt[kind])
+}
+
+// Emit produces one output kind from the downloaded video at ff.OutputPath
+// with the encoder backend eb. enc holds the job's planned settings; Emit adapts them to the kind and
+// decides whether streams can be copied. When the job has several outputs,
+// progress is reported as the kind's sub-task of the job.
+func Emit(ctx context.Context, eb encoder.Backend, kind string, dv model.DownloadedVideo, enc model.EncodeOptions, opts model.CLIOptions, ff encoder.Options) (model.OutputVideo, error) {
+	if ff.Reporter != nil && len(Emits(opts)) > 1 {
+		ff.Reporter = taskReporter{Reporter: ff.Reporter, task: kind}
+	}
+	enc.StreamCopy, enc.AudioCopy = false, false
+	switch kind {
+	case EmitMP4:
+		enc.AudioOnly = false
+		enc.ModeCRF = opts.MaxSizeMB == 0 || dv.DurationSec <= 0
+		if StreamCopy(opts, dv, enc) {
+			enc.StreamCopy = true
+			slog.Info("source already meets the target; remuxing without re-encoding", "id", dv.ID)
+		} else {
+			enc.AudioCopy = AudioCopy(opts, dv, enc)
+		}
+	case EmitAudio:
+		enc.AudioOnly, enc.ModeCRF = true, true
+		enc.Intro, enc.Outro, enc.PosterAtSec = nil, nil, 0
+		enc.AudioCopy = AudioCopy(opts, dv, enc)
+	case EmitGIF:
+		enc.AudioOnly, enc.GIF, enc.ModeCRF = false, true, true
+		enc.Intro, enc.Outro, enc.PosterAtSec = nil, nil, 0
+		if enc.LongSidePx <= 0 || enc.LongSidePx > gifLongSide {
+			enc.LongSidePx = gifLongSide
+		}
+	case EmitThumb:
+		return emitThumb(ctx, dv, enc, opts, ff)
+	default:
+		return model.OutputVideo{}, fmt.Errorf("unknown output %q", kind)
+	}
+	return eb.Encode(ctx, dv, enc, ff)
+}
+
+// emitThumb writes a JPEG of the --poster-at frame, or one from a second into
+// the clip, at the output's resolution.
+func emitThumb(ctx context.Context, dv model.DownloadedVideo, enc model.EncodeOptions, opts model.CLIOptions, ff encoder.Options) (model.OutputVideo, error) {
+	at := opts.PosterAt
+	if at <= 0 && (dv.DurationSec <= 0 || dv.DurationSec >= 2) {
+		at = 1
+	}
+	side := enc.LongSidePx
+	if side <= 0 {
+		side = 720
+	}
+	if ff.Reporter != nil {
+		ff.Reporter.Update(progress.Update{JobID: ff.JobID, Stage: progress.StageEncoding, Message: "Extracting frame"})
+	}
+	if err := media.ExtractThumbnail(ctx, media.ThumbnailOptions{
+		FFmpegPath: ff.FFmpegPath,
+		Source:     dv.InputPath,
+		OutputPath: ff.OutputPath,
+		MaxWidth:   side,
+		MaxHeight:  side,
+		SeekSec:    enc.StartSec + at,
+	}); err != nil {
+		return model.OutputVideo{}, err
+	}
+	return model.OutputVideo{OutputPath: ff.OutputPath, Bytes: util.FileSize(ff.OutputPath), LongSidePx: side}, nil
+}
+
+// taskReporter tags a job's progress updates with the output being made.
+type taskReporter struct {
+	progress.Reporter
+	task string
+}
+
+func (r taskReporter) Update(u progress.Update) {
+	u.Task = r.task
+	r.Reporter.Update(u)
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/encoder"
+	"ig2wa/internal/model"
+)
+
+// Sample-encode layout: up to SamplePoints pieces of SampleSeconds each,
+// spread evenly through the video.
+const (
+	SamplePoints  = 3
+	SampleSeconds = 5.0
+)
+
+// SizeEstimate is a predicted output size range from sample encodes.
+type SizeEstimate struct {
+	LowBytes  int64   `json:"low_bytes"`
+	HighBytes int64   `json:"high_bytes"`
+	Samples   int     `json:"samples"`
+	SampleSec float64 `json:"sample_sec"`
+}
+
+// EstimateSize predicts the final output size by encoding short samples
+// straight from the source's stream URLs with the planned settings and
+// scaling each sample's byte rate to the full duration. The range spans the
+// lowest and highest sample, so static and busy scenes both count.
+func EstimateSize(ctx context.Context, rawURL string, dl downloader.Options, dv model.DownloadedVideo, enc model.EncodeOptions, ff encoder.Options) (SizeEstimate, error) {
+	if dv.DurationSec <= 0 {
+		return SizeEstimate{}, errors.New("duration unknown")
+	}
+	inputs, err := downloader.StreamURLs(ctx, rawURL, dl)
+	if err != nil {
+		return SizeEstimate{}, err
+	}
+	if enc.AudioOnly && len(inputs) == 2 {
+		inputs = inputs[1:] // the audio stream alone
+	}
+
+	starts, dur := samplePoints(dv.DurationSec)
+	for i := range starts {
+		starts[i] += enc.StartSec // dv is already trimmed; sample within the clip
+	}
+	samples, err := encoder.SampleEncode(ctx, inputs, dv, enc, ff, starts, dur)
+	if err != nil {
+		return SizeEstimate{}, err
+	}
+	est := SizeEstimate{Samples: len(samples), SampleSec: dur}
+	for i, s := range samples {
+		size := int64(float64(s.Bytes) / s.DurSec * encoder.OutputDuration(dv, enc))
+		if i == 0 || size < est.LowBytes {
+			est.LowBytes = size
+		}
+		if size > est.HighBytes {
+			est.HighBytes = size
+		}
+	}
+	return est, nil
+}
+
+// FormulaBytes predicts a size-mode output from the bitrate formula alone,
+// with audio at audioKbps (0 = the default) and the standard video bitrate
+// clamp: the target size, or less when the bitrate cap is reached first.
+func FormulaBytes(maxSizeMB, audioKbps int, durationSec float64) int64 {
+	if maxSizeMB <= 0 || durationSec <= 0 {
+		return 0
+	}
+	enc := sizeModeOptions(maxSizeMB, audioKbps)
+	kbps := encoder.VideoBitrateKbps(enc, durationSec) + enc.AudioBitrateKbps
+	return int64(float64(kbps) * 1000 / 8 * durationSec)
+}
+
+// samplePoints returns sample start offsets and length for a video of the
+// given duration: the whole video when it is short, otherwise SamplePoints
+// pieces centred at 1/4, 2/4, and 3/4 of the way through.
+func samplePoints(duration float64) ([]float64, float64) {
+	if duration <= SamplePoints*SampleSeconds {
+		return []float64{0}, duration
+	}
+	var starts []float64
+	for i := 1; i <= SamplePoints; i++ {
+		starts = append(starts, duration*float64(i)/(SamplePoints+1)-SampleSeconds/2)
+	}
+	return starts, SampleSeconds
+}
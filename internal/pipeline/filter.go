@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// rejectReason checks a listed video against f and returns why it is
+// filtered out, or "" to keep it. When the listing leaves out a field the
+// filter needs (flat YouTube listings have no dates), the video's full
+// metadata is fetched first.
+func rejectReason(ctx context.Context, f model.SourceFilter, e downloader.Entry, dlOpts downloader.Options) (string, error) {
+	title, dur, published := e.Title, e.Duration, e.Published()
+	needDur := (f.MinDuration > 0 || f.MaxDuration > 0) && dur <= 0
+	needDate := !f.Since.IsZero() && published.IsZero()
+	needTitle := f.MatchTitle != nil && title == ""
+	if needDur || needDate || needTitle {
+		info, err := downloader.FetchInfo(ctx, e.URL, dlOpts)
+		if err != nil {
+			return "", err
+		}
+		title, dur, published = info.Title, info.Duration, info.Published()
+	}
+
+	d := time.Duration(dur * float64(time.Second))
+	switch {
+	case !f.Since.IsZero() && !published.IsZero() && published.Before(f.Since):
+		return fmt.Sprintf("published %s, before %s", published.Format(time.DateOnly), f.Since.Format(time.DateOnly)), nil
+	case f.MinDuration > 0 && dur > 0 && d < f.MinDuration:
+		return fmt.Sprintf("%s long, shorter than %s", util.FormatTimestamp(dur), f.MinDuration), nil
+	case f.MaxDuration > 0 && dur > 0 && d > f.MaxDuration:
+		return fmt.Sprintf("%s long, longer than %s", util.FormatTimestamp(dur), f.MaxDuration), nil
+	case f.MatchTitle != nil && !f.MatchTitle.MatchString(title):
+		return fmt.Sprintf("title %q does not match %s", title, f.MatchTitle), nil
+	}
+	return "", nil
+}
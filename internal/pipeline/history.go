@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/history"
+	"ig2wa/internal/model"
+)
+
+// RecordHistory appends a finished job to the history file unless history is
+// disabled, and a successful one to the --download-archive file if set.
+// Best-effort: a write failure is logged, not returned.
+func RecordHistory(opts model.CLIOptions, hc HookContext) {
+	if opts.DryRun {
+		return
+	}
+	if opts.DownloadArchive != "" && hc.Err == nil {
+		recordArchive(opts.DownloadArchive, hc)
+	}
+	if opts.NoHistory {
+		return
+	}
+	e := history.Entry{
+		URL:         hc.URL,
+		VideoID:     hc.Video.ID,
+		Title:       hc.Video.Title,
+		Uploader:    hc.Video.Uploader,
+		Status:      history.StatusSuccess,
+		OutputPath:  hc.Output.OutputPath,
+		RemoteURL:   hc.Output.RemoteURL,
+		Bytes:       hc.Output.Bytes,
+		SourceBytes: hc.SourceBytes,
+		DurationSec: hc.Video.DurationSec,
+		Tags:        opts.Tags,
+		MetadataSec: seconds(hc.Times.Metadata),
+		DownloadSec: seconds(hc.Times.Download),
+		EncodeSec:   seconds(hc.Times.Encode),
+		UploadSec:   seconds(hc.Times.Upload),
+		ElapsedSec:  seconds(hc.Times.Total),
+	}
+	if (opts.KeepTemp || opts.Source != "" || opts.SourceCacheMB > 0) && !hc.Video.Sectioned {
+		e.SourcePath = hc.Video.InputPath // still there for a redo
+	}
+	if hc.Err != nil {
+		e.Status, e.Error = history.StatusFailure, hc.Err.Error()
+	}
+	if err := history.Append(e); err != nil {
+		slog.Warn("could not record history", "url", hc.URL, "err", err)
+	}
+}
+
+// seconds rounds d to tenths of a second for the history and reports.
+func seconds(d time.Duration) float64 {
+	return math.Round(d.Seconds()*10) / 10
+}
+
+func recordArchive(path string, hc HookContext) {
+	a, err := downloader.OpenArchive(path)
+	if err == nil {
+		err = a.Add(hc.URL, hc.Video.ID)
+	}
+	if err != nil {
+		slog.Warn("could not update download archive", "path", path, "url", hc.URL, "err", err)
+	}
+}
@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+	"ig2wa/internal/util/media"
+)
+
+// HookContext describes a finished job to its on-success or on-failure hook
+// and to the history file.
+type HookContext struct {
+	URL    string
+	Video  model.DownloadedVideo // Zero when the download itself failed
+	Output model.OutputVideo     // Zero unless the encode succeeded
+	Err    error                 // nil on success
+
+	SourceBytes int64 // Size of the downloaded source; 0 if unknown
+
+	Started time.Time           // When the job started
+	Times   progress.StageTimes // Time spent per stage so far; see Finish
+
+	// Progress reporting (optional); hook output is forwarded as log lines.
+	Reporter progress.Reporter
+	JobID    string
+}
+
+// Downloaded records the time since start as the download, less the time
+// the backend spent on metadata beforehand.
+func (hc *HookContext) Downloaded(start time.Time, dv model.DownloadedVideo) {
+	d := time.Since(start)
+	hc.Times.Metadata = min(dv.MetadataTime, d)
+	hc.Times.Download = d - hc.Times.Metadata
+}
+
+// Finish sets Times.Total from Started. Call it once the job is over, before
+// recording it.
+func (hc *HookContext) Finish() {
+	if !hc.Started.IsZero() {
+		hc.Times.Total = time.Since(hc.Started)
+	}
+}
+
+// HookPlaceholders are the placeholders of a hook's arguments, in the order
+// `sniplette help templates` lists them.
+var HookPlaceholders = []media.Placeholder{
+	{Name: "output", Desc: "the output file"},
+	{Name: "url", Desc: "the URL given"},
+	{Name: "title", Desc: "the video's title"},
+	{Name: "uploader", Desc: "the uploader"},
+	{Name: "id", Desc: "the video ID"},
+	{Name: "channel_id", Desc: "the uploader's channel ID"},
+	{Name: "webpage_url", Desc: "the video's page, as yt-dlp reports it"},
+	{Name: "upload_date", Desc: "the upload date, YYYY-MM-DD"},
+	{Name: "view_count", Desc: "the views"},
+	{Name: "like_count", Desc: "the likes"},
+	{Name: "status", Desc: "success or failure"},
+	{Name: "error", Desc: "why the job failed"},
+}
+
+// RunHook runs the configured on-success or on-failure command for a finished
+// job, if any. Arguments may contain HookPlaceholders (unknown metadata is
+// ""), and the same details are passed as SNIPLETTE_* environment variables.
+// The hook gets its own time limit (opts.HookTimeout) and its output is
+// logged. Nothing runs once ctx is done (e.g. the run was interrupted).
+func RunHook(ctx context.Context, opts model.CLIOptions, hc HookContext) error {
+	status, argv := "success", opts.OnSuccess
+	if hc.Err != nil {
+		status, argv = "failure", opts.OnFailure
+	}
+	if len(argv) == 0 || ctx.Err() != nil {
+		return nil
+	}
+
+	vars := map[string]string{
+		"status":   status,
+		"url":      hc.URL,
+		"output":   hc.Output.OutputPath,
+		"title":    hc.Video.Title,
+		"uploader": hc.Video.Uploader,
+		"id":       hc.Video.ID,
+		"error":    "",
+
+		"channel_id":  hc.Video.ChannelID,
+		"webpage_url": hc.Video.WebpageURL,
+		"upload_date": "",
+		"view_count":  countOrEmpty(hc.Video.ViewCount),
+		"like_count":  countOrEmpty(hc.Video.LikeCount),
+	}
+	if !hc.Video.Published.IsZero() {
+		vars["upload_date"] = hc.Video.Published.Format("2006-01-02")
+	}
+	if hc.Err != nil {
+		vars["error"] = hc.Err.Error()
+	}
+	r := media.PlaceholderReplacer(HookPlaceholders, vars)
+	args := make([]string, len(argv))
+	for i, a := range argv {
+		args[i] = r.Replace(a)
+	}
+
+	env := []string{
+		"SNIPLETTE_STATUS=" + status,
+		"SNIPLETTE_URL=" + hc.URL,
+		"SNIPLETTE_OUTPUT=" + hc.Output.OutputPath,
+		"SNIPLETTE_BYTES=" + strconv.FormatInt(hc.Output.Bytes, 10),
+		"SNIPLETTE_TITLE=" + hc.Video.Title,
+		"SNIPLETTE_UPLOADER=" + hc.Video.Uploader,
+		"SNIPLETTE_ID=" + hc.Video.ID,
+		"SNIPLETTE_CHANNEL_ID=" + vars["channel_id"],
+		"SNIPLETTE_WEBPAGE_URL=" + vars["webpage_url"],
+		"SNIPLETTE_UPLOAD_DATE=" + vars["upload_date"],
+		"SNIPLETTE_VIEW_COUNT=" + vars["view_count"],
+		"SNIPLETTE_LIKE_COUNT=" + vars["like_count"],
+		"SNIPLETTE_DURATION=" + strconv.FormatFloat(hc.Video.DurationSec, 'f', -1, 64),
+		"SNIPLETTE_ERROR=" + vars["error"],
+		"SNIPLETTE_JOB_ID=" + hc.JobID,
+	}
+
+	stage := "on-" + status + " hook"
+	logLine := func(stream progress.LogStream) func(string) {
+		return func(line string) {
+			slog.Info(stage, "url", hc.URL, "line", line)
+			if hc.Reporter != nil {
+				hc.Reporter.Log(progress.Log{JobID: hc.JobID, Stream: stream, Line: stage + ": " + line})
+			}
+		}
+	}
+	_, err := util.Run(ctx, util.CmdSpec{
+		Path:       args[0],
+		Args:       args[1:],
+		Env:        env,
+		Timeout:    opts.HookTimeout,
+		Stage:      stage,
+		StdoutLine: logLine(progress.StreamStdout),
+		StderrLine: logLine(progress.StreamStderr),
+	})
+	return err
+}
+
+// hookRunner runs the --on-success or --on-failure command (see RunHook) of
+// a job that ended that way.
+type hookRunner struct{ success bool }
+
+func (h hookRunner) Name() string {
+	if h.success {
+		return "on-success"
+	}
+	return "on-failure"
+}
+
+func (h hookRunner) Process(ctx context.Context, pc *PostContext) error {
+	if (pc.Job.Err == nil) != h.success {
+		return nil
+	}
+	return RunHook(ctx, pc.Options, pc.Job)
+}
+
+// countOrEmpty formats a metadata count for a hook; "" when unknown (0).
+func countOrEmpty(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
@@ -1,6 +1,94 @@
 package pipeline
 
-import "ig2wa/internal/model"
+import (
+	"fmt"
+
+	"ig2wa/internal/encoder"
+	"ig2wa/internal/model"
+)
+
+// Encoder settings a job gets unless its options (--x264-preset,
+// --h264-profile, --keyint, --pix-fmt, --audio-kbps) say otherwise.
+const (
+	DefaultAudioKbps    = 96   // AAC bitrate
+	DefaultVideoMinKbps = 500  // Size mode never goes below this video bitrate...
+	DefaultVideoMaxKbps = 8000 // ...or above this one
+	DefaultX264Preset   = "veryfast"
+	DefaultH264Profile  = "main"
+	DefaultKeyInt       = 48 // GOP size: a keyframe every 2 s at 24 fps
+	DefaultPixFmt       = "yuv420p"
+)
+
+// NewEncodeOptions plans the encode of dv: the resolution and CRF from
+// PlanResolutionAndCRF, size or CRF mode, the trim range, and opts' filters
+// and encoder settings (the defaults above where unset). Bumpers, copy decisions, and the
+// embedded caption are left to the caller.
+func NewEncodeOptions(opts model.CLIOptions, dv model.DownloadedVideo, presetCRF int, trimStart, trimEnd float64) (model.EncodeOptions, error) {
+	longSide, crf := PlanResolutionAndCRF(opts, dv, presetCRF)
+	enc := model.EncodeOptions{
+		LongSidePx:       longSide,
+		ModeCRF:          opts.MaxSizeMB == 0 || dv.DurationSec <= 0 || opts.AudioOnly,
+		CRF:              crf,
+		MaxSizeMB:        opts.MaxSizeMB,
+		AudioBitrateKbps: intOr(opts.AudioKbps, DefaultAudioKbps),
+		VideoMinKbps:     DefaultVideoMinKbps,
+		VideoMaxKbps:     DefaultVideoMaxKbps,
+		Preset:           stringOr(opts.X264Preset, DefaultX264Preset),
+		Profile:          stringOr(opts.H264Profile, DefaultH264Profile),
+		PixFmt:           stringOr(opts.PixFmt, DefaultPixFmt),
+		AudioOnly:        opts.AudioOnly,
+		KeyInt:           intOr(opts.KeyInt, DefaultKeyInt),
+		CBR:              opts.CBR,
+		Denoise:          opts.Denoise,
+		Sharpen:          opts.Sharpen,
+		FadeSec:          opts.FadeSec,
+		PosterAtSec:      opts.PosterAt,
+		StartSec:         trimStart,
+		EndSec:           trimEnd,
+	}
+	switch {
+	case enc.MaxSizeMB < 0:
+		return enc, fmt.Errorf("invalid target size: %d MB", enc.MaxSizeMB)
+	case enc.CRF < 0 || enc.CRF > 51:
+		return enc, fmt.Errorf("invalid CRF: %d (valid: 0-51)", enc.CRF)
+	case enc.StartSec < 0 || (enc.EndSec > 0 && enc.EndSec <= enc.StartSec):
+		return enc, fmt.Errorf("invalid trim range: %gs-%gs", enc.StartSec, enc.EndSec)
+	case enc.AudioBitrateKbps < 32 || enc.AudioBitrateKbps > 320:
+		return enc, fmt.Errorf("invalid audio bitrate: %d kbps (valid: 32-320)", enc.AudioBitrateKbps)
+	}
+	if !enc.AudioOnly {
+		if err := encoder.CheckH264(enc.Profile, enc.PixFmt); err != nil {
+			return enc, err
+		}
+	}
+	return enc, nil
+}
+
+func stringOr(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func intOr(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// sizeModeOptions are the encoder settings FormulaBytes predicts with: a
+// size-mode encode to maxSizeMB with audio at audioKbps (0 = the default)
+// and the default video bitrate clamp.
+func sizeModeOptions(maxSizeMB, audioKbps int) model.EncodeOptions {
+	return model.EncodeOptions{
+		MaxSizeMB:        maxSizeMB,
+		AudioBitrateKbps: intOr(audioKbps, DefaultAudioKbps),
+		VideoMinKbps:     DefaultVideoMinKbps,
+		VideoMaxKbps:     DefaultVideoMaxKbps,
+	}
+}
 
 // PlanResolutionAndCRF computes the target long-side resolution (avoiding upscaling)
 // and determines the CRF to use, given the chosen preset CRF.
@@ -17,6 +105,21 @@ func PlanResolutionAndCRF(opts model.CLIOptions, dv model.DownloadedVideo, prese
 	return target, presetCRF
 }
 
+// PresetDefaults returns a quality preset's long-side resolution, target
+// size in MB, and CRF.
+func PresetDefaults(q model.QualityPreset) (resolution int, maxSizeMB int, crf int) {
+	switch q {
+	case model.PresetLow:
+		return 540, 20, 26
+	case model.PresetHigh:
+		return 1080, 100, 19
+	case model.PresetMedium:
+		fallthrough
+	default:
+		return 720, 50, 22
+	}
+}
+
 // DefaultCRF maps a quality preset to a default CRF.
 func DefaultCRF(q model.QualityPreset) int {
 	switch q {
@@ -36,4 +139,105 @@ func maxInt(a, b int) int {
 		return a
 	}
 	return b
-}
\ No newline at end of file
+}
+
+// Plan describes what a job would do; `sniplette plan` renders a list of them
+// as a table or JSON.
+type Plan struct {
+	URL          string        `json:"url"`
+	Title        string        `json:"title,omitempty"`
+	Uploader     string        `json:"uploader,omitempty"`
+	DurationSec  float64       `json:"duration_sec,omitempty"` // Of the clip when trimmed
+	TrimStartSec float64       `json:"trim_start_sec,omitempty"`
+	TrimEndSec   float64       `json:"trim_end_sec,omitempty"`
+	Chapter      string        `json:"chapter,omitempty"`
+	SourceWidth  int           `json:"source_width,omitempty"`
+	SourceHeight int           `json:"source_height,omitempty"`
+	SourceFPS    float64       `json:"source_fps,omitempty"`
+	SourceVCodec string        `json:"source_vcodec,omitempty"`
+	SourceACodec string        `json:"source_acodec,omitempty"`
+	SourceBytes  int64         `json:"source_bytes,omitempty"` // Approximate download size
+	UploadDate   string        `json:"upload_date,omitempty"`  // YYYY-MM-DD
+	ViewCount    int64         `json:"view_count,omitempty"`
+	LikeCount    int64         `json:"like_count,omitempty"`
+	Downloader   string        `json:"downloader,omitempty"`
+	FFmpeg       string        `json:"ffmpeg,omitempty"`
+	OutputPath   string        `json:"output_path,omitempty"`
+	Mode         string        `json:"mode,omitempty"` // "size", "crf", or "audio"
+	LongSidePx   int           `json:"long_side_px,omitempty"`
+	CRF          int           `json:"crf,omitempty"`
+	TargetMB     int           `json:"target_mb,omitempty"`
+	VideoKbps    int           `json:"video_kbps,omitempty"`
+	AudioKbps    int           `json:"audio_kbps,omitempty"`
+	Caption      string        `json:"caption,omitempty"`
+	Estimate     *SizeEstimate `json:"estimate,omitempty"`
+	DownloadCmd  []string      `json:"download_cmd,omitempty"` // Full yt-dlp argv, with --show-commands
+	FFmpegCmd    []string      `json:"ffmpeg_cmd,omitempty"`   // Full ffmpeg argv, with --show-commands
+	Formats      []PlanFormat  `json:"formats,omitempty"`      // Source formats, with --formats
+	Error        string        `json:"error,omitempty"`        // Set when planning this URL failed
+}
+
+// PlanFormat is a source format in a plan (see model.SourceFormat).
+type PlanFormat struct {
+	ID       string  `json:"id"`
+	Ext      string  `json:"ext,omitempty"`
+	Width    int     `json:"width,omitempty"`
+	Height   int     `json:"height,omitempty"`
+	FPS      float64 `json:"fps,omitempty"`
+	VCodec   string  `json:"vcodec,omitempty"`
+	ACodec   string  `json:"acodec,omitempty"`
+	Kbps     float64 `json:"kbps,omitempty"`
+	Bytes    int64   `json:"bytes,omitempty"`
+	Note     string  `json:"note,omitempty"`
+	Selected bool    `json:"selected,omitempty"`
+}
+
+// PlanFormats converts dv's source formats for a plan.
+func PlanFormats(dv model.DownloadedVideo) []PlanFormat {
+	out := make([]PlanFormat, len(dv.Formats))
+	for i, f := range dv.Formats {
+		out[i] = PlanFormat{
+			ID: f.ID, Ext: f.Ext, Width: f.Width, Height: f.Height, FPS: f.FPS, VCodec: f.VCodec,
+			ACodec: f.ACodec, Kbps: f.Kbps, Bytes: f.Bytes, Note: f.Note, Selected: f.Selected,
+		}
+	}
+	return out
+}
+
+// NewPlan describes the planned encode of dv.
+func NewPlan(url string, dv model.DownloadedVideo, enc model.EncodeOptions, opts model.CLIOptions) Plan {
+	p := Plan{
+		URL:          url,
+		Title:        dv.Title,
+		Uploader:     dv.Uploader,
+		DurationSec:  dv.DurationSec,
+		SourceWidth:  dv.Width,
+		SourceHeight: dv.Height,
+		SourceFPS:    dv.FPS,
+		SourceVCodec: dv.VCodec,
+		SourceACodec: dv.ACodec,
+		SourceBytes:  dv.SizeApprox,
+		ViewCount:    dv.ViewCount,
+		LikeCount:    dv.LikeCount,
+		TrimStartSec: enc.StartSec,
+		TrimEndSec:   enc.EndSec,
+		Chapter:      dv.Chapter,
+		AudioKbps:    enc.AudioBitrateKbps,
+		Caption:      string(opts.Caption),
+	}
+	if !dv.Published.IsZero() {
+		p.UploadDate = dv.Published.Format("2006-01-02")
+	}
+	switch {
+	case enc.AudioOnly:
+		p.Mode = "audio"
+	case enc.ModeCRF:
+		p.Mode, p.LongSidePx, p.CRF = "crf", enc.LongSidePx, enc.CRF
+	default:
+		p.Mode, p.LongSidePx, p.TargetMB = "size", enc.LongSidePx, opts.MaxSizeMB
+		if dv.DurationSec > 0 {
+			p.VideoKbps = encoder.VideoBitrateKbps(enc, encoder.OutputDuration(dv, enc))
+		}
+	}
+	return p
+}
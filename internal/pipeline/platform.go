@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// OptionsForURL returns opts with the per-platform overrides for url's
+// platform applied. URLs of unknown platforms get opts unchanged.
+func OptionsForURL(opts model.CLIOptions, url string) model.CLIOptions {
+	pl, _, err := util.DetectPlatform(url)
+	if err != nil {
+		return opts
+	}
+	po, ok := opts.Platforms[string(pl)]
+	if !ok {
+		return opts
+	}
+	if po.Resolution > 0 {
+		opts.Resolution = po.Resolution
+	}
+	if po.MaxSizeMB != nil {
+		opts.MaxSizeMB = *po.MaxSizeMB
+	}
+	if po.Format != "" {
+		opts.Format = po.Format
+	}
+	if po.Cookies != "" {
+		opts.Cookies = po.Cookies
+	}
+	if po.CookiesFromBrowser != "" {
+		opts.CookiesFromBrowser = po.CookiesFromBrowser
+	}
+	if po.RateLimit != "" {
+		opts.RateLimit = po.RateLimit
+	}
+	if po.GeoBypass != "" {
+		opts.GeoBypass = po.GeoBypass
+	}
+	if po.SourceAddress != "" {
+		opts.SourceAddress = po.SourceAddress
+	}
+	return opts
+}
@@ -0,0 +1,240 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+	"ig2wa/internal/util/media"
+)
+
+// PostContext carries everything a post-processor may need.
+type PostContext struct {
+	Video      model.DownloadedVideo
+	Output     model.OutputVideo   // The output being processed; PhaseUpload steps update it
+	Outputs    []model.OutputVideo // Every output of the job, the main one first
+	Options    model.CLIOptions
+	FFmpegPath string
+
+	// Job is the finished job, for PhaseResult steps
+	Job HookContext
+
+	// Progress reporting (optional)
+	Reporter progress.Reporter
+	JobID    string
+}
+
+// PostProcessor is a single step run on each output of a successful encode,
+// or once the job is over (see Phase).
+type PostProcessor interface {
+	Name() string
+	Process(ctx context.Context, pc *PostContext) error
+}
+
+// Phase is when a post-processor runs.
+type Phase int
+
+const (
+	// PhaseOutput steps run on each output of a successful encode, the ones
+	// configured (post_process) in their order.
+	PhaseOutput Phase = iota
+	// PhaseUpload steps run on each output before the PhaseOutput ones, so
+	// those see where it went. Every registered one runs, in the order of
+	// registration, and decides for itself whether it applies (e.g. the
+	// uploader, with --upload); an error fails the job in StepUpload.
+	PhaseUpload
+	// PhaseResult steps run once the job is over, successful or not, before
+	// its Result is reported; every registered one runs, in the order of
+	// registration, and decides for itself whether it applies (e.g. the
+	// on-success hook).
+	PhaseResult
+)
+
+// DefaultPostProcessors is used when no post_process list is configured.
+var DefaultPostProcessors = []string{"caption"}
+
+type postEntry struct {
+	phase   Phase
+	factory func() PostProcessor
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]postEntry{}
+	phased     = map[Phase][]string{} // Names of the steps of the other phases, in registration order
+)
+
+// RegisterPostProcessor makes a post-processor available by name for config selection.
+func RegisterPostProcessor(name string, factory func() PostProcessor) {
+	RegisterPhaseProcessor(PhaseOutput, name, factory)
+}
+
+// RegisterPhaseProcessor registers a post-processor of phase. Only
+// PhaseOutput ones are selected by config; the others always run.
+func RegisterPhaseProcessor(phase Phase, name string, factory func() PostProcessor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	name = strings.ToLower(name)
+	registry[name] = postEntry{phase: phase, factory: factory}
+	if phase != PhaseOutput {
+		phased[phase] = append(phased[phase], name)
+	}
+}
+
+// PostProcessorNames lists the post-processors config can select, in sorted
+// order.
+func PostProcessorNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for n, e := range registry {
+		if e.phase == PhaseOutput {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PhaseProcessors returns the post-processors of a phase other than
+// PhaseOutput, in the order of registration.
+func PhaseProcessors(phase Phase) []PostProcessor {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]PostProcessor, 0, len(phased[phase]))
+	for _, n := range phased[phase] {
+		out = append(out, registry[n].factory())
+	}
+	return out
+}
+
+// PostProcessorsFor resolves the configured post-processor names in order.
+func PostProcessorsFor(names []string) ([]PostProcessor, error) {
+	if len(names) == 0 {
+		names = DefaultPostProcessors
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]PostProcessor, 0, len(names))
+	for _, n := range names {
+		e, ok := registry[strings.ToLower(strings.TrimSpace(n))]
+		if !ok || e.phase != PhaseOutput {
+			return nil, fmt.Errorf("unknown post-processor %q", n)
+		}
+		out = append(out, e.factory())
+	}
+	return out, nil
+}
+
+// RunPostProcessors runs each step in order. Steps are best-effort: a failure
+// is collected and the remaining steps still run.
+func RunPostProcessors(ctx context.Context, procs []PostProcessor, pc *PostContext) []error {
+	var errs []error
+	for _, p := range procs {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		slog.Debug("post-process", "step", p.Name(), "output", pc.Output.OutputPath)
+		if err := p.Process(ctx, pc); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		}
+	}
+	return errs
+}
+
+func init() {
+	RegisterPostProcessor("caption", func() PostProcessor { return captionWriter{} })
+	RegisterPostProcessor("thumbnail", func() PostProcessor { return thumbnailWriter{} })
+	RegisterPhaseProcessor(PhaseUpload, "upload", func() PostProcessor { return outputUploader{} })
+	RegisterPhaseProcessor(PhaseResult, "on-success", func() PostProcessor { return hookRunner{success: true} })
+	RegisterPhaseProcessor(PhaseResult, "on-failure", func() PostProcessor { return hookRunner{} })
+}
+
+// sidecarDone reports whether an output before pc.Output that the step
+// applies to (ok) has the same ext sidecar: a job's outputs share their name,
+// so the first of them gets the sidecar.
+func sidecarDone(pc *PostContext, ext string, ok func(model.OutputVideo) bool) bool {
+	path := util.SidecarPath(pc.Output.OutputPath, ext)
+	for _, o := range pc.Outputs {
+		if o.OutputPath == pc.Output.OutputPath {
+			return false
+		}
+		if ok(o) && util.SidecarPath(o.OutputPath, ext) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// captionWriter writes the caption .txt sidecar when captions are enabled,
+// naming where the job's outputs were uploaded. Embedded captions are
+// written by the encoder.
+type captionWriter struct{}
+
+func (captionWriter) Name() string { return "caption" }
+
+func (captionWriter) Process(_ context.Context, pc *PostContext) error {
+	all := func(model.OutputVideo) bool { return true }
+	if !pc.Options.Caption.Sidecar() || sidecarDone(pc, ".txt", all) {
+		return nil
+	}
+	text := media.CaptionText(pc.Video)
+	var remotes []string
+	for _, o := range pc.Outputs {
+		if o.RemoteURL != "" && !slices.Contains(remotes, o.RemoteURL) {
+			remotes = append(remotes, o.RemoteURL)
+		}
+	}
+	if len(remotes) > 0 {
+		text += "\n---\nUPLOADED TO\n" + strings.Join(remotes, "\n") + "\n"
+	}
+	_, err := util.WriteCaptionFile(pc.Output.OutputPath, text)
+	return err
+}
+
+// thumbnailWriter writes a .jpg poster frame next to video outputs: the
+// --poster-at frame if set, otherwise one from a second in. An emitted thumb
+// (--emit thumb) already is that file, so it is left alone.
+type thumbnailWriter struct{}
+
+func (thumbnailWriter) Name() string { return "thumbnail" }
+
+// thumbnailSource reports whether o is an output the thumbnail can be taken
+// from.
+func thumbnailSource(o model.OutputVideo) bool {
+	return !o.AudioOnly && util.SidecarPath(o.OutputPath, ".jpg") != o.OutputPath
+}
+
+func (thumbnailWriter) Process(ctx context.Context, pc *PostContext) error {
+	if !thumbnailSource(pc.Output) || sidecarDone(pc, ".jpg", thumbnailSource) {
+		return nil
+	}
+	jpg := util.SidecarPath(pc.Output.OutputPath, ".jpg")
+	for _, o := range pc.Outputs {
+		if o.OutputPath == jpg {
+			return nil
+		}
+	}
+	seek := 1.0
+	if pc.Output.PosterSec > 0 {
+		seek = pc.Output.PosterSec
+	} else if pc.Video.DurationSec > 0 && pc.Video.DurationSec < 2 {
+		seek = 0
+	}
+	return media.ExtractThumbnail(ctx, media.ThumbnailOptions{
+		FFmpegPath: pc.FFmpegPath,
+		Source:     pc.Output.OutputPath,
+		OutputPath: jpg,
+		MaxWidth:   640,
+		MaxHeight:  640,
+		SeekSec:    seek,
+	})
+}
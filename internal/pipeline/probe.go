@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"log/slog"
+
+	"ig2wa/internal/encoder"
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// StreamCopy reports whether the job can remux dv instead of re-encoding it:
+// the source already meets enc (see encoder.CanStreamCopy), and the encode
+// is not forced.
+func StreamCopy(opts model.CLIOptions, dv model.DownloadedVideo, enc model.EncodeOptions) bool {
+	return !encodeForced(opts) && encoder.CanStreamCopy(dv, enc)
+}
+
+// AudioCopy reports whether the job can keep dv's audio as it is while
+// encoding the video (see encoder.CanCopyAudio).
+func AudioCopy(opts model.CLIOptions, dv model.DownloadedVideo, enc model.EncodeOptions) bool {
+	return !encodeForced(opts) && encoder.CanCopyAudio(dv, enc)
+}
+
+// encodeForced reports whether --force-encode or --ffmpeg-args (whose
+// options may target the encoder) rule out copying streams.
+func encodeForced(opts model.CLIOptions) bool {
+	return opts.ForceEncode || len(opts.FFmpegArgs) > 0
+}
+
+// Frame size assumed for YouTube Shorts, which are uploaded as 9:16 video.
+const (
+	shortsWidth  = 1080
+	shortsHeight = 1920
+)
+
+// AssumeShortsShape gives a Shorts video whose size neither the metadata nor
+// ffprobe reported a vertical 1080x1920 frame, so scaling, the no-upscale
+// rule, and plans don't treat it as landscape. Other videos are returned
+// unchanged.
+func AssumeShortsShape(dv model.DownloadedVideo) model.DownloadedVideo {
+	if (dv.Width > 0 && dv.Height > 0) || !util.IsShortsURL(dv.URL) {
+		return dv
+	}
+	slog.Debug("size unknown; assuming a vertical Shorts frame", "url", dv.URL, "width", shortsWidth, "height", shortsHeight)
+	dv.Width, dv.Height = shortsWidth, shortsHeight
+	return dv
+}
@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"container/heap"
+
+	"ig2wa/internal/model"
+)
+
+// JobQueue holds the jobs of a run that wait for a slot: the highest
+// priority first, and in the order they were added within a priority.
+// Priorities can change while jobs wait. It is not safe for concurrent use.
+type JobQueue struct {
+	items queueItems
+	byID  map[string]*queueItem
+	seq   int
+}
+
+type queueItem struct {
+	id       string
+	priority model.Priority
+	seq      int // Order of arrival
+	index    int // Position in the heap
+}
+
+// NewJobQueue returns an empty queue.
+func NewJobQueue() *JobQueue {
+	return &JobQueue{byID: make(map[string]*queueItem)}
+}
+
+// Len is the number of waiting jobs.
+func (q *JobQueue) Len() int { return len(q.items) }
+
+// Push adds a job behind the waiting jobs of the same or higher priority.
+// Pushing a job that is already waiting only changes its priority.
+func (q *JobQueue) Push(id string, p model.Priority) {
+	if q.SetPriority(id, p) {
+		return
+	}
+	it := &queueItem{id: id, priority: p, seq: q.seq}
+	q.seq++
+	q.byID[id] = it
+	heap.Push(&q.items, it)
+}
+
+// Pop removes and returns the job to start next.
+func (q *JobQueue) Pop() (string, bool) {
+	if len(q.items) == 0 {
+		return "", false
+	}
+	it := heap.Pop(&q.items).(*queueItem)
+	delete(q.byID, it.id)
+	return it.id, true
+}
+
+// Priority returns a waiting job's priority.
+func (q *JobQueue) Priority(id string) (model.Priority, bool) {
+	it, ok := q.byID[id]
+	if !ok {
+		return model.PriorityNormal, false
+	}
+	return it.priority, true
+}
+
+// SetPriority moves a waiting job to priority p, keeping its place among the
+// jobs of that priority by arrival. It reports whether the job was waiting.
+func (q *JobQueue) SetPriority(id string, p model.Priority) bool {
+	it, ok := q.byID[id]
+	if !ok {
+		return false
+	}
+	it.priority = p
+	heap.Fix(&q.items, it.index)
+	return true
+}
+
+// queueItems implements heap.Interface.
+type queueItems []*queueItem
+
+func (s queueItems) Len() int { return len(s) }
+
+func (s queueItems) Less(i, j int) bool {
+	if s[i].priority != s[j].priority {
+		return s[i].priority > s[j].priority
+	}
+	return s[i].seq < s[j].seq
+}
+
+func (s queueItems) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+	s[i].index, s[j].index = i, j
+}
+
+func (s *queueItems) Push(x any) {
+	it := x.(*queueItem)
+	it.index = len(*s)
+	*s = append(*s, it)
+}
+
+func (s *queueItems) Pop() any {
+	old := *s
+	it := old[len(old)-1]
+	old[len(old)-1] = nil
+	*s = old[:len(old)-1]
+	return it
+}
@@ -0,0 +1,291 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ig2wa/internal/dirs"
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+)
+
+// Report formats for --report.
+const (
+	ReportJSON     = "json"
+	ReportCSV      = "csv"
+	ReportMarkdown = "md"
+)
+
+// ReportJob is one finished job in a batch report.
+type ReportJob struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title,omitempty"`
+	Uploader    string   `json:"uploader,omitempty"`
+	Status      string   `json:"status"`
+	OutputPath  string   `json:"output_path,omitempty"`
+	RemoteURL   string   `json:"remote_url,omitempty"`
+	Bytes       int64    `json:"bytes,omitempty"`
+	SourceBytes int64    `json:"source_bytes,omitempty"`
+	DurationSec float64  `json:"duration_sec,omitempty"`
+	Settings    string   `json:"settings,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Error       string   `json:"error,omitempty"`
+
+	MetadataSec float64 `json:"metadata_sec,omitempty"`
+	DownloadSec float64 `json:"download_sec,omitempty"`
+	EncodeSec   float64 `json:"encode_sec,omitempty"`
+	UploadSec   float64 `json:"upload_sec,omitempty"`
+	ElapsedSec  float64 `json:"elapsed_sec,omitempty"`
+}
+
+// Report collects the finished jobs of a run for --report. Jobs may finish
+// concurrently (TUI), so Add is safe to call from several goroutines.
+type Report struct {
+	Started time.Time
+
+	mu   sync.Mutex
+	jobs []ReportJob
+}
+
+// NewReport starts an empty report.
+func NewReport() *Report {
+	return &Report{Started: time.Now()}
+}
+
+// Add records a finished job. A nil Report ignores it.
+func (r *Report) Add(opts model.CLIOptions, hc HookContext) {
+	if r == nil {
+		return
+	}
+	j := ReportJob{
+		URL:         hc.URL,
+		Title:       hc.Video.Title,
+		Uploader:    hc.Video.Uploader,
+		Status:      "success",
+		OutputPath:  hc.Output.OutputPath,
+		RemoteURL:   hc.Output.RemoteURL,
+		Bytes:       hc.Output.Bytes,
+		SourceBytes: hc.SourceBytes,
+		DurationSec: hc.Video.DurationSec,
+		Settings:    reportSettings(opts, hc.Output),
+		Tags:        opts.Tags,
+		MetadataSec: seconds(hc.Times.Metadata),
+		DownloadSec: seconds(hc.Times.Download),
+		EncodeSec:   seconds(hc.Times.Encode),
+		UploadSec:   seconds(hc.Times.Upload),
+		ElapsedSec:  seconds(hc.Times.Total),
+	}
+	if hc.Err != nil {
+		j.Status, j.Error = "failure", hc.Err.Error()
+	}
+	r.mu.Lock()
+	r.jobs = append(r.jobs, j)
+	r.mu.Unlock()
+}
+
+// Jobs returns the jobs recorded so far, in the order they finished.
+func (r *Report) Jobs() []ReportJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ReportJob(nil), r.jobs...)
+}
+
+// reportSettings summarizes how a job was encoded, e.g. "720p, 1302 kbps
+// (medium, max 50 MB)".
+func reportSettings(opts model.CLIOptions, out model.OutputVideo) string {
+	var parts []string
+	switch {
+	case out.OutputPath == "":
+	case out.Copied:
+		parts = append(parts, "remuxed")
+	case out.AudioOnly:
+		parts = append(parts, "audio only")
+	default:
+		if out.LongSidePx > 0 {
+			parts = append(parts, fmt.Sprintf("%dp", out.LongSidePx))
+		}
+		if out.UsedBitrateKbps > 0 {
+			parts = append(parts, fmt.Sprintf("%d kbps", out.UsedBitrateKbps))
+		} else if out.UsedCRF > 0 {
+			parts = append(parts, fmt.Sprintf("CRF %d", out.UsedCRF))
+		}
+	}
+	target := string(opts.Quality)
+	if opts.MaxSizeMB > 0 {
+		target += fmt.Sprintf(", max %d MB", opts.MaxSizeMB)
+	}
+	if len(parts) == 0 {
+		return target
+	}
+	return strings.Join(parts, ", ") + " (" + target + ")"
+}
+
+// ReportPath resolves --report: a format name (json, csv, md) writes a
+// timestamped file to outDir; anything else is a path whose extension picks
+// the format. It returns the path and the format.
+func ReportPath(value, outDir string, now time.Time) (string, string, error) {
+	if f, ok := reportFormat(value); ok {
+		return filepath.Join(outDir, "sniplette-report-"+now.Format("20060102-150405")+"."+f), f, nil
+	}
+	ext := strings.TrimPrefix(filepath.Ext(value), ".")
+	f, ok := reportFormat(ext)
+	if !ok {
+		return "", "", fmt.Errorf("unknown report format %q (use json, csv, or md, or a path ending in one)", value)
+	}
+	return value, f, nil
+}
+
+func reportFormat(s string) (string, bool) {
+	switch strings.ToLower(s) {
+	case ReportJSON:
+		return ReportJSON, true
+	case ReportCSV:
+		return ReportCSV, true
+	case ReportMarkdown, "markdown":
+		return ReportMarkdown, true
+	}
+	return "", false
+}
+
+// Write saves the report at path in format (see ReportPath).
+func (r *Report) Write(path, format string) error {
+	jobs := r.Jobs()
+	if err := dirs.Ensure(filepath.Dir(path)); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case ReportJSON:
+		err = r.writeJSON(f, jobs)
+	case ReportCSV:
+		err = writeReportCSV(f, jobs)
+	default:
+		err = r.writeMarkdown(f, jobs)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (r *Report) writeJSON(w io.Writer, jobs []ReportJob) error {
+	if jobs == nil {
+		jobs = []ReportJob{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Started   time.Time   `json:"started"`
+		Succeeded int         `json:"succeeded"`
+		Failed    int         `json:"failed"`
+		Jobs      []ReportJob `json:"jobs"`
+	}{r.Started, countStatus(jobs, "success"), countStatus(jobs, "failure"), jobs})
+}
+
+func writeReportCSV(w io.Writer, jobs []ReportJob) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"url", "title", "uploader", "status", "output_path", "remote_url", "bytes", "source_bytes", "duration_sec", "settings", "tags", "error",
+		"metadata_sec", "download_sec", "encode_sec", "upload_sec", "elapsed_sec"})
+	for _, j := range jobs {
+		_ = cw.Write([]string{j.URL, j.Title, j.Uploader, j.Status, j.OutputPath, j.RemoteURL,
+			strconv.FormatInt(j.Bytes, 10), strconv.FormatInt(j.SourceBytes, 10),
+			strconv.FormatFloat(j.DurationSec, 'f', -1, 64), j.Settings, strings.Join(j.Tags, ";"), j.Error,
+			csvSeconds(j.MetadataSec), csvSeconds(j.DownloadSec), csvSeconds(j.EncodeSec), csvSeconds(j.UploadSec), csvSeconds(j.ElapsedSec)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', -1, 64)
+}
+
+func (r *Report) writeMarkdown(w io.Writer, jobs []ReportJob) error {
+	fmt.Fprintf(w, "# Sniplette report, %s\n\n", r.Started.Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "%d job(s): %d succeeded, %d failed.\n\n", len(jobs), countStatus(jobs, "success"), countStatus(jobs, "failure"))
+	if tags := reportTags(jobs); len(tags) > 0 {
+		fmt.Fprintf(w, "Tags: %s\n\n", mdEscape(strings.Join(tags, ", ")))
+	}
+	fmt.Fprintln(w, "| # | Video | Result | Size | Duration | Settings | Took |")
+	fmt.Fprintln(w, "|---|-------|--------|------|----------|----------|------|")
+	for i, j := range jobs {
+		video := j.URL
+		if j.Title != "" {
+			video = "[" + mdEscape(j.Title) + "](" + j.URL + ")"
+		}
+		result := "✅ " + mdEscape(filepath.Base(j.OutputPath))
+		if j.RemoteURL != "" {
+			result += " ([uploaded](" + j.RemoteURL + "))"
+		}
+		size := "-"
+		if j.Bytes > 0 {
+			size = util.HumanizeBytes(j.Bytes)
+			if j.SourceBytes > 0 {
+				size += " (from " + util.HumanizeBytes(j.SourceBytes) + ")"
+			}
+		}
+		if j.Status != "success" {
+			result = "❌ " + mdEscape(j.Error)
+		}
+		dur := "-"
+		if j.DurationSec > 0 {
+			dur = (time.Duration(j.DurationSec) * time.Second).String()
+		}
+		took := "-"
+		if j.ElapsedSec > 0 {
+			took = progress.StageTimes{
+				Metadata: util.SecondsDuration(j.MetadataSec),
+				Download: util.SecondsDuration(j.DownloadSec),
+				Encode:   util.SecondsDuration(j.EncodeSec),
+				Upload:   util.SecondsDuration(j.UploadSec),
+				Total:    util.SecondsDuration(j.ElapsedSec),
+			}.String()
+		}
+		fmt.Fprintf(w, "| %d | %s | %s | %s | %s | %s | %s |\n", i+1, video, result, size, dur, mdEscape(j.Settings), took)
+	}
+	return nil
+}
+
+// reportTags lists the tags of the jobs, in order of first use. A run's jobs
+// usually share them.
+func reportTags(jobs []ReportJob) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, j := range jobs {
+		for _, t := range j.Tags {
+			if !seen[t] {
+				seen[t] = true
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+func countStatus(jobs []ReportJob, status string) int {
+	n := 0
+	for _, j := range jobs {
+		if j.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// mdEscape keeps a value inside its Markdown table cell.
+func mdEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"sync"
+
+	"ig2wa/internal/util"
+)
+
+// CPU utilisation bands for the adaptive scheduler: above cpuHigh the limit
+// shrinks, below cpuLow it may grow.
+const (
+	cpuHigh = 0.90
+	cpuLow  = 0.70
+)
+
+// Scheduler decides how many jobs may run at once. A fixed scheduler always
+// allows Max. An adaptive one starts at Min and, on every Tick, grows while
+// there is queued work, CPU headroom, and the last extra slot raised the
+// aggregate download rate; it shrinks when the CPU is saturated or an extra
+// slot did not help (the link is the bottleneck). Running jobs are never
+// stopped; a lower limit only holds back new starts.
+type Scheduler struct {
+	mu       sync.Mutex
+	min, max int
+	limit    int
+	adaptive bool
+
+	cpu      util.CPUSampler
+	rates    map[string]float64 // job -> current download rate (bytes/s)
+	grewAt   float64            // aggregate rate when the limit last grew; 0 = not probing
+	cooldown int                // ticks before the next probe verdict or change
+}
+
+// NewFixedScheduler allows n concurrent jobs.
+func NewFixedScheduler(n int) *Scheduler {
+	if n <= 0 {
+		n = 1
+	}
+	return &Scheduler{min: n, max: n, limit: n}
+}
+
+// NewAdaptiveScheduler moves the limit between min and max.
+func NewAdaptiveScheduler(min, max int) *Scheduler {
+	if min <= 0 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	s := &Scheduler{min: min, max: max, limit: min, adaptive: true, rates: map[string]float64{}}
+	s.cpu.Sample() // prime
+	return s
+}
+
+// Adaptive reports whether the limit changes over time (and Tick is useful).
+func (s *Scheduler) Adaptive() bool { return s.adaptive }
+
+// Limit returns the current number of jobs allowed to run.
+func (s *Scheduler) Limit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limit
+}
+
+// ObserveRate records a job's current download rate in bytes per second.
+func (s *Scheduler) ObserveRate(jobID string, bytesPerSec float64) {
+	if !s.adaptive {
+		return
+	}
+	s.mu.Lock()
+	s.rates[jobID] = bytesPerSec
+	s.mu.Unlock()
+}
+
+// JobDone forgets a job's download rate (call when it leaves the download
+// stage or finishes).
+func (s *Scheduler) JobDone(jobID string) {
+	if !s.adaptive {
+		return
+	}
+	s.mu.Lock()
+	delete(s.rates, jobID)
+	s.mu.Unlock()
+}
+
+// Tick re-evaluates the limit given the number of running and queued jobs
+// and returns the new limit.
+func (s *Scheduler) Tick(running, queued int) int {
+	if !s.adaptive {
+		return s.limit
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rate float64
+	for _, r := range s.rates {
+		rate += r
+	}
+	busy, haveCPU := s.cpu.Sample()
+	if s.cooldown > 0 {
+		s.cooldown--
+	}
+
+	switch {
+	case haveCPU && busy > cpuHigh && s.limit > s.min:
+		s.limit--
+		s.grewAt, s.cooldown = 0, 2
+	case s.grewAt > 0:
+		if s.cooldown > 0 {
+			break // give the new job time to reach its download
+		}
+		// Judge the last probe: an extra slot should add real throughput.
+		if rate < s.grewAt*1.10 && s.limit > s.min {
+			s.limit--
+			s.cooldown = 5
+		}
+		s.grewAt = 0
+	case queued > 0 && running >= s.limit && s.limit < s.max && s.cooldown == 0 &&
+		(!haveCPU || busy < cpuLow):
+		s.limit++
+		if len(s.rates) > 0 {
+			s.grewAt = rate
+		}
+		s.cooldown = 2
+	}
+	return s.limit
+}
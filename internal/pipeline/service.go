@@ -0,0 +1,426 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"ig2wa/internal/diag"
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/encoder"
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+	"ig2wa/internal/util/media"
+)
+
+// Service runs jobs from URL to finished output: download, plan, encode,
+// upload, the after-encode steps, and the history, report, and hook of each
+// job. The TUI and the plain CLI both run their jobs through it.
+type Service struct {
+	DownloaderPath string
+	FFmpegPath     string
+}
+
+// Job is one URL for Service.RunJob.
+type Job struct {
+	ID        string
+	URL       string
+	Options   model.CLIOptions  // For this URL (see OptionsForURL)
+	PresetCRF int               // CRF of the quality preset (see DefaultCRF)
+	Reporter  progress.Reporter // Gets the job's progress and one Result; nil = none
+	Report    *Report           // Collects the finished job; nil = none
+
+	// SelectFormat, when set, picks the source format (--pick-format).
+	SelectFormat func(context.Context, []downloader.Format) (string, error)
+	// Downloaded, when set, is called with the source as soon as it is
+	// downloaded, e.g. to show a thumbnail.
+	Downloaded func(dv model.DownloadedVideo, tempDir string)
+}
+
+// JobOutcome is what a job produced.
+type JobOutcome struct {
+	Plan    *Plan               // Dry runs only
+	Outputs []model.OutputVideo // Main output first; empty in dry runs
+	Times   progress.StageTimes
+	Skipped string // Why the job did nothing (with --on-duplicate skip); "" = it ran
+}
+
+// Steps a job can fail in (see JobError).
+const (
+	StepPrepare  = "prepare" // Trimming, bumpers, and other settings checked against the video
+	StepDownload = "download"
+	StepEncode   = "encode"
+	StepUpload   = "upload"
+)
+
+// What a job does when another job, in this process or another, is already
+// snipping the same video (model.CLIOptions.OnDuplicate; "" = wait).
+const (
+	DuplicateWait = "wait"
+	DuplicateSkip = "skip"
+)
+
+// JobError is the error of a failed job, with the step it failed in.
+type JobError struct {
+	Step string
+	Err  error
+}
+
+func (e *JobError) Error() string {
+	if e.Step == StepPrepare {
+		return e.Err.Error()
+	}
+	return e.Step + ": " + e.Err.Error()
+}
+
+func (e *JobError) Unwrap() error { return e.Err }
+
+// RunJob runs job to the end. In dry runs it stops after planning and returns
+// the plan. Errors are *JobError. Unless it is a dry run, the job is recorded
+// in the history and the report and the PhaseResult post-processors (the
+// hooks) run, all with ctx rather than the job's own time limit, before the
+// Result is reported.
+func (s Service) RunJob(ctx context.Context, job Job) (res JobOutcome, err error) {
+	opts := job.Options
+	hook := HookContext{URL: job.URL, Reporter: job.Reporter, JobID: job.ID, Started: time.Now()}
+	var result progress.Result
+	defer func() {
+		if opts.DryRun && err == nil {
+			result.OutputPath = res.Plan.OutputPath
+		}
+		hook.Err, result.JobID, result.Err, result.Skipped = err, job.ID, err, res.Skipped
+		hook.Finish()
+		res.Times, result.Times = hook.Times, hook.Times
+		if !opts.DryRun && res.Skipped == "" {
+			RecordHistory(opts, hook)
+			job.Report.Add(opts, hook)
+			s.runResultSteps(ctx, job, hook)
+		}
+		if job.Reporter != nil {
+			job.Reporter.Result(result)
+		}
+	}()
+
+	jobCtx, cancel := util.StageContext(ctx, "job", opts.JobTimeout)
+	defer cancel()
+	fail := func(step string, err error) error {
+		err = util.TimeoutCause(jobCtx, err)
+		if step != StepPrepare {
+			diag.RecordFailure(job.URL, err)
+		}
+		return &JobError{Step: step, Err: err}
+	}
+
+	// A short link the batch couldn't resolve gets one more try here, so
+	// only its own job fails
+	if util.IsShortLink(job.URL) {
+		resolved, rerr := util.ResolveShortLink(jobCtx, job.URL)
+		if rerr != nil {
+			return res, fail(StepDownload, rerr)
+		}
+		job.URL, hook.URL = resolved, resolved
+	}
+
+	// One job per video at a time, across processes
+	if !opts.DryRun {
+		lock, lerr := util.LockVideo(jobCtx, job.URL, opts.OnDuplicate != DuplicateSkip, func(pid int) {
+			job.warn(fmt.Sprintf("waiting for another sniplette job (pid %d) snipping this video", pid))
+		})
+		if errors.Is(lerr, util.ErrVideoBusy) {
+			res.Skipped = lerr.Error()
+			return res, nil
+		}
+		if lerr != nil {
+			return res, fail(StepPrepare, lerr)
+		}
+		defer lock.Unlock()
+	}
+
+	// Download and encode, here or on a remote worker
+	p, perr := s.produceJob(jobCtx, job, &hook)
+	if perr != nil {
+		var je *JobError
+		if errors.As(perr, &je) {
+			return res, fail(je.Step, je.Err)
+		}
+		return res, fail(StepDownload, perr)
+	}
+	if p.Plan != nil {
+		res.Plan = p.Plan
+		return res, nil
+	}
+	dv, outs := p.Video, p.Outputs
+	hook.Output = outs[0]
+	result.OutputPath, result.Bytes = outs[0].OutputPath, outs[0].Bytes
+
+	// After-encode steps on every output: the uploads (--upload) first, as
+	// they fail the job and the caption names where the outputs went, then
+	// the configured ones (caption, thumbnail, ...)
+	procs, perr := PostProcessorsFor(opts.PostProcessors)
+	if perr != nil {
+		return res, fail(StepPrepare, perr)
+	}
+	uploads := PhaseProcessors(PhaseUpload)
+	upStart := time.Now()
+	for i := range outs {
+		pc := s.postContext(job, dv, outs, i)
+		for _, u := range uploads {
+			uerr := u.Process(jobCtx, &pc)
+			if opts.Upload != "" {
+				hook.Times.Upload = time.Since(upStart)
+			}
+			if uerr != nil {
+				return res, fail(StepUpload, uerr)
+			}
+		}
+		outs[i] = pc.Output
+	}
+	out := outs[0]
+	hook.Output, result.RemoteURL = out, out.RemoteURL
+	res.Outputs = outs
+	for i := range outs {
+		pc := s.postContext(job, dv, outs, i)
+		for _, werr := range RunPostProcessors(jobCtx, procs, &pc) {
+			job.warn("post-process " + werr.Error())
+		}
+	}
+	return res, nil
+}
+
+// produced is what the download and encode of a job made.
+type produced struct {
+	Video   model.DownloadedVideo
+	Outputs []model.OutputVideo // Main output first
+	Plan    *Plan               // Dry runs only, instead of the outputs
+}
+
+// produce downloads job's video and encodes its outputs (see Emits) into
+// job.Options.OutDir, recording the video and the time taken in hook, or
+// plans them in a dry run. Errors are *JobError.
+func (s Service) produce(jobCtx context.Context, job Job, hook *HookContext) (produced, error) {
+	opts := job.Options
+	fail := func(step string, err error) error { return &JobError{Step: step, Err: err} }
+
+	// Download (metadata only in dry runs)
+	backend := downloader.SelectBackend(job.URL, opts.Backends, opts.Backend)
+	dlOpts := downloader.Options{
+		Backend:            backend,
+		DownloaderPath:     s.DownloaderPath,
+		FFmpegPath:         s.FFmpegPath,
+		BackendPath:        opts.BackendPaths[backend],
+		ExtraArgs:          opts.DLArgs,
+		TempBase:           opts.TempBase,
+		Format:             opts.Format,
+		Cookies:            opts.Cookies,
+		CookiesFromBrowser: opts.CookiesFromBrowser,
+		RateLimit:          opts.RateLimit,
+		GeoBypass:          opts.GeoBypass,
+		SourceAddress:      opts.SourceAddress,
+		MetadataTimeout:    opts.MetadataTimeout,
+		MetadataCacheTTL:   opts.MetadataCacheTTL,
+		WaitLive:           opts.WaitLive,
+		DownloadTimeout:    opts.DownloadTimeout,
+		Verbose:            opts.Verbose,
+		KeepTemp:           opts.KeepTemp,
+		MetadataOnly:       opts.DryRun,
+		Source:             opts.Source,
+		SourceCacheMB:      opts.SourceCacheMB,
+		SelectFormat:       job.SelectFormat,
+		Reporter:           job.Reporter,
+		JobID:              job.ID,
+	}
+	if opts.TrimStartSec > 0 || opts.TrimEndSec > 0 || opts.Chapter != "" {
+		dlOpts.Section, dlOpts.SectionNeedsInfo = TrimSection(opts), opts.Chapter != ""
+	}
+	dlStart := time.Now()
+	dv, tempDir, derr := downloader.Download(jobCtx, job.URL, dlOpts)
+	hook.Downloaded(dlStart, dv)
+	defer func() {
+		if !opts.KeepTemp && tempDir != "" {
+			_ = util.RemoveTempWorkdir(tempDir)
+		}
+	}()
+	if derr != nil {
+		return produced{}, fail(StepDownload, derr)
+	}
+	if job.Downloaded != nil {
+		job.Downloaded(dv, tempDir)
+	}
+
+	eb, berr := encoder.LookupBackend(opts.Encoder)
+	if berr != nil {
+		return produced{}, fail(StepPrepare, berr)
+	}
+	dv = AssumeShortsShape(eb.Probe(jobCtx, dv))
+	hook.Video, hook.SourceBytes = dv, util.FileSize(dv.InputPath)
+	dv, trimStart, trimEnd, terr := Trim(opts, dv)
+	if terr != nil {
+		return produced{}, fail(StepPrepare, terr)
+	}
+
+	// Plan encoding
+	encOpts, perr := NewEncodeOptions(opts, dv, job.PresetCRF, trimStart, trimEnd)
+	if perr != nil {
+		return produced{}, fail(StepPrepare, perr)
+	}
+	if encOpts.Intro, encOpts.Outro, berr = Bumpers(jobCtx, opts); berr != nil {
+		return produced{}, fail(StepPrepare, berr)
+	}
+	// Copy decisions are made per output by Emit; these are for the dry-run
+	// plan.
+	encOpts.StreamCopy = StreamCopy(opts, dv, encOpts)
+	encOpts.AudioCopy = !encOpts.StreamCopy && AudioCopy(opts, dv, encOpts)
+	if opts.Caption.Embedded() {
+		encOpts.Comment = media.EmbeddedCaption(media.CaptionText(dv))
+	}
+
+	// Output filename
+	emits := Emits(opts)
+	base := media.OutputBasename(dv, encOpts.LongSidePx, opts.MaxSizeMB, encOpts, opts.NameDate)
+	outDir := filepath.Join(opts.OutDir, media.OrganizedSubdir(opts.Organize, dv, time.Now()))
+	outputPath := filepath.Join(outDir, util.FitFilename(outDir, base, OutputExts(opts)...)+EmitExt(emits[0]))
+
+	ff := encoder.Options{
+		FFmpegPath: s.FFmpegPath,
+		Verbose:    opts.Verbose,
+		OutputPath: outputPath,
+		ExtraArgs:  opts.FFmpegArgs,
+		WorkDir:    tempDir,
+		Timeout:    opts.EncodeTimeout,
+		Nice:       opts.Nice,
+		Threads:    opts.Threads,
+		Reporter:   job.Reporter,
+		JobID:      job.ID,
+
+		Remotes:     opts.EncodeRemotes,
+		RemoteToken: opts.RemoteToken,
+	}
+	if opts.DryRun {
+		plan := s.plan(jobCtx, job, dlOpts, dv, encOpts, ff)
+		plan.OutputPath = outputPath
+		if opts.ShowCommands {
+			plan.DownloadCmd, plan.FFmpegCmd = PlanCommands(eb, job.URL, dlOpts, s.DownloaderPath, s.FFmpegPath, tempDir, outputPath, dv, encOpts, opts)
+		}
+		if opts.ShowFormats {
+			plan.Formats = PlanFormats(dv)
+		}
+		return produced{Plan: &plan}, nil
+	}
+
+	// Encode: the main output, then any other --emit outputs next to it
+	outs := make([]model.OutputVideo, 0, len(emits))
+	encStart := time.Now()
+	for i, kind := range emits {
+		if i > 0 {
+			ff.OutputPath = EmitPath(outputPath, kind)
+		}
+		o, eerr := Emit(jobCtx, eb, kind, dv, encOpts, opts, ff) // a copy shows as "Remuxing"
+		hook.Times.Encode = time.Since(encStart)
+		if eerr != nil {
+			return produced{}, fail(StepEncode, eerr)
+		}
+		outs = append(outs, o)
+	}
+	// Size overshoot warning (best-effort)
+	if emits[0] == EmitMP4 && !encOpts.ModeCRF && opts.MaxSizeMB > 0 {
+		maxBytes := int64(opts.MaxSizeMB) * 1024 * 1024
+		if outs[0].Bytes > int64(float64(maxBytes)*1.10) {
+			job.warn(fmt.Sprintf("output size (%0.2f MB) exceeds target (%d MB). Consider lowering bitrate or preset.",
+				float64(outs[0].Bytes)/(1024*1024), opts.MaxSizeMB))
+		}
+	}
+	return produced{Video: dv, Outputs: outs}, nil
+}
+
+// postContext is the PostContext of the output i of job.
+func (s Service) postContext(job Job, dv model.DownloadedVideo, outs []model.OutputVideo, i int) PostContext {
+	return PostContext{
+		Video:      dv,
+		Output:     outs[i],
+		Outputs:    outs,
+		Options:    job.Options,
+		FFmpegPath: s.FFmpegPath,
+		Reporter:   job.Reporter,
+		JobID:      job.ID,
+	}
+}
+
+// runResultSteps runs the PhaseResult post-processors (the hooks) of a
+// finished job, unless ctx is done (e.g. the run was interrupted).
+func (s Service) runResultSteps(ctx context.Context, job Job, hook HookContext) {
+	if ctx.Err() != nil {
+		return
+	}
+	for _, werr := range RunPostProcessors(ctx, PhaseProcessors(PhaseResult), &PostContext{
+		Video:      hook.Video,
+		Output:     hook.Output,
+		Options:    job.Options,
+		FFmpegPath: s.FFmpegPath,
+		Job:        hook,
+		Reporter:   job.Reporter,
+		JobID:      job.ID,
+	}) {
+		job.warn("hook " + werr.Error())
+	}
+}
+
+// plan describes the dry run of job, with a size estimate from sample
+// encodes if asked for (--sample).
+func (s Service) plan(ctx context.Context, job Job, dlOpts downloader.Options, dv model.DownloadedVideo, enc model.EncodeOptions, ff encoder.Options) Plan {
+	dlLabel := s.DownloaderPath
+	if dlOpts.Backend != "" && dlOpts.Backend != downloader.DefaultBackend {
+		dlLabel = dlOpts.Backend + " backend"
+	}
+	plan := NewPlan(job.URL, dv, enc, job.Options)
+	plan.Downloader, plan.FFmpeg = dlLabel, s.FFmpegPath
+	if job.Options.SampleEncode {
+		ff.Reporter, ff.OutputPath = nil, ""
+		e, err := EstimateSize(ctx, job.URL, dlOpts, dv, enc, ff)
+		if err != nil {
+			slog.Warn("sample encode failed; showing the formula estimate only", "url", job.URL, "err", err)
+		} else {
+			plan.Estimate = &e
+		}
+	}
+	return plan
+}
+
+// warn reports a problem that doesn't fail the job: to the job's log when it
+// has a reporter, as a warning log otherwise.
+func (job Job) warn(msg string) {
+	if job.Reporter != nil {
+		job.Reporter.Log(progress.Log{JobID: job.ID, Stream: progress.StreamStderr, Line: "warning: " + msg})
+		return
+	}
+	slog.Warn(msg, "url", job.URL)
+}
+
+// PlanCommands builds the yt-dlp and encoder (eb) command lines a run would
+// use. The encoder input is a placeholder because the downloaded file's extension is
+// only known after the download.
+func PlanCommands(eb encoder.Backend, rawURL string, dlOpts downloader.Options, dlPath, ffmpegPath, tempDir, outputPath string, dv model.DownloadedVideo, enc model.EncodeOptions, opts model.CLIOptions) (dlCmd, ffCmd []string) {
+	if args, err := downloader.BuildDownloadArgs(rawURL, dlOpts, tempDir, enc.StartSec, enc.EndSec); err == nil {
+		dlCmd = append([]string{dlPath}, args...)
+		if enc.StartSec > 0 || enc.EndSec > 0 {
+			// Only the trimmed part is downloaded, so ffmpeg starts at 0.
+			if enc.EndSec > 0 {
+				enc.EndSec -= enc.StartSec
+			}
+			enc.StartSec = 0
+		}
+	}
+	dv.InputPath = filepath.Join(tempDir, dv.ID+".<ext>")
+	if cmd, err := eb.Command(dv, enc, encoder.Options{
+		FFmpegPath: ffmpegPath,
+		OutputPath: outputPath,
+		ExtraArgs:  opts.FFmpegArgs,
+		Threads:    opts.Threads,
+	}); err == nil {
+		ffCmd = cmd
+	}
+	return dlCmd, ffCmd
+}
@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/history"
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// LatestNew lists the newest n videos of a channel, profile, or playlist and
+// returns the URLs of those not in seen that pass opts.Filter, oldest first.
+// The returned videos are added to seen so a video listed by two sources is
+// only snipped once.
+func LatestNew(ctx context.Context, opts model.CLIOptions, dlPath, source string, n int, seen history.Index) ([]string, error) {
+	o := OptionsForURL(opts, source)
+	dlOpts := downloader.Options{
+		DownloaderPath:     dlPath,
+		Cookies:            o.Cookies,
+		CookiesFromBrowser: o.CookiesFromBrowser,
+		GeoBypass:          o.GeoBypass,
+		SourceAddress:      o.SourceAddress,
+		ExtraArgs:          o.DLArgs,
+		MetadataTimeout:    o.MetadataTimeout,
+		MetadataCacheTTL:   o.MetadataCacheTTL,
+		Verbose:            o.Verbose,
+	}
+	entries, err := downloader.ListLatest(ctx, source, n, dlOpts)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	// Listings are newest first; snip in publishing order.
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if seen.Seen(e.URL, e.ID) {
+			continue
+		}
+		if _, _, err := util.DetectPlatform(e.URL); err != nil {
+			continue
+		}
+		if opts.Filter.Active() {
+			reason, err := rejectReason(ctx, opts.Filter, e, dlOpts)
+			if err != nil {
+				slog.Warn("could not check filters; skipping", "url", e.URL, "err", err)
+				continue
+			}
+			if reason != "" {
+				slog.Info("filtered out", "url", e.URL, "reason", reason)
+				continue
+			}
+		}
+		seen.Add(e.URL, e.ID)
+		urls = append(urls, e.URL)
+	}
+	return urls, nil
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/util"
+)
+
+// Trim applies the --trim range or --chapter to a downloaded video. It returns
+// the video with DurationSec shortened to the clip, so bitrate planning,
+// estimates, and progress all work on what is actually encoded, plus the
+// source range to pass to the encoder. Without a trim range or chapter dv is
+// returned unchanged.
+func Trim(opts model.CLIOptions, dv model.DownloadedVideo) (model.DownloadedVideo, float64, float64, error) {
+	start, end := opts.TrimStartSec, opts.TrimEndSec
+	if opts.Chapter != "" {
+		ch, err := FindChapter(dv.Chapters, opts.Chapter)
+		if err != nil {
+			return dv, 0, 0, err
+		}
+		dv.Chapter = ch.Title
+		start, end = ch.StartSec, ch.EndSec
+	}
+	if start <= 0 && end <= 0 {
+		return dv, 0, 0, nil
+	}
+	if dv.DurationSec > 0 {
+		if start >= dv.DurationSec {
+			return dv, 0, 0, fmt.Errorf("trim start %s is past the end of the video (%s)", util.FormatTimestamp(start), util.FormatTimestamp(dv.DurationSec))
+		}
+		if end >= dv.DurationSec {
+			end = 0 // to the end
+		}
+		stop := dv.DurationSec
+		if end > 0 {
+			stop = end
+		}
+		dv.DurationSec = stop - start
+	} else if end > 0 {
+		dv.DurationSec = end - start
+	}
+	if dv.Sectioned {
+		// The download already starts at the cut.
+		start -= dv.SectionStartSec
+		if end > 0 {
+			end -= dv.SectionStartSec
+		}
+	}
+	return dv, start, end, nil
+}
+
+// TrimSection returns the part of the video to download when trimming, for
+// downloader.Options.Section; 0, 0 downloads everything. A video with
+// chapters but no match also downloads everything, and Trim reports the error.
+func TrimSection(opts model.CLIOptions) func(model.DownloadedVideo) (float64, float64) {
+	return func(dv model.DownloadedVideo) (float64, float64) {
+		_, start, end, err := Trim(opts, dv)
+		if err != nil {
+			return 0, 0
+		}
+		return start, end
+	}
+}
+
+// FindChapter picks a chapter by 1-based index or by title: an exact match
+// (ignoring case) wins, otherwise the title must contain sel in exactly one
+// chapter.
+func FindChapter(chapters []model.Chapter, sel string) (model.Chapter, error) {
+	if len(chapters) == 0 {
+		return model.Chapter{}, fmt.Errorf("--chapter %q: the video has no chapters", sel)
+	}
+	if n, err := strconv.Atoi(sel); err == nil {
+		if n < 1 || n > len(chapters) {
+			return model.Chapter{}, fmt.Errorf("--chapter %d: the video has %d chapters", n, len(chapters))
+		}
+		return chapters[n-1], nil
+	}
+	var matches []int
+	for i, c := range chapters {
+		if strings.EqualFold(c.Title, sel) {
+			return c, nil
+		}
+		if strings.Contains(strings.ToLower(c.Title), strings.ToLower(sel)) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return chapters[matches[0]], nil
+	case 0:
+		all := make([]int, len(chapters))
+		for i := range all {
+			all[i] = i
+		}
+		return model.Chapter{}, fmt.Errorf("--chapter %q: no such chapter (%s)", sel, chapterList(chapters, all))
+	default:
+		return model.Chapter{}, fmt.Errorf("--chapter %q matches %d chapters (%s); be more specific or use the number", sel, len(matches), chapterList(chapters, matches))
+	}
+}
+
+// chapterList names the chapters at idx with their numbers.
+func chapterList(chapters []model.Chapter, idx []int) string {
+	names := make([]string, len(idx))
+	for j, i := range idx {
+		names[j] = fmt.Sprintf("%d. %s", i+1, chapters[i].Title)
+	}
+	return strings.Join(names, ", ")
+}
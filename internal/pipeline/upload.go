@@ -0,0 +1,45 @@
+package pipeline
+
+import (
+	"context"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/uploader"
+	"ig2wa/internal/util/deps"
+)
+
+// Upload sends a finished output to opts.Upload, if set, and records where it
+// went in out.RemoteURL. The local file is kept either way.
+func Upload(ctx context.Context, out model.OutputVideo, opts model.CLIOptions, rep progress.Reporter, jobID string) (model.OutputVideo, error) {
+	if opts.Upload == "" {
+		return out, nil
+	}
+	rclone, err := deps.FindRclone()
+	if err != nil {
+		return out, err
+	}
+	remote, err := uploader.Upload(ctx, out.OutputPath, uploader.Options{
+		RclonePath: rclone,
+		Dest:       opts.Upload,
+		Verbose:    opts.Verbose,
+		Reporter:   rep,
+		JobID:      jobID,
+	})
+	if err != nil {
+		return out, err
+	}
+	out.RemoteURL = remote
+	return out, nil
+}
+
+// outputUploader sends an output to --upload, if set (see Upload).
+type outputUploader struct{}
+
+func (outputUploader) Name() string { return "upload" }
+
+func (outputUploader) Process(ctx context.Context, pc *PostContext) error {
+	out, err := Upload(ctx, pc.Output, pc.Options, pc.Reporter, pc.JobID)
+	pc.Output = out
+	return err
+}
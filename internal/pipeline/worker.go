@@ -0,0 +1,242 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ig2wa/internal/encoder"
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/remote"
+	"ig2wa/internal/util"
+)
+
+// A batch's jobs can be spread over other machines' `sniplette daemon
+// --listen` (model.CLIOptions.RemoteWorkers). A worker downloads the video
+// and encodes its outputs; the client fetches them and does the rest of the
+// job (uploads, post-processors, history, hooks) as for a local one.
+
+// RemoteJobPath is where a server takes jobs, relative to its base URL; their
+// outputs are fetched from remote.OutputsPath.
+const RemoteJobPath = "/v1/jobs"
+
+// RemoteJobRequest is the JSON body of a remote job. The options are cleaned
+// with WorkerOptions on both ends.
+type RemoteJobRequest struct {
+	URL       string           `json:"url"`
+	Options   model.CLIOptions `json:"options"`
+	PresetCRF int              `json:"preset_crf,omitempty"`
+}
+
+// RemoteJobEvent is one JSON line of the response to a remote job: progress
+// and log lines while it runs, then the result or an error.
+type RemoteJobEvent struct {
+	Update *progress.Update `json:"update,omitempty"`
+	Log    *progress.Log    `json:"log,omitempty"`
+	Result *RemoteJobResult `json:"result,omitempty"`
+	Error  string           `json:"error,omitempty"`
+	Step   string           `json:"step,omitempty"` // Of the error (see JobError)
+}
+
+// RemoteJobResult is what a remote job made.
+type RemoteJobResult struct {
+	Video       model.DownloadedVideo `json:"video"`
+	Outputs     []RemoteOutput        `json:"outputs"` // Main output first
+	Times       progress.StageTimes   `json:"times"`
+	SourceBytes int64                 `json:"source_bytes"`
+}
+
+// RemoteOutput is one output of a remote job.
+type RemoteOutput struct {
+	Output model.OutputVideo `json:"output"`
+	Name   string            `json:"name"` // To fetch it by
+	Path   string            `json:"path"` // Relative to the output directory (--organize)
+}
+
+// RemoteWorkable reports whether a job with opts can run on a remote worker:
+// not when it needs files or raw arguments of this machine.
+func RemoteWorkable(opts model.CLIOptions) bool {
+	return !opts.DryRun && len(opts.DLArgs) == 0 && len(opts.FFmpegArgs) == 0 &&
+		opts.Cookies == "" && opts.CookiesFromBrowser == "" && opts.Source == "" &&
+		opts.Intro == "" && opts.Outro == "" && opts.SourceAddress == ""
+}
+
+// WorkerOptions returns opts as a remote worker runs them: writing to outDir,
+// with the server's own tools, and without anything that names files of the
+// client or runs commands (raw arguments, hooks, uploads).
+func WorkerOptions(opts model.CLIOptions, outDir string) model.CLIOptions {
+	opts.OutDir = outDir
+	opts.TempBase, opts.DLBinary, opts.BackendPaths = "", "", nil
+	opts.DLArgs, opts.FFmpegArgs = nil, nil
+	opts.Cookies, opts.CookiesFromBrowser, opts.Source = "", "", ""
+	opts.SourceAddress, opts.Platforms, opts.SourceCacheMB = "", nil, 0 // Job options are per URL already
+	opts.Intro, opts.Outro = "", ""
+	opts.Latest, opts.Filter = 0, model.SourceFilter{}
+	opts.KeepTemp, opts.DryRun, opts.SampleEncode = false, false, false
+	if opts.Encoder == encoder.RemoteBackend {
+		opts.Encoder = ""
+	}
+	opts.EncodeRemotes, opts.RemoteWorkers = nil, nil
+	opts.Upload, opts.OnSuccess, opts.OnFailure = "", nil, nil
+	opts.PostProcessors, opts.DownloadArchive, opts.NoHistory = nil, "", true
+	opts.Report, opts.ProgressFile, opts.ProgressWebhook = "", "", ""
+	return opts
+}
+
+// workers are the job endpoints of this process.
+var workers = remote.NewPool()
+
+// produceJob produces job here, or on the least loaded of its
+// RemoteWorkers (remote.Local = here) when it can run there. When a worker
+// can't be reached or fails mid-job, it is passed over for a while and the
+// job moves to the next one; failures of the job itself are final.
+func (s Service) produceJob(ctx context.Context, job Job, hook *HookContext) (produced, error) {
+	opts := job.Options
+	if len(opts.RemoteWorkers) == 0 || !RemoteWorkable(opts) || job.SelectFormat != nil {
+		return s.produce(ctx, job, hook)
+	}
+	tried := map[string]bool{}
+	var errs []error
+	for {
+		ep, ok := workers.Acquire(ctx, opts.RemoteWorkers, tried, opts.RemoteToken)
+		if !ok {
+			return produced{}, &JobError{Step: StepDownload, Err: fmt.Errorf("no worker could take the job: %w", errors.Join(errs...))}
+		}
+		tried[ep] = true
+		if ep == remote.Local {
+			p, err := s.produce(ctx, job, hook)
+			workers.Release(ep, false)
+			return p, err
+		}
+		p, err := s.produceOn(ctx, ep, job, hook)
+		var ee *remote.EndpointError
+		failed := errors.As(err, &ee) && ctx.Err() == nil
+		workers.Release(ep, failed)
+		if !failed {
+			return p, err
+		}
+		job.warn(fmt.Sprintf("worker %s failed, moving the job: %v", remote.Host(ep), err))
+		errs = append(errs, err)
+	}
+}
+
+// produceOn runs the download and encode of job on the daemon at base,
+// relaying its progress, and fetches the outputs into the output directory.
+func (s Service) produceOn(ctx context.Context, base string, job Job, hook *HookContext) (produced, error) {
+	base = strings.TrimRight(base, "/")
+	host := remote.Host(base)
+	body, err := json.Marshal(RemoteJobRequest{URL: job.URL, Options: WorkerOptions(job.Options, ""), PresetCRF: job.PresetCRF})
+	if err != nil {
+		return produced{}, &JobError{Step: StepPrepare, Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+RemoteJobPath, bytes.NewReader(body))
+	if err != nil {
+		return produced{}, &JobError{Step: StepPrepare, Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	remote.SetAuth(req, job.Options.RemoteToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return produced{}, &remote.EndpointError{Err: fmt.Errorf("remote worker %s: %w", host, err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("remote worker %s: %w", host, remote.StatusError(resp))
+		if remote.FailedStatus(resp.StatusCode) || resp.StatusCode == http.StatusNotFound { // 404: a server without remote jobs
+			return produced{}, &remote.EndpointError{Err: err}
+		}
+		return produced{}, &JobError{Step: StepPrepare, Err: err}
+	}
+
+	var res *RemoteJobResult
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		var ev RemoteJobEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			return produced{}, &remote.EndpointError{Err: fmt.Errorf("remote worker %s: bad reply: %w", host, err)}
+		}
+		switch {
+		case ev.Error != "":
+			step := ev.Step
+			if step != StepPrepare && step != StepDownload {
+				step = StepEncode
+			}
+			return produced{}, &JobError{Step: step, Err: fmt.Errorf("remote worker %s: %s", host, ev.Error)}
+		case ev.Result != nil:
+			res = ev.Result
+		case ev.Update != nil && job.Reporter != nil:
+			u := *ev.Update
+			u.JobID = job.ID
+			u.Message += " (" + host + ")"
+			job.Reporter.Update(u)
+		case ev.Log != nil && job.Reporter != nil:
+			l := *ev.Log
+			l.JobID = job.ID
+			job.Reporter.Log(l)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return produced{}, &remote.EndpointError{Err: fmt.Errorf("remote worker %s: %w", host, util.TimeoutCause(ctx, err))}
+	}
+	if res == nil || len(res.Outputs) == 0 {
+		return produced{}, &remote.EndpointError{Err: fmt.Errorf("remote worker %s: connection closed before the job was done", host)}
+	}
+	hook.Video, hook.SourceBytes = res.Video, res.SourceBytes
+	hook.Times.Metadata, hook.Times.Download, hook.Times.Encode = res.Times.Metadata, res.Times.Download, res.Times.Encode
+
+	// The outputs share their name, fitted to this output directory
+	if job.Reporter != nil {
+		job.Reporter.Update(progress.Update{JobID: job.ID, Stage: progress.StageEncoding, Percent: -1, Message: "Fetching from remote worker (" + host + ")"})
+	}
+	main := filepath.FromSlash(res.Outputs[0].Path)
+	dir := filepath.Join(job.Options.OutDir, filepath.Dir(main))
+	name := util.FitFilename(dir, strings.TrimSuffix(filepath.Base(main), filepath.Ext(main)), OutputExts(job.Options)...)
+	outs := make([]model.OutputVideo, 0, len(res.Outputs))
+	for _, ro := range res.Outputs {
+		dst := filepath.Join(dir, name+filepath.Ext(ro.Path))
+		tmp := dst + ".part"
+		if err := remote.Fetch(ctx, base, ro.Name, job.Options.RemoteToken, tmp); err != nil {
+			_ = util.RemoveIfExists(tmp)
+			return produced{}, &remote.EndpointError{Err: fmt.Errorf("remote worker %s: %w", host, err)}
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			return produced{}, &JobError{Step: StepEncode, Err: fmt.Errorf("finalize output: %w", err)}
+		}
+		o := ro.Output
+		o.OutputPath, o.Bytes = dst, util.FileSize(dst)
+		outs = append(outs, o)
+	}
+	slog.Debug("remote job done", "worker", host, "url", job.URL)
+	return produced{Video: res.Video, Outputs: outs}, nil
+}
+
+// RunWorkerJob runs the download and encode of a remote job into outDir for
+// a server, reporting to rep. The outputs' names are left for the server to
+// set. Errors are *JobError.
+func (s Service) RunWorkerJob(ctx context.Context, req RemoteJobRequest, outDir string, rep progress.Reporter) (RemoteJobResult, error) {
+	job := Job{ID: "remote", URL: req.URL, Options: WorkerOptions(req.Options, outDir), PresetCRF: req.PresetCRF, Reporter: rep}
+	var hook HookContext
+	p, err := s.produce(ctx, job, &hook)
+	if err != nil {
+		return RemoteJobResult{}, err
+	}
+	res := RemoteJobResult{Video: p.Video, Times: hook.Times, SourceBytes: hook.SourceBytes}
+	for _, o := range p.Outputs {
+		rel, err := filepath.Rel(outDir, o.OutputPath)
+		if err != nil {
+			return RemoteJobResult{}, &JobError{Step: StepEncode, Err: err}
+		}
+		res.Outputs = append(res.Outputs, RemoteOutput{Output: o, Path: filepath.ToSlash(rel)})
+	}
+	return res, nil
+}
@@ -0,0 +1,171 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"ig2wa/internal/i18n"
+)
+
+// Console renders progress as plain text for non-TUI runs, in the language
+// i18n.Setup selected. In line mode it
+// prints at most one line per job per Interval (plus every stage change); in
+// in-place mode it rewrites a single line with carriage returns.
+type Console struct {
+	mu       sync.Mutex
+	w        io.Writer
+	inPlace  bool
+	interval time.Duration
+	jobs     map[string]*consoleJob
+
+	downloaded int64 // Source bytes transferred by all jobs so far
+}
+
+type consoleJob struct {
+	stage     Stage
+	task      string
+	lastPrint time.Time
+	lineLen   int   // length of the last in-place line, for clearing
+	bytes     int64 // Source bytes transferred so far
+}
+
+// NewConsole creates a console reporter writing to w. Use inPlace=true when w is
+// a terminal so updates overwrite the current line instead of scrolling.
+func NewConsole(w io.Writer, inPlace bool) *Console {
+	return &Console{
+		w:        w,
+		inPlace:  inPlace,
+		interval: time.Second,
+		jobs:     make(map[string]*consoleJob),
+	}
+}
+
+// Update implements Reporter.
+func (c *Console) Update(u Update) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	j := c.job(u.JobID)
+	if u.Stage == StageDownloading && u.Bytes != nil {
+		c.downloaded += *u.Bytes - j.bytes
+		j.bytes = *u.Bytes
+	}
+	now := time.Now()
+	interval := c.interval
+	if c.inPlace {
+		interval = interval / 10
+	}
+	stageChanged := u.Stage != j.stage || u.Task != j.task
+	if !stageChanged && now.Sub(j.lastPrint) < interval {
+		return
+	}
+	if stageChanged && c.inPlace && j.lineLen > 0 {
+		// Keep the finished stage visible and start a fresh line.
+		fmt.Fprintln(c.w)
+		j.lineLen = 0
+	}
+	j.stage, j.task = u.Stage, u.Task
+	j.lastPrint = now
+	c.print(j, formatUpdate(u))
+}
+
+// Log implements Reporter; raw subprocess lines are passed through verbatim.
+func (c *Console) Log(l Log) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	j := c.job(l.JobID)
+	c.endLine(j)
+	fmt.Fprintf(c.w, "%s%s\n", jobPrefix(l.JobID), l.Line)
+}
+
+// Result implements Reporter. It only terminates any in-place line; callers
+// print their own final "Saved:" or error message.
+func (c *Console) Result(r Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endLine(c.job(r.JobID))
+	delete(c.jobs, r.JobID)
+}
+
+// Downloaded returns the source bytes all jobs have transferred, as far as
+// their downloaders reported them.
+func (c *Console) Downloaded() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.downloaded
+}
+
+func (c *Console) job(id string) *consoleJob {
+	j, ok := c.jobs[id]
+	if !ok {
+		j = &consoleJob{}
+		c.jobs[id] = j
+	}
+	return j
+}
+
+func (c *Console) print(j *consoleJob, line string) {
+	if !c.inPlace {
+		fmt.Fprintln(c.w, line)
+		return
+	}
+	pad := ""
+	if n := j.lineLen - len(line); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	fmt.Fprintf(c.w, "\r%s%s", line, pad)
+	j.lineLen = len(line)
+}
+
+func (c *Console) endLine(j *consoleJob) {
+	if c.inPlace && j.lineLen > 0 {
+		fmt.Fprintln(c.w)
+		j.lineLen = 0
+	}
+}
+
+func jobPrefix(id string) string {
+	if id == "" {
+		return ""
+	}
+	return "[" + id + "] "
+}
+
+func formatUpdate(u Update) string {
+	var b strings.Builder
+	b.WriteString(jobPrefix(u.JobID))
+	stage := i18n.String(string(u.Stage))
+	if u.Task != "" {
+		stage += " " + u.Task
+	}
+	fmt.Fprintf(&b, "%-11s", stage)
+	if u.Percent >= 0 {
+		fmt.Fprintf(&b, " %5.1f%%", u.Percent)
+	}
+	if u.Speed != nil && *u.Speed != "" {
+		fmt.Fprintf(&b, "  %s", *u.Speed)
+	}
+	if u.FPS != nil {
+		b.WriteString(i18n.Sprintf("  %.0f fps", *u.FPS))
+	}
+	if u.ETA != nil {
+		b.WriteString(i18n.Sprintf("  ETA %s", formatETA(*u.ETA)))
+	}
+	if u.Message != "" && u.Percent < 0 {
+		fmt.Fprintf(&b, "  %s", i18n.String(u.Message))
+	}
+	return b.String()
+}
+
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
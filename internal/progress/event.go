@@ -0,0 +1,243 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event is the JSON form of a progress event, as written by JSONL and posted
+// by Webhook. Type is "update", "log", or "result".
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	JobID   string    `json:"job_id"`
+	Stage   Stage     `json:"stage,omitempty"`
+	Task    string    `json:"task,omitempty"`
+	Percent *float64  `json:"percent,omitempty"` // Updates with a known percentage only
+	ETASec  *float64  `json:"eta_sec,omitempty"`
+	Bytes   *int64    `json:"bytes,omitempty"`
+	Speed   string    `json:"speed,omitempty"`
+	Message string    `json:"message,omitempty"`
+
+	Stream string `json:"stream,omitempty"` // Logs: "stdout" or "stderr"
+	Line   string `json:"line,omitempty"`
+
+	OutputPath string  `json:"output_path,omitempty"` // Results
+	RemoteURL  string  `json:"remote_url,omitempty"`
+	ElapsedSec float64 `json:"elapsed_sec,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	Skipped    string  `json:"skipped,omitempty"` // Why the job did nothing
+}
+
+func updateEvent(u Update) Event {
+	e := Event{Time: time.Now(), Type: "update", JobID: u.JobID, Stage: u.Stage, Task: u.Task, Bytes: u.Bytes, Message: u.Message}
+	if u.Percent >= 0 {
+		p := u.Percent
+		e.Percent = &p
+	}
+	if u.ETA != nil {
+		s := u.ETA.Seconds()
+		e.ETASec = &s
+	}
+	if u.Speed != nil {
+		e.Speed = *u.Speed
+	}
+	return e
+}
+
+func logEvent(l Log) Event {
+	stream := "stdout"
+	if l.Stream == StreamStderr {
+		stream = "stderr"
+	}
+	return Event{Time: time.Now(), Type: "log", JobID: l.JobID, Stream: stream, Line: l.Line}
+}
+
+func resultEvent(r Result) Event {
+	e := Event{Time: time.Now(), Type: "result", JobID: r.JobID, OutputPath: r.OutputPath, RemoteURL: r.RemoteURL, ElapsedSec: r.Times.Total.Seconds(), Skipped: r.Skipped}
+	if r.Bytes > 0 {
+		b := r.Bytes
+		e.Bytes = &b
+	}
+	if r.Err != nil {
+		e.Error = r.Err.Error()
+	}
+	return e
+}
+
+// JSONL writes every event as a line of JSON (see Event), e.g. for
+// --progress-file.
+type JSONL struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	c   io.Closer // Closed by Close; nil if the writer isn't ours
+}
+
+// NewJSONL writes events to w.
+func NewJSONL(w io.Writer) *JSONL {
+	return &JSONL{enc: json.NewEncoder(w)}
+}
+
+// CreateJSONL appends events to the file at path, creating it if needed.
+func CreateJSONL(path string) (*JSONL, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	j := NewJSONL(f)
+	j.c = f
+	return j, nil
+}
+
+func (j *JSONL) write(e Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_ = j.enc.Encode(e)
+}
+
+// Update implements Reporter.
+func (j *JSONL) Update(u Update) { j.write(updateEvent(u)) }
+
+// Log implements Reporter.
+func (j *JSONL) Log(l Log) { j.write(logEvent(l)) }
+
+// Result implements Reporter.
+func (j *JSONL) Result(r Result) { j.write(resultEvent(r)) }
+
+// Close closes the file CreateJSONL opened.
+func (j *JSONL) Close() error {
+	if j.c == nil {
+		return nil
+	}
+	return j.c.Close()
+}
+
+// Webhook posts updates and results (not logs) to a URL as JSON (see Event),
+// one request per event, from a goroutine of its own so a slow endpoint
+// doesn't hold up the jobs. Events that pile up beyond a small backlog are
+// dropped, as are events sent after Close (a job may still report once the
+// run has stopped waiting for it) and events not delivered by the time ctx is
+// done or Close gives up. Put a Throttle in front of it to keep the rate of
+// updates down.
+type Webhook struct {
+	url    string
+	client *http.Client
+	events chan Event
+	done   chan struct{}
+	ctx    context.Context // Done = stop posting
+	cancel context.CancelFunc
+
+	mu     sync.Mutex // Guards events against send after Close
+	closed bool
+}
+
+// webhookBacklog is how many events may wait for delivery.
+const webhookBacklog = 64
+
+// webhookCloseTimeout is how long Close waits for the backlog to go out.
+const webhookCloseTimeout = 10 * time.Second
+
+// NewWebhook starts posting events to url until ctx is done.
+func NewWebhook(ctx context.Context, url string) *Webhook {
+	c, cancel := context.WithCancel(ctx)
+	w := &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		events: make(chan Event, webhookBacklog),
+		done:   make(chan struct{}),
+		ctx:    c,
+		cancel: cancel,
+	}
+	go w.loop()
+	return w
+}
+
+func (w *Webhook) send(e Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.events <- e:
+	default:
+		slog.Debug("progress webhook backlog full; event dropped", "type", e.Type, "job", e.JobID)
+	}
+}
+
+// Update implements Reporter.
+func (w *Webhook) Update(u Update) { w.send(updateEvent(u)) }
+
+// Log implements Reporter; logs are not posted.
+func (w *Webhook) Log(Log) {}
+
+// Result implements Reporter.
+func (w *Webhook) Result(r Result) { w.send(resultEvent(r)) }
+
+// Close delivers the events still waiting, for up to webhookCloseTimeout or
+// until the context of NewWebhook is done; the rest are dropped, as are later
+// events.
+func (w *Webhook) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.events)
+	}
+	w.mu.Unlock()
+	t := time.NewTimer(webhookCloseTimeout)
+	defer t.Stop()
+	select {
+	case <-w.done:
+	case <-t.C:
+	}
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+func (w *Webhook) loop() {
+	defer close(w.done)
+	dropped := 0
+	for e := range w.events {
+		if w.ctx.Err() != nil {
+			dropped++
+			continue
+		}
+		if err := w.post(e); err != nil && w.ctx.Err() == nil {
+			slog.Warn("progress webhook failed", "url", w.url, "err", err)
+		}
+	}
+	if dropped > 0 {
+		slog.Warn("progress webhook stopped; events dropped", "url", w.url, "count", dropped)
+	}
+}
+
+func (w *Webhook) post(e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
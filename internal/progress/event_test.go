@@ -0,0 +1,59 @@
+package progress
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSendAfterClose(t *testing.T) {
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(context.Background(), srv.URL)
+	w.Result(Result{JobID: "a"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// A job finishing after the run stopped waiting must not panic
+	w.Update(Update{JobID: "b", Stage: StageEncoding})
+	w.Result(Result{JobID: "b"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if got := posts.Load(); got != 1 {
+		t.Errorf("posted %d events, want 1", got)
+	}
+}
+
+func TestWebhookCloseStopsWithContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewWebhook(ctx, srv.URL)
+	for i := 0; i < webhookBacklog; i++ {
+		w.Result(Result{JobID: "a"})
+	}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Close took %v after the context was done", d)
+	}
+}
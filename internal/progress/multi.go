@@ -0,0 +1,141 @@
+package progress
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// Multi passes every event on to each of its reporters, in order.
+type Multi []Reporter
+
+// NewMulti combines reporters, skipping nil ones. A single reporter is
+// returned as is; none gives nil.
+func NewMulti(reporters ...Reporter) Reporter {
+	var m Multi
+	for _, r := range reporters {
+		if r != nil {
+			m = append(m, r)
+		}
+	}
+	switch len(m) {
+	case 0:
+		return nil
+	case 1:
+		return m[0]
+	}
+	return m
+}
+
+// Update implements Reporter.
+func (m Multi) Update(u Update) {
+	for _, r := range m {
+		r.Update(u)
+	}
+}
+
+// Log implements Reporter.
+func (m Multi) Log(l Log) {
+	for _, r := range m {
+		r.Log(l)
+	}
+}
+
+// Result implements Reporter.
+func (m Multi) Result(r Result) {
+	for _, rep := range m {
+		rep.Result(r)
+	}
+}
+
+// Close closes the reporters that need it (see Close).
+func (m Multi) Close() error {
+	var errs []error
+	for _, r := range m {
+		errs = append(errs, Close(r))
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes and releases r if it holds anything, such as a Throttle, a
+// JSONL file, or a Webhook; other reporters are left alone.
+func Close(r Reporter) error {
+	switch c := r.(type) {
+	case io.Closer:
+		return c.Close()
+	case interface{ Close() }:
+		c.Close()
+	}
+	return nil
+}
+
+// Middleware wraps a Reporter to change what reaches it.
+type Middleware func(Reporter) Reporter
+
+// Chain wraps r in mws; the first one sees the events first.
+func Chain(r Reporter, mws ...Middleware) Reporter {
+	for i := len(mws) - 1; i >= 0; i-- {
+		r = mws[i](r)
+	}
+	return r
+}
+
+// Filter passes on only the updates keep accepts. Logs and results always
+// go through.
+func Filter(keep func(Update) bool) Middleware {
+	return func(next Reporter) Reporter {
+		return filtered{next: next, keep: keep}
+	}
+}
+
+type filtered struct {
+	next Reporter
+	keep func(Update) bool
+}
+
+func (f filtered) Update(u Update) {
+	if f.keep(u) {
+		f.next.Update(u)
+	}
+}
+func (f filtered) Log(l Log)       { f.next.Log(l) }
+func (f filtered) Result(r Result) { f.next.Result(r) }
+func (f filtered) Close() error    { return Close(f.next) }
+
+// Prefix puts p in front of every job ID, e.g. to tell apart the jobs of
+// several runs that share a reporter.
+func Prefix(p string) Middleware {
+	return func(next Reporter) Reporter {
+		return prefixed{next: next, p: p}
+	}
+}
+
+type prefixed struct {
+	next Reporter
+	p    string
+}
+
+func (pr prefixed) Update(u Update) {
+	u.JobID = pr.p + u.JobID
+	pr.next.Update(u)
+}
+
+func (pr prefixed) Log(l Log) {
+	l.JobID = pr.p + l.JobID
+	pr.next.Log(l)
+}
+
+func (pr prefixed) Result(r Result) {
+	r.JobID = pr.p + r.JobID
+	pr.next.Result(r)
+}
+
+func (pr prefixed) Close() error { return Close(pr.next) }
+
+// Throttled is NewThrottle as a Middleware. Close the resulting reporter when
+// done.
+func Throttled(interval time.Duration) Middleware {
+	return func(next Reporter) Reporter {
+		return NewThrottle(next, interval)
+	}
+}
@@ -1,6 +1,10 @@
 package progress
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Stage identifies a high-level step in the pipeline.
 type Stage string
@@ -11,6 +15,7 @@ const (
 	StageDownloading Stage = "downloading"
 	StageMerging     Stage = "merging"
 	StageEncoding    Stage = "encoding"
+	StageUploading   Stage = "uploading"
 	StageCompleted   Stage = "completed"
 	StageError       Stage = "error"
 )
@@ -33,7 +38,11 @@ type Update struct {
 	ETA     *time.Duration // optional
 	Bytes   *int64         // optional cumulative bytes
 	Speed   *string        // optional, e.g., "2.5MiB/s" or "1.2x"
+	FPS     *float64       // optional, frames encoded per second
+	Bitrate *string        // optional output bitrate so far, e.g., "1502.3kbits/s"
+	Frame   *int64         // optional frames encoded so far
 	Message string         // short human-friendly status line
+	Task    string         // Output being made when a job has several (e.g. "gif"); "" = the job as a whole
 }
 
 // Log is a structured log line associated with a job.
@@ -48,7 +57,49 @@ type Result struct {
 	JobID      string
 	OutputPath string
 	Bytes      int64
-	Err        error // nil on success
+	RemoteURL  string     // Where --upload put the output, if anywhere
+	Times      StageTimes // Time spent per stage; zero in dry runs
+	Err        error      // nil on success
+	Skipped    string     // Why the job did nothing (another job was snipping the video); "" = it ran
+}
+
+// StageTimes is how long a job spent in each stage, wall clock. A stage that
+// didn't run (or, for Metadata, ran as part of the download) is 0.
+type StageTimes struct {
+	Metadata time.Duration
+	Download time.Duration
+	Encode   time.Duration // All outputs of the job
+	Upload   time.Duration
+	Total    time.Duration // From start to finish, including the steps between stages
+}
+
+// String lists the stages that ran, e.g. "metadata 1.2s, download 14s,
+// encode 1m3s; 1m19s in all".
+func (t StageTimes) String() string {
+	var parts []string
+	for _, s := range []struct {
+		name string
+		d    time.Duration
+	}{{"metadata", t.Metadata}, {"download", t.Download}, {"encode", t.Encode}, {"upload", t.Upload}} {
+		if s.d > 0 {
+			parts = append(parts, s.name+" "+roundElapsed(s.d))
+		}
+	}
+	if t.Total <= 0 {
+		return strings.Join(parts, ", ")
+	}
+	if len(parts) == 0 {
+		return roundElapsed(t.Total)
+	}
+	return strings.Join(parts, ", ") + "; " + roundElapsed(t.Total) + " in all"
+}
+
+// roundElapsed keeps a tenth of a second for short durations only.
+func roundElapsed(d time.Duration) string {
+	if d < 10*time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
 }
 
 // Reporter is implemented by UI or any observer interested in progress events.
@@ -56,4 +107,33 @@ type Reporter interface {
 	Update(u Update)
 	Log(l Log)
 	Result(r Result)
-}
\ No newline at end of file
+}
+
+// ParseRate converts a transfer speed as printed by yt-dlp (e.g. "2.50MiB/s",
+// "850.3KiB/s") into bytes per second.
+func ParseRate(s string) (float64, bool) {
+	return ParseSize(strings.TrimSuffix(strings.TrimSpace(s), "/s"))
+}
+
+// ParseSize converts a size as printed by yt-dlp (e.g. "10.00MiB", "~850.3KiB")
+// into bytes. A leading "~" (an estimate) is ignored.
+func ParseSize(s string) (float64, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "~")
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+		{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"kB", 1e3}, {"B", 1},
+	}
+	for _, u := range units {
+		if num, found := strings.CutSuffix(s, u.suffix); found {
+			v, err := strconv.ParseFloat(num, 64)
+			if err != nil || v < 0 {
+				return 0, false
+			}
+			return v * u.mult, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,197 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle passes updates on to another Reporter at a steady cadence: at most
+// one per job per interval, the latest one, with the percentage kept from
+// going backwards and the ETA smoothed. A job's first update, stage changes,
+// and 100% go through at once (after whatever was held back), so the last
+// state of a stage is never lost. Logs and results are not throttled.
+//
+// Updates and results reach next in order from a goroutine of the Throttle's
+// own, so a slow sink doesn't hold up the jobs reporting, and a sink may
+// report back into the Throttle. Call Close when done to deliver what is
+// still held back.
+type Throttle struct {
+	mu   sync.Mutex
+	next Reporter
+	jobs map[string]*throttledJob
+	out  []throttledCall // Waiting to be passed on, in order
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// throttledCall is an update or a result to pass on.
+type throttledCall struct {
+	update *Update
+	result *Result
+}
+
+type throttledJob struct {
+	stage   Stage
+	task    string
+	percent float64        // Highest percentage of the stage so far
+	eta     *time.Duration // Smoothed
+	pending *Update        // Latest update not yet passed on
+}
+
+// etaWeight is how much a new ETA counts against the smoothed one; yt-dlp's
+// estimate swings with every burst of the connection.
+const etaWeight = 0.3
+
+// newPassDrop is how many points the percentage must fall to start over.
+const newPassDrop = 50
+
+// NewThrottle wraps next, passing updates on every interval.
+func NewThrottle(next Reporter, interval time.Duration) *Throttle {
+	t := &Throttle{
+		next: next,
+		jobs: make(map[string]*throttledJob),
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go t.loop(interval)
+	return t
+}
+
+// Update implements Reporter.
+func (t *Throttle) Update(u Update) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j, ok := t.jobs[u.JobID]
+	if !ok || u.Stage != j.stage || u.Task != j.task {
+		if ok {
+			t.flush(j)
+		}
+		j = &throttledJob{stage: u.Stage, task: u.Task, percent: -1}
+		t.jobs[u.JobID] = j
+		j.smooth(&u)
+		t.enqueue(throttledCall{update: &u})
+		return
+	}
+	j.smooth(&u)
+	j.pending = &u
+	if u.Percent >= 100 {
+		t.flush(j)
+	}
+}
+
+// Log implements Reporter.
+func (t *Throttle) Log(l Log) {
+	t.next.Log(l)
+}
+
+// Result implements Reporter. The job's held-back update goes first.
+func (t *Throttle) Result(r Result) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if j, ok := t.jobs[r.JobID]; ok {
+		t.flush(j)
+		delete(t.jobs, r.JobID)
+	}
+	t.enqueue(throttledCall{result: &r})
+}
+
+// Close stops the cadence, delivers the updates still held back, and closes
+// the wrapped reporter (see Close).
+func (t *Throttle) Close() error {
+	close(t.stop)
+	<-t.done
+	return Close(t.next)
+}
+
+func (t *Throttle) loop(interval time.Duration) {
+	defer close(t.done)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-t.stop:
+			t.flushAll()
+			t.send()
+			return
+		case <-tick.C:
+			t.flushAll()
+			t.send()
+		case <-t.wake:
+			t.send()
+		}
+	}
+}
+
+func (t *Throttle) flushAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, j := range t.jobs {
+		t.flush(j)
+	}
+}
+
+// flush queues j's held-back update, if any. t.mu must be held.
+func (t *Throttle) flush(j *throttledJob) {
+	if j.pending == nil {
+		return
+	}
+	u := *j.pending
+	j.pending = nil
+	t.enqueue(throttledCall{update: &u})
+}
+
+// enqueue queues c to be passed on by loop. t.mu must be held, which keeps
+// a job's updates in order.
+func (t *Throttle) enqueue(c throttledCall) {
+	t.out = append(t.out, c)
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// send passes on the queued calls, without holding t.mu.
+func (t *Throttle) send() {
+	for {
+		t.mu.Lock()
+		calls := t.out
+		t.out = nil
+		t.mu.Unlock()
+		if len(calls) == 0 {
+			return
+		}
+		for _, c := range calls {
+			if c.update != nil {
+				t.next.Update(*c.update)
+			} else {
+				t.next.Result(*c.result)
+			}
+		}
+	}
+}
+
+// smooth keeps u's percentage from dipping below what the stage already
+// showed and averages its ETA with the previous ones. A big drop is a new
+// pass rather than a dip: yt-dlp counts video and audio from 0 separately.
+func (j *throttledJob) smooth(u *Update) {
+	if u.Percent >= 0 {
+		if u.Percent > j.percent || u.Percent < j.percent-newPassDrop {
+			j.percent = u.Percent
+		}
+		u.Percent = j.percent
+	}
+	if u.ETA == nil {
+		j.eta = nil
+		return
+	}
+	eta := *u.ETA
+	if j.eta != nil {
+		eta = time.Duration(etaWeight*float64(eta) + (1-etaWeight)*float64(*j.eta))
+	}
+	j.eta = &eta
+	shown := eta
+	u.ETA = &shown
+}
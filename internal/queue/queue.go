@@ -0,0 +1,236 @@
+// Package queue keeps named lists of URLs waiting to be snipped, so links can
+// be collected during the day (from any shell) and processed in one run later.
+package queue
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ig2wa/internal/dirs"
+	"ig2wa/internal/util"
+)
+
+// DefaultName is the queue used when none is named.
+const DefaultName = "default"
+
+const ext = ".jsonl"
+
+// Item is one queued URL.
+type Item struct {
+	URL   string    `json:"url"`
+	Added time.Time `json:"added"`
+	Tries int       `json:"tries,omitempty"` // Failed runs so far
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// CheckName rejects queue names that can't be file names.
+func CheckName(name string) error {
+	if !validName.MatchString(name) || strings.Contains(name, ".claimed") {
+		return fmt.Errorf("invalid queue name %q (use letters, digits, '.', '_', '-')", name)
+	}
+	return nil
+}
+
+// Dir returns where queues are stored (in the state dir).
+func Dir() (string, error) {
+	d, err := dirs.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "queues"), nil
+}
+
+func path(name string) (string, error) {
+	if err := CheckName(name); err != nil {
+		return "", err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+ext), nil
+}
+
+// Add appends items to the named queue. Each item is one small O_APPEND
+// write, so adds from several shells at once don't interleave.
+func Add(name string, items ...Item) error {
+	p, err := path(name)
+	if err != nil {
+		return err
+	}
+	if err := dirs.Ensure(filepath.Dir(p)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		if it.Added.IsZero() {
+			it.Added = time.Now()
+		}
+		line, err := json.Marshal(it)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// List returns the named queue's items, oldest first.
+func List(name string) ([]Item, error) {
+	p, err := path(name)
+	if err != nil {
+		return nil, err
+	}
+	return read(p)
+}
+
+// Names returns the queues that exist, sorted.
+func Names() ([]string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var out []string
+	for _, e := range entries {
+		n := e.Name()
+		if !e.IsDir() && strings.HasSuffix(n, ext) && !strings.Contains(n, ".claimed") {
+			out = append(out, strings.TrimSuffix(n, ext))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Clear empties the named queue.
+func Clear(name string) error {
+	p, err := path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Claim takes every item off the named queue for processing. The queue file
+// is renamed away first, so URLs added meanwhile start a fresh queue instead
+// of being lost. Call Done with the claim once the items have been handled.
+func Claim(name string) (*Claimed, error) {
+	p, err := path(name)
+	if err != nil {
+		return nil, err
+	}
+	recoverClaims(name, p)
+	claimed := strings.TrimSuffix(p, ext) + fmt.Sprintf(".claimed-%d", os.Getpid()) + ext
+	if err := os.Rename(p, claimed); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Claimed{name: name}, nil
+		}
+		return nil, err
+	}
+	items, err := read(claimed)
+	if err != nil {
+		// Put the file back as it is rather than lose the items read would
+		// skip (e.g. past a line too long). Linking won't clobber a queue an
+		// add started meanwhile; the claimed file then stays for a later run.
+		if lerr := os.Link(claimed, p); lerr != nil {
+			return nil, fmt.Errorf("%w (the items remain in %s)", err, claimed)
+		}
+		_ = os.Remove(claimed)
+		return nil, err
+	}
+	return &Claimed{name: name, path: claimed, Items: items}, nil
+}
+
+// Claimed is a batch of items taken off a queue by Claim.
+type Claimed struct {
+	Items []Item
+
+	name string
+	path string
+}
+
+// Done finishes a claim. The failed items go back on the queue with their
+// try count raised, and the unfinished ones (e.g. after an interrupt) as they
+// were, as do any that an add slipped into the claimed file after it was
+// read; the claimed file is then removed.
+func (c *Claimed) Done(failed, unfinished []Item) error {
+	if c.path == "" {
+		return nil
+	}
+	var back []Item
+	for _, it := range failed {
+		it.Tries++
+		back = append(back, it)
+	}
+	back = append(back, unfinished...)
+	if late, err := read(c.path); err == nil && len(late) > len(c.Items) {
+		back = append(back, late[len(c.Items):]...)
+	}
+	if len(back) > 0 {
+		if err := Add(c.name, back...); err != nil {
+			return fmt.Errorf("requeue %d item(s): %w (they remain in %s)", len(back), err, c.path)
+		}
+	}
+	return os.Remove(c.path)
+}
+
+// recoverClaims puts the items of claims whose run died (crash, kill) back on
+// the queue at p.
+func recoverClaims(name, p string) {
+	matches, _ := filepath.Glob(strings.TrimSuffix(p, ext) + ".claimed-*" + ext)
+	for _, m := range matches {
+		pidStr := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), name+".claimed-"), ext)
+		if pid, err := strconv.Atoi(pidStr); err != nil || util.ProcessAlive(pid) {
+			continue
+		}
+		items, err := read(m)
+		if err == nil && Add(name, items...) == nil {
+			_ = os.Remove(m)
+		}
+	}
+}
+
+// read loads a queue file; a missing file is an empty queue and malformed
+// lines are skipped.
+func read(p string) ([]Item, error) {
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Item
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var it Item
+		if json.Unmarshal(sc.Bytes(), &it) == nil && it.URL != "" {
+			out = append(out, it)
+		}
+	}
+	return out, sc.Err()
+}
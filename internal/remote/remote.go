@@ -0,0 +1,228 @@
+// Package remote is the client side of other machines' `sniplette daemon
+// --listen`, shared by remote encodes (see encoder.RemoteBackend) and remote
+// jobs (see pipeline.Service): the endpoints, how busy they are, and
+// fetching what they made.
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ig2wa/internal/util"
+)
+
+// Local in a list of endpoints stands for this machine.
+const Local = "local"
+
+// Paths of a server, relative to its base URL.
+const (
+	OutputsPath = "/v1/outputs/"
+	LoadPath    = "/v1/load"
+)
+
+// endpointRetry is how long an endpoint that failed is passed over while
+// others are available.
+const endpointRetry = time.Minute
+
+// loadTimeout bounds asking an endpoint for its load.
+const loadTimeout = 5 * time.Second
+
+// Load is the reply to GET LoadPath: how busy a server is with the encodes
+// and jobs of all its clients.
+type Load struct {
+	Encodes int `json:"encodes"` // Encodes and jobs being uploaded, waiting for a worker, or running
+	Workers int `json:"workers"`
+}
+
+// EndpointError is a failure to reach or talk to an endpoint, as opposed to
+// the work failing there.
+type EndpointError struct{ Err error }
+
+func (e *EndpointError) Error() string { return e.Err.Error() }
+func (e *EndpointError) Unwrap() error { return e.Err }
+
+// Host returns the host of the endpoint base, for messages.
+func Host(base string) string {
+	if u, err := url.Parse(base); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return base
+}
+
+// SetAuth adds the bearer token to req.
+func SetAuth(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// StatusError describes a failed request, with the start of the server's
+// message.
+func StatusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if s := strings.TrimSpace(string(msg)); s != "" {
+		return fmt.Errorf("%s: %s", resp.Status, s)
+	}
+	return fmt.Errorf("%s", resp.Status)
+}
+
+// FailedStatus reports whether a request answered with code failed because
+// of the endpoint (see EndpointError) rather than the work.
+func FailedStatus(code int) bool {
+	return code >= 500 || code == http.StatusUnauthorized
+}
+
+// Fetch downloads the output name of the server at base to dst.
+func Fetch(ctx context.Context, base, name, token, dst string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+OutputsPath+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	SetAuth(req, token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch remote output: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch remote output: %w", StatusError(resp))
+	}
+	if err := util.EnsureDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("fetch remote output: %w", err)
+	}
+	return f.Close()
+}
+
+// GetLoad asks the server at base for its load. A server too old to report
+// one counts as having one worker and no other clients.
+func GetLoad(ctx context.Context, base, token string) (Load, error) {
+	ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+	defer cancel()
+	var l Load
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+LoadPath, nil)
+	if err != nil {
+		return l, err
+	}
+	SetAuth(req, token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return l, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(&l); err != nil {
+			return l, fmt.Errorf("bad load reply: %w", err)
+		}
+		return l, nil
+	case http.StatusNotFound:
+		return l, nil
+	}
+	return l, StatusError(resp)
+}
+
+// Pool tracks the work this process runs on each endpoint, and the ones
+// that failed recently, across the jobs of a run.
+type Pool struct {
+	mu   sync.Mutex
+	busy map[string]int       // Running here
+	down map[string]time.Time // Failed; passed over until then
+}
+
+// NewPool returns an empty pool.
+func NewPool() *Pool {
+	return &Pool{busy: map[string]int{}, down: map[string]time.Time{}}
+}
+
+// Acquire picks the least loaded endpoint of list not in skip, preferring
+// ones that haven't failed lately (in list order on ties), and counts it
+// busy. A server's load is what it reports (see Load), so the work of other
+// sniplette processes and machines counts too; an endpoint that doesn't
+// answer is marked down. Local has one worker.
+func (p *Pool) Acquire(ctx context.Context, list []string, skip map[string]bool, token string) (string, bool) {
+	now := time.Now()
+	loads := make([]Load, len(list))
+	var wg sync.WaitGroup
+	for i, ep := range list {
+		if skip[ep] || ep == Local || p.isDown(ep, now) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, ep string) {
+			defer wg.Done()
+			l, err := GetLoad(ctx, ep, token)
+			if err != nil {
+				slog.Warn("remote endpoint failed", "endpoint", ep, "err", err)
+				p.markDown(ep)
+				return
+			}
+			loads[i] = l
+		}(i, ep)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now = time.Now()
+	best, bestDown := -1, true
+	for i, ep := range list {
+		if skip[ep] {
+			continue
+		}
+		// Our own work may not have reached the server yet
+		loads[i].Encodes = max(loads[i].Encodes, p.busy[ep])
+		loads[i].Workers = max(loads[i].Workers, 1)
+		down := now.Before(p.down[ep])
+		if best < 0 || (bestDown && !down) || (down == bestDown && loads[i].Encodes*loads[best].Workers < loads[best].Encodes*loads[i].Workers) {
+			best, bestDown = i, down
+		}
+	}
+	if best < 0 {
+		return "", false
+	}
+	p.busy[list[best]]++
+	return list[best], true
+}
+
+func (p *Pool) isDown(ep string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return now.Before(p.down[ep])
+}
+
+// markDown passes ep over for endpointRetry.
+func (p *Pool) markDown(ep string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.down[ep] = time.Now().Add(endpointRetry)
+}
+
+// Release ends work on ep; failed marks ep down for endpointRetry.
+func (p *Pool) Release(ep string, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.busy[ep]--
+	if failed {
+		p.down[ep] = time.Now().Add(endpointRetry)
+	} else {
+		delete(p.down, ep)
+	}
+}
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"ig2wa/internal/i18n"
+)
+
+// In accessible mode (--accessible) the TUI draws no spinners, progress bars,
+// or thumbnails. Jobs are announced instead, one plain line each time one
+// starts, enters a stage, passes another accessibleStep percent of it, or
+// finishes, printed above a static hint line, so a screen reader or a
+// terminal recording reads them in order. The lines always take the form
+// "Job N of M: <what happened>". When the TUI exits, the run's summary is
+// printed in place of the hint line.
+
+// accessibleStep is the progress, in percent, between a stage's announcements.
+const accessibleStep = 25
+
+// announce returns a command printing what changed about js since its last
+// announcement, or nil when nothing did or the TUI isn't in accessible mode.
+func (m Model) announce(js *jobState) tea.Cmd {
+	if !m.opts.Accessible || js.saidDone {
+		return nil
+	}
+	var what string
+	switch {
+	case js.done && js.err != nil:
+		js.saidDone = true
+		what = i18n.Sprintf("failed. %s", js.status)
+	case js.done:
+		js.saidDone = true
+		what = i18n.Sprintf("done. %s", js.status)
+	case js.stage != js.said:
+		js.said, js.saidPct = js.stage, 0
+		what = i18n.String(string(js.stage))
+		if js.status != "" && !strings.EqualFold(js.status, string(js.stage)) {
+			what += ", " + js.status
+		}
+	default:
+		step := int(js.percent) / accessibleStep * accessibleStep
+		if step <= js.saidPct || step >= 100 {
+			return nil
+		}
+		js.saidPct = step
+		what = i18n.Sprintf("%s, %d percent", i18n.String(string(js.stage)), step)
+	}
+	return m.say(js, what)
+}
+
+// announceStart returns a command printing that js started.
+func (m Model) announceStart(js *jobState) tea.Cmd {
+	if !m.opts.Accessible {
+		return nil
+	}
+	js.said, js.saidPct = js.stage, 0
+	return m.say(js, i18n.Sprintf("started, %s", js.url))
+}
+
+// say prints one announcement about js.
+func (m Model) say(js *jobState, what string) tea.Cmd {
+	n := 0
+	for i, id := range m.jobOrder {
+		if id == js.id {
+			n = i + 1
+		}
+	}
+	return tea.Println(i18n.Sprintf("Job %d of %d: %s", n, len(m.jobOrder), what))
+}
+
+// sayAll prints a line that isn't about one job, in accessible mode.
+func (m Model) sayAll(line string) tea.Cmd {
+	if !m.opts.Accessible {
+		return nil
+	}
+	return tea.Println(line)
+}
+
+// quit exits the TUI. In accessible mode it first prints the summary the
+// TUI shows below the jobs: the completed files and the total downloaded.
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	m.quitting = true
+	if !m.opts.Accessible {
+		return m, tea.Quit
+	}
+	var lines []string
+	if completed := m.completedFiles(); len(completed) > 0 {
+		lines = append(lines, i18n.String("✓ Completed Files:"))
+		for _, path := range completed {
+			lines = append(lines, "  • "+path)
+		}
+	}
+	if dl := m.downloadedBytes(); dl > 0 {
+		lines = append(lines, i18n.Sprintf("Downloaded %s in total", humanizeBytes(dl)))
+	}
+	if len(lines) == 0 {
+		return m, tea.Quit
+	}
+	return m, tea.Sequence(tea.Println(strings.Join(lines, "\n")), tea.Quit)
+}
+
+// viewAccessible is the View of accessible mode: the help or the format
+// picker when open, and otherwise a hint line that stays the same, so that
+// nothing is redrawn while jobs run. The last frame is empty, leaving the
+// announcements and the summary as the final output.
+func (m Model) viewAccessible() string {
+	if m.quitting {
+		return ""
+	}
+	if m.showHelp {
+		return m.viewHelp()
+	}
+	if js := m.activePicker(); js != nil {
+		return m.viewPicker(js)
+	}
+	return i18n.String("Press q to quit or ? for help.")
+}
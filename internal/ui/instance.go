@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"ig2wa/internal/daemon"
+)
+
+// maxForwardedJobs caps the jobs a single-instance TUI takes from other
+// processes, so resultCh always has room for every job's result.
+const maxForwardedJobs = 1000
+
+// instanceMsg is a request from another sniplette process (--single-instance);
+// the TUI answers it on reply.
+type instanceMsg struct {
+	req   daemon.Request
+	reply chan<- daemon.Response
+	// Set by whichever of the TUI and serveInstance gets to the request
+	// first: the TUI to answer it, serveInstance to give up on it
+	taken *atomic.Bool
+}
+
+// serveInstance takes requests on the daemon socket for the TUI until ctx is
+// done, then closes done. Jobs handed over join the TUI's list, so a second
+// launch adds its URLs here instead of opening another TUI.
+func serveInstance(ctx context.Context, prog *tea.Program, done chan<- struct{}) {
+	defer close(done)
+	_ = daemon.ServeInstance(ctx, func(req daemon.Request) daemon.Response {
+		reply := make(chan daemon.Response, 1)
+		taken := new(atomic.Bool)
+		go prog.Send(instanceMsg{req: req, reply: reply, taken: taken})
+		select {
+		case resp := <-reply:
+			return resp
+		case <-ctx.Done():
+			if taken.CompareAndSwap(false, true) {
+				return daemon.Response{Error: "the TUI is quitting", Instance: daemon.InstanceTUI}
+			}
+		case <-time.After(5 * time.Second):
+			if taken.CompareAndSwap(false, true) {
+				return daemon.Response{Error: "the TUI did not answer", Instance: daemon.InstanceTUI}
+			}
+		}
+		// The TUI took the request just in time; its answer is on the way
+		return <-reply
+	})
+}
+
+// answer handles a request from another process: add queues its URLs as new
+// jobs with the options they came with, and status lists every job.
+func (m *Model) answer(req daemon.Request) (daemon.Response, tea.Cmd) {
+	resp := daemon.Response{Instance: daemon.InstanceTUI}
+	switch req.Op {
+	case daemon.OpAdd:
+		if m.shuttingDown || m.jobsCtx.Err() != nil {
+			resp.Error = "the TUI is shutting down"
+			return resp, nil
+		}
+		if len(m.jobOrder)+len(req.URLs) > cap(m.resultCh) {
+			resp.Error = "the TUI takes no more jobs; start a new run once it is done"
+			return resp, nil
+		}
+		var cmds []tea.Cmd
+		for _, u := range req.URLs {
+			id := toID(len(m.jobOrder), u)
+			js := newJobState(id, u, m.styles)
+			js.priority = req.Options.Priority
+			opts := req.Options
+			opts.RemoteToken = m.opts.RemoteToken // never sent over the socket
+			js.opts = &opts
+			m.jobs[id] = &js
+			m.jobOrder = append(m.jobOrder, id)
+			m.queue.Push(id, js.priority)
+			resp.Jobs = append(resp.Jobs, m.instanceJob(len(m.jobOrder)-1))
+			if !m.opts.Accessible {
+				cmds = append(cmds, js.spinner.Tick)
+			}
+		}
+		if m.depsChecked && m.depsErr == nil {
+			cmds = append(cmds, m.startNextWorkers())
+		}
+		return resp, tea.Batch(cmds...)
+	case daemon.OpStatus:
+		for i := range m.jobOrder {
+			resp.Jobs = append(resp.Jobs, m.instanceJob(i))
+		}
+	case daemon.OpStop:
+		resp.Error = "the running instance is a TUI; quit it there"
+	default:
+		resp.Error = fmt.Sprintf("unknown operation %q", req.Op)
+	}
+	return resp, nil
+}
+
+// instanceJob describes the i-th job in the daemon's terms; IDs count from 1
+// in list order.
+func (m Model) instanceJob(i int) daemon.Job {
+	js := m.jobs[m.jobOrder[i]]
+	j := daemon.Job{ID: i + 1, URL: js.url, State: daemon.StateQueued, Added: js.added}
+	switch {
+	case js.done && js.err != nil:
+		j.State, j.Error = daemon.StateFailed, js.err.Error()
+	case js.done:
+		j.State = daemon.StateDone
+	case js.started:
+		j.State = daemon.StateRunning
+	}
+	return j
+}
@@ -1,8 +1,11 @@
 package ui
 
 import (
+	"time"
+
 	bubblesprogress "github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	"ig2wa/internal/model"
 	"ig2wa/internal/progress"
 )
 
@@ -13,18 +16,40 @@ type jobState struct {
 	status string
 	err    error
 	done   bool
+	// Failed because the TUI canceled it on quit or at the end of a drain
+	canceled bool
 
 	outputPath string
 	bytes      int64
+	downloaded int64   // Source bytes transferred so far, when the downloader reports them
 	percent    float64 // -1 means unknown
+	encStats   string  // Frame, fps, bitrate, and speed of a running encode
 
 	spinner spinner.Model
 	bar     bubblesprogress.Model
 
-	started bool
+	priority  model.Priority
+	opts      *model.CLIOptions // Options of its own (a batch entry with flags, or handed over with --single-instance); nil = the run's
+	started   bool
+	added     time.Time
+	startedAt time.Time
+	eta       *time.Duration      // Of the current stage, when the reporter knows it
+	times     progress.StageTimes // Set when the job finishes
+
+	// Pending source format choice (nil when not awaiting input)
+	picker *formatPicker
+
+	// Pre-rendered thumbnail (empty when unavailable or disabled)
+	thumb string
 
 	// Optional: recent logs (kept small)
 	logsRing []string
+
+	// Last announced in accessible mode: the stage, its progress, and
+	// whether the outcome was
+	said     progress.Stage
+	saidPct  int
+	saidDone bool
 }
 
 func newJobState(id, url string, styles Styles) jobState {
@@ -42,5 +67,6 @@ func newJobState(id, url string, styles Styles) jobState {
 		percent: -1,
 		spinner: sp,
 		bar:     bar,
+		added:   time.Now(),
 	}
-}
\ No newline at end of file
+}
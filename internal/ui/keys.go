@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"ig2wa/internal/i18n"
+)
+
+// keyMap lists all TUI actions. Bindings can be remapped via the "keys"
+// config section, e.g. keys: { quit: ["x", "ctrl+c"] }.
+type keyMap struct {
+	Quit   key.Binding
+	Help   key.Binding
+	Up     key.Binding
+	Down   key.Binding
+	Select key.Binding
+	Cancel key.Binding
+	Raise  key.Binding
+	Lower  key.Binding
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Quit:   key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", i18n.String("quit"))),
+		Help:   key.NewBinding(key.WithKeys("?"), key.WithHelp("?", i18n.String("toggle help"))),
+		Up:     key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", i18n.String("move up"))),
+		Down:   key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", i18n.String("move down"))),
+		Select: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", i18n.String("select format"))),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", i18n.String("use automatic format"))),
+		Raise:  key.NewBinding(key.WithKeys("+", "="), key.WithHelp("+", i18n.String("raise priority of queued job"))),
+		Lower:  key.NewBinding(key.WithKeys("-"), key.WithHelp("-", i18n.String("lower priority of queued job"))),
+	}
+}
+
+// bindings maps config names to the bindings they control.
+func (k *keyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":   &k.Quit,
+		"help":   &k.Help,
+		"up":     &k.Up,
+		"down":   &k.Down,
+		"select": &k.Select,
+		"cancel": &k.Cancel,
+		"raise":  &k.Raise,
+		"lower":  &k.Lower,
+	}
+}
+
+// applyOverrides remaps bindings from config. Unknown actions are ignored.
+func (k keyMap) applyOverrides(overrides map[string][]string) keyMap {
+	all := k.bindings()
+	for name, keys := range overrides {
+		b, ok := all[strings.ToLower(name)]
+		if !ok || len(keys) == 0 {
+			continue
+		}
+		desc := b.Help().Desc
+		b.SetKeys(keys...)
+		b.SetHelp(strings.Join(keys, "/"), desc)
+	}
+	return k
+}
+
+// ShortHelp implements help.KeyMap.
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Help, k.Quit}
+}
+
+// FullHelp implements help.KeyMap.
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Help, k.Quit},
+		{k.Up, k.Down, k.Select, k.Cancel},
+		{k.Raise, k.Lower},
+	}
+}
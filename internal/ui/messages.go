@@ -1,6 +1,9 @@
 package ui
 
-import "ig2wa/internal/progress"
+import (
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/progress"
+)
 
 type depsCheckedMsg struct {
 	DownloaderPath string
@@ -8,10 +11,17 @@ type depsCheckedMsg struct {
 	Err            error
 }
 
+// jobStartMsg reports that a job's goroutine has begun running it.
 type jobStartMsg struct {
 	JobID string
 }
 
+// jobExitMsg reports that a job's goroutine has returned, which frees its
+// slot.
+type jobExitMsg struct {
+	JobID string
+}
+
 type jobUpdateMsg struct {
 	U progress.Update
 }
@@ -24,4 +34,27 @@ type jobResultMsg struct {
 	R progress.Result
 }
 
-type allDoneMsg struct{}
\ No newline at end of file
+type jobThumbMsg struct {
+	JobID string
+	Art   string // Pre-rendered thumbnail cells
+}
+
+type jobFormatsMsg struct {
+	JobID   string
+	Formats []downloader.Format
+	Reply   chan string // Receives the chosen selector ("" = default)
+}
+
+type allDoneMsg struct{}
+
+// shutdownTimeoutMsg ends the wait for canceled jobs to stop.
+type shutdownTimeoutMsg struct{}
+
+// drainMsg asks the TUI to wind down gracefully (SIGTERM, SIGHUP).
+type drainMsg struct{}
+
+// graceOverMsg ends a drain's grace period: running jobs are canceled.
+type graceOverMsg struct{}
+
+// schedTickMsg asks the adaptive scheduler to re-evaluate the job limit.
+type schedTickMsg struct{}
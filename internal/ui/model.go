@@ -8,22 +8,34 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	bubblesprogress "github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
-	"ig2wa/internal/downloader"
-	"ig2wa/internal/encoder"
+	"ig2wa/internal/i18n"
 	"ig2wa/internal/model"
+	"ig2wa/internal/pipeline"
 	"ig2wa/internal/progress"
 	"ig2wa/internal/util"
 	"ig2wa/internal/util/deps"
 	"ig2wa/internal/util/media"
-	"ig2wa/internal/pipeline"
 )
 
 type Model struct {
-	ctx    context.Context
+	ctx    context.Context // Done once the TUI stops reading events
 	cancel context.CancelFunc
+	// The jobs' context, canceled first on quit; the TUI then keeps running
+	// until every job has stopped and cleaned up (see shuttingDown)
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	// Quit was pressed or a drain requested: no new jobs start, and the TUI
+	// exits when the last running job's goroutine returns or
+	// shutdownTimeout passes after they were canceled
+	shuttingDown bool
+	draining     bool // Running jobs get opts.ShutdownGrace before they are canceled
 
 	// App state (deps)
 	depsChecked    bool
@@ -37,56 +49,113 @@ type Model struct {
 	jobOrder []string
 	jobs     map[string]*jobState
 	selected int
-	workers  int
-	running  int
-	next     int // next index in urls to start
+	sched    *pipeline.Scheduler
+	running  map[string]bool    // Jobs whose goroutine hasn't returned yet
+	queue    *pipeline.JobQueue // Jobs not started yet, by priority
+	// Closed once the last job launched has begun running; the next one
+	// waits for it, so jobs start in the order queue hands them out
+	lastStart chan struct{}
+	report    *pipeline.Report // Collects finished jobs for --report; nil = none
 
 	// UI
 	width, height int
 	styles        Styles
+	graphics      graphicsMode // How job boxes show thumbnails; graphicsNone = not at all
+	kitty         *kittyWriter // The TUI's output, with graphicsKitty
+	iterm         *itermWriter // The TUI's output, with graphicsITerm
+	keys          keyMap
+	help          help.Model
+	showHelp      bool
+	quitting      bool // The last frame is being drawn
 
 	// Internal event channel used by reporter to feed tea messages
 	eventCh chan tea.Msg
+	// Job results, apart from the other events: buffered for one per job so
+	// a job can always hand its result over, even once the TUI has stopped
+	// reading (see Run)
+	resultCh chan progress.Result
+	jobsWG   *sync.WaitGroup // Running job goroutines
+	// Reporter of every job: eventCh behind a throttle, so bursts of
+	// yt-dlp lines reach the UI as steady updates
+	rep *progress.Throttle
+	// Other reporters fed alongside the TUI; nil = none
+	sinks progress.Reporter
 }
 
-func NewModel(ctx context.Context, urls []string, opts model.CLIOptions) Model {
+// updateInterval is the cadence of job updates in the TUI (10 Hz).
+const updateInterval = 100 * time.Millisecond
+
+// NewModel returns the TUI model of a run of urls with opts; a URL whose
+// entry in perJob (by index) isn't nil runs with those options instead,
+// including their priority.
+func NewModel(ctx context.Context, urls []string, opts model.CLIOptions, perJob []*model.CLIOptions) Model {
 	c, cancel := context.WithCancel(ctx)
+	jobsCtx, cancelJobs := context.WithCancel(c)
 	sty := defaultStyles()
 
 	jobs := make(map[string]*jobState, len(urls))
 	order := make([]string, 0, len(urls))
+	queue := pipeline.NewJobQueue()
 	for i, u := range urls {
 		id := toID(i, u)
 		js := newJobState(id, u, sty)
 		js.bar = bubblesprogress.New(bubblesprogress.WithDefaultGradient(), bubblesprogress.WithWidth(40))
+		js.priority = opts.Priority
+		if i < len(perJob) && perJob[i] != nil {
+			js.opts = perJob[i]
+			js.priority = perJob[i].Priority
+		}
 		jobs[id] = &js
 		order = append(order, id)
+		queue.Push(id, js.priority)
 	}
 
-	workers := opts.Jobs
-	if workers <= 0 {
-		workers = 2
+	eventCh := make(chan tea.Msg, 256)
+	results := len(urls)
+	if opts.SingleInstance {
+		results += maxForwardedJobs
+	}
+	resultCh := make(chan progress.Result, results)
+	graphics := graphicsNone
+	if !opts.NoThumbnails && !opts.Accessible {
+		graphics = detectGraphics()
+	}
+	sched := pipeline.NewFixedScheduler(opts.Jobs)
+	if opts.Jobs <= 0 {
+		sched = pipeline.NewAdaptiveScheduler(1, opts.MaxJobs)
 	}
 
 	return Model{
-		ctx:      c,
-		cancel:   cancel,
-		urls:     urls,
-		opts:     opts,
-		jobs:     jobs,
-		jobOrder: order,
-		selected: 0,
-		workers:  workers,
-		styles:   sty,
-		eventCh:  make(chan tea.Msg, 256),
+		ctx:        c,
+		cancel:     cancel,
+		jobsCtx:    jobsCtx,
+		cancelJobs: cancelJobs,
+		urls:       urls,
+		opts:       opts,
+		jobs:       jobs,
+		jobOrder:   order,
+		selected:   0,
+		sched:      sched,
+		running:    make(map[string]bool),
+		queue:      queue,
+		styles:     sty,
+		graphics:   graphics,
+		keys:       defaultKeyMap().applyOverrides(opts.KeyBindings),
+		help:       help.New(),
+		eventCh:    eventCh,
+		resultCh:   resultCh,
+		jobsWG:     new(sync.WaitGroup),
+		rep:        progress.NewThrottle(teaReporter{ctx: c, ch: eventCh, results: resultCh}, updateInterval),
 	}
 }
 
 func (m Model) Init() tea.Cmd {
 	var cmds []tea.Cmd
 	for _, id := range m.jobOrder {
-		sp := m.jobs[id].spinner
-		cmds = append(cmds, sp.Tick)
+		if !m.opts.Accessible { // no spinners in accessible mode
+			sp := m.jobs[id].spinner
+			cmds = append(cmds, sp.Tick)
+		}
 	}
 	// Listen for reporter events
 	cmds = append(cmds, m.listenEventsCmd())
@@ -96,15 +165,42 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var say tea.Cmd // Accessible mode's announcement of the change
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
-			m.cancel()
-			return m, tea.Quit
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m.shutdown()
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = !m.showHelp
+			return m, nil
+		}
+		if js := m.activePicker(); js != nil {
+			return m.updatePicker(js, msg)
+		}
+		switch {
+		case key.Matches(msg, m.keys.Up):
+			m.selected = max(m.selected-1, 0)
+			return m, nil
+		case key.Matches(msg, m.keys.Down):
+			m.selected = min(m.selected+1, len(m.jobOrder)-1)
+			return m, nil
+		case key.Matches(msg, m.keys.Raise), key.Matches(msg, m.keys.Lower):
+			if m.selected < len(m.jobOrder) {
+				id := m.jobOrder[m.selected]
+				p := m.jobs[id].priority
+				if key.Matches(msg, m.keys.Raise) && p < model.PriorityHigh {
+					p++
+				} else if key.Matches(msg, m.keys.Lower) && p > model.PriorityLow {
+					p--
+				}
+				m.setPriority(id, p)
+			}
+			return m, nil
 		}
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width
 
 	case depsCheckedMsg:
 		m.depsChecked = true
@@ -116,24 +212,65 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			for _, id := range m.jobOrder {
 				js := m.jobs[id]
 				js.stage = progress.StageError
-				js.status = fmt.Sprintf("Dependency error: %v", m.depsErr)
+				js.status = i18n.Sprintf("Dependency error: %v", m.depsErr)
 				js.err = m.depsErr
 				js.done = true
 			}
-			return m, tea.Quit
+			return m.quit()
 		}
 		// Start initial workers
-		return m, m.startNextWorkersCmd()
+		return m, tea.Batch(m.startNextWorkers(), m.schedTickCmd())
+
+	case schedTickMsg:
+		if m.queue.Len() == 0 {
+			return m, nil // everything started; nothing left to scale
+		}
+		m.sched.Tick(len(m.running), m.queue.Len())
+		return m, tea.Batch(m.startNextWorkers(), m.schedTickCmd())
 
 	case jobUpdateMsg:
+		// Results are read ahead of the events, so a finished job's last
+		// updates can still come after its result
 		u := msg.U
-		if js, ok := m.jobs[u.JobID]; ok {
+		if js, ok := m.jobs[u.JobID]; ok && !js.done {
 			js.stage = u.Stage
 			js.percent = u.Percent
-			js.status = u.Message
-			if u.Bytes != nil {
+			js.eta = u.ETA
+			js.encStats = ""
+			if u.Stage == progress.StageEncoding {
+				js.encStats = encodeStats(u)
+			}
+			js.status = i18n.String(u.Message)
+			if u.Task != "" {
+				js.status = u.Task + ": " + js.status
+			}
+			if u.Bytes != nil && u.Stage == progress.StageDownloading {
+				js.downloaded = *u.Bytes
+			} else if u.Bytes != nil {
 				js.bytes = *u.Bytes
 			}
+			if u.Stage != progress.StageDownloading {
+				m.sched.JobDone(u.JobID)
+			} else if u.Speed != nil {
+				if rate, ok := progress.ParseRate(*u.Speed); ok {
+					m.sched.ObserveRate(u.JobID, rate)
+				}
+			}
+			say = m.announce(js)
+		}
+	case jobFormatsMsg:
+		if js, ok := m.jobs[msg.JobID]; ok {
+			js.picker = newFormatPicker(msg.Formats, msg.Reply)
+			js.status = i18n.String("Waiting for format choice")
+			if m.opts.Accessible {
+				say = m.say(js, js.status)
+			}
+		} else {
+			msg.Reply <- ""
+		}
+	case jobThumbMsg:
+		if js, ok := m.jobs[msg.JobID]; ok {
+			js.thumb = msg.Art
 		}
 	case jobLogMsg:
 		l := msg.L
@@ -145,43 +282,56 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			js.logsRing = append(js.logsRing, line)
 		}
+	case jobStartMsg:
+		if js, ok := m.jobs[msg.JobID]; ok && !js.done {
+			js.startedAt = time.Now()
+			js.status = i18n.String("Starting")
+			say = m.announceStart(js)
+		}
 	case jobResultMsg:
-		r := msg.R
-		if js, ok := m.jobs[r.JobID]; ok {
-			js.done = true
-			js.err = r.Err
-			if r.Err == nil {
-				js.stage = progress.StageCompleted
-				js.percent = 100
-				js.outputPath = r.OutputPath
-				js.bytes = r.Bytes
-				// Set informative status with basename and size
-				if r.OutputPath != "" {
-					name := filepath.Base(r.OutputPath)
-					size := humanizeBytes(r.Bytes)
-					if m.opts.DryRun {
-						js.status = fmt.Sprintf("Planned: %s (%s)", name, size)
-					} else {
-						js.status = fmt.Sprintf("Saved: %s (%s)", name, size)
-					}
-				} else {
-					js.status = "Completed"
-				}
-			} else {
-				js.stage = progress.StageError
-				js.status = r.Err.Error()
-				js.percent = -1
+		if m.applyResult(msg.R) {
+			m.sched.JobDone(msg.R.JobID)
+			say = m.announce(m.jobs[msg.R.JobID])
+		}
+	case jobExitMsg:
+		// The slot is free only once the job's goroutine is gone (hooks
+		// included), so --jobs 1 never overlaps two jobs
+		if m.running[msg.JobID] {
+			delete(m.running, msg.JobID)
+			if m.shuttingDown && len(m.running) == 0 {
+				m.cancel()
+				return m.quit()
 			}
-			m.running--
-			// Start next job if any remain
-			return m, m.startNextWorkersCmd()
+			return m, tea.Batch(m.startNextWorkers(), m.listenEventsCmd())
+		}
+	case shutdownTimeoutMsg:
+		// Jobs that still haven't stopped are left to Run's own wait
+		m.cancel()
+		return m.quit()
+	case drainMsg:
+		return m.drain()
+	case graceOverMsg:
+		if m.jobsCtx.Err() == nil {
+			m.cancelJobs()
+			return m, tea.Batch(m.sayAll(i18n.Sprintf("Shutting down… stopping %d job(s) and cleaning up (press q again to exit now)", len(m.running))),
+				tea.Tick(shutdownTimeout, func(time.Time) tea.Msg { return shutdownTimeoutMsg{} }))
 		}
 	case allDoneMsg:
-		return m, tea.Quit
+		if m.ctx.Err() == nil && !m.shuttingDown && (m.queue.Len() > 0 || len(m.running) > 0) {
+			return m, nil // jobs were handed over meanwhile
+		}
+		return m.quit()
+	case instanceMsg:
+		if !msg.taken.CompareAndSwap(false, true) {
+			return m, nil // serveInstance gave up on it and told the sender so
+		}
+		resp, cmd := m.answer(msg.req)
+		msg.reply <- resp
+		return m, cmd
 	}
 
 	// Update per-job components (spinner)
-	var cmds []tea.Cmd
+	cmds := []tea.Cmd{say}
 	for _, id := range m.jobOrder {
 		js := m.jobs[id]
 		var c tea.Cmd
@@ -195,7 +345,57 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// applyResult marks the job of r finished, reporting whether it was still
+// running.
+func (m *Model) applyResult(r progress.Result) bool {
+	js, ok := m.jobs[r.JobID]
+	if !ok || js.done {
+		return false
+	}
+	js.done = true
+	js.err = r.Err
+	js.canceled = r.Err != nil && m.jobsCtx.Err() != nil
+	js.times, js.eta = r.Times, nil
+	if r.Skipped != "" {
+		js.stage = progress.StageCompleted
+		js.status = i18n.Sprintf("Skipped: %s", r.Skipped)
+	} else if r.Err == nil {
+		js.stage = progress.StageCompleted
+		js.percent = 100
+		js.outputPath = r.OutputPath
+		js.bytes = r.Bytes
+		// Set informative status with basename and size
+		if r.OutputPath != "" {
+			name := filepath.Base(r.OutputPath)
+			size := humanizeBytes(r.Bytes)
+			if m.opts.DryRun {
+				js.status = i18n.Sprintf("Planned: %s (%s)", name, size)
+			} else if r.RemoteURL != "" {
+				js.status = i18n.Sprintf("Saved: %s (%s) → %s", name, size, r.RemoteURL)
+			} else {
+				js.status = i18n.Sprintf("Saved: %s (%s)", name, size)
+			}
+		} else {
+			js.status = i18n.String("Completed")
+		}
+	} else {
+		js.stage = progress.StageError
+		js.status = r.Err.Error()
+		js.percent = -1
+	}
+	return true
+}
+
 func (m Model) View() string {
+	if m.opts.Accessible {
+		return m.viewAccessible()
+	}
+	if m.showHelp {
+		return m.viewHeader() + "\n\n" + m.viewHelp()
+	}
+	if js := m.activePicker(); js != nil {
+		return m.viewHeader() + "\n\n" + m.viewPicker(js) + "\n\n" + m.viewJobs()
+	}
 	summary := m.viewSummary()
 	if summary != "" {
 		return m.viewHeader() + "\n\n" + m.viewJobs() + "\n" + summary
@@ -203,11 +403,19 @@ func (m Model) View() string {
 	return m.viewHeader() + "\n\n" + m.viewJobs()
 }
 
+// listenEventsCmd waits for the next event, results first.
 func (m Model) listenEventsCmd() tea.Cmd {
 	return func() tea.Msg {
+		select {
+		case r := <-m.resultCh:
+			return jobResultMsg{R: r}
+		default:
+		}
 		select {
 		case <-m.ctx.Done():
 			return allDoneMsg{}
+		case r := <-m.resultCh:
+			return jobResultMsg{R: r}
 		case msg := <-m.eventCh:
 			return msg
 		}
@@ -228,145 +436,198 @@ func (m Model) checkDepsCmd() tea.Cmd {
 	}
 }
 
-func (m Model) startNextWorkersCmd() tea.Cmd {
-	return func() tea.Msg {
-		// If canceled, stop
-		select {
-		case <-m.ctx.Done():
-			return allDoneMsg{}
-		default:
-		}
-		for m.running < m.workers && m.next < len(m.urls) {
-			idx := m.next
-			jobID := m.jobOrder[idx]
-			url := m.urls[idx]
-			m.next++
-			m.running++
-			// Mark job started
-			if js := m.jobs[jobID]; js != nil {
-				js.started = true
-				js.status = "Queued"
-				js.stage = progress.StageMetadata
-			}
-			// Launch job goroutine
-			go m.runJob(jobID, url)
+// shutdownTimeout bounds how long the TUI shows "shutting down" before it
+// exits anyway.
+const shutdownTimeout = 15 * time.Second
+
+// shutdown cancels the running jobs and waits, in the TUI, for them to stop
+// and remove their temp files and partial outputs. Quitting again exits at
+// once.
+func (m Model) shutdown() (tea.Model, tea.Cmd) {
+	if m.jobsCtx.Err() != nil || len(m.running) == 0 {
+		m.cancel()
+		return m.quit()
+	}
+	m.shuttingDown = true
+	m.cancelJobs()
+	return m, tea.Batch(m.sayAll(i18n.Sprintf("Shutting down… stopping %d job(s) and cleaning up (press q again to exit now)", len(m.running))),
+		tea.Tick(shutdownTimeout, func(time.Time) tea.Msg { return shutdownTimeoutMsg{} }))
+}
+
+// drain starts no more jobs and lets the running ones finish, canceling them
+// once opts.ShutdownGrace has passed. Quitting meanwhile cancels them at
+// once.
+func (m Model) drain() (tea.Model, tea.Cmd) {
+	if m.shuttingDown {
+		return m, nil
+	}
+	m.shuttingDown, m.draining = true, true
+	if len(m.running) == 0 {
+		m.cancel()
+		return m.quit()
+	}
+	return m, tea.Batch(m.sayAll(i18n.Sprintf("Shutting down… finishing %d running job(s) first (press q to stop them now)", len(m.running))),
+		tea.Tick(m.opts.ShutdownGrace, func(time.Time) tea.Msg { return graceOverMsg{} }))
+}
+
+// setPriority moves a job that hasn't started yet to priority p.
+func (m *Model) setPriority(id string, p model.Priority) {
+	if m.queue.SetPriority(id, p) {
+		m.jobs[id].priority = p
+	}
+}
+
+// startNextWorkers starts queued jobs, highest priority first, while the
+// scheduler allows more to run. Only Update calls it (pointer receiver), so
+// scheduling state is never touched from a job's goroutine; jobs report back
+// with jobStartMsg and jobExitMsg.
+func (m *Model) startNextWorkers() tea.Cmd {
+	allDone := func() tea.Msg { return allDoneMsg{} }
+	// If canceled or winding down, start nothing more; the running jobs
+	// still report back
+	if m.jobsCtx.Err() != nil || m.shuttingDown {
+		if len(m.running) == 0 {
+			return allDone
 		}
-		if m.next >= len(m.urls) && m.running == 0 {
-			return allDoneMsg{}
+		return nil
+	}
+	for len(m.running) < m.sched.Limit() && m.queue.Len() > 0 {
+		jobID, _ := m.queue.Pop()
+		js := m.jobs[jobID]
+		if js == nil || js.started || js.done {
+			continue // never start a job twice
 		}
-		// No specific message now; rely on reporter events
+		js.started = true
+		js.status = i18n.String("Starting")
+		js.stage = progress.StageMetadata
+		m.running[jobID] = true
+		url, opts := js.url, m.jobOptions(js)
+
+		prev, started := m.lastStart, make(chan struct{})
+		m.lastStart = started
+		job := *m
+		m.jobsWG.Add(1)
+		go func() {
+			defer job.jobsWG.Done()
+			if prev != nil {
+				select {
+				case <-prev:
+				case <-job.ctx.Done():
+				}
+			}
+			job.post(jobStartMsg{JobID: jobID})
+			close(started)
+			job.runJob(jobID, url, opts)
+			job.post(jobExitMsg{JobID: jobID})
+		}()
+	}
+	if m.queue.Len() == 0 && len(m.running) == 0 {
+		return allDone
+	}
+	return nil
+}
+
+// schedInterval is how often the adaptive scheduler re-evaluates the limit.
+const schedInterval = 3 * time.Second
+
+// schedTickCmd schedules the next adaptive concurrency check.
+func (m Model) schedTickCmd() tea.Cmd {
+	if !m.sched.Adaptive() {
 		return nil
 	}
+	return tea.Tick(schedInterval, func(time.Time) tea.Msg { return schedTickMsg{} })
 }
 
-func (m Model) runJob(jobID, url string) {
-	rep := teaReporter{ch: m.eventCh}
-
-	// Step 1: Download metadata (or full if not dry-run)
-	dv, tempDir, derr := downloader.Download(m.ctx, url, downloader.Options{
-		DownloaderPath: m.downloaderPath,
-		Verbose:        m.opts.Verbose,
-		KeepTemp:       m.opts.KeepTemp,
-		MetadataOnly:   m.opts.DryRun,
-		Reporter:       rep,
-		JobID:          jobID,
-	})
-	// Cleanup unless keep-temp
-	defer func() {
-		if !m.opts.KeepTemp && tempDir != "" {
-			_ = os.RemoveAll(tempDir)
-		}
-	}()
-
-	if derr != nil {
-		rep.Result(progress.Result{JobID: jobID, Err: fmt.Errorf("downloader: %w", derr)})
-		return
+// jobOptions returns the options js runs with.
+func (m Model) jobOptions(js *jobState) model.CLIOptions {
+	if js.opts != nil {
+		return *js.opts
 	}
+	return m.opts
+}
 
-	// Plan encoding
-	targetLongSide, usedCRF := pipeline.PlanResolutionAndCRF(m.opts, dv, pipeline.DefaultCRF(m.opts.Quality))
-	encOpts := model.EncodeOptions{
-		LongSidePx:       targetLongSide,
-		ModeCRF:          m.opts.MaxSizeMB == 0 || dv.DurationSec <= 0 || m.opts.AudioOnly,
-		CRF:              usedCRF,
-		MaxSizeMB:        m.opts.MaxSizeMB,
-		AudioBitrateKbps: 96,
-		VideoMinKbps:     500,
-		VideoMaxKbps:     8000,
-		Preset:           "veryfast",
-		Profile:          "main",
-		AudioOnly:        m.opts.AudioOnly,
-		KeyInt:           48,
-	}
-
-	// Dry run: no encode, just finalize result
-	ext := ".mp4"
-	if m.opts.AudioOnly {
-		ext = ".m4a"
-	}
-	base := media.OutputBasename(dv, targetLongSide, m.opts.MaxSizeMB, encOpts)
-	outputPath := filepath.Join(m.opts.OutDir, base+ext)
-
-	if m.opts.DryRun {
-		// Present plan as status
-		name := filepath.Base(outputPath)
-		rep.Update(progress.Update{
-			JobID:   jobID,
-			Stage:   progress.StageCompleted,
-			Percent: 100,
-			Message: fmt.Sprintf("Planned: %s (dry-run)", name),
-		})
-		rep.Result(progress.Result{JobID: jobID, OutputPath: outputPath, Bytes: 0, Err: nil})
-		return
+// runJob runs one URL through pipeline.Service with opts; its progress and
+// result reach the TUI through the reporter.
+func (m Model) runJob(jobID, url string, opts model.CLIOptions) {
+	opts = pipeline.OptionsForURL(opts, url)
+	job := pipeline.Job{
+		ID:        jobID,
+		URL:       url,
+		Options:   opts,
+		PresetCRF: pipeline.DefaultCRF(opts.Quality),
+		Reporter:  progress.NewMulti(m.rep, m.sinks),
+		Report:    m.report,
+	}
+	if opts.PickFormat {
+		job.SelectFormat = m.formatSelectFunc(jobID)
+	}
+	if m.graphics != graphicsNone {
+		job.Downloaded = func(dv model.DownloadedVideo, tempDir string) {
+			m.sendThumbnail(jobID, dv, tempDir)
+		}
 	}
+	svc := pipeline.Service{DownloaderPath: m.downloaderPath, FFmpegPath: m.ffmpegPath}
+	_, _ = svc.RunJob(m.jobsCtx, job) // the error is in the job's result
+}
 
-	// Encode
-	out, eerr := encoder.Encode(m.ctx, dv, encOpts, encoder.Options{
-		FFmpegPath: m.ffmpegPath,
-		Verbose:    m.opts.Verbose,
-		OutputPath: outputPath,
-		Reporter:   rep,
-		JobID:      jobID,
-	})
-	if eerr != nil {
-		rep.Result(progress.Result{JobID: jobID, Err: fmt.Errorf("encode: %w", eerr)})
+// sendThumbnail extracts a small preview (remote thumbnail first, then a frame
+// from the downloaded source) and forwards it to the UI. Failures are non-fatal.
+func (m Model) sendThumbnail(jobID string, dv model.DownloadedVideo, tempDir string) {
+	if tempDir == "" {
 		return
 	}
-
-	// Caption
-	if m.opts.Caption == model.CaptionTxt {
-		caption := media.CaptionText(dv)
-		if _, werr := util.WriteCaptionFile(out.OutputPath, caption); werr != nil && m.opts.Verbose {
-			rep.Log(progress.Log{JobID: jobID, Stream: progress.StreamStderr, Line: fmt.Sprintf("warning: failed to write caption: %v", werr)})
+	dst := filepath.Join(tempDir, "thumb.png")
+	var sources []media.ThumbnailOptions
+	if dv.Thumbnail != "" {
+		sources = append(sources, media.ThumbnailOptions{Source: dv.Thumbnail})
+	}
+	if dv.InputPath != "" {
+		seek := 1.0
+		if dv.DurationSec > 0 && dv.DurationSec < 2 {
+			seek = 0
+		}
+		sources = append(sources, media.ThumbnailOptions{Source: dv.InputPath, SeekSec: seek})
+	}
+	for _, src := range sources {
+		src.FFmpegPath = m.ffmpegPath
+		src.OutputPath = dst
+		src.MaxWidth, src.MaxHeight = thumbSource(m.graphics)
+		if err := media.ExtractThumbnail(m.jobsCtx, src); err != nil {
+			if m.opts.Verbose {
+				m.post(jobLogMsg{L: progress.Log{JobID: jobID, Stream: progress.StreamStderr, Line: fmt.Sprintf("thumbnail: %v", err)}})
+			}
+			continue
+		}
+		art, err := renderThumbnail(dst, m.graphics, m.kitty, m.iterm)
+		if err != nil {
+			continue
 		}
+		m.post(jobThumbMsg{JobID: jobID, Art: art})
+		return
 	}
+}
 
-	// Send final update with filename before result
-	name := filepath.Base(out.OutputPath)
-	size := humanizeBytes(out.Bytes)
-	rep.Update(progress.Update{
-		JobID:   jobID,
-		Stage:   progress.StageCompleted,
-		Percent: 100,
-		Message: fmt.Sprintf("Saved: %s (%s)", name, size),
-	})
-
-	rep.Result(progress.Result{JobID: jobID, OutputPath: out.OutputPath, Bytes: out.Bytes, Err: nil})
+// post hands msg to the TUI unless the job or the TUI is over.
+func (m Model) post(msg tea.Msg) {
+	select {
+	case m.eventCh <- msg:
+	case <-m.ctx.Done():
+	}
 }
 
 type teaReporter struct {
-	ch chan tea.Msg
+	ctx     context.Context // Done once the TUI stops reading
+	ch      chan tea.Msg
+	results chan<- progress.Result
 }
 
+// Update waits for room in the channel rather than dropping the update: the
+// throttle in front of it keeps the rate low, and a dropped update could be
+// the last one of its stage.
 func (r teaReporter) Update(u progress.Update) {
-	// Block on completion messages to ensure they're delivered
-	if u.Stage == progress.StageCompleted || u.Stage == progress.StageError {
-		r.ch <- jobUpdateMsg{U: u}
-		return
-	}
 	select {
 	case r.ch <- jobUpdateMsg{U: u}:
-	default:
+	case <-r.ctx.Done():
 	}
 }
 func (r teaReporter) Log(l progress.Log) {
@@ -375,9 +636,18 @@ func (r teaReporter) Log(l progress.Log) {
 	default:
 	}
 }
+
+// Result never blocks for long: resultCh has room for every job's result, so
+// it gets through even when the TUI is quitting, and Run drains it.
 func (r teaReporter) Result(res progress.Result) {
-	// Always block on Result messages - they're critical
-	r.ch <- jobResultMsg{R: res}
+	select {
+	case r.results <- res:
+	case <-r.ctx.Done():
+		select {
+		case r.results <- res:
+		default:
+		}
+	}
 }
 
 func findDownloader(custom string) (string, error) {
@@ -527,4 +797,4 @@ func itoa(i int) string {
 		buf[pos] = '-'
 	}
 	return string(buf[pos:])
-}
\ No newline at end of file
+}
@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	"ig2wa/internal/model"
+	"ig2wa/internal/progress"
+)
+
+func TestLateUpdateKeepsJobCompleted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewModel(ctx, []string{"https://example.com/v"}, model.CLIOptions{NoThumbnails: true}, nil)
+	id := toID(0, "")
+
+	// The job's last update is sent before its result, but the TUI reads
+	// results first
+	rep := teaReporter{ctx: ctx, ch: m.eventCh, results: m.resultCh}
+	rep.Update(progress.Update{JobID: id, Stage: progress.StageEncoding, Percent: 97, Message: "Encoding"})
+	rep.Result(progress.Result{JobID: id, OutputPath: "/tmp/v.mp4", Bytes: 1024})
+	for i := 0; i < 2; i++ {
+		next, _ := m.Update(m.listenEventsCmd()())
+		m = next.(Model)
+	}
+
+	js := m.jobs[id]
+	if !js.done || js.stage != progress.StageCompleted || js.percent != 100 {
+		t.Errorf("job: done %v, stage %v, percent %v; want completed at 100", js.done, js.stage, js.percent)
+	}
+}
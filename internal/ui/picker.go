@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/i18n"
+)
+
+// formatPicker holds the per-job source format choice awaiting user input.
+type formatPicker struct {
+	formats []downloader.Format // best first; index 0 of the list is "auto"
+	cursor  int
+	reply   chan string
+}
+
+// pickerVisibleRows bounds how many formats are listed at once.
+const pickerVisibleRows = 10
+
+func newFormatPicker(formats []downloader.Format, reply chan string) *formatPicker {
+	// yt-dlp lists formats worst to best; show best first.
+	ordered := make([]downloader.Format, 0, len(formats))
+	for i := len(formats) - 1; i >= 0; i-- {
+		f := formats[i]
+		if !f.HasVideo() && !f.HasAudio() {
+			continue // storyboards and other non-media entries
+		}
+		ordered = append(ordered, f)
+	}
+	return &formatPicker{formats: ordered, reply: reply}
+}
+
+// rows is the number of selectable entries including the leading "auto" row.
+func (p *formatPicker) rows() int {
+	return len(p.formats) + 1
+}
+
+// selection returns the yt-dlp selector for the highlighted row ("" = auto).
+func (p *formatPicker) selection() string {
+	if p.cursor == 0 || p.cursor > len(p.formats) {
+		return ""
+	}
+	return p.formats[p.cursor-1].Selector()
+}
+
+// formatSelectFunc returns a downloader.Options.SelectFormat hook that asks the
+// TUI for a choice and blocks until the user answers or the context ends.
+func (m Model) formatSelectFunc(jobID string) func(context.Context, []downloader.Format) (string, error) {
+	return func(ctx context.Context, formats []downloader.Format) (string, error) {
+		reply := make(chan string, 1)
+		select {
+		case m.eventCh <- jobFormatsMsg{JobID: jobID, Formats: formats, Reply: reply}:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		select {
+		case sel := <-reply:
+			return sel, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// activePicker returns the first job (in display order) awaiting a format choice.
+func (m Model) activePicker() *jobState {
+	for _, id := range m.jobOrder {
+		if js := m.jobs[id]; js != nil && js.picker != nil {
+			return js
+		}
+	}
+	return nil
+}
+
+func (m Model) updatePicker(js *jobState, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := js.picker
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if p.cursor < p.rows()-1 {
+			p.cursor++
+		}
+	case key.Matches(msg, m.keys.Select):
+		p.reply <- p.selection()
+		js.picker = nil
+		js.status = i18n.String("Starting download")
+	case key.Matches(msg, m.keys.Cancel):
+		p.reply <- ""
+		js.picker = nil
+		js.status = i18n.String("Starting download")
+	}
+	return m, nil // a key isn't an event; the listener already running keeps going
+}
+
+func (m Model) viewPicker(js *jobState) string {
+	p := js.picker
+	var b strings.Builder
+	b.WriteString(m.styles.Header.Render(i18n.Sprintf("Pick source format: %s", truncate(js.url, 48))))
+	b.WriteString("\n")
+
+	// Scroll window around the cursor
+	start := 0
+	if p.cursor >= pickerVisibleRows {
+		start = p.cursor - pickerVisibleRows + 1
+	}
+	end := start + pickerVisibleRows
+	if end > p.rows() {
+		end = p.rows()
+	}
+	for i := start; i < end; i++ {
+		label := i18n.String("auto (best video + best audio)")
+		if i > 0 {
+			label = p.formats[i-1].Label()
+		}
+		if i == p.cursor {
+			b.WriteString(m.styles.Title.Render("> " + label))
+		} else {
+			b.WriteString(m.styles.JobInfo.Render("  " + label))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(m.styles.Faint.Render(i18n.Sprintf("%d formats • ", len(p.formats))))
+	b.WriteString(m.help.ShortHelpView([]key.Binding{m.keys.Up, m.keys.Down, m.keys.Select, m.keys.Cancel}))
+	return m.styles.Box.Render(b.String())
+}
@@ -3,26 +3,87 @@ package ui
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"ig2wa/internal/model"
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
 )
 
-// Run launches the TUI with the provided URLs and options.
-// Integration with the main program and orchestration will be added next.
-func Run(ctx context.Context, urls []string, opts model.CLIOptions) error {
-	m := NewModel(ctx, urls, opts)
-	prog := tea.NewProgram(m, tea.WithContext(ctx))
+// Run launches the TUI with the provided URLs and options; a URL whose entry
+// in perJob (by index) isn't nil uses the options given there instead.
+// Finished jobs are added to report when it is non-nil, and every progress
+// event also goes to sinks when non-nil.
+func Run(ctx context.Context, urls []string, opts model.CLIOptions, perJob []*model.CLIOptions, report *pipeline.Report, sinks progress.Reporter) error {
+	m := NewModel(ctx, urls, opts, perJob)
+	m.report = report
+	m.sinks = sinks
+	progOpts := []tea.ProgramOption{tea.WithContext(ctx)}
+	// Thumbnails go out with the frames (see kittyWriter and itermWriter).
+	// Bubble Tea only watches the size of an *os.File output, so Run does.
+	switch m.graphics {
+	case graphicsKitty:
+		m.kitty = newKittyWriter(os.Stdout)
+		progOpts = append(progOpts, tea.WithOutput(m.kitty))
+	case graphicsITerm:
+		m.iterm = newITermWriter(os.Stdout)
+		progOpts = append(progOpts, tea.WithOutput(m.iterm))
+	}
+	prog := tea.NewProgram(m, progOpts...)
+	if m.kitty != nil || m.iterm != nil {
+		defer watchSize(prog, os.Stdout)()
+	}
+	instanceDone := make(chan struct{})
+	if opts.SingleInstance {
+		go serveInstance(m.ctx, prog, instanceDone)
+	} else {
+		close(instanceDone)
+	}
+	drain := util.DrainFrom(ctx)
+	stopWatch := make(chan struct{})
+	go func() {
+		select {
+		case <-drain.Done():
+			prog.Send(drainMsg{})
+		case <-stopWatch:
+		}
+	}()
 	final, err := prog.Run()
+	close(stopWatch)
+	// Nothing reads the events anymore: unblock jobs still reporting, then
+	// collect the results the TUI didn't get to
+	m.cancel()
+	<-instanceDone
+	m.waitJobs(jobsDrainTimeout)
+	_ = m.rep.Close()
 	if err != nil {
 		return err
 	}
 	if fm, ok := final.(Model); ok {
+		fm.drainResults()
+		if drain.Started() {
+			var unfinished []string
+			for _, id := range fm.jobOrder {
+				if js := fm.jobs[id]; !js.started || js.canceled {
+					unfinished = append(unfinished, js.url)
+				}
+			}
+			if len(unfinished) > 0 {
+				return &DrainedError{Unfinished: unfinished}
+			}
+		}
 		var failed []string
+		var first error
 		for _, id := range fm.jobOrder {
 			js := fm.jobs[id]
 			if js != nil && js.err != nil {
+				if first == nil {
+					first = js.err
+				}
 				url := js.url
 				msg := js.err.Error()
 				if url != "" {
@@ -33,8 +94,59 @@ func Run(ctx context.Context, urls []string, opts model.CLIOptions) error {
 			}
 		}
 		if len(failed) > 0 {
-			return fmt.Errorf("%d job(s) failed:\n%s", len(failed), strings.Join(failed, "\n"))
+			return &FailedJobsError{Failed: len(failed), Total: len(fm.jobOrder), Details: failed, First: first}
 		}
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// jobsDrainTimeout bounds how long Run waits for canceled jobs to wrap up
+// (a hook may still be running) after the TUI stops.
+const jobsDrainTimeout = 10 * time.Second
+
+// waitJobs waits up to timeout for the job goroutines to return.
+func (m Model) waitJobs(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		m.jobsWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// drainResults applies the results still waiting in resultCh, so a job that
+// finished as the TUI quit isn't left showing its last progress.
+func (m *Model) drainResults() {
+	for {
+		select {
+		case r := <-m.resultCh:
+			m.applyResult(r)
+		default:
+			return
+		}
+	}
+}
+
+// DrainedError is returned by Run when a drain (SIGTERM, SIGHUP) stopped it
+// with jobs unfinished.
+type DrainedError struct {
+	Unfinished []string // URLs of the jobs that didn't start or were canceled
+}
+
+func (e *DrainedError) Error() string {
+	return fmt.Sprintf("stopped with %d job(s) unfinished", len(e.Unfinished))
+}
+
+// FailedJobsError is returned by Run when one or more jobs failed.
+type FailedJobsError struct {
+	Failed, Total int
+	Details       []string // One "- url: error" line per failed job
+	First         error    // Of the first failed job, in the order given
+}
+
+func (e *FailedJobsError) Error() string {
+	return fmt.Sprintf("%d job(s) failed:\n%s", e.Failed, strings.Join(e.Details, "\n"))
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package ui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+)
+
+// watchSize sends prog the size of the terminal f, now and on every resize,
+// until the returned function is called.
+func watchSize(prog *tea.Program, f *os.File) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			if w, h, err := term.GetSize(int(f.Fd())); err == nil {
+				prog.Send(tea.WindowSizeMsg{Width: w, Height: h})
+			}
+			select {
+			case <-sig:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
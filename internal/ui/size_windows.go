@@ -0,0 +1,19 @@
+//go:build windows
+
+package ui
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+)
+
+// watchSize sends prog the size of the terminal f. Windows has no resize
+// signal, so later sizes aren't sent.
+func watchSize(prog *tea.Program, f *os.File) (stop func()) {
+	if w, h, err := term.GetSize(int(f.Fd())); err == nil {
+		go prog.Send(tea.WindowSizeMsg{Width: w, Height: h})
+	}
+	return func() {}
+}
@@ -37,4 +37,4 @@ func defaultStyles() Styles {
 		StageDL:   base.Foreground(lipgloss.Color("#06B6D4")),
 		StageEnc:  base.Foreground(lipgloss.Color("#D946EF")),
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,301 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Thumbnail box: thumbMaxWidth × thumbMaxHeight/2 cells. Half blocks draw a
+// source of up to thumbMaxWidth × thumbMaxHeight pixels, one pixel per column
+// and two per row; the image protocols get a source thumbScale times as large
+// and let the terminal scale it into the same cells.
+const (
+	thumbMaxWidth  = 24
+	thumbMaxHeight = 16
+	thumbScale     = 8
+)
+
+// graphicsMode is how the TUI draws thumbnails.
+type graphicsMode int
+
+const (
+	graphicsNone       graphicsMode = iota // No thumbnails
+	graphicsHalfBlocks                     // 24-bit colored half blocks
+	graphicsKitty                          // kitty graphics protocol, placed with Unicode placeholders
+	graphicsITerm                          // iTerm2 inline images
+)
+
+// detectGraphics picks how the current terminal draws thumbnails: the kitty
+// graphics protocol in kitty, iTerm2's inline images in iTerm2, and colored
+// half blocks in WezTerm and, since tmux and screen don't pass the protocols
+// on, in kitty or iTerm2 behind them. Other terminals get none.
+func detectGraphics() graphicsMode {
+	kitty := os.Getenv("KITTY_WINDOW_ID") != "" || strings.Contains(os.Getenv("TERM"), "kitty")
+	iterm := os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("LC_TERMINAL") == "iTerm2"
+	multiplexed := os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") || strings.HasPrefix(os.Getenv("TERM"), "tmux")
+	switch {
+	case kitty && !multiplexed:
+		return graphicsKitty
+	case iterm && !multiplexed:
+		return graphicsITerm
+	case kitty, iterm, os.Getenv("WEZTERM_EXECUTABLE") != "", os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return graphicsHalfBlocks
+	}
+	return graphicsNone
+}
+
+// thumbSource returns the largest source, in pixels, mode draws thumbnails of.
+func thumbSource(mode graphicsMode) (w, h int) {
+	if mode == graphicsHalfBlocks {
+		return thumbMaxWidth, thumbMaxHeight
+	}
+	return thumbMaxWidth * thumbScale, thumbMaxHeight * thumbScale
+}
+
+// thumbCells returns the cells a protocol draws a source of size b in.
+func thumbCells(b image.Rectangle) (cols, rows int) {
+	cols = min(max((b.Dx()+thumbScale-1)/thumbScale, 1), thumbMaxWidth)
+	rows = min(max((b.Dy()+2*thumbScale-1)/(2*thumbScale), 1), thumbMaxHeight/2)
+	return cols, rows
+}
+
+// renderThumbnail loads a PNG and returns the cells drawing it in the TUI.
+// Bubble Tea measures and truncates rendered lines by their printable width,
+// which would corrupt an image escape's payload, so the protocols keep it out
+// of the view: kitty's image goes out through kitty (see kittyWriter) and the
+// view holds Unicode placeholders for it, and iTerm2's rows go through iterm
+// (see itermWriter), the view holding markers for them.
+func renderThumbnail(path string, mode graphicsMode, kitty *kittyWriter, iterm *itermWriter) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	switch mode {
+	case graphicsKitty:
+		if kitty != nil {
+			return kittyThumbnail(kitty, img, data), nil
+		}
+	case graphicsITerm:
+		if iterm != nil {
+			return iterm.add(img)
+		}
+	}
+	return halfBlocks(img), nil
+}
+
+// halfBlocks draws img with 24-bit colored half blocks, two pixels per cell.
+func halfBlocks(img image.Image) string {
+	bounds := img.Bounds()
+	var b strings.Builder
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			tr, tg, tb := rgb8(img, x, y)
+			if y+1 < bounds.Max.Y {
+				br, bg, bb := rgb8(img, x, y+1)
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", tr, tg, tb, br, bg, bb)
+			} else {
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm▀", tr, tg, tb)
+			}
+		}
+		b.WriteString("\x1b[0m")
+		if y+2 < bounds.Max.Y {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func rgb8(img image.Image, x, y int) (uint8, uint8, uint8) {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)
+}
+
+// newImageID returns an image ID for the terminal: random, so images of
+// other programs and other sniplette runs in the same window keep theirs, and
+// 24 bits, the most a placeholder's foreground color carries.
+func newImageID() int {
+	return 1 + rand.Intn(1<<24-1)
+}
+
+// kittyChunk is the most base64 payload one kitty graphics command carries.
+const kittyChunk = 4096
+
+// kittyPlaceholder is the character of the cells a kitty image is shown in,
+// and kittyDiacritics the marks numbering their rows and columns (the start
+// of the table in kitty's graphics protocol docs).
+const kittyPlaceholder = '\U0010EEEE'
+
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F,
+	0x0346, 0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357,
+	0x035B, 0x0363, 0x0364, 0x0365, 0x0366, 0x0367, 0x0368, 0x0369,
+}
+
+// kittyThumbnail sends the PNG data of img to the terminal through kw as a
+// kitty image with a virtual placement of the thumbnail's cells, and returns
+// the placeholder cells showing it.
+func kittyThumbnail(kw *kittyWriter, img image.Image, data []byte) string {
+	id := newImageID()
+	cols, rows := thumbCells(img.Bounds())
+	payload := base64.StdEncoding.EncodeToString(data)
+	var cmd strings.Builder
+	for first := true; first || payload != ""; first = false {
+		chunk := payload[:min(len(payload), kittyChunk)]
+		payload = payload[len(chunk):]
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&cmd, "\x1b_Ga=T,f=100,U=1,q=2,i=%d,c=%d,r=%d,m=%d;%s\x1b\\", id, cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&cmd, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	kw.send(cmd.String())
+
+	// The image ID is the cells' foreground color
+	color := fmt.Sprintf("\x1b[38;2;%d;%d;%dm", id>>16&0xff, id>>8&0xff, id&0xff)
+	var b strings.Builder
+	for r := 0; r < rows; r++ {
+		b.WriteString(color)
+		for c := 0; c < cols; c++ {
+			b.WriteRune(kittyPlaceholder)
+			b.WriteRune(kittyDiacritics[r])
+			b.WriteRune(kittyDiacritics[c])
+		}
+		b.WriteString("\x1b[39m")
+		if r < rows-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// kittyWriter is the TUI's output in kitty. Images sent through it go out
+// ahead of the next frame, in the same write, so Bubble Tea's renderer is the
+// only writer to the terminal and an image's payload never lands in the
+// middle of a frame.
+type kittyWriter struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	pending []byte // Image commands waiting for the next frame
+}
+
+func newKittyWriter(w io.Writer) *kittyWriter {
+	return &kittyWriter{w: w}
+}
+
+// send queues the escape sequence of an image for the next frame.
+func (kw *kittyWriter) send(seq string) {
+	kw.mu.Lock()
+	kw.pending = append(kw.pending, seq...)
+	kw.mu.Unlock()
+}
+
+// Write implements io.Writer, writing the images sent since the last frame
+// and then the frame p.
+func (kw *kittyWriter) Write(p []byte) (int, error) {
+	kw.mu.Lock()
+	out := p
+	if len(kw.pending) > 0 {
+		out = append(kw.pending, p...)
+		kw.pending = nil
+	}
+	kw.mu.Unlock()
+	if _, err := kw.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// itermMarkerRe matches the marker of an iTerm2 thumbnail row in a frame
+// ("\x1b[<id>;<row>;1337z") and the blank cells after it. Bubble Tea reads
+// the marker as a zero-width escape sequence.
+var itermMarkerRe = regexp.MustCompile("\x1b\\[([0-9]+);([0-9]+);1337z( *)")
+
+// itermWriter is the TUI's output in iTerm2. It replaces the marker of each
+// thumbnail row in a frame with the row's inline image, drawn over the blank
+// cells following the marker with the cursor left in place. Every row is an
+// image of its own, so a line the TUI repaints gets its part back.
+type itermWriter struct {
+	w io.Writer
+
+	mu   sync.Mutex
+	rows map[int][]string // Escape sequences of the rows, by image ID
+	cols map[int]int      // Width of the images in cells, by image ID
+}
+
+func newITermWriter(w io.Writer) *itermWriter {
+	return &itermWriter{w: w, rows: make(map[int][]string), cols: make(map[int]int)}
+}
+
+// add registers img and returns the cells of its thumbnail: a marker per row,
+// followed by the blank cells the row's image covers.
+func (iw *itermWriter) add(img image.Image) (string, error) {
+	id := newImageID()
+	cols, rows := thumbCells(img.Bounds())
+	b := img.Bounds()
+	seqs := make([]string, rows)
+	for r := range seqs {
+		band := image.Rect(b.Min.X, b.Min.Y+r*b.Dy()/rows, b.Max.X, b.Min.Y+(r+1)*b.Dy()/rows)
+		part := image.NewNRGBA(image.Rect(0, 0, band.Dx(), band.Dy()))
+		draw.Draw(part, part.Bounds(), img, band.Min, draw.Src)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, part); err != nil {
+			return "", err
+		}
+		seqs[r] = fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%d;height=1;preserveAspectRatio=0;doNotMoveCursor=1:%s\a",
+			buf.Len(), cols, base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+	iw.mu.Lock()
+	iw.rows[id], iw.cols[id] = seqs, cols
+	iw.mu.Unlock()
+
+	lines := make([]string, rows)
+	for r := range lines {
+		lines[r] = fmt.Sprintf("\x1b[%d;%d;1337z", id, r) + strings.Repeat(" ", cols)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Write implements io.Writer, drawing the thumbnail rows in p. A row with
+// fewer blank cells than its image is wide (cut short in a narrow window)
+// stays blank.
+func (iw *itermWriter) Write(p []byte) (int, error) {
+	if !bytes.Contains(p, []byte(";1337z")) {
+		return iw.w.Write(p)
+	}
+	iw.mu.Lock()
+	out := itermMarkerRe.ReplaceAllFunc(p, func(m []byte) []byte {
+		sub := itermMarkerRe.FindSubmatch(m)
+		id, _ := strconv.Atoi(string(sub[1]))
+		r, _ := strconv.Atoi(string(sub[2]))
+		blank, cols := sub[3], iw.cols[id]
+		if r >= len(iw.rows[id]) || len(blank) < cols {
+			return blank
+		}
+		return []byte("\x1b7" + iw.rows[id][r] + "\x1b8" + fmt.Sprintf("\x1b[%dC", cols) + string(blank[cols:]))
+	})
+	iw.mu.Unlock()
+	if _, err := iw.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
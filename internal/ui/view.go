@@ -3,7 +3,11 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/lipgloss"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/model"
 	"ig2wa/internal/progress"
 )
 
@@ -14,8 +18,20 @@ func (m Model) viewHeader() string {
 			done++
 		}
 	}
-	title := m.styles.Title.Render("ig2wa — Instagram/YouTube to WhatsApp")
-	sub := m.styles.Subtitle.Render(fmt.Sprintf("Jobs: %d/%d done • q: quit", done, total))
+	title := m.styles.Title.Render(i18n.String("ig2wa — Instagram/YouTube to WhatsApp"))
+	jobs := i18n.Sprintf("Jobs: %d/%d done • ", done, total)
+	if dl := m.downloadedBytes(); dl > 0 {
+		jobs += i18n.Sprintf("%s downloaded • ", humanizeBytes(dl))
+	}
+	if m.sched.Adaptive() {
+		jobs += i18n.Sprintf("%d at a time (auto) • ", m.sched.Limit())
+	}
+	sub := m.styles.Subtitle.Render(jobs) + m.help.ShortHelpView(m.keys.ShortHelp())
+	if m.shuttingDown && m.jobsCtx.Err() == nil {
+		sub = m.styles.Warning.Render(i18n.Sprintf("Shutting down… finishing %d running job(s) first (press q to stop them now)", len(m.running)))
+	} else if m.shuttingDown {
+		sub = m.styles.Warning.Render(i18n.Sprintf("Shutting down… stopping %d job(s) and cleaning up (press q again to exit now)", len(m.running)))
+	}
 	return title + "\n" + sub
 }
 
@@ -38,55 +54,166 @@ func (m Model) viewJob(js *jobState) string {
 		stageStyle = m.styles.StageDL
 	case progress.StageEncoding:
 		stageStyle = m.styles.StageEnc
+	case progress.StageUploading:
+		stageStyle = m.styles.StageDL
 	case progress.StageCompleted:
 		stageStyle = m.styles.Success
 	case progress.StageError:
 		stageStyle = m.styles.Error
 	}
 
-	left := m.styles.JobTitle.Render(truncate(js.url, 48))
-	stage := stageStyle.Render(string(js.stage))
+	cursor := "  "
+	if m.selected < len(m.jobOrder) && m.jobOrder[m.selected] == js.id {
+		cursor = "▸ " // the job +/- change the priority of
+	}
+	left := cursor + m.styles.JobTitle.Render(truncate(js.url, 48))
+	stage := stageStyle.Render(i18n.String(string(js.stage)))
 
 	var right string
-	if js.percent >= 0 && js.percent <= 100 {
+	if m.shuttingDown && m.jobsCtx.Err() != nil && m.running[js.id] && !js.done {
+		right = m.styles.Spinner.Render(js.spinner.View()) + " " + m.styles.Warning.Render(i18n.String("stopping"))
+	} else if js.percent >= 0 && js.percent <= 100 {
 		right = fmt.Sprintf("%s %5.1f%%", js.bar.ViewAs(js.percent/100.0), js.percent)
 	} else if js.done && js.err == nil {
-		right = m.styles.Success.Render("✓ done")
+		right = m.styles.Success.Render(i18n.String("✓ done"))
 	} else if js.err != nil {
-		right = m.styles.Error.Render("✗ error")
+		right = m.styles.Error.Render(i18n.String("✗ error"))
+	} else if js.stage == progress.StageMerging {
+		// ffmpeg steps after the download report no percentage
+		right = m.styles.Spinner.Render(js.spinner.View()) + " " + m.styles.Faint.Render(i18n.String("merging"))
 	} else {
-		right = m.styles.Spinner.Render(js.spinner.View()) + " " + m.styles.Faint.Render("waiting")
+		right = m.styles.Spinner.Render(js.spinner.View()) + " " + m.styles.Faint.Render(i18n.String("waiting"))
+	}
+
+	if js.downloaded > 0 && (js.stage == progress.StageDownloading || js.stage == progress.StageMerging) {
+		right += "  " + humanizeBytes(js.downloaded)
+	}
+	if js.encStats != "" && !js.done {
+		right += "  " + js.encStats
+	}
+	if t := jobTiming(js); t != "" {
+		right += "  " + m.styles.Faint.Render(t)
 	}
 
 	info := js.status
 	line1 := fmt.Sprintf("%s  %s", left, stage)
+	if !js.started {
+		switch js.priority {
+		case model.PriorityHigh:
+			line1 += "  " + m.styles.Warning.Render(i18n.String("↑ high priority"))
+		case model.PriorityLow:
+			line1 += "  " + m.styles.Faint.Render(i18n.String("↓ low priority"))
+		}
+	}
 	line2 := m.styles.JobInfo.Render(info)
-	return m.styles.Box.Render(line1+"\n"+right+"\n"+line2)
+	body := line1 + "\n" + right + "\n" + line2
+	if m.graphics != graphicsNone && js.thumb != "" {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, js.thumb, "  ", body)
+	}
+	return m.styles.Box.Render(body)
 }
 
-func (m Model) viewSummary() string {
-	var completed []string
-	for _, id := range m.jobOrder {
-		js := m.jobs[id]
-		if js.done && js.err == nil && js.outputPath != "" {
-			completed = append(completed, js.outputPath)
-		}
+// jobTiming is how long a running job has taken so far and the ETA of its
+// current stage, or the time per stage once it is done.
+func jobTiming(js *jobState) string {
+	switch {
+	case js.done:
+		return js.times.String()
+	case js.startedAt.IsZero():
+		return ""
+	}
+	t := i18n.Sprintf("elapsed %s", formatClock(time.Since(js.startedAt)))
+	if js.eta != nil {
+		t += i18n.Sprintf(" • ETA %s", formatClock(*js.eta))
+	}
+	return t
+}
+
+// encodeStats renders ffmpeg's live numbers, e.g. "frame 1204 • 48 fps •
+// 1502.3 kb/s • 1.6x", so a struggling encode stands out.
+func encodeStats(u progress.Update) string {
+	var parts []string
+	if u.Frame != nil {
+		parts = append(parts, i18n.Sprintf("frame %d", *u.Frame))
+	}
+	if u.FPS != nil {
+		parts = append(parts, fmt.Sprintf("%.0f fps", *u.FPS))
+	}
+	if u.Bitrate != nil {
+		parts = append(parts, strings.Replace(*u.Bitrate, "kbits/s", " kb/s", 1))
 	}
-	
+	if u.Speed != nil && *u.Speed != "" {
+		parts = append(parts, *u.Speed)
+	}
+	return strings.Join(parts, " • ")
+}
+
+// formatClock renders d as m:ss or h:mm:ss.
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, mins, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, mins, s)
+	}
+	return fmt.Sprintf("%d:%02d", mins, s)
+}
+
+// downloadedBytes totals the source bytes transferred by the batch's jobs.
+func (m Model) downloadedBytes() int64 {
+	var n int64
+	for _, js := range m.jobs {
+		n += js.downloaded
+	}
+	return n
+}
+
+func (m Model) viewSummary() string {
+	completed := m.completedFiles()
 	if len(completed) == 0 {
 		return ""
 	}
-	
+
 	var b strings.Builder
-	b.WriteString(m.styles.Subtitle.Render("✓ Completed Files:"))
+	b.WriteString(m.styles.Subtitle.Render(i18n.String("✓ Completed Files:")))
 	b.WriteString("\n")
 	for _, path := range completed {
 		b.WriteString(m.styles.Success.Render("  • " + path))
 		b.WriteString("\n")
 	}
+	if dl := m.downloadedBytes(); dl > 0 {
+		b.WriteString(m.styles.Faint.Render("  " + i18n.Sprintf("Downloaded %s in total", humanizeBytes(dl))))
+		b.WriteString("\n")
+	}
 	return b.String()
 }
 
+// completedFiles lists the outputs of the jobs that succeeded, in order, each
+// with the job's times.
+func (m Model) completedFiles() []string {
+	var completed []string
+	for _, id := range m.jobOrder {
+		js := m.jobs[id]
+		if js.done && js.err == nil && js.outputPath != "" {
+			line := js.outputPath
+			if js.times.Total > 0 {
+				line += "  (" + js.times.String() + ")"
+			}
+			completed = append(completed, line)
+		}
+	}
+	return completed
+}
+
+func (m Model) viewHelp() string {
+	var b strings.Builder
+	b.WriteString(m.styles.Header.Render(i18n.String("Keybindings")))
+	b.WriteString("\n\n")
+	b.WriteString(m.help.FullHelpView(m.keys.FullHelp()))
+	b.WriteString("\n\n")
+	b.WriteString(m.styles.Faint.Render(i18n.String("Remap keys in the config file under \"keys\" (e.g., quit: [\"x\"]).")))
+	return m.styles.Box.Render(b.String())
+}
+
 func truncate(s string, n int) string {
 	if n <= 0 || len([]rune(s)) <= n {
 		return s
@@ -96,4 +223,4 @@ func truncate(s string, n int) string {
 		return s
 	}
 	return string(rs[:n-1]) + "…"
-}
\ No newline at end of file
+}
@@ -0,0 +1,494 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"ig2wa/internal/downloader"
+	"ig2wa/internal/i18n"
+	"ig2wa/internal/model"
+	"ig2wa/internal/pipeline"
+	"ig2wa/internal/util"
+)
+
+// WizardTarget is a destination app and the largest file it takes comfortably.
+type WizardTarget struct {
+	Name      string
+	MaxSizeMB int // 0 = no limit (quality/CRF mode)
+}
+
+// WizardTargets are the apps offered by the wizard, most common first.
+var WizardTargets = []WizardTarget{
+	{Name: "WhatsApp", MaxSizeMB: 16},
+	{Name: "Telegram", MaxSizeMB: 50},
+	{Name: "Signal", MaxSizeMB: 95},
+	{Name: "Discord", MaxSizeMB: 10},
+	{Name: "Email", MaxSizeMB: 20},
+	{Name: "Keep quality (no size limit)", MaxSizeMB: 0},
+}
+
+// WizardResult holds the choices made in the wizard, ready to become run flags.
+type WizardResult struct {
+	URL          string
+	MaxSizeMB    int // 0 = CRF mode
+	Quality      model.QualityPreset
+	TrimStartSec float64
+	TrimEndSec   float64 // 0 = to the end
+	Caption      model.CaptionMode
+}
+
+// FetchInfoFunc fetches a URL's metadata for the wizard.
+type FetchInfoFunc func(ctx context.Context, url string) (downloader.YTDLPInfo, error)
+
+type wizardStep int
+
+const (
+	stepURL wizardStep = iota
+	stepFetching
+	stepTarget
+	stepQuality
+	stepTrim
+	stepCaption
+	stepConfirm
+)
+
+var (
+	wizardQualities = []model.QualityPreset{model.PresetLow, model.PresetMedium, model.PresetHigh}
+	wizardCaptions  = []model.CaptionMode{model.CaptionTxt, model.CaptionEmbed, model.CaptionBoth, model.CaptionNone}
+
+	// wizardCaptionLabels are the caption step's rows and summary values,
+	// in English; they are translated when shown.
+	wizardCaptionLabels = map[model.CaptionMode][2]string{
+		model.CaptionTxt:   {"Yes, as a .txt file", "yes (.txt)"},
+		model.CaptionEmbed: {"Yes, inside the video file", "yes (in the file)"},
+		model.CaptionBoth:  {"Yes, both", "yes (.txt and in the file)"},
+		model.CaptionNone:  {"No", "no"},
+	}
+)
+
+type wizardInfoMsg struct {
+	info downloader.YTDLPInfo
+	err  error
+}
+
+type wizardModel struct {
+	ctx   context.Context
+	fetch FetchInfoFunc
+
+	step    wizardStep
+	url     textinput.Model
+	start   textinput.Model
+	end     textinput.Model
+	info    downloader.YTDLPInfo
+	err     error // last fetch or input error, shown under the current step
+	cursor  int   // highlighted row on list steps
+	target  int
+	quality int
+	caption int
+	done    bool // confirmed; false when the user backed out
+
+	audioKbps int // Of the encode, for the size estimate
+
+	styles Styles
+	keys   keyMap
+	help   help.Model
+}
+
+func newWizardModel(ctx context.Context, url string, opts model.CLIOptions, fetch FetchInfoFunc) wizardModel {
+	newInput := func(placeholder string, width int) textinput.Model {
+		ti := textinput.New()
+		ti.Placeholder = placeholder
+		ti.Width = width
+		ti.Prompt = "› "
+		return ti
+	}
+	m := wizardModel{
+		ctx:       ctx,
+		fetch:     fetch,
+		url:       newInput("https://…", 60),
+		start:     newInput(i18n.String("start, e.g. 0:05 (empty = beginning)"), 40),
+		end:       newInput(i18n.String("end, e.g. 0:35 (empty = end)"), 40),
+		quality:   1,
+		styles:    defaultStyles(),
+		audioKbps: opts.AudioKbps,
+		keys:      defaultKeyMap().applyOverrides(opts.KeyBindings),
+		help:      help.New(),
+	}
+	for i, q := range wizardQualities {
+		if q == opts.Quality {
+			m.quality = i
+		}
+	}
+	for i, c := range wizardCaptions {
+		if c == opts.Caption {
+			m.caption = i
+		}
+	}
+	m.url.SetValue(url)
+	m.url.Focus()
+	return m
+}
+
+// RunWizard asks for a single job's settings interactively, showing a live
+// size estimate, and returns them. url may be empty, in which case the wizard
+// asks for it first. ok is false when the user quits before confirming.
+func RunWizard(ctx context.Context, url string, opts model.CLIOptions, fetch FetchInfoFunc) (res WizardResult, ok bool, err error) {
+	m := newWizardModel(ctx, url, opts, fetch)
+	if url != "" {
+		m.step = stepFetching
+	}
+	final, err := tea.NewProgram(m, tea.WithContext(ctx)).Run()
+	if err != nil {
+		return WizardResult{}, false, err
+	}
+	wm, _ := final.(wizardModel)
+	if !wm.done {
+		return WizardResult{}, false, nil
+	}
+	start, end, _ := wm.trimRange()
+	return WizardResult{
+		URL:          strings.TrimSpace(wm.url.Value()),
+		MaxSizeMB:    WizardTargets[wm.target].MaxSizeMB,
+		Quality:      wizardQualities[wm.quality],
+		TrimStartSec: start,
+		TrimEndSec:   end,
+		Caption:      wizardCaptions[wm.caption],
+	}, true, nil
+}
+
+func (m wizardModel) Init() tea.Cmd {
+	if m.step == stepFetching {
+		return m.fetchCmd()
+	}
+	return textinput.Blink
+}
+
+func (m wizardModel) fetchCmd() tea.Cmd {
+	url := strings.TrimSpace(m.url.Value())
+	return func() tea.Msg {
+		info, err := m.fetch(m.ctx, url)
+		return wizardInfoMsg{info: info, err: err}
+	}
+}
+
+func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case wizardInfoMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.step = stepURL
+			m.url.Focus()
+			return m, textinput.Blink
+		}
+		m.info, m.err = msg.info, nil
+		return m.enter(stepTarget)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			return m.back()
+		case "enter":
+			return m.next()
+		}
+		switch m.step {
+		case stepURL:
+			var cmd tea.Cmd
+			m.url, cmd = m.url.Update(msg)
+			return m, cmd
+		case stepTrim:
+			return m.updateTrim(msg)
+		case stepTarget, stepQuality, stepCaption:
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.cursor < m.rows()-1 {
+					m.cursor++
+				}
+			}
+		case stepConfirm:
+			if key.Matches(msg, m.keys.Quit) {
+				return m, tea.Quit
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m wizardModel) updateTrim(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "shift+tab", "up", "down":
+		if m.start.Focused() {
+			m.start.Blur()
+			m.end.Focus()
+		} else {
+			m.end.Blur()
+			m.start.Focus()
+		}
+		return m, textinput.Blink
+	}
+	var cmd tea.Cmd
+	if m.start.Focused() {
+		m.start, cmd = m.start.Update(msg)
+	} else {
+		m.end, cmd = m.end.Update(msg)
+	}
+	_, _, m.err = m.trimRange()
+	return m, cmd
+}
+
+// enter switches to step, placing the cursor on that step's current choice.
+func (m wizardModel) enter(step wizardStep) (tea.Model, tea.Cmd) {
+	m.step = step
+	switch step {
+	case stepTarget:
+		m.cursor = m.target
+	case stepQuality:
+		m.cursor = m.quality
+	case stepCaption:
+		m.cursor = m.caption
+	case stepTrim:
+		m.start.Focus()
+		return m, textinput.Blink
+	}
+	return m, nil
+}
+
+func (m wizardModel) next() (tea.Model, tea.Cmd) {
+	switch m.step {
+	case stepURL:
+		url := strings.TrimSpace(m.url.Value())
+		if url == "" {
+			return m, nil
+		}
+		if _, _, err := util.DetectPlatform(url); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = nil
+		m.url.Blur()
+		m.step = stepFetching
+		return m, m.fetchCmd()
+	case stepTarget:
+		m.target = m.cursor
+		return m.enter(stepQuality)
+	case stepQuality:
+		m.quality = m.cursor
+		return m.enter(stepTrim)
+	case stepTrim:
+		if _, _, err := m.trimRange(); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.start.Blur()
+		m.end.Blur()
+		return m.enter(stepCaption)
+	case stepCaption:
+		m.caption = m.cursor
+		return m.enter(stepConfirm)
+	case stepConfirm:
+		m.done = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m wizardModel) back() (tea.Model, tea.Cmd) {
+	switch m.step {
+	case stepURL, stepFetching, stepTarget:
+		return m, tea.Quit
+	case stepTrim:
+		m.start.Blur()
+		m.end.Blur()
+		m.err = nil
+	}
+	return m.enter(m.step - 1)
+}
+
+// rows is the number of choices on the current list step.
+func (m wizardModel) rows() int {
+	switch m.step {
+	case stepTarget:
+		return len(WizardTargets)
+	case stepQuality:
+		return len(wizardQualities)
+	case stepCaption:
+		return len(wizardCaptions)
+	}
+	return 0
+}
+
+// trimRange parses the trim inputs and checks them against the duration.
+func (m wizardModel) trimRange() (start, end float64, err error) {
+	s, e := strings.TrimSpace(m.start.Value()), strings.TrimSpace(m.end.Value())
+	if s == "" && e == "" {
+		return 0, 0, nil
+	}
+	if start, end, err = util.ParseTimeRange(s + "-" + e); err != nil {
+		return 0, 0, err
+	}
+	if d := m.info.Duration; d > 0 && start >= d {
+		return 0, 0, errors.New(i18n.Sprintf("start is past the end of the video (%s)", util.FormatTimestamp(d)))
+	}
+	return start, end, nil
+}
+
+// choices returns the target, quality, and caption picks, using the
+// highlighted row for the step being edited so the estimate follows the cursor.
+func (m wizardModel) choices() (target, quality int) {
+	target, quality = m.target, m.quality
+	switch m.step {
+	case stepTarget:
+		target = m.cursor
+	case stepQuality:
+		quality = m.cursor
+	}
+	return target, quality
+}
+
+// estimateLine describes the output the current choices would produce.
+func (m wizardModel) estimateLine() string {
+	target, quality := m.choices()
+	res, _, crf := pipeline.PresetDefaults(wizardQualities[quality])
+	maxMB := WizardTargets[target].MaxSizeMB
+
+	start, end, err := m.trimRange()
+	if err != nil {
+		start, end = 0, 0
+	}
+	dv := model.DownloadedVideo{DurationSec: m.info.Duration, Width: m.info.Width, Height: m.info.Height}
+	dv, _, _, _ = pipeline.Trim(model.CLIOptions{TrimStartSec: start, TrimEndSec: end}, dv)
+	longSide, _ := pipeline.PlanResolutionAndCRF(model.CLIOptions{Resolution: res}, dv, crf)
+
+	parts := []string{fmt.Sprintf("%dp", longSide)}
+	if dv.DurationSec > 0 {
+		parts = append(parts, i18n.Sprintf("%s long", util.FormatTimestamp(dv.DurationSec)))
+	}
+	switch {
+	case maxMB == 0:
+		parts = append(parts, i18n.Sprintf("CRF %d, size depends on the content", crf))
+	case dv.DurationSec <= 0:
+		parts = append(parts, i18n.Sprintf("at most %d MB", maxMB))
+	default:
+		parts = append(parts, i18n.Sprintf("~%s (limit %d MB)", util.HumanizeBytes(pipeline.FormulaBytes(maxMB, m.audioKbps, dv.DurationSec)), maxMB))
+	}
+	return i18n.Sprintf("Estimate: %s", strings.Join(parts, " • "))
+}
+
+func (m wizardModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.styles.Title.Render(i18n.String("Sniplette wizard")))
+	b.WriteString("\n\n")
+
+	if m.step > stepFetching {
+		b.WriteString(m.styles.Header.Render(truncate(valueOrDash(m.info.Title), 60)))
+		b.WriteString("\n")
+		meta := []string{valueOrDash(m.info.Uploader)}
+		if m.info.Duration > 0 {
+			meta = append(meta, util.FormatTimestamp(m.info.Duration))
+		}
+		if m.info.Width > 0 && m.info.Height > 0 {
+			meta = append(meta, fmt.Sprintf("%dx%d", m.info.Width, m.info.Height))
+		}
+		b.WriteString(m.styles.Faint.Render(strings.Join(meta, " • ")))
+		b.WriteString("\n\n")
+	}
+
+	switch m.step {
+	case stepURL:
+		b.WriteString(i18n.String("Paste the link to the video:") + "\n")
+		b.WriteString(m.url.View())
+	case stepFetching:
+		b.WriteString(m.styles.StageMeta.Render(i18n.Sprintf("Fetching details for %s…", truncate(m.url.Value(), 48))))
+	case stepTarget:
+		b.WriteString(i18n.String("Where will you send it?") + "\n")
+		for i, t := range WizardTargets {
+			label := i18n.String(t.Name)
+			if t.MaxSizeMB > 0 {
+				label = i18n.Sprintf("%s (up to %d MB)", label, t.MaxSizeMB)
+			}
+			b.WriteString(m.viewRow(i, label))
+		}
+	case stepQuality:
+		b.WriteString(i18n.String("Picture quality:") + "\n")
+		for i, q := range wizardQualities {
+			res, _, _ := pipeline.PresetDefaults(q)
+			b.WriteString(m.viewRow(i, fmt.Sprintf("%s (%dp)", i18n.String(string(q)), res)))
+		}
+	case stepTrim:
+		b.WriteString(i18n.String("Keep only part of the video? Leave both empty for all of it.") + "\n")
+		b.WriteString(m.start.View() + "\n" + m.end.View())
+	case stepCaption:
+		b.WriteString(i18n.String("Save the caption with the video?") + "\n")
+		for i, c := range wizardCaptions {
+			b.WriteString(m.viewRow(i, i18n.String(wizardCaptionLabels[c][0])))
+		}
+	case stepConfirm:
+		b.WriteString(i18n.String("Ready:") + "\n")
+		b.WriteString(m.styles.JobInfo.Render(m.summary()))
+	}
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString(m.styles.Error.Render(m.err.Error()))
+		b.WriteString("\n")
+	}
+	if m.step > stepFetching {
+		b.WriteString("\n" + m.styles.Success.Render(m.estimateLine()) + "\n")
+	}
+	b.WriteString("\n" + m.styles.Faint.Render(m.helpLine()))
+	return m.styles.Box.Render(b.String())
+}
+
+func (m wizardModel) viewRow(i int, label string) string {
+	if i == m.cursor {
+		return m.styles.Title.Render("> "+label) + "\n"
+	}
+	return m.styles.JobInfo.Render("  "+label) + "\n"
+}
+
+// summary lists the confirmed choices.
+func (m wizardModel) summary() string {
+	t := WizardTargets[m.target]
+	trim := i18n.String("whole video")
+	if start, end, err := m.trimRange(); err == nil && (start > 0 || end > 0) {
+		to := i18n.String("end")
+		if end > 0 {
+			to = util.FormatTimestamp(end)
+		}
+		trim = i18n.Sprintf("%s to %s", util.FormatTimestamp(start), to)
+	}
+	caption := i18n.String(wizardCaptionLabels[wizardCaptions[m.caption]][1])
+	return i18n.Sprintf("  For:      %s\n  Quality:  %s\n  Part:     %s\n  Caption:  %s\n",
+		i18n.String(t.Name), i18n.String(string(wizardQualities[m.quality])), trim, caption)
+}
+
+func (m wizardModel) helpLine() string {
+	switch m.step {
+	case stepURL:
+		return i18n.String("enter continue • esc/ctrl+c quit")
+	case stepFetching:
+		return i18n.String("esc/ctrl+c quit")
+	case stepTrim:
+		return i18n.String("tab switch field • enter continue • esc back")
+	case stepConfirm:
+		return i18n.String("enter start • esc back • ctrl+c quit")
+	}
+	return i18n.String("↑/↓ choose • enter continue • esc back")
+}
+
+func valueOrDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,133 @@
+package uploader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ig2wa/internal/progress"
+	"ig2wa/internal/util"
+)
+
+// Options control an rclone upload.
+type Options struct {
+	RclonePath string
+	Dest       string // s3://bucket/prefix, or an rclone remote such as "nas:videos"
+	Verbose    bool
+
+	// Progress reporting (optional)
+	Reporter progress.Reporter
+	JobID    string
+}
+
+// Validate checks that dest looks like something Upload can handle.
+func Validate(dest string) error {
+	switch {
+	case dest == "":
+		return errors.New("empty destination")
+	case strings.HasPrefix(dest, "s3://"):
+		if strings.Trim(strings.TrimPrefix(dest, "s3://"), "/") == "" {
+			return fmt.Errorf("%q has no bucket", dest)
+		}
+		return nil
+	case strings.Contains(dest, ":"):
+		return nil // rclone remote (or a Windows drive path, which rclone copies locally)
+	}
+	return fmt.Errorf("%q is neither s3://bucket/prefix nor an rclone remote (name:path)", dest)
+}
+
+// RemoteURL returns where Upload puts a file called name.
+func RemoteURL(dest, name string) string {
+	if strings.HasSuffix(dest, ":") || strings.HasSuffix(dest, "/") {
+		return dest + name
+	}
+	return dest + "/" + name
+}
+
+// rcloneTarget maps an s3:// URL onto an rclone on-the-fly S3 remote that
+// takes credentials from the usual AWS environment and config files. Other
+// destinations are already rclone paths.
+func rcloneTarget(dest string) string {
+	if rest, ok := strings.CutPrefix(dest, "s3://"); ok {
+		return ":s3,env_auth=true:" + rest
+	}
+	return dest
+}
+
+// rcloneStats is the part of rclone's JSON stats log line we use.
+type rcloneStats struct {
+	Stats *struct {
+		Bytes      int64   `json:"bytes"`
+		TotalBytes int64   `json:"totalBytes"`
+		Speed      float64 `json:"speed"`
+		ETA        *int64  `json:"eta"`
+	} `json:"stats"`
+}
+
+// Upload copies localPath to opts.Dest with rclone and returns the remote
+// URL of the uploaded file.
+func Upload(ctx context.Context, localPath string, opts Options) (string, error) {
+	if opts.RclonePath == "" {
+		return "", errors.New("rclone path is required")
+	}
+	if err := Validate(opts.Dest); err != nil {
+		return "", err
+	}
+	name := filepath.Base(localPath)
+	remote := RemoteURL(opts.Dest, name)
+	report(opts, 0, nil, nil, "Uploading")
+
+	args := []string{
+		"copyto", util.LongPath(localPath), RemoteURL(rcloneTarget(opts.Dest), name),
+		"--use-json-log", "-v", "--stats", "1s",
+	}
+	_, err := util.Run(ctx, util.CmdSpec{
+		Path:    opts.RclonePath,
+		Args:    args,
+		Verbose: opts.Verbose && opts.Reporter == nil,
+		Stage:   "upload",
+		// rclone logs (including stats) go to stderr
+		StderrLine: func(line string) {
+			if opts.Reporter == nil {
+				return
+			}
+			var st rcloneStats
+			if json.Unmarshal([]byte(line), &st) == nil && st.Stats != nil && st.Stats.TotalBytes > 0 {
+				pct := float64(st.Stats.Bytes) / float64(st.Stats.TotalBytes) * 100
+				speed := util.HumanizeBytes(int64(st.Stats.Speed)) + "/s"
+				var eta *time.Duration
+				if st.Stats.ETA != nil {
+					d := time.Duration(*st.Stats.ETA) * time.Second
+					eta = &d
+				}
+				report(opts, pct, &speed, eta, "Uploading")
+			}
+			if opts.Verbose {
+				opts.Reporter.Log(progress.Log{JobID: opts.JobID, Stream: progress.StreamStderr, Line: line})
+			}
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("rclone copy to %s: %w", opts.Dest, err)
+	}
+	report(opts, 100, nil, nil, "Uploaded")
+	return remote, nil
+}
+
+func report(opts Options, pct float64, speed *string, eta *time.Duration, msg string) {
+	if opts.Reporter == nil {
+		return
+	}
+	opts.Reporter.Update(progress.Update{
+		JobID:   opts.JobID,
+		Stage:   progress.StageUploading,
+		Percent: pct,
+		Speed:   speed,
+		ETA:     eta,
+		Message: msg,
+	})
+}
@@ -6,10 +6,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // CmdSpec describes a subprocess to run.
@@ -24,6 +28,15 @@ type CmdSpec struct {
 	StdoutLine    func(string) // Called for each stdout line (if non-nil)
 	StderrLine    func(string) // Called for each stderr line (if non-nil)
 	CaptureStdout bool         // When false, do not buffer stdout into CmdResult (still invoke StdoutLine)
+
+	// Timeout stops the command after this long (0 = none); the error then
+	// matches ErrTimeout and names Stage (default: the binary name).
+	Timeout time.Duration
+	Stage   string
+
+	// Nice lowers the scheduling priority of the process and its children
+	// (Unix niceness 1..19; on Windows >0 is below-normal, >=15 idle). 0 = unchanged.
+	Nice int
 }
 
 // CmdResult contains captured output and exit status.
@@ -41,7 +54,23 @@ type CmdResult struct {
 func Run(ctx context.Context, spec CmdSpec) (CmdResult, error) {
 	var stdoutBuf, stderrBuf bytes.Buffer
 
+	if spec.Timeout > 0 {
+		stage := spec.Stage
+		if stage == "" {
+			stage = filepath.Base(spec.Path)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = StageContext(ctx, stage, spec.Timeout)
+		defer cancel()
+	}
+
 	cmd := exec.CommandContext(ctx, spec.Path, spec.Args...)
+	configureProcess(cmd)
+	exited := make(chan struct{})
+	cmd.Cancel = func() error { return stopProcess(cmd, exited) }
+	// Grandchildren can keep the pipes open after the child dies; don't hang
+	// on them. Must exceed stopGrace so a graceful stop is not cut short.
+	cmd.WaitDelay = stopGrace + 2*time.Second
 	if spec.Dir != "" {
 		cmd.Dir = spec.Dir
 	}
@@ -49,23 +78,27 @@ func Run(ctx context.Context, spec CmdSpec) (CmdResult, error) {
 		cmd.Env = append(os.Environ(), spec.Env...)
 	}
 
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return CmdResult{Stdout: nil, Stderr: nil, Code: -1, Err: err}, err
-	}
-	stderrPipe, err := cmd.StderrPipe()
-	if err != nil {
-		return CmdResult{Stdout: nil, Stderr: nil, Code: -1, Err: err}, err
-	}
+	// io.Pipe rather than StdoutPipe: Wait then finishes copying (bounded by
+	// WaitDelay) before we close the writers, so no output is lost and a
+	// grandchild holding the pipe open cannot hang us.
+	stdoutPipe, stdoutW := io.Pipe()
+	stderrPipe, stderrW := io.Pipe()
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
 
-	if spec.Verbose {
-		// Print the command line before execution
-		fmt.Fprintf(os.Stderr, "+ %s\n", shellQuote(spec.Path, spec.Args))
-	}
+	// Echo the command line (shown on the console with --verbose/--log-level debug)
+	slog.Debug("exec", "cmd", ShellQuote(spec.Path, spec.Args), "dir", spec.Dir)
 
 	if err := cmd.Start(); err != nil {
+		stdoutW.Close()
+		stderrW.Close()
 		return CmdResult{Stdout: nil, Stderr: nil, Code: -1, Err: err}, err
 	}
+	if spec.Nice > 0 {
+		if err := setPriority(cmd, spec.Nice); err != nil {
+			slog.Debug("could not lower process priority", "cmd", spec.Path, "err", err)
+		}
+	}
 
 	var wg sync.WaitGroup
 	wg.Add(2)
@@ -77,7 +110,7 @@ func Run(ctx context.Context, spec CmdSpec) (CmdResult, error) {
 		// Increase buffer size to handle large JSON outputs (e.g., yt-dlp --dump-json)
 		// Default is 64KB, but YouTube metadata can be 500KB+
 		const maxCapacity = 1024 * 1024 // 1 MB
-		buf := make([]byte, 0, 64*1024)  // initial buffer
+		buf := make([]byte, 0, 64*1024) // initial buffer
 		sc.Buffer(buf, maxCapacity)
 		for sc.Scan() {
 			line := sc.Text()
@@ -98,9 +131,8 @@ func Run(ctx context.Context, spec CmdSpec) (CmdResult, error) {
 		// If the scanner errors, preserve it in buffers for debugging
 		if err := sc.Err(); err != nil {
 			// Do not fail outright; command exit will reflect errors
-			if spec.Verbose {
-				fmt.Fprintf(os.Stderr, "stdout scan error: %v\n", err)
-			}
+			slog.Warn("stdout scan error", "cmd", spec.Path, "err", err)
+			_, _ = io.Copy(io.Discard, stdoutPipe) // keep the child from blocking on a full pipe
 		}
 	}()
 
@@ -125,13 +157,19 @@ func Run(ctx context.Context, spec CmdSpec) (CmdResult, error) {
 			stderrBuf.WriteByte('\n')
 		}
 		if err := sc.Err(); err != nil {
-			if spec.Verbose {
-				fmt.Fprintf(os.Stderr, "stderr scan error: %v\n", err)
-			}
+			slog.Warn("stderr scan error", "cmd", spec.Path, "err", err)
+			_, _ = io.Copy(io.Discard, stderrPipe)
 		}
 	}()
 
 	waitErr := cmd.Wait()
+	close(exited)
+	if ctx.Err() != nil {
+		// Reap anything in the group that outlived the child.
+		_ = killProcessTree(cmd)
+	}
+	stdoutW.Close()
+	stderrW.Close()
 	// Ensure readers drain remaining data
 	wg.Wait()
 
@@ -153,13 +191,66 @@ func Run(ctx context.Context, spec CmdSpec) (CmdResult, error) {
 	}
 
 	if waitErr != nil {
-		return res, fmt.Errorf("command failed (exit %d): %w", code, waitErr)
+		slog.Debug("command failed", "cmd", spec.Path, "exit", code, "stderr", tail(res.Stderr, 4096))
+		return res, &CmdError{
+			Command: ShellQuote(spec.Path, spec.Args),
+			Code:    code,
+			Stderr:  res.Stderr,
+			Err:     TimeoutCause(ctx, waitErr),
+		}
 	}
 	return res, nil
 }
 
-// shellQuote returns a printable shell-like command string for logging.
-func shellQuote(path string, args []string) string {
+// stopGrace is how long a canceled command gets to exit after being
+// interrupted (ffmpeg needs a moment to write its trailer) before the whole
+// process tree is killed.
+const stopGrace = 3 * time.Second
+
+// stopProcess is the cancellation hook for Run: interrupt the process tree,
+// then kill it if it has not exited within stopGrace.
+func stopProcess(cmd *exec.Cmd, exited <-chan struct{}) error {
+	if err := interruptProcessTree(cmd); err != nil {
+		return killProcessTree(cmd)
+	}
+	go func() {
+		select {
+		case <-exited:
+		case <-time.After(stopGrace):
+			_ = killProcessTree(cmd)
+		}
+	}()
+	return nil
+}
+
+// CmdError is returned by Run when the subprocess exits unsuccessfully.
+// It keeps the captured stderr so callers can surface it in diagnostics.
+type CmdError struct {
+	Command string // Printable command line
+	Code    int
+	Stderr  []byte
+	Err     error
+}
+
+func (e *CmdError) Error() string {
+	return fmt.Sprintf("command failed (exit %d): %v", e.Code, e.Err)
+}
+
+func (e *CmdError) Unwrap() error {
+	return e.Err
+}
+
+// tail returns at most the last n bytes of b as a string.
+func tail(b []byte, n int) string {
+	if len(b) > n {
+		b = b[len(b)-n:]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// ShellQuote returns a printable shell-like command line, for logs and for
+// users to copy-paste.
+func ShellQuote(path string, args []string) string {
 	b := &strings.Builder{}
 	b.WriteString(quote(path))
 	for _, a := range args {
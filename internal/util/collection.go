@@ -0,0 +1,45 @@
+package util
+
+import "strings"
+
+// IsCollectionURL reports whether raw points at a YouTube channel or playlist
+// or an Instagram profile, story, or highlight rather than a single video or
+// post.
+func IsCollectionURL(raw string) bool {
+	if IsStoryURL(raw) {
+		return StoryItemID(raw) == ""
+	}
+	pl, u, err := DetectPlatform(raw)
+	if err != nil {
+		return false
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	first := strings.ToLower(segs[0])
+	switch pl {
+	case PlatformYouTube:
+		if strings.HasPrefix(first, "@") {
+			return true
+		}
+		switch first {
+		case "channel", "c", "user":
+			return true
+		case "playlist":
+			return u.Query().Get("list") != ""
+		}
+	case PlatformInstagram:
+		switch first {
+		case "", "p", "reel", "tv", "s", "share", "stories", "explore", "accounts":
+			return false
+		case "reels":
+			return len(segs) == 1 // instagram.com/reels/<id> is a single reel
+		}
+		if len(segs) == 3 {
+			switch segs[1] {
+			case "p", "reel", "tv":
+				return false // instagram.com/<user>/reel/<id>
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CPUSampler measures system-wide CPU utilisation between calls to Sample.
+type CPUSampler struct {
+	prevIdle, prevTotal uint64
+}
+
+// Sample returns the fraction (0..1) of CPU time spent busy since the
+// previous call. The first call only primes the sampler and reports !ok.
+func (s *CPUSampler) Sample() (busy float64, ok bool) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0, false
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, false
+	}
+	var idle, total uint64
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		total += v
+		if i == 3 || i == 4 { // idle, iowait
+			idle += v
+		}
+	}
+	dIdle, dTotal := idle-s.prevIdle, total-s.prevTotal
+	primed := s.prevTotal != 0
+	s.prevIdle, s.prevTotal = idle, total
+	if !primed || dTotal == 0 {
+		return 0, false
+	}
+	return 1 - float64(dIdle)/float64(dTotal), true
+}
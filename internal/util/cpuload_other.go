@@ -0,0 +1,13 @@
+//go:build !linux
+
+package util
+
+// CPUSampler measures system-wide CPU utilisation between calls to Sample.
+// Only Linux is supported for now; elsewhere Sample always reports !ok.
+type CPUSampler struct{}
+
+// Sample returns the fraction (0..1) of CPU time spent busy since the
+// previous call.
+func (s *CPUSampler) Sample() (busy float64, ok bool) {
+	return 0, false
+}
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that only say where a link was shared
+// from. Those in platformTracking mean something else on other sites, so
+// they're only dropped from links to supported platforms.
+var (
+	trackingParams   = []string{"fbclid", "gclid", "mc_cid", "mc_eid"}
+	platformTracking = []string{"igsh", "igshid", "si", "feature", "pp", "ab_channel", "mibextid", "rdid", "sfnsn", "share_id", "rdt"}
+)
+
+// CleanURL removes share-tracking query parameters (utm_*, fbclid,
+// Instagram's igsh, YouTube's si, ...) and the fragment from raw. Instagram
+// links are also put in canonical form (see NormalizeURL). Direct media
+// links, whose query is often a signed token, and anything it can't parse are
+// returned as they are.
+func CleanURL(raw string) string {
+	pl, _, err := DetectPlatform(strings.TrimSpace(raw))
+	switch {
+	case err == nil && pl == PlatformInstagram:
+		return NormalizeURL(strings.TrimSpace(raw), pl)
+	case err == nil && pl == PlatformDirect:
+		return raw
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	q := u.Query()
+	drop := trackingParams
+	if _, _, err := DetectPlatform(raw); err == nil {
+		drop = append(append([]string(nil), trackingParams...), platformTracking...)
+	}
+	changed := false
+	for k := range q {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "utm_") || containsString(drop, lk) {
+			q.Del(k)
+			changed = true
+		}
+	}
+	if !changed && u.Fragment == "" {
+		return raw
+	}
+	u.RawQuery = q.Encode()
+	u.Fragment = ""
+	return u.String()
+}
+
+// SameVideoKey returns a key that is equal for links to the same video:
+// platform and ID when the link carries one (so youtu.be/x and
+// youtube.com/watch?v=x match), otherwise the cleaned URL without scheme,
+// "www.", and trailing slash.
+func SameVideoKey(raw string) string {
+	if pl, _, err := DetectPlatform(raw); err == nil {
+		if id := VideoIDFromURL(raw); id != "" {
+			return string(pl) + ":" + id
+		}
+	}
+	u, err := url.Parse(CleanURL(raw))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(raw)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	key := host + strings.TrimSuffix(u.Path, "/")
+	if u.RawQuery != "" {
+		key += "?" + u.Query().Encode() // sorted
+	}
+	return key
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package util
+
+import "testing"
+
+func TestCleanURL(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"https://www.youtube.com/watch?v=abc&si=xyz&utm_source=tw#t=10", "https://www.youtube.com/watch?v=abc"},
+		{"https://youtu.be/abc?si=x", "https://youtu.be/abc"},
+		{"https://www.reddit.com/r/x/comments/abc/t/?share_id=1&utm_name=ios", "https://www.reddit.com/r/x/comments/abc/t/"},
+		{"https://www.youtube.com/watch?v=abc", "https://www.youtube.com/watch?v=abc"},
+		// Instagram links come out canonical
+		{"https://www.instagram.com/reels/C1x/?igsh=abc&utm_source=ig", "https://www.instagram.com/reel/C1x/"},
+		// Platform parameters mean something else elsewhere
+		{"https://example.com/page?si=keep&fbclid=z", "https://example.com/page?si=keep"},
+		// Direct media queries are often signed
+		{"https://example.com/v.mp4?token=abc&utm_source=x", "https://example.com/v.mp4?token=abc&utm_source=x"},
+		{"not a url", "not a url"},
+	} {
+		if got := CleanURL(tc.in); got != tc.want {
+			t.Errorf("CleanURL(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSameVideoKey(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		same bool
+	}{
+		{"https://youtu.be/abc?si=x", "https://www.youtube.com/watch?v=abc&feature=share", true},
+		{"https://www.youtube.com/shorts/abc", "https://youtube.com/watch?v=abc", true},
+		{"https://instagram.com/someuser/reel/C1x", "https://www.instagram.com/reels/C1x/?igsh=1", true},
+		{"https://example.com/page/?utm_source=x", "http://www.example.com/page", true},
+		{"https://example.com/page?b=2&a=1", "https://example.com/page?a=1&b=2", true},
+		{"https://youtu.be/abc", "https://youtu.be/abd", false},
+		{"https://example.com/page?id=1", "https://example.com/page?id=2", false},
+	} {
+		if got := SameVideoKey(tc.a) == SameVideoKey(tc.b); got != tc.same {
+			t.Errorf("SameVideoKey(%q) = %q, SameVideoKey(%q) = %q; want same %v", tc.a, SameVideoKey(tc.a), tc.b, SameVideoKey(tc.b), tc.same)
+		}
+	}
+}
@@ -4,33 +4,73 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 )
 
 // FindDownloader returns the path to yt-dlp or youtube-dl.
 // If customPath is non-empty, it tries that path or looks it up in PATH.
+// Otherwise a managed copy (see `sniplette deps install`) is preferred over PATH.
 func FindDownloader(customPath string) (string, error) {
 	if customPath != "" {
 		if _, err := os.Stat(customPath); err == nil {
 			return customPath, nil
 		}
+		// On Windows, accept "C:\tools\yt-dlp" for "C:\tools\yt-dlp.exe".
+		if exe := exeName(customPath); exe != customPath && filepath.Ext(customPath) == "" {
+			if _, err := os.Stat(exe); err == nil {
+				return exe, nil
+			}
+		}
 		if p, err := exec.LookPath(customPath); err == nil {
 			return p, nil
 		}
 		return "", fmt.Errorf("could not find downloader at %q", customPath)
 	}
+	if p, ok := managedPath("yt-dlp"); ok {
+		return p, nil
+	}
 	if p, err := exec.LookPath("yt-dlp"); err == nil {
 		return p, nil
 	}
 	if p, err := exec.LookPath("youtube-dl"); err == nil {
 		return p, nil
 	}
-	return "", fmt.Errorf("could not find yt-dlp or youtube-dl in PATH. Please install yt-dlp.")
+	return "", fmt.Errorf("could not find yt-dlp or youtube-dl in PATH. Please install yt-dlp (or run 'sniplette deps install').")
 }
 
-// FindFFmpeg returns the path to the ffmpeg binary in PATH.
+// FindFFmpeg returns the path to the ffmpeg binary, preferring a managed copy over PATH.
 func FindFFmpeg() (string, error) {
+	if p, ok := managedPath("ffmpeg"); ok {
+		return p, nil
+	}
 	if p, err := exec.LookPath("ffmpeg"); err == nil {
 		return p, nil
 	}
-	return "", fmt.Errorf("could not find ffmpeg in PATH. Please install ffmpeg.")
-}
\ No newline at end of file
+	return "", fmt.Errorf("could not find ffmpeg in PATH. Please install ffmpeg (or run 'sniplette deps install --ffmpeg').")
+}
+
+// FindFFprobe returns the path to ffprobe: a managed copy, the one next to
+// ffmpeg, or PATH.
+func FindFFprobe() (string, error) {
+	if p, ok := managedPath("ffprobe"); ok {
+		return p, nil
+	}
+	if ff, err := FindFFmpeg(); err == nil {
+		p := filepath.Join(filepath.Dir(ff), exeName("ffprobe"))
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	if p, err := exec.LookPath("ffprobe"); err == nil {
+		return p, nil
+	}
+	return "", fmt.Errorf("could not find ffprobe in PATH (it ships with ffmpeg).")
+}
+
+// FindRclone returns the path to rclone, used by --upload.
+func FindRclone() (string, error) {
+	if p, err := exec.LookPath("rclone"); err == nil {
+		return p, nil
+	}
+	return "", fmt.Errorf("could not find rclone in PATH; --upload needs it (https://rclone.org/install/).")
+}
@@ -0,0 +1,134 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FFmpegCaps lists what the installed ffmpeg build can do.
+type FFmpegCaps struct {
+	Encoders map[string]bool `json:"-"`
+	Filters  map[string]bool `json:"-"`
+	HWAccels []string        `json:"hwaccels"`
+}
+
+// Feature ties a sniplette feature to the ffmpeg components it needs.
+type Feature struct {
+	Name     string   `json:"name"`
+	Encoders []string `json:"encoders,omitempty"`
+	Filters  []string `json:"filters,omitempty"`
+	AnyOf    bool     `json:"-"` // Any one of Encoders is enough
+	Required bool     `json:"required"`
+}
+
+// FeatureStatus reports whether a feature is usable with the probed build.
+type FeatureStatus struct {
+	Feature
+	Available bool     `json:"available"`
+	Missing   []string `json:"missing,omitempty"`
+}
+
+// Features lists the ffmpeg components sniplette features depend on.
+var Features = []Feature{
+	{Name: "H.264 video (default output)", Encoders: []string{"libx264"}, Filters: []string{"scale"}, Required: true},
+	{Name: "AAC audio (default output)", Encoders: []string{"aac"}, Required: true},
+	{Name: "Thumbnails", Encoders: []string{"png", "mjpeg"}, AnyOf: true},
+	{Name: "HEVC/H.265 video", Encoders: []string{"libx265"}},
+	{Name: "Opus audio", Encoders: []string{"libopus"}},
+	{Name: "Loudness normalization", Filters: []string{"loudnorm"}},
+	{Name: "Subtitle burn-in", Filters: []string{"subtitles"}},
+	{Name: "Text overlays/watermarks", Filters: []string{"drawtext"}},
+	{Name: "Denoise/sharpen", Filters: []string{"hqdn3d", "unsharp"}},
+	{Name: "Strong denoise", Filters: []string{"nlmeans"}},
+}
+
+// ProbeFFmpeg enumerates encoders, filters, and hwaccels of the ffmpeg binary.
+func ProbeFFmpeg(ctx context.Context, path string) (FFmpegCaps, error) {
+	caps := FFmpegCaps{}
+	enc, err := toolOutput(ctx, path, "-hide_banner", "-encoders")
+	if err != nil {
+		return caps, err
+	}
+	caps.Encoders = parseFFmpegList(enc, func(f []string) (string, bool) {
+		// " V....D libx264   libx264 H.264 ..." (6-char capability flags)
+		return f[1], len(f) >= 2 && len(f[0]) == 6 && f[1] != "="
+	})
+	filt, err := toolOutput(ctx, path, "-hide_banner", "-filters")
+	if err != nil {
+		return caps, err
+	}
+	caps.Filters = parseFFmpegList(filt, func(f []string) (string, bool) {
+		// " TSC scale   V->V   Scale the input video size..."
+		return f[1], len(f) >= 3 && strings.Contains(f[2], "->")
+	})
+	hw, err := toolOutput(ctx, path, "-hide_banner", "-hwaccels")
+	if err != nil {
+		return caps, err
+	}
+	for _, line := range strings.Split(hw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		caps.HWAccels = append(caps.HWAccels, line)
+	}
+	return caps, nil
+}
+
+// Evaluate reports the availability of each known feature.
+func (c FFmpegCaps) Evaluate() []FeatureStatus {
+	out := make([]FeatureStatus, 0, len(Features))
+	for _, f := range Features {
+		st := FeatureStatus{Feature: f, Available: true}
+		var missingEnc []string
+		for _, e := range f.Encoders {
+			if !c.Encoders[e] {
+				missingEnc = append(missingEnc, e)
+			}
+		}
+		switch {
+		case f.AnyOf && len(f.Encoders) > 0 && len(missingEnc) == len(f.Encoders):
+			st.Available = false
+			st.Missing = append(st.Missing, strings.Join(missingEnc, "|"))
+		case !f.AnyOf && len(missingEnc) > 0:
+			st.Available = false
+			st.Missing = append(st.Missing, missingEnc...)
+		}
+		for _, fl := range f.Filters {
+			if !c.Filters[fl] {
+				st.Available = false
+				st.Missing = append(st.Missing, fl)
+			}
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// MissingRequired describes required features the build cannot provide.
+func (c FFmpegCaps) MissingRequired() []string {
+	var out []string
+	for _, st := range c.Evaluate() {
+		if st.Required && !st.Available {
+			out = append(out, fmt.Sprintf("%s needs %s", st.Name, strings.Join(st.Missing, ", ")))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func parseFFmpegList(out string, pick func(fields []string) (string, bool)) map[string]bool {
+	names := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) < 2 {
+			continue
+		}
+		if name, ok := pick(f); ok {
+			names[name] = true
+		}
+	}
+	return names
+}
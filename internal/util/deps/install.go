@@ -0,0 +1,319 @@
+package deps
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Release locations. yt-dlp publishes standalone binaries plus SHA2-256SUMS;
+// BtbN/FFmpeg-Builds publishes static ffmpeg/ffprobe builds plus checksums.sha256.
+const (
+	ytdlpReleaseBase  = "https://github.com/yt-dlp/yt-dlp/releases/latest/download/"
+	ytdlpChecksums    = "SHA2-256SUMS"
+	ffmpegReleaseBase = "https://github.com/BtbN/FFmpeg-Builds/releases/download/latest/"
+	ffmpegChecksums   = "checksums.sha256"
+)
+
+// ErrUnsupportedPlatform is returned when no managed build exists for this OS/arch.
+var ErrUnsupportedPlatform = errors.New("no managed build for this platform")
+
+// InstallOptions controls managed tool installation.
+type InstallOptions struct {
+	Client   *http.Client     // nil uses http.DefaultClient
+	Progress func(msg string) // optional status callback
+	Force    bool             // re-download even if present
+}
+
+func (o InstallOptions) client() *http.Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	return http.DefaultClient
+}
+
+func (o InstallOptions) say(format string, args ...any) {
+	if o.Progress != nil {
+		o.Progress(fmt.Sprintf(format, args...))
+	}
+}
+
+// ytdlpAsset picks the standalone yt-dlp release asset for this platform.
+func ytdlpAsset() (string, error) {
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64":
+		return "yt-dlp_linux", nil
+	case "linux/arm64":
+		return "yt-dlp_linux_aarch64", nil
+	case "darwin/amd64", "darwin/arm64":
+		return "yt-dlp_macos", nil
+	case "windows/amd64", "windows/386":
+		return "yt-dlp.exe", nil
+	default:
+		return "", fmt.Errorf("yt-dlp: %w (%s/%s)", ErrUnsupportedPlatform, runtime.GOOS, runtime.GOARCH)
+	}
+}
+
+// ffmpegAsset picks the static ffmpeg archive for this platform.
+func ffmpegAsset() (string, error) {
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64":
+		return "ffmpeg-master-latest-linux64-gpl.tar.xz", nil
+	case "linux/arm64":
+		return "ffmpeg-master-latest-linuxarm64-gpl.tar.xz", nil
+	case "windows/amd64":
+		return "ffmpeg-master-latest-win64-gpl.zip", nil
+	default:
+		return "", fmt.Errorf("ffmpeg: %w (%s/%s); install it with your package manager (e.g., brew install ffmpeg)", ErrUnsupportedPlatform, runtime.GOOS, runtime.GOARCH)
+	}
+}
+
+// InstallYTDLP downloads the latest standalone yt-dlp into ManagedBinDir,
+// verifying its SHA-256 against the release checksums.
+func InstallYTDLP(ctx context.Context, opts InstallOptions) (string, error) {
+	asset, err := ytdlpAsset()
+	if err != nil {
+		return "", err
+	}
+	dir, err := ManagedBinDir()
+	if err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, exeName("yt-dlp"))
+	if !opts.Force {
+		if _, err := os.Stat(dst); err == nil {
+			opts.say("yt-dlp already installed at %s (use --force to reinstall)", dst)
+			return dst, nil
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	sums, err := fetchChecksums(ctx, opts, ytdlpReleaseBase+ytdlpChecksums)
+	if err != nil {
+		return "", err
+	}
+	want, ok := sums[asset]
+	if !ok {
+		return "", fmt.Errorf("no checksum for %s in %s", asset, ytdlpChecksums)
+	}
+	opts.say("Downloading %s", ytdlpReleaseBase+asset)
+	tmp, err := downloadVerified(ctx, opts, ytdlpReleaseBase+asset, dir, want)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp, 0o755); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	opts.say("Installed yt-dlp to %s", dst)
+	return dst, nil
+}
+
+// InstallFFmpeg downloads a static ffmpeg build, verifies it, and extracts
+// ffmpeg and ffprobe into ManagedBinDir.
+func InstallFFmpeg(ctx context.Context, opts InstallOptions) (string, error) {
+	asset, err := ffmpegAsset()
+	if err != nil {
+		return "", err
+	}
+	dir, err := ManagedBinDir()
+	if err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, exeName("ffmpeg"))
+	if !opts.Force {
+		if _, err := os.Stat(dst); err == nil {
+			opts.say("ffmpeg already installed at %s (use --force to reinstall)", dst)
+			return dst, nil
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	sums, err := fetchChecksums(ctx, opts, ffmpegReleaseBase+ffmpegChecksums)
+	if err != nil {
+		return "", err
+	}
+	want, ok := sums[asset]
+	if !ok {
+		return "", fmt.Errorf("no checksum for %s in %s", asset, ffmpegChecksums)
+	}
+	opts.say("Downloading %s", ffmpegReleaseBase+asset)
+	archive, err := downloadVerified(ctx, opts, ffmpegReleaseBase+asset, dir, want)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(archive)
+
+	opts.say("Extracting ffmpeg and ffprobe")
+	for _, tool := range []string{"ffmpeg", "ffprobe"} {
+		if err := extractTool(ctx, archive, asset, exeName(tool), dir); err != nil {
+			return "", fmt.Errorf("extract %s: %w", tool, err)
+		}
+	}
+	opts.say("Installed ffmpeg to %s", dst)
+	return dst, nil
+}
+
+// fetchChecksums downloads a sha256sum-style file into a name -> hex digest map.
+func fetchChecksums(ctx context.Context, opts InstallOptions, url string) (map[string]string, error) {
+	body, err := httpGet(ctx, opts, url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch checksums: %w", err)
+	}
+	defer body.Close()
+	sums := make(map[string]string)
+	sc := bufio.NewScanner(body)
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) != 2 {
+			continue
+		}
+		sums[strings.TrimPrefix(f[1], "*")] = strings.ToLower(f[0])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read checksums: %w", err)
+	}
+	return sums, nil
+}
+
+// downloadVerified streams url into a temp file in dir and checks its SHA-256.
+func downloadVerified(ctx context.Context, opts InstallOptions, url, dir, wantHex string) (string, error) {
+	body, err := httpGet(ctx, opts, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	f, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, copyErr := io.Copy(io.MultiWriter(f, h), body)
+	closeErr := f.Close()
+	if copyErr != nil || closeErr != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("download %s: %w", url, errors.Join(copyErr, closeErr))
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != wantHex {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, want %s", url, got, wantHex)
+	}
+	return f.Name(), nil
+}
+
+func httpGet(ctx context.Context, opts InstallOptions, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := opts.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// extractTool copies the archive member named bin/<name> into dir/<name>.
+func extractTool(ctx context.Context, archive, asset, name, dir string) error {
+	dst := filepath.Join(dir, name)
+	if strings.HasSuffix(asset, ".zip") {
+		zr, err := zip.OpenReader(archive)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		for _, zf := range zr.File {
+			if filepath.Base(zf.Name) != name || !strings.Contains(zf.Name, "/bin/") {
+				continue
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			return writeExecutable(dst, rc)
+		}
+		return fmt.Errorf("%s not found in archive", name)
+	}
+
+	// .tar.xz: the standard library has no xz reader, so use the system tar.
+	member := strings.TrimSuffix(asset, ".tar.xz") + "/bin/" + name
+	cmd := exec.CommandContext(ctx, "tar", "-xJOf", archive, member)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("run tar: %w", err)
+	}
+	// tar's exit status says whether the member came out whole, so dst is
+	// only replaced once it is known
+	tmp, werr := writeTemp(dst, out)
+	if werr != nil {
+		_, _ = io.Copy(io.Discard, out) // let tar finish
+	}
+	if err := cmd.Wait(); err != nil {
+		if werr == nil {
+			_ = os.Remove(tmp)
+		}
+		return fmt.Errorf("tar: %w", err)
+	}
+	if werr != nil {
+		return werr
+	}
+	return os.Rename(tmp, dst)
+}
+
+// writeExecutable writes r to dst through a temporary file, so a failed copy
+// never leaves a partial dst.
+func writeExecutable(dst string, r io.Reader) error {
+	tmp, err := writeTemp(dst, r)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// writeTemp writes r to an executable temporary file next to dst and returns
+// its path. Nothing is left behind on error.
+func writeTemp(dst string, r io.Reader) (string, error) {
+	tmp := dst + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
@@ -0,0 +1,38 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"ig2wa/internal/dirs"
+)
+
+// ManagedBinDir is where `sniplette deps install` places downloaded tools.
+func ManagedBinDir() (string, error) {
+	d, err := dirs.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "bin"), nil
+}
+
+// managedPath returns the managed copy of a tool if it exists and is a regular file.
+func managedPath(name string) (string, bool) {
+	dir, err := ManagedBinDir()
+	if err != nil {
+		return "", false
+	}
+	p := filepath.Join(dir, exeName(name))
+	if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() {
+		return p, true
+	}
+	return "", false
+}
+
+func exeName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
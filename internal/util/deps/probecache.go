@@ -0,0 +1,86 @@
+package deps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ig2wa/internal/dirs"
+)
+
+// The output of toolOutput's commands (versions, encoders, filters,
+// hwaccels) is cached on disk per binary and arguments, so a run doesn't
+// start half a dozen yt-dlp and ffmpeg processes before its first job. An
+// entry holds while the binary's size and modification time are unchanged:
+// installing or updating a tool replaces the file.
+
+type probeEntry struct {
+	ModTime time.Time `json:"mtime"`
+	Size    int64     `json:"size"`
+	Output  string    `json:"output"`
+}
+
+func probeCachePath(path string, args []string) (string, error) {
+	d, err := dirs.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(path + "\x00" + strings.Join(args, "\x00")))
+	return filepath.Join(d, "probes", hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// cachedProbe returns the cached output of path run with args, if path is
+// still the binary that produced it.
+func cachedProbe(path string, args []string) (string, bool) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	cp, err := probeCachePath(path, args)
+	if err != nil {
+		return "", false
+	}
+	b, err := os.ReadFile(cp)
+	if err != nil {
+		return "", false
+	}
+	var e probeEntry
+	if json.Unmarshal(b, &e) != nil || !e.ModTime.Equal(fi.ModTime()) || e.Size != fi.Size() {
+		return "", false
+	}
+	return e.Output, true
+}
+
+// storeProbe caches the output of path run with args. Best-effort: failures
+// only cost a later rerun.
+func storeProbe(path string, args []string, out string) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	cp, err := probeCachePath(path, args)
+	if err != nil {
+		return
+	}
+	dir := filepath.Dir(cp)
+	if err := dirs.Ensure(dir); err != nil {
+		return
+	}
+	b, err := json.Marshal(probeEntry{ModTime: fi.ModTime(), Size: fi.Size(), Output: out})
+	if err != nil {
+		return
+	}
+	// Write then rename so a concurrent reader never sees half a file.
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(b)
+	if cerr := tmp.Close(); werr != nil || cerr != nil || os.Rename(tmp.Name(), cp) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
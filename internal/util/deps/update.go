@@ -0,0 +1,62 @@
+package deps
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// IsManaged reports whether path lives in ManagedBinDir.
+func IsManaged(path string) bool {
+	dir, err := ManagedBinDir()
+	if err != nil || path == "" {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return filepath.Dir(abs) == filepath.Clean(dir)
+}
+
+// UpdateDownloader brings the downloader at path up to date. Managed copies are
+// re-downloaded (with checksum verification); other yt-dlp installs are asked to
+// update themselves with `yt-dlp -U`, which works for the standalone binaries
+// but is refused by pip/distro installs (the error says how to update instead).
+func UpdateDownloader(ctx context.Context, path string, opts InstallOptions) error {
+	if IsManaged(path) {
+		opts.Force = true
+		_, err := InstallYTDLP(ctx, opts)
+		return err
+	}
+	if strings.Contains(strings.ToLower(filepath.Base(path)), "youtube-dl") {
+		return fmt.Errorf("youtube-dl cannot self-update; install yt-dlp (or run 'sniplette deps install')")
+	}
+
+	opts.say("Running %s -U", path)
+	cmd := exec.CommandContext(ctx, path, "-U")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("run %s -U: %w", filepath.Base(path), err)
+	}
+	var last string
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		last = sc.Text()
+		opts.say("%s", last)
+	}
+	if err := cmd.Wait(); err != nil {
+		if last != "" {
+			return fmt.Errorf("%s -U failed: %s", filepath.Base(path), last)
+		}
+		return fmt.Errorf("%s -U failed: %w", filepath.Base(path), err)
+	}
+	return nil
+}
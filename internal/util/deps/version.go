@@ -0,0 +1,157 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Known-good minimums. Older yt-dlp releases routinely fail on Instagram, and
+// ffmpeg 4.x is the oldest line with the filters/flags the encoder relies on.
+const (
+	MinYTDLPVersion   = "2023.07.06"
+	MinFFmpegMajor    = 4
+	YTDLPStaleAfter   = 60 * 24 * time.Hour
+	versionCmdTimeout = 15 * time.Second
+)
+
+// Check is the outcome of inspecting one external tool.
+type Check struct {
+	Tool     string   `json:"tool"`
+	Path     string   `json:"path"`
+	Version  string   `json:"version,omitempty"`
+	OK       bool     `json:"ok"`                 // false when below the minimum or not runnable
+	Outdated bool     `json:"outdated,omitempty"` // below the minimum or stale; an update would help
+	Problems []string `json:"problems,omitempty"` // blocking issues
+	Warnings []string `json:"warnings,omitempty"` // advisory issues (e.g., stale)
+}
+
+// ToolVersion runs the tool's version command and returns its first output line.
+func ToolVersion(ctx context.Context, path string, args ...string) (string, error) {
+	out, err := toolOutput(ctx, path, args...)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// toolOutput runs a short informational tool command and returns its stdout,
+// or the cached stdout of the last run of the same binary (see probeEntry).
+func toolOutput(ctx context.Context, path string, args ...string) (string, error) {
+	if out, ok := cachedProbe(path, args); ok {
+		return out, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, versionCmdTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("run %s %s: %w", filepath.Base(path), strings.Join(args, " "), err)
+	}
+	storeProbe(path, args, string(out))
+	return string(out), nil
+}
+
+// CheckDownloader validates yt-dlp/youtube-dl: minimum version, staleness, and
+// use of the unmaintained youtube-dl.
+func CheckDownloader(ctx context.Context, path string, now time.Time) Check {
+	c := Check{Tool: "downloader", Path: path, OK: true}
+	v, err := ToolVersion(ctx, path, "--version")
+	if err != nil {
+		c.OK = false
+		c.Problems = append(c.Problems, err.Error())
+		return c
+	}
+	c.Version = v
+
+	if strings.Contains(strings.ToLower(filepath.Base(path)), "youtube-dl") {
+		c.Warnings = append(c.Warnings, "youtube-dl is unmaintained and usually broken for Instagram; install yt-dlp")
+	}
+	released, ok := ParseYTDLPDate(v)
+	if !ok {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("unrecognized version %q; cannot verify minimum %s", v, MinYTDLPVersion))
+		return c
+	}
+	minDate, _ := ParseYTDLPDate(MinYTDLPVersion)
+	if released.Before(minDate) {
+		c.OK = false
+		c.Outdated = true
+		c.Problems = append(c.Problems, fmt.Sprintf("version %s is older than the minimum %s; run 'sniplette deps update'", v, MinYTDLPVersion))
+		return c
+	}
+	if age := now.Sub(released); age > YTDLPStaleAfter {
+		c.Outdated = true
+		c.Warnings = append(c.Warnings, fmt.Sprintf("version %s is %d days old; Instagram extraction often breaks on stale releases, run 'sniplette deps update'", v, int(age.Hours()/24)))
+	}
+	return c
+}
+
+// CheckFFmpeg validates that ffmpeg runs, meets the minimum major version, and
+// ships the encoders/filters the default output needs. Git builds
+// (e.g., "N-113000-g...") cannot be version-compared and are accepted.
+func CheckFFmpeg(ctx context.Context, path string) Check {
+	c := Check{Tool: "ffmpeg", Path: path, OK: true}
+	line, err := ToolVersion(ctx, path, "-version")
+	if err != nil {
+		c.OK = false
+		c.Problems = append(c.Problems, err.Error())
+		return c
+	}
+	v := ParseFFmpegVersion(line)
+	c.Version = v
+	if major, ok := ffmpegMajor(v); ok && major < MinFFmpegMajor {
+		c.OK = false
+		c.Problems = append(c.Problems, fmt.Sprintf("version %s is older than the minimum %d.0; please upgrade ffmpeg", v, MinFFmpegMajor))
+	}
+	caps, err := ProbeFFmpeg(ctx, path)
+	if err != nil {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("could not probe capabilities: %v", err))
+		return c
+	}
+	for _, m := range caps.MissingRequired() {
+		c.OK = false
+		c.Problems = append(c.Problems, "this build lacks required components: "+m)
+	}
+	return c
+}
+
+var ytdlpDateRe = regexp.MustCompile(`^(\d{4})\.(\d{1,2})\.(\d{1,2})`)
+
+// ParseYTDLPDate extracts the release date from a yt-dlp/youtube-dl version
+// such as "2024.08.06" or the nightly form "2024.08.06.232456".
+func ParseYTDLPDate(v string) (time.Time, bool) {
+	m := ytdlpDateRe.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return time.Time{}, false
+	}
+	y, _ := strconv.Atoi(m[1])
+	mo, _ := strconv.Atoi(m[2])
+	d, _ := strconv.Atoi(m[3])
+	return time.Date(y, time.Month(mo), d, 0, 0, 0, 0, time.UTC), true
+}
+
+var ffmpegVersionRe = regexp.MustCompile(`(?i)ffmpeg version (\S+)`)
+
+// ParseFFmpegVersion extracts the version token from `ffmpeg -version` output.
+func ParseFFmpegVersion(line string) string {
+	if m := ffmpegVersionRe.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	return strings.TrimSpace(line)
+}
+
+var ffmpegMajorRe = regexp.MustCompile(`^n?(\d+)\.`)
+
+func ffmpegMajor(v string) (int, bool) {
+	m := ffmpegMajorRe.FindStringSubmatch(v)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
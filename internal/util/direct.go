@@ -0,0 +1,69 @@
+package util
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// directMediaExts are the file extensions of links to a media file or
+// stream manifest rather than a page.
+var directMediaExts = map[string]bool{
+	".mp4": true, ".m4v": true, ".mov": true, ".webm": true, ".mkv": true,
+	".m3u8": true, ".mpd": true,
+}
+
+// genericMediaNames are manifest and file names that say nothing about the
+// video; DirectMediaName uses the directory above them instead.
+var genericMediaNames = map[string]bool{
+	"master": true, "index": true, "playlist": true, "manifest": true, "video": true,
+	"stream": true, "media": true, "main": true,
+}
+
+// isDirectMedia reports whether u is an http(s) link whose path ends in a
+// media file or manifest extension.
+func isDirectMedia(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	return directMediaExts[strings.ToLower(path.Ext(u.Path))]
+}
+
+// IsStreamManifest reports whether raw is a direct link to an HLS (.m3u8) or
+// DASH (.mpd) manifest, which ffmpeg fetches rather than a plain GET.
+func IsStreamManifest(raw string) bool {
+	pl, u, err := DetectPlatform(strings.TrimSpace(raw))
+	if err != nil || pl != PlatformDirect {
+		return false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".m3u8", ".mpd":
+		return true
+	}
+	return false
+}
+
+// DirectMediaName returns a name for the video at a direct media link: the
+// file name without extension (or, for generic names like master.m3u8, the
+// directory it is in) and the host without "www.".
+func DirectMediaName(raw string) (name, host string) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "download", ""
+	}
+	host = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := len(segs) - 1; i >= 0; i-- {
+		name = strings.TrimSuffix(segs[i], path.Ext(segs[i]))
+		if i == len(segs)-1 && genericMediaNames[strings.ToLower(name)] {
+			continue
+		}
+		if name != "" {
+			return name, host
+		}
+	}
+	if name == "" {
+		name = "download"
+	}
+	return name, host
+}
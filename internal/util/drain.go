@@ -0,0 +1,74 @@
+package util
+
+import (
+	"context"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// Drain is a request to stop gracefully, as a service manager makes with
+// SIGTERM: start no new work, let running work finish (within a grace
+// period), and keep what was left for later.
+type Drain struct {
+	once sync.Once
+	done chan struct{}
+	sig  os.Signal
+}
+
+// NewDrain returns a drain that hasn't been requested yet.
+func NewDrain() *Drain {
+	return &Drain{done: make(chan struct{})}
+}
+
+// Start requests the drain; sig is the signal that asked for it. Later calls
+// do nothing.
+func (d *Drain) Start(sig os.Signal) {
+	d.once.Do(func() {
+		d.sig = sig
+		close(d.done)
+	})
+}
+
+// Done is closed once the drain is requested. A nil Drain's never is.
+func (d *Drain) Done() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+	return d.done
+}
+
+// Started reports whether the drain was requested.
+func (d *Drain) Started() bool {
+	select {
+	case <-d.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// ExitCode is the conventional status of a process stopped by the drain's
+// signal (128 + signal number, e.g. 143 for SIGTERM); 1 when unknown.
+func (d *Drain) ExitCode() int {
+	if !d.Started() {
+		return 1
+	}
+	if s, ok := d.sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+type drainKey struct{}
+
+// WithDrain attaches d to ctx.
+func WithDrain(ctx context.Context, d *Drain) context.Context {
+	return context.WithValue(ctx, drainKey{}, d)
+}
+
+// DrainFrom returns the drain attached to ctx, or nil (which never starts).
+func DrainFrom(ctx context.Context) *Drain {
+	d, _ := ctx.Value(drainKey{}).(*Drain)
+	return d
+}
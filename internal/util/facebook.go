@@ -0,0 +1,50 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+)
+
+// facebookSections are the first path segments of Facebook links that are
+// not a page or profile name.
+var facebookSections = map[string]bool{
+	"watch": true, "reel": true, "reels": true, "videos": true, "share": true, "story.php": true,
+	"video.php": true, "photo.php": true, "permalink.php": true, "groups": true, "events": true,
+	"login": true, "profile.php": true, "people": true, "hashtag": true, "plugins": true,
+}
+
+// facebookVideoID returns the video ID of a Facebook reel or video link
+// (facebook.com/reel/<id>, /watch?v=<id>, /<page>/videos/[<slug>/]<id>), or
+// "" when the link doesn't carry one (fb.watch and share links).
+func facebookVideoID(u *url.URL) string {
+	if v := u.Query().Get("v"); isDigits(v) {
+		return v
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(segs); i++ {
+		switch segs[i] {
+		case "reel", "videos":
+			for _, s := range segs[i+1:] {
+				if isDigits(s) {
+					return s
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// FacebookPage returns the page or profile name in a Facebook link, such as
+// "nasa" in facebook.com/nasa/videos/123, or "".
+func FacebookPage(raw string) string {
+	pl, u, err := DetectPlatform(strings.TrimSpace(raw))
+	if err != nil || pl != PlatformFacebook || !strings.HasSuffix(strings.ToLower(u.Hostname()), "facebook.com") {
+		return ""
+	}
+	first := strings.Split(strings.Trim(u.Path, "/"), "/")[0]
+	if first == "" || facebookSections[strings.ToLower(first)] {
+		return ""
+	}
+	return first
+}
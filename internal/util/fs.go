@@ -1,20 +1,37 @@
 package util
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"syscall"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"ig2wa/internal/dirs"
 )
 
-// MakeTempWorkdir creates a unique temp directory under the app cache dir.
+// MakeTempWorkdir creates a unique temp directory under the app cache dir and
+// records it in the state dir; remove it with RemoveTempWorkdir.
 func MakeTempWorkdir(prefix string) (string, error) {
-	base, err := dirs.TempBaseDir()
-	if err != nil {
-		return "", err
+	return MakeTempWorkdirIn("", prefix)
+}
+
+// MakeTempWorkdirIn is MakeTempWorkdir with an explicit base directory; an
+// empty base uses dirs.TempBaseDir.
+func MakeTempWorkdirIn(base, prefix string) (string, error) {
+	if base == "" {
+		b, err := dirs.TempBaseDir()
+		if err != nil {
+			return "", err
+		}
+		base = b
 	}
 	if err := os.MkdirAll(base, 0o755); err != nil {
 		return "", err
@@ -24,6 +41,7 @@ func MakeTempWorkdir(prefix string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	trackWorkdir(dir)
 	return dir, nil
 }
 
@@ -48,9 +66,10 @@ func RemoveIfExists(path string) error {
 
 // SanitizeFilename cleans a string to be safe as a filename:
 // - Replace spaces with underscores
-// - Replace forbidden characters with underscores
-// - Trim duplicated underscores
-// - Truncate to a reasonable length (~200 runes)
+// - Replace forbidden characters and control characters with underscores
+// - Trim duplicated underscores and leading/trailing dots (Windows drops them)
+// - Truncate to a reasonable length (~200 runes, at most maxComponentBytes)
+// - Mark Windows device names (CON, AUX, COM1, ...) with an underscore
 func SanitizeFilename(s string) string {
 	if s == "" {
 		return "untitled"
@@ -59,9 +78,12 @@ func SanitizeFilename(s string) string {
 	s = strings.ReplaceAll(s, " ", "_")
 	// Replace forbidden characters
 	forbidden := `[]/\:*?"<>|#%{}$!@+^~\` + "`" + `=&;`
-	for _, r := range forbidden {
-		s = strings.ReplaceAll(s, string(r), "_")
-	}
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(forbidden, r) {
+			return '_'
+		}
+		return r
+	}, s)
 	// Collapse runs of underscores
 	for strings.Contains(s, "__") {
 		s = strings.ReplaceAll(s, "__", "_")
@@ -70,32 +92,187 @@ func SanitizeFilename(s string) string {
 
 	// Truncate to 200 runes while preserving UTF-8 integrity
 	const maxRunes = 200
-	if utf8.RuneCountInString(s) > maxRunes {
+	if utf8.RuneCountInString(s) > maxRunes || len(s) > maxComponentBytes {
 		var b strings.Builder
 		b.Grow(len(s))
 		count := 0
 		for _, r := range s {
-			if count >= maxRunes {
+			if count >= maxRunes || b.Len()+utf8.RuneLen(r) > maxComponentBytes {
 				break
 			}
 			b.WriteRune(r)
 			count++
 		}
-		s = b.String()
+		s = strings.TrimRight(b.String(), "._-") // the cut may end on one
 	}
 
 	if s == "" {
 		return "untitled"
 	}
+	if isReservedName(s) {
+		// "aux.mp4_" is still reserved; the mark goes on the stem.
+		stem, rest, found := strings.Cut(s, ".")
+		if s = stem + "_"; found {
+			s += "." + rest
+		}
+	}
 	return s
 }
 
+// reservedNames are device names Windows refuses as file names, with or
+// without an extension ("aux.mp4" fails too). Windows also reserves COM and
+// LPT with superscript digits.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+	"COM¹": true, "COM²": true, "COM³": true, "LPT¹": true, "LPT²": true, "LPT³": true,
+}
+
+func isReservedName(s string) bool {
+	stem, _, _ := strings.Cut(s, ".")
+	return reservedNames[strings.ToUpper(stem)]
+}
+
+// maxComponentBytes caps one sanitized part of a name, so that a few of them
+// joined still fit maxNameBytes more often than not; FitFilename enforces
+// the limit on the whole name.
+const maxComponentBytes = 200
+
+// maxNameBytes is the longest file name common filesystems accept.
+const maxNameBytes = 255
+
+// windowsMaxPath is MAX_PATH without the terminating NUL. Longer paths fail
+// on Windows unless long paths are enabled.
+const windowsMaxPath = 259
+
+// partSuffix marks a file MoveFile is still copying into place.
+const partSuffix = ".part"
+
+// FitFilename returns base, shortened if needed so that every file named
+// base+ext for the given extensions (the output and its sidecars), even
+// while MoveFile copies it, fits the file name limit of dir's filesystem
+// (see nameMax) and, on Windows, MAX_PATH. A shortened name ends in "~" and
+// a hash of the full base, so it is the same on every run and different long
+// names stay different.
+func FitFilename(dir, base string, exts ...string) string {
+	windows := runtime.GOOS == "windows"
+	if windows {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	}
+	return fitName(dir, base, nameMax(dir), windows, exts)
+}
+
+// fitName is FitFilename for a file name limit of limit bytes, and MAX_PATH
+// when windows is set.
+func fitName(dir, base string, limit int, windows bool, exts []string) string {
+	suffix := len(partSuffix)
+	for _, e := range exts {
+		suffix = max(suffix, len(e)+len(partSuffix))
+	}
+	fits := func(name string) bool {
+		if len(name)+suffix > limit {
+			return false
+		}
+		// Windows counts UTF-16 code units.
+		return !windows ||
+			len(utf16.Encode([]rune(filepath.Join(dir, name))))+suffix <= windowsMaxPath
+	}
+	if fits(base) {
+		return base
+	}
+	sum := sha1.Sum([]byte(base))
+	tag := "~" + hex.EncodeToString(sum[:4])
+	var b strings.Builder
+	keep := ""
+	for _, r := range base {
+		b.WriteRune(r)
+		if !fits(b.String() + tag) {
+			break
+		}
+		keep = b.String()
+	}
+	if keep = strings.TrimRight(keep, "._-"); keep == "" {
+		return tag[1:]
+	}
+	return keep + tag
+}
+
+// SidecarPath swaps the extension of outputPath for ext (e.g., ".txt").
+func SidecarPath(outputPath, ext string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ext
+}
+
 // WriteCaptionFile writes a .txt with the same basename as the given outputPath.
 func WriteCaptionFile(outputPath string, content string) (string, error) {
-	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
-	captionPath := base + ".txt"
+	captionPath := SidecarPath(outputPath, ".txt")
 	if err := os.WriteFile(captionPath, []byte(content), 0o644); err != nil {
 		return "", err
 	}
 	return captionPath, nil
 }
+
+// HumanizeBytes formats a byte count with binary units (e.g., "1.5 MB").
+func HumanizeBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	units := []string{"KB", "MB", "GB", "TB", "PB"}
+	return fmt.Sprintf("%.1f %s", float64(b)/float64(div), units[exp])
+}
+
+// FileSize returns the size of the file at path, or 0 if it cannot be read.
+func FileSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// MoveFile renames src to dst, falling back to copy-and-delete when they are
+// on different filesystems.
+func MoveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	} else if !errors.Is(err, syscall.EXDEV) && !isCrossDevice(err) {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + partSuffix
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
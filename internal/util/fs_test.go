@@ -0,0 +1,125 @@
+package util
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"", "untitled"},
+		{"___", "untitled"},
+		{"My Video: Part 1/2", "My_Video_Part_1_2"},
+		{"  ..hidden..  ", "hidden"},
+		{"a\x00b\tc\x7f", "a_b_c"},
+		{"a__b--c", "a_b--c"},
+		{"日本語 タイトル", "日本語_タイトル"},
+		// Windows device names, with or without an extension, in any case
+		{"CON", "CON_"},
+		{"aux.mp4", "aux_.mp4"},
+		{"Com1.txt", "Com1_.txt"},
+		{"lpt9.tar.gz", "lpt9_.tar.gz"},
+		{"COM¹", "COM¹_"},
+		{"conference", "conference"},
+		{"con_", "con_"},
+	} {
+		if got := SanitizeFilename(tc.in); got != tc.want {
+			t.Errorf("SanitizeFilename(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSanitizeFilenameTruncates(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"runes", strings.Repeat("a", 250), strings.Repeat("a", 200)},
+		{"two-byte runes", strings.Repeat("é", 150), strings.Repeat("é", 100)},
+		{"three-byte runes cut whole", strings.Repeat("日", 210), strings.Repeat("日", 66)},
+		{"cut on a dot", strings.Repeat("a", 199) + ".b", strings.Repeat("a", 199)},
+	} {
+		got := SanitizeFilename(tc.in)
+		if got != tc.want {
+			t.Errorf("%s: SanitizeFilename = %q (%d bytes), want %q", tc.name, got, len(got), tc.want)
+		}
+		if !utf8.ValidString(got) || len(got) > maxComponentBytes {
+			t.Errorf("%s: SanitizeFilename = %q, not valid UTF-8 within %d bytes", tc.name, got, maxComponentBytes)
+		}
+	}
+}
+
+func TestFitFilename(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	winDir := `C:\Users\someone\Videos\sniplette`
+	for _, tc := range []struct {
+		name    string
+		dir     string
+		base    string
+		limit   int
+		windows bool
+		exts    []string
+	}{
+		{"255-byte names", "/out", long, 255, false, []string{".mp4", ".txt"}},
+		{"eCryptfs", "/home/u/Private", long, 143, false, []string{".mp4", ".webm"}},
+		{"eCryptfs, multi-byte", "/home/u/Private", strings.Repeat("日", 60), 143, false, []string{".mp4"}},
+		{"MAX_PATH", winDir, strings.Repeat("b", 250), 255, true, []string{".mp4"}},
+		{"MAX_PATH, UTF-16 pairs", winDir, strings.Repeat("😀", 100), 255, true, []string{".mp4"}},
+	} {
+		got := fitName(tc.dir, tc.base, tc.limit, tc.windows, tc.exts)
+		sum := sha1.Sum([]byte(tc.base))
+		tag := "~" + hex.EncodeToString(sum[:4])
+		if !strings.HasSuffix(got, tag) || !strings.HasPrefix(tc.base, strings.TrimSuffix(got, tag)) {
+			t.Errorf("%s: fitName = %q, want a prefix of the base and %q", tc.name, got, tag)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: fitName = %q, not valid UTF-8", tc.name, got)
+		}
+		for _, ext := range tc.exts {
+			name := got + ext + partSuffix
+			if len(name) > tc.limit {
+				t.Errorf("%s: %q is %d bytes, over %d", tc.name, name, len(name), tc.limit)
+			}
+			if n := len(utf16.Encode([]rune(filepath.Join(tc.dir, name)))); tc.windows && n > windowsMaxPath {
+				t.Errorf("%s: path of %q is %d UTF-16 units, over MAX_PATH", tc.name, name, n)
+			}
+		}
+		// Only as short as it has to be: one more rune wouldn't fit
+		suffix := len(partSuffix)
+		for _, ext := range tc.exts {
+			suffix = max(suffix, len(ext)+len(partSuffix))
+		}
+		if kept := strings.TrimSuffix(got, tag); !tc.windows && len(kept) < len(tc.base) {
+			_, size := utf8.DecodeRuneInString(tc.base[len(kept):])
+			if len(got)+size+suffix <= tc.limit {
+				t.Errorf("%s: fitName = %q, could keep more of the base", tc.name, got)
+			}
+		}
+	}
+}
+
+func TestFitFilenameTag(t *testing.T) {
+	a := strings.Repeat("x", 300) + "one"
+	b := strings.Repeat("x", 300) + "two"
+	fit := func(base string) string { return fitName("/out", base, 255, false, []string{".mp4"}) }
+	if fit(a) != fit(a) {
+		t.Errorf("fitName isn't stable: %q, %q", fit(a), fit(a))
+	}
+	if fit(a) == fit(b) {
+		t.Errorf("fitName gives long names differing past the cut the same name %q", fit(a))
+	}
+	if got := fit("short"); got != "short" {
+		t.Errorf("fitName(%q) = %q, want it unchanged", "short", got)
+	}
+	if got := fitName("/out", strings.Repeat("x", 300), 20, false, []string{".mp4"}); len(got)+len(".mp4.part") > 20 {
+		t.Errorf("fitName at 20 bytes = %q, too long", got)
+	}
+}
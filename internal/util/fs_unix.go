@@ -0,0 +1,33 @@
+//go:build !windows
+
+package util
+
+import (
+	"os"
+	"syscall"
+)
+
+// SameFilesystem reports whether paths a and b (or their nearest existing
+// ancestors) live on the same device, i.e. whether a rename between them is cheap.
+func SameFilesystem(a, b string) bool {
+	da, okA := deviceOf(existingAncestor(a))
+	db, okB := deviceOf(existingAncestor(b))
+	return okA && okB && da == db
+}
+
+func deviceOf(path string) (uint64, bool) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
+
+func isCrossDevice(error) bool { return false }
+
+// LongPath returns p unchanged; only Windows needs long-path handling.
+func LongPath(p string) string { return p }
@@ -0,0 +1,48 @@
+//go:build windows
+
+package util
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// SameFilesystem reports whether paths a and b are on the same volume, i.e.
+// whether a rename between them is cheap.
+func SameFilesystem(a, b string) bool {
+	va := filepath.VolumeName(absOrSelf(existingAncestor(a)))
+	vb := filepath.VolumeName(absOrSelf(existingAncestor(b)))
+	return va != "" && strings.EqualFold(va, vb)
+}
+
+func absOrSelf(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+// ERROR_NOT_SAME_DEVICE is what MoveFileEx reports for cross-volume renames.
+const errorNotSameDevice syscall.Errno = 17
+
+func isCrossDevice(err error) bool { return errors.Is(err, errorNotSameDevice) }
+
+// LongPath adds the \\?\ prefix to absolute paths at or beyond MAX_PATH so
+// external tools (ffmpeg) can write them. Go's os package does this itself, so
+// it is only needed for paths handed to subprocesses.
+func LongPath(p string) string {
+	const maxPath = 260
+	if len(p) < maxPath-12 || strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
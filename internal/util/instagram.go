@@ -0,0 +1,160 @@
+package util
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// storyMediaParam pins one item of a story or highlight. Instagram puts it in
+// highlight share links (/s/<slug>?story_media_id=<media pk>_<user pk>); it
+// also holds the item IDs of expanded story sources (see StoryItemURL).
+const storyMediaParam = "story_media_id"
+
+// instagramKeepParams are the query parameters that change what an Instagram
+// link shows; the rest (igsh, utm_source, ...) only track sharing.
+var instagramKeepParams = []string{"img_index", storyMediaParam}
+
+// shortcodeAlphabet encodes media pks as the IDs yt-dlp reports for posts and
+// story items.
+const shortcodeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// canonicalInstagram rewrites an Instagram link to the form yt-dlp's
+// extractors match: www.instagram.com over https, /reel/<id>/ for
+// /reels/<id> and /<user>/reel/<id>, /p/<id>/ for /tv/<id> and
+// /<user>/p/<id>, /stories/highlights/<id>/ for highlight share slugs
+// (/s/<slug>), and only the query parameters that matter.
+func canonicalInstagram(u *url.URL) *url.URL {
+	c := *u
+	c.Scheme, c.Host, c.User, c.RawPath = "https", "www.instagram.com", nil, ""
+	c.Fragment, c.RawFragment, c.ForceQuery = "", "", false
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segs) == 3 && !isInstagramSection(segs[0]) {
+		segs = segs[1:] // /<user>/reel/<id>, /<user>/p/<id>
+	}
+	switch {
+	case len(segs) == 2 && (segs[0] == "reel" || segs[0] == "reels"):
+		c.Path = "/reel/" + segs[1] + "/"
+	case len(segs) == 2 && (segs[0] == "p" || segs[0] == "tv"):
+		c.Path = "/p/" + segs[1] + "/"
+	case len(segs) == 2 && segs[0] == "s":
+		if id := highlightFromSlug(segs[1]); id != "" {
+			c.Path = "/stories/highlights/" + id + "/"
+		}
+	case len(segs) >= 2 && segs[0] == "stories":
+		c.Path = "/" + strings.Join(segs, "/") + "/"
+	}
+	q := u.Query()
+	for k := range q {
+		if !containsString(instagramKeepParams, k) {
+			q.Del(k)
+		}
+	}
+	c.RawQuery = q.Encode()
+	return &c
+}
+
+// isInstagramSection reports whether an Instagram path starts with a site
+// section rather than a user name.
+func isInstagramSection(s string) bool {
+	switch s {
+	case "p", "reel", "reels", "tv", "s", "share", "stories", "explore", "accounts":
+		return true
+	}
+	return false
+}
+
+// highlightFromSlug decodes a highlight share slug, base64 of
+// "highlight:<id>", to the highlight's ID, or returns "".
+func highlightFromSlug(slug string) string {
+	for _, enc := range []*base64.Encoding{base64.RawURLEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.StdEncoding} {
+		b, err := enc.DecodeString(slug)
+		if err != nil {
+			continue
+		}
+		if id, ok := strings.CutPrefix(string(b), "highlight:"); ok && isDigits(id) {
+			return id
+		}
+		return ""
+	}
+	return ""
+}
+
+// IsStoryURL reports whether raw is an Instagram story or story highlight
+// link. yt-dlp extracts these as a list of items (and only with login
+// cookies), so a link without a pinned item is a collection.
+func IsStoryURL(raw string) bool {
+	pl, u, err := DetectPlatform(raw)
+	if err != nil || pl != PlatformInstagram {
+		return false
+	}
+	segs := strings.Split(strings.Trim(canonicalInstagram(u).Path, "/"), "/")
+	return segs[0] == "stories" && len(segs) >= 2
+}
+
+// StoryItemID returns the yt-dlp ID of the story item raw is pinned to: the
+// item in its path (/stories/<user>/<pk>/) or its story_media_id, or "".
+func StoryItemID(raw string) string {
+	if !IsStoryURL(raw) {
+		return ""
+	}
+	_, u, _ := DetectPlatform(raw)
+	c := canonicalInstagram(u)
+	if id := c.Query().Get(storyMediaParam); id != "" {
+		return shortcodeFromPK(id)
+	}
+	segs := strings.Split(strings.Trim(c.Path, "/"), "/")
+	if len(segs) == 3 && segs[1] != "highlights" {
+		return shortcodeFromPK(segs[2])
+	}
+	return ""
+}
+
+// StoryItemURL returns the link to item id of the story or highlight at
+// source.
+func StoryItemURL(source, id string) string {
+	_, u, err := DetectPlatform(source)
+	if err != nil {
+		return source
+	}
+	c := canonicalInstagram(u)
+	q := c.Query()
+	q.Set(storyMediaParam, id)
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// shortcodeFromPK converts a media pk ("<media pk>_<user pk>" or just the
+// media pk) to the shortcode yt-dlp uses as the ID. Anything else is taken to
+// be a shortcode already.
+func shortcodeFromPK(pk string) string {
+	media, _, _ := strings.Cut(pk, "_")
+	if len(media) < 15 || !isDigits(media) {
+		return pk
+	}
+	n, err := strconv.ParseUint(media, 10, 64)
+	if err != nil || n == 0 {
+		return pk
+	}
+	var out []byte
+	for ; n > 0; n /= uint64(len(shortcodeAlphabet)) {
+		out = append(out, shortcodeAlphabet[n%uint64(len(shortcodeAlphabet))])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestNormalizeInstagram(t *testing.T) {
+	slug := base64.RawURLEncoding.EncodeToString([]byte("highlight:17900000000000000"))
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"https://www.instagram.com/reels/C1x/?igsh=abc&utm_source=ig", "https://www.instagram.com/reel/C1x/"},
+		{"https://instagram.com/someuser/reel/C1x", "https://www.instagram.com/reel/C1x/"},
+		{"https://instagram.com/someuser/p/C2y?img_index=2&igshid=1", "https://www.instagram.com/p/C2y/?img_index=2"},
+		{"https://m.instagram.com/tv/C3z/", "https://www.instagram.com/p/C3z/"},
+		{"instagram.com/p/C4a", "https://www.instagram.com/p/C4a/"},
+		{"https://www.instagram.com/stories/someuser/3100000000000000000?igsh=1", "https://www.instagram.com/stories/someuser/3100000000000000000/"},
+		{"https://www.instagram.com/s/" + slug + "?story_media_id=3100000000000000000_123", "https://www.instagram.com/stories/highlights/17900000000000000/?story_media_id=3100000000000000000_123"},
+		// Not a highlight slug: left as it is
+		{"https://www.instagram.com/s/bm90LWEtaGlnaGxpZ2h0", "https://www.instagram.com/s/bm90LWEtaGlnaGxpZ2h0"},
+		{"https://www.instagram.com/someuser/", "https://www.instagram.com/someuser/"},
+	} {
+		if got := NormalizeURL(tc.in, PlatformInstagram); got != tc.want {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestStoryItemID(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		story bool
+		id    string
+	}{
+		{"https://www.instagram.com/stories/someuser/", true, ""},
+		{"https://www.instagram.com/stories/someuser/3100000000000000000/", true, "CsFaZNTtgAA"},
+		{"https://www.instagram.com/stories/highlights/179/?story_media_id=3100000000000000000_123", true, "CsFaZNTtgAA"},
+		{"https://www.instagram.com/stories/highlights/179/?story_media_id=CsFaZNTtgAA", true, "CsFaZNTtgAA"},
+		{"https://www.instagram.com/stories/highlights/179/", true, ""},
+		{"https://www.instagram.com/p/C2y/", false, ""},
+		{"https://www.youtube.com/watch?v=abc", false, ""},
+	} {
+		if got := IsStoryURL(tc.in); got != tc.story {
+			t.Errorf("IsStoryURL(%q) = %v, want %v", tc.in, got, tc.story)
+		}
+		if got := StoryItemID(tc.in); got != tc.id {
+			t.Errorf("StoryItemID(%q) = %q, want %q", tc.in, got, tc.id)
+		}
+	}
+	item := StoryItemURL("https://instagram.com/stories/highlights/179/?igsh=1", "CsFaZNTtgAA")
+	if want := "https://www.instagram.com/stories/highlights/179/?story_media_id=CsFaZNTtgAA"; item != want {
+		t.Errorf("StoryItemURL = %q, want %q", item, want)
+	}
+	if got := StoryItemID(item); got != "CsFaZNTtgAA" {
+		t.Errorf("StoryItemID(StoryItemURL(...)) = %q, want the item", got)
+	}
+}
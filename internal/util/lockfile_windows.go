@@ -0,0 +1,26 @@
+//go:build windows
+
+package util
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffset is where tryLockFile's locked byte lies, past any PID written to
+// the file, so other processes can still read the holder's PID.
+const lockOffset = 1 << 30
+
+// tryLockFile takes an exclusive lock on f without waiting; the lock goes
+// with f's handle, so Windows releases it when f is closed or the process
+// dies.
+func tryLockFile(f *os.File) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLockHeld
+	}
+	return err
+}
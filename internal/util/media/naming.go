@@ -2,20 +2,40 @@ package media
 
 import (
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"ig2wa/internal/model"
 	"ig2wa/internal/util"
 )
 
+// platformPrefixes stand in for the uploader in output names when the
+// metadata has none.
+var platformPrefixes = map[util.Platform]string{
+	util.PlatformInstagram: "ig",
+	util.PlatformYouTube:   "yt",
+	util.PlatformFacebook:  "fb",
+	util.PlatformReddit:    "rd",
+}
+
 // OutputBasename builds a safe, informative base filename (without extension)
-// derived from metadata and encoding options.
-func OutputBasename(dv model.DownloadedVideo, longSide int, maxSizeMB int, enc model.EncodeOptions) string {
+// derived from metadata and encoding options. Reddit posts are named after
+// their subreddit (r_<sub>) rather than the poster. With dated set, the name
+// starts with the upload date (YYYYMMDD) when it is known.
+func OutputBasename(dv model.DownloadedVideo, longSide int, maxSizeMB int, enc model.EncodeOptions, dated bool) string {
 	uploader := dv.Uploader
-	if uploader == "" {
-		uploader = "ig"
+	if dv.Subreddit != "" {
+		uploader = "r_" + dv.Subreddit
+	} else if uploader == "" {
+		uploader = namePrefix(dv.URL)
 	}
 	id := dv.ID
+	if id == "" {
+		id = util.VideoIDFromURL(dv.URL)
+	}
 	if id == "" {
 		id = dv.Title
 	}
@@ -23,6 +43,12 @@ func OutputBasename(dv model.DownloadedVideo, longSide int, maxSizeMB int, enc m
 	id = util.SanitizeFilename(id)
 
 	parts := []string{uploader, id}
+	if dated && !dv.Published.IsZero() {
+		parts = append([]string{dv.Published.Format("20060102")}, parts...)
+	}
+	if dv.Chapter != "" {
+		parts = append(parts, util.SanitizeFilename(dv.Chapter))
+	}
 	if enc.AudioOnly {
 		parts = append(parts, "audio")
 	} else {
@@ -36,7 +62,27 @@ func OutputBasename(dv model.DownloadedVideo, longSide int, maxSizeMB int, enc m
 	return strings.Join(parts, "_")
 }
 
+// namePrefix returns the platform's short name from platformPrefixes, the
+// host of a direct media link, or "video".
+func namePrefix(url string) string {
+	pl, _, err := util.DetectPlatform(url)
+	if err != nil {
+		return "video"
+	}
+	if pl == util.PlatformDirect {
+		if _, host := util.DirectMediaName(url); host != "" {
+			return host
+		}
+	}
+	if p, ok := platformPrefixes[pl]; ok {
+		return p
+	}
+	return "video"
+}
+
 // CaptionText renders a caption text with title/uploader/url and description.
+// Reddit posts also get their subreddit, and the upload date and view/like
+// counts are added when the metadata has them.
 func CaptionText(dv model.DownloadedVideo) string {
 	var b strings.Builder
 	title := strings.TrimSpace(dv.Title)
@@ -49,14 +95,177 @@ func CaptionText(dv model.DownloadedVideo) string {
 		b.WriteString(uploader)
 		b.WriteString("\n")
 	}
+	if dv.Subreddit != "" {
+		b.WriteString("r/" + dv.Subreddit + "\n")
+	}
 	if dv.URL != "" {
 		b.WriteString(dv.URL)
 		b.WriteString("\n")
 	}
+	if dv.Chapter != "" {
+		b.WriteString("Chapter: " + dv.Chapter + "\n")
+	}
+	if stats := captionStats(dv); stats != "" {
+		b.WriteString(stats + "\n")
+	}
 	b.WriteString("\n---\nORIGINAL CAPTION\n")
 	if dv.Description != "" {
 		b.WriteString(dv.Description)
 		b.WriteString("\n")
 	}
 	return b.String()
-}
\ No newline at end of file
+}
+
+// captionStats is e.g. "Uploaded 2024-03-01 · 12,345 views · 678 likes", with
+// the unknown parts left out.
+func captionStats(dv model.DownloadedVideo) string {
+	var parts []string
+	if !dv.Published.IsZero() {
+		parts = append(parts, "Uploaded "+dv.Published.Format("2006-01-02"))
+	}
+	if dv.ViewCount > 0 {
+		parts = append(parts, groupThousands(dv.ViewCount)+" views")
+	}
+	if dv.LikeCount > 0 {
+		parts = append(parts, groupThousands(dv.LikeCount)+" likes")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// groupThousands formats n with comma separators.
+func groupThousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// maxEmbeddedCaptionBytes caps captions embedded in output metadata. Players
+// show only a few lines, and very long tags bloat the command line on Windows.
+const maxEmbeddedCaptionBytes = 4000
+
+// EmbeddedCaption prepares caption text for the output's comment and
+// description tags: valid UTF-8, Unix newlines, no other control characters,
+// and at most maxEmbeddedCaptionBytes (cut at a line or rune boundary and
+// marked with "…").
+func EmbeddedCaption(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\n' && r != '\t') || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.TrimSpace(text)
+	if len(text) <= maxEmbeddedCaptionBytes {
+		return text
+	}
+	cut := text[:maxEmbeddedCaptionBytes-len("…")]
+	for !utf8.ValidString(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	if i := strings.LastIndexByte(cut, '\n'); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
+
+// Organize layouts accepted by --organize, as subfolder templates.
+var organizeLayouts = map[string]string{
+	"none":     "",
+	"platform": "{platform}/{uploader}",
+	"date":     "{year}/{month}",
+}
+
+// PlaceholderReplacer replaces each of ps in a template with its value in
+// vals.
+func PlaceholderReplacer(ps []Placeholder, vals map[string]string) *strings.Replacer {
+	pairs := make([]string, 0, 2*len(ps))
+	for _, p := range ps {
+		pairs = append(pairs, "{"+p.Name+"}", vals[p.Name])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// OrganizeTemplate resolves an --organize value: a named layout (none,
+// platform, date) or a custom template such as "{platform}/{year}".
+func OrganizeTemplate(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	if t, ok := organizeLayouts[strings.ToLower(v)]; ok {
+		return t, nil
+	}
+	if !strings.Contains(v, "{") {
+		return "", fmt.Errorf("unknown layout %q (valid: none|platform|date, or a template like {platform}/{year})", v)
+	}
+	return v, nil
+}
+
+// Placeholder is a {Name} of a template, and what it is filled in with.
+type Placeholder struct {
+	Name string
+	Desc string
+}
+
+// OrganizePlaceholders are the placeholders of an organize template, in the
+// order `sniplette help templates` lists them.
+var OrganizePlaceholders = []Placeholder{
+	{Name: "platform", Desc: "instagram, youtube, facebook, reddit, or other"},
+	{Name: "uploader", Desc: `the uploader ("unknown" when missing)`},
+	{Name: "channel_id", Desc: `the uploader's channel ID ("unknown" when missing)`},
+	{Name: "id", Desc: "the video ID"},
+	{Name: "year", Desc: "the year of the run"},
+	{Name: "month", Desc: "the month of the run"},
+	{Name: "day", Desc: "the day of the run"},
+	{Name: "upload_year", Desc: "the upload year, or the run's when unknown"},
+	{Name: "upload_month", Desc: "the upload month, or the run's when unknown"},
+	{Name: "upload_day", Desc: "the upload day, or the run's when unknown"},
+}
+
+// OrganizedSubdir expands an organize template for a video into a relative
+// directory, filling in OrganizePlaceholders. Each path segment is sanitized,
+// so metadata cannot escape the output dir.
+func OrganizedSubdir(template string, dv model.DownloadedVideo, now time.Time) string {
+	if template == "" {
+		return ""
+	}
+	platform := "other"
+	if pl, _, err := util.DetectPlatform(dv.URL); err == nil {
+		platform = string(pl)
+	}
+	uploader := dv.Uploader
+	if uploader == "" {
+		uploader = "unknown"
+	}
+	channelID := dv.ChannelID
+	if channelID == "" {
+		channelID = "unknown"
+	}
+	uploaded := dv.Published
+	if uploaded.IsZero() {
+		uploaded = now
+	}
+	vals := map[string]string{
+		"platform":     platform,
+		"uploader":     uploader,
+		"channel_id":   channelID,
+		"id":           dv.ID,
+		"upload_year":  uploaded.Format("2006"),
+		"upload_month": uploaded.Format("01"),
+		"upload_day":   uploaded.Format("02"),
+		"year":         now.Format("2006"),
+		"month":        now.Format("01"),
+		"day":          now.Format("02"),
+	}
+	r := PlaceholderReplacer(OrganizePlaceholders, vals)
+	var segs []string
+	for _, seg := range strings.Split(filepath.ToSlash(template), "/") {
+		if seg = strings.TrimSpace(r.Replace(seg)); seg != "" {
+			segs = append(segs, util.SanitizeFilename(seg))
+		}
+	}
+	return filepath.Join(segs...)
+}
@@ -0,0 +1,149 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"ig2wa/internal/util"
+)
+
+// StreamInfo describes one stream of a media file as reported by ffprobe.
+type StreamInfo struct {
+	Index      int
+	Type       string // "video", "audio", "subtitle", ...
+	Codec      string // e.g. "h264", "aac"
+	Profile    string
+	PixFmt     string
+	Width      int
+	Height     int
+	BitRate    int64 // bits/s; 0 if unknown
+	SampleRate int
+	Channels   int
+	Rotation   int // Clockwise display rotation in degrees: 0, 90, 180, or 270
+}
+
+// ProbeResult is what ffprobe reports about a media file.
+type ProbeResult struct {
+	FormatName  string // e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	DurationSec float64
+	BitRate     int64 // Overall bits/s; 0 if unknown
+	Streams     []StreamInfo
+}
+
+// Video returns the first video stream, if any.
+func (p ProbeResult) Video() (StreamInfo, bool) {
+	return p.first("video")
+}
+
+// Audio returns the first audio stream, if any.
+func (p ProbeResult) Audio() (StreamInfo, bool) {
+	return p.first("audio")
+}
+
+func (p ProbeResult) first(kind string) (StreamInfo, bool) {
+	for _, s := range p.Streams {
+		if s.Type == kind {
+			return s, true
+		}
+	}
+	return StreamInfo{}, false
+}
+
+// Probe runs ffprobe on a local media file.
+func Probe(ctx context.Context, ffprobePath, path string) (ProbeResult, error) {
+	if ffprobePath == "" {
+		return ProbeResult{}, errors.New("ffprobe path is required")
+	}
+	res, err := util.Run(ctx, util.CmdSpec{
+		Path:  ffprobePath,
+		Args:  []string{"-v", "error", "-print_format", "json", "-show_format", "-show_streams", util.LongPath(path)},
+		Stage: "probe",
+	})
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("ffprobe: %w", err)
+	}
+
+	// ffprobe reports most numbers as strings.
+	var raw struct {
+		Format struct {
+			FormatName string `json:"format_name"`
+			Duration   string `json:"duration"`
+			BitRate    string `json:"bit_rate"`
+		} `json:"format"`
+		Streams []struct {
+			Index      int    `json:"index"`
+			CodecType  string `json:"codec_type"`
+			CodecName  string `json:"codec_name"`
+			Profile    string `json:"profile"`
+			PixFmt     string `json:"pix_fmt"`
+			Width      int    `json:"width"`
+			Height     int    `json:"height"`
+			BitRate    string `json:"bit_rate"`
+			SampleRate string `json:"sample_rate"`
+			Channels   int    `json:"channels"`
+			Duration   string `json:"duration"`
+			Tags       struct {
+				Rotate string `json:"rotate"`
+			} `json:"tags"`
+			SideData []struct {
+				Rotation float64 `json:"rotation"`
+			} `json:"side_data_list"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(res.Stdout, &raw); err != nil {
+		return ProbeResult{}, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+	out := ProbeResult{
+		FormatName:  raw.Format.FormatName,
+		DurationSec: parseFloat(raw.Format.Duration),
+		BitRate:     int64(parseFloat(raw.Format.BitRate)),
+	}
+	for _, s := range raw.Streams {
+		var side []float64
+		for _, d := range s.SideData {
+			side = append(side, d.Rotation)
+		}
+		out.Streams = append(out.Streams, StreamInfo{
+			Index:      s.Index,
+			Type:       s.CodecType,
+			Codec:      s.CodecName,
+			Profile:    s.Profile,
+			PixFmt:     s.PixFmt,
+			Width:      s.Width,
+			Height:     s.Height,
+			BitRate:    int64(parseFloat(s.BitRate)),
+			SampleRate: int(parseFloat(s.SampleRate)),
+			Channels:   s.Channels,
+			Rotation:   rotation(s.Tags.Rotate, side),
+		})
+		if out.DurationSec <= 0 {
+			out.DurationSec = parseFloat(s.Duration)
+		}
+	}
+	return out, nil
+}
+
+// rotation normalizes a stream's rotation: the legacy "rotate" tag is
+// clockwise, while display-matrix side data (newer ffmpeg) is
+// counterclockwise.
+func rotation(tag string, side []float64) int {
+	deg := int(parseFloat(tag))
+	for _, r := range side {
+		if r != 0 {
+			deg = -int(r)
+			break
+		}
+	}
+	return ((deg % 360) + 360) % 360
+}
+
+func parseFloat(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,55 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"ig2wa/internal/util"
+)
+
+// ThumbnailOptions controls thumbnail extraction.
+type ThumbnailOptions struct {
+	FFmpegPath string
+	Source     string // Remote thumbnail URL or local media file
+	OutputPath string // Destination PNG path
+	MaxWidth   int    // Bounding box in pixels; aspect ratio is preserved
+	MaxHeight  int
+	SeekSec    float64 // Seek offset for video sources; ignored when 0
+}
+
+// ExtractThumbnail renders a single downscaled PNG frame from the source using ffmpeg.
+// ffmpeg reads both remote image URLs (e.g., yt-dlp's thumbnail field) and local media.
+func ExtractThumbnail(ctx context.Context, opts ThumbnailOptions) error {
+	if opts.FFmpegPath == "" {
+		return errors.New("ffmpeg path is required")
+	}
+	if opts.Source == "" || opts.OutputPath == "" {
+		return errors.New("thumbnail source and output path are required")
+	}
+	w, h := opts.MaxWidth, opts.MaxHeight
+	if w <= 0 {
+		w = 32
+	}
+	if h <= 0 {
+		h = 16
+	}
+	args := []string{"-y", "-loglevel", "error"}
+	if opts.SeekSec > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.2f", opts.SeekSec))
+	}
+	args = append(args,
+		"-i", opts.Source,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", w, h),
+		util.LongPath(opts.OutputPath),
+	)
+	if err := util.EnsureDir(filepath.Dir(opts.OutputPath)); err != nil {
+		return fmt.Errorf("ensure thumbnail dir: %w", err)
+	}
+	if _, err := util.Run(ctx, util.CmdSpec{Path: opts.FFmpegPath, Args: args}); err != nil {
+		return fmt.Errorf("thumbnail extraction failed: %w", err)
+	}
+	return nil
+}
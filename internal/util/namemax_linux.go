@@ -0,0 +1,14 @@
+package util
+
+import "syscall"
+
+// nameMax returns the longest file name, in bytes, that the filesystem
+// holding dir (or its nearest existing ancestor) accepts. eCryptfs with
+// encrypted names, for one, takes only 143.
+func nameMax(dir string) int {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(existingAncestor(dir), &st); err != nil || st.Namelen <= 0 || st.Namelen > maxNameBytes {
+		return maxNameBytes
+	}
+	return int(st.Namelen)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package util
+
+// nameMax returns the longest file name, in bytes, that the filesystem
+// holding dir accepts. Only Linux reports it for now; elsewhere it is the
+// common limit.
+func nameMax(string) int {
+	return maxNameBytes
+}
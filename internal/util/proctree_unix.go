@@ -0,0 +1,34 @@
+//go:build !windows
+
+package util
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess prepares cmd before Start: run it in its own process
+// group, so cancellation reaches yt-dlp's python children and ffmpeg merger
+// too, and a terminal ctrl+c is left to us rather than hitting them directly.
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptProcessTree asks the process group to stop. SIGINT lets ffmpeg
+// finalize its output and yt-dlp clean up its .part files.
+func interruptProcessTree(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)
+}
+
+// setPriority renices the command's process group.
+func setPriority(cmd *exec.Cmd, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PGRP, cmd.Process.Pid, nice)
+}
+
+// killProcessTree kills the whole process group.
+func killProcessTree(cmd *exec.Cmd) error {
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
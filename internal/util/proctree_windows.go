@@ -0,0 +1,66 @@
+//go:build windows
+
+package util
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// configureProcess prepares cmd before Start: run console tools in their own
+// process group so they don't receive the console's ctrl+c before we decide
+// how to stop them.
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+var procGenerateConsoleCtrlEvent = syscall.NewLazyDLL("kernel32.dll").NewProc("GenerateConsoleCtrlEvent")
+
+// interruptProcessTree sends ctrl+break to the command's process group, which
+// ffmpeg and yt-dlp treat like ctrl+c. It fails when we have no console.
+func interruptProcessTree(cmd *exec.Cmd) error {
+	r, _, err := procGenerateConsoleCtrlEvent.Call(syscall.CTRL_BREAK_EVENT, uintptr(cmd.Process.Pid))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+const (
+	processSetInformation    = 0x0200
+	belowNormalPriorityClass = 0x4000
+	idlePriorityClass        = 0x0040
+)
+
+var procSetPriorityClass = syscall.NewLazyDLL("kernel32.dll").NewProc("SetPriorityClass")
+
+// setPriority maps a Unix niceness onto a Windows priority class. Children
+// inherit the class when they start.
+func setPriority(cmd *exec.Cmd, nice int) error {
+	class := uintptr(belowNormalPriorityClass)
+	if nice >= 15 {
+		class = idlePriorityClass
+	}
+	h, err := syscall.OpenProcess(processSetInformation, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		return err
+	}
+	defer syscall.CloseHandle(h)
+	if r, _, err := procSetPriorityClass.Call(uintptr(h), class); r == 0 {
+		return err
+	}
+	return nil
+}
+
+// killProcessTree stops the command and everything it spawned. Process.Kill
+// only terminates the direct child, which leaves yt-dlp's ffmpeg merger or
+// PyInstaller's inner python.exe running; taskkill /T walks the whole tree.
+func killProcessTree(cmd *exec.Cmd) error {
+	kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+	kill.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	if err := kill.Run(); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redditPostID returns the post ID of a Reddit link
+// (reddit.com/r/<sub>/comments/<id>/..., reddit.com/comments/<id>,
+// redd.it/<id>), or "" when the link doesn't carry one (v.redd.it links name
+// the video, not the post).
+func redditPostID(u *url.URL) string {
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if strings.EqualFold(strings.TrimPrefix(u.Hostname(), "www."), "redd.it") {
+		return segs[0]
+	}
+	for i := 0; i+1 < len(segs); i++ {
+		if segs[i] == "comments" {
+			return segs[i+1]
+		}
+	}
+	return ""
+}
+
+// canonicalReddit rewrites redd.it/<id> post links to
+// www.reddit.com/comments/<id>/, which yt-dlp's Reddit extractor matches.
+// Other links are returned as they are.
+func canonicalReddit(u *url.URL) *url.URL {
+	if !strings.EqualFold(strings.TrimPrefix(u.Hostname(), "www."), "redd.it") {
+		return u
+	}
+	id := redditPostID(u)
+	if id == "" {
+		return u
+	}
+	return &url.URL{Scheme: "https", Host: "www.reddit.com", Path: "/comments/" + id + "/"}
+}
+
+// Subreddit returns the subreddit in a Reddit link, such as "videos" in
+// reddit.com/r/videos/comments/<id>/, or "".
+func Subreddit(raw string) string {
+	pl, u, err := DetectPlatform(strings.TrimSpace(raw))
+	if err != nil || pl != PlatformReddit {
+		return ""
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segs) >= 2 && strings.EqualFold(segs[0], "r") {
+		return segs[1]
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// shortLinkHosts are link shorteners and share wrappers whose links lead to
+// the actual video through redirects.
+var shortLinkHosts = map[string]bool{
+	"l.instagram.com": true, "l.facebook.com": true, "lm.facebook.com": true,
+	"t.co": true, "bit.ly": true, "tinyurl.com": true, "goo.gl": true, "ow.ly": true,
+	"buff.ly": true, "is.gd": true, "t.ly": true, "rebrand.ly": true, "lnkd.in": true,
+	"shorturl.at": true, "cutt.ly": true, "tiny.cc": true, "trib.al": true, "dlvr.it": true,
+	"v.redd.it": true, "out.reddit.com": true,
+}
+
+// Limits for following a short link's redirects.
+const (
+	maxShortLinkHops    = 10
+	shortLinkTimeout    = 10 * time.Second
+	shortLinkUserAgent  = "Mozilla/5.0 (compatible; sniplette)"
+	shortLinkMaxURLSize = 4096
+)
+
+// IsShortLink reports whether raw is a link shortener or share wrapper link
+// (see ResolveShortLink).
+func IsShortLink(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if shortLinkHosts[host] {
+		return true
+	}
+	// instagram.com/share/<slug> and facebook.com/share/v/<slug> (the apps'
+	// share buttons) redirect to the post.
+	pl, _, err := DetectPlatform(raw)
+	if err == nil && (pl == PlatformInstagram || pl == PlatformFacebook) && strings.HasPrefix(u.Path, "/share/") {
+		return true
+	}
+	// reddit.com/r/<sub>/s/<slug> (the app's share button) redirects to the post.
+	if err == nil && pl == PlatformReddit {
+		segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+		return len(segs) == 4 && segs[0] == "r" && segs[2] == "s"
+	}
+	// Facebook sends logged-out visitors to facebook.com/login/?next=<link>.
+	if err == nil && pl == PlatformFacebook && strings.HasPrefix(u.Path, "/login") && u.Query().Get("next") != "" {
+		return true
+	}
+	// youtube.com/redirect?q=... wraps links in video descriptions.
+	return (host == "youtube.com" || host == "m.youtube.com") && u.Path == "/redirect"
+}
+
+// ResolveShortLink returns the link that raw, a short link or share wrapper,
+// leads to. Wrappers that carry the target in the query (l.instagram.com/?u=,
+// youtube.com/redirect?q=) are unwrapped without a request; other links are
+// followed with HEAD requests (GET if refused), for at most
+// maxShortLinkHops redirects and stopping at the first link to a supported
+// platform. Other links are returned as they are.
+func ResolveShortLink(ctx context.Context, raw string) (string, error) {
+	cur := strings.TrimSpace(raw)
+	if !IsShortLink(cur) {
+		return cur, nil
+	}
+	for hop := 0; hop < maxShortLinkHops; hop++ {
+		if target := wrappedTarget(cur); target != "" && IsShortLink(cur) {
+			cur = target
+		} else {
+			next, err := nextHop(ctx, cur)
+			if err != nil {
+				return "", fmt.Errorf("could not resolve short link %s: %w", raw, err)
+			}
+			if next == "" {
+				return CleanURL(cur), nil // no further redirect
+			}
+			cur = next
+		}
+		if _, _, err := DetectPlatform(cur); err == nil && !IsShortLink(cur) {
+			return CleanURL(cur), nil
+		}
+	}
+	return "", fmt.Errorf("could not resolve short link %s: more than %d redirects", raw, maxShortLinkHops)
+}
+
+// wrappedTarget returns the link a share wrapper carries in its query.
+func wrappedTarget(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	for _, k := range []string{"u", "q", "url", "next"} {
+		if t := u.Query().Get(k); strings.HasPrefix(t, "http://") || strings.HasPrefix(t, "https://") {
+			return t
+		}
+	}
+	return ""
+}
+
+// nextHop requests raw without following redirects and returns where it
+// redirects to, or "" if it doesn't.
+func nextHop(ctx context.Context, raw string) (string, error) {
+	client := &http.Client{
+		Timeout:       shortLinkTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	var resp *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, raw, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", shortLinkUserAgent)
+		resp, err = client.Do(req)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) || errors.Is(err, context.DeadlineExceeded) || isTimeout(err) {
+				return "", fmt.Errorf("%v (offline? pass the full video link instead)", err)
+			}
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 400 {
+			break // some shorteners refuse HEAD; then GET is tried
+		}
+	}
+	switch {
+	case resp.StatusCode >= 400:
+		return "", errors.New(resp.Status)
+	case resp.StatusCode < 300:
+		return "", nil
+	}
+	loc := resp.Header.Get("Location")
+	if loc == "" || len(loc) > shortLinkMaxURLSize {
+		return "", fmt.Errorf("%s without a usable Location", resp.Status)
+	}
+	base, _ := url.Parse(raw)
+	next, err := base.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
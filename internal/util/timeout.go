@@ -0,0 +1,50 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout matches (errors.Is) any error caused by a stage or a whole job
+// running past its configured time limit.
+var ErrTimeout = errors.New("timed out")
+
+// TimeoutError reports which stage ran out of time.
+type TimeoutError struct {
+	Stage string        // e.g. "metadata", "download", "encode", "job"
+	Limit time.Duration // The limit that was exceeded
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.Stage, e.Limit)
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// StageContext bounds ctx by limit; limit <= 0 means no limit. When the limit
+// fires, context.Cause of the returned context (and of any context derived
+// from it) is a *TimeoutError naming stage.
+func StageContext(ctx context.Context, stage string, limit time.Duration) (context.Context, context.CancelFunc) {
+	if limit <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, limit, &TimeoutError{Stage: stage, Limit: limit})
+}
+
+// TimeoutCause marks err as a timeout when ctx was stopped by a StageContext
+// limit, so callers can tell a hung stage from a failure or a user cancel.
+// Other errors are returned unchanged.
+func TimeoutCause(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	var te *TimeoutError
+	if errors.As(context.Cause(ctx), &te) {
+		return fmt.Errorf("%w: %v", te, err)
+	}
+	return err
+}
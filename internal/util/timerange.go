@@ -0,0 +1,98 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseTimestamp parses a position such as "90", "1:30", "1:02:03", or
+// "0:05.5" into seconds.
+func ParseTimestamp(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty timestamp")
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	var sec float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) || v < 0 || (i > 0 && v >= 60) {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		sec = sec*60 + v
+	}
+	return sec, nil
+}
+
+// ParseTimeRange parses "START-END" into seconds. Either side may be empty:
+// "-0:30" is the first 30 seconds and "1:00-" runs to the end, reported as
+// end 0.
+func ParseTimeRange(s string) (start, end float64, err error) {
+	a, b, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range %q (want START-END, e.g. 1:05-1:30)", s)
+	}
+	if strings.TrimSpace(a) != "" {
+		if start, err = ParseTimestamp(a); err != nil {
+			return 0, 0, err
+		}
+	}
+	if strings.TrimSpace(b) != "" {
+		if end, err = ParseTimestamp(b); err != nil {
+			return 0, 0, err
+		}
+		if end <= start {
+			return 0, 0, fmt.Errorf("invalid range %q: end must be after start", s)
+		}
+	}
+	return start, end, nil
+}
+
+// SecondsDuration converts seconds, as the history and reports store them,
+// to a time.Duration.
+func SecondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// FormatTimestamp renders seconds as m:ss, or h:mm:ss from an hour up.
+func FormatTimestamp(sec float64) string {
+	t := int(sec + 0.5)
+	if t >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", t/3600, t/60%60, t%60)
+	}
+	return fmt.Sprintf("%d:%02d", t/60, t%60)
+}
+
+// ParseSince parses a cut-off given as a date ("2024-01-31") or as a period
+// before now ("7d", "2w", or a Go duration such as "48h").
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	day := 24 * time.Hour
+	var d time.Duration
+	var err error
+	switch {
+	case strings.HasSuffix(s, "d"), strings.HasSuffix(s, "w"):
+		var n int
+		n, err = strconv.Atoi(s[:len(s)-1])
+		d = time.Duration(n) * day
+		if strings.HasSuffix(s, "w") {
+			d *= 7
+		}
+	default:
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("invalid date or period %q (want e.g. 2024-01-31, 7d, or 2w)", s)
+	}
+	return now.Add(-d), nil
+}
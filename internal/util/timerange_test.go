@@ -0,0 +1,34 @@
+package util
+
+import "testing"
+
+func TestParseTimestamp(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want float64
+	}{
+		{"90", 90},
+		{"1:30", 90},
+		{"1:02:03", 3723},
+		{"0:05.5", 5.5},
+		{" 7 ", 7},
+	} {
+		got, err := ParseTimestamp(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseTimestamp(%q) = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "-5", "1:60", "1:2:3:4", "abc", "NaN", "nan", "1:NaN", "Inf", "+Inf", "-inf", "1:infinity"} {
+		if got, err := ParseTimestamp(in); err == nil {
+			t.Errorf("ParseTimestamp(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestParseTimeRangeRejectsNaN(t *testing.T) {
+	for _, in := range []string{"NaN-1:00", "0:10-NaN", "Inf-", "-Inf"} {
+		if start, end, err := ParseTimeRange(in); err == nil {
+			t.Errorf("ParseTimeRange(%q) = %v, %v; want an error", in, start, end)
+		}
+	}
+}
@@ -11,12 +11,19 @@ type Platform string
 const (
 	PlatformInstagram Platform = "instagram"
 	PlatformYouTube   Platform = "youtube"
+	PlatformFacebook  Platform = "facebook"
+	PlatformReddit    Platform = "reddit"
 	PlatformThreads   Platform = "threads"
+
+	// PlatformDirect is a direct link to a media file or stream manifest
+	// (.mp4, .m3u8, ...) on any other host, such as a CDN.
+	PlatformDirect Platform = "direct"
 )
 
 // DetectPlatform parses a raw URL string and determines if it targets a
-// supported platform (Instagram, YouTube, or Threads). It returns the detected
-// platform, the parsed URL, or an error with a clear message if unsupported.
+// supported platform (Instagram, YouTube, Facebook, Reddit, or Threads), or
+// is a direct media link (PlatformDirect). It returns the detected platform,
+// the parsed URL, or an error with a clear message if unsupported.
 func DetectPlatform(raw string) (Platform, *url.URL, error) {
 	u, err := url.Parse(raw)
 	if err == nil && (u.Scheme == "" || u.Host == "") {
@@ -30,17 +37,33 @@ func DetectPlatform(raw string) (Platform, *url.URL, error) {
 
 	host := strings.ToLower(u.Host)
 	host = strings.TrimPrefix(host, "www.")
+	if strings.HasSuffix(host, ".instagram.com") && host != "l.instagram.com" {
+		host = "instagram.com" // m., regional, and other subdomains; l. is a link wrapper
+	}
+	if strings.HasSuffix(host, ".facebook.com") && host != "l.facebook.com" && host != "lm.facebook.com" {
+		host = "facebook.com" // m., web., mbasic.; l. and lm. are link wrappers
+	}
+	if strings.HasSuffix(host, ".reddit.com") && host != "out.reddit.com" {
+		host = "reddit.com" // old., new., np., m.; out. is a link wrapper
+	}
 
 	switch host {
 	case "instagram.com", "instagr.am", "m.instagram.com":
 		return PlatformInstagram, u, nil
 	case "youtube.com", "m.youtube.com", "youtu.be":
 		return PlatformYouTube, u, nil
+	case "facebook.com", "fb.com", "fb.watch":
+		return PlatformFacebook, u, nil
+	case "reddit.com", "redd.it", "v.redd.it":
+		return PlatformReddit, u, nil
 	case "threads.net", "threads.com":
-		return "", nil, fmt.Errorf("unsupported URL %q: Threads is not currently supported (yt-dlp has no extractor). Use Instagram or YouTube.", raw)
+		return "", nil, fmt.Errorf("unsupported URL %q: Threads is not currently supported (yt-dlp has no extractor). Use Instagram, YouTube, Facebook, or Reddit.", raw)
 	default:
+		if isDirectMedia(u) {
+			return PlatformDirect, u, nil
+		}
 		return "", nil, fmt.Errorf(
-			"unsupported URL %q: only Instagram or YouTube are supported (instagram.com, instagr.am, youtube.com, youtu.be)",
+			"unsupported URL %q: only Instagram, YouTube, Facebook, Reddit, or direct media links (.mp4, .m3u8, ...) are supported (instagram.com, instagr.am, youtube.com, youtu.be, facebook.com, fb.watch, reddit.com, v.redd.it)",
 			raw,
 		)
 	}
@@ -48,9 +71,15 @@ func DetectPlatform(raw string) (Platform, *url.URL, error) {
 
 // NormalizeURL normalizes service-specific URLs for compatibility with external tools.
 // For PlatformThreads, convert any threads.com host (and subdomains) to threads.net.
+// For PlatformInstagram, rewrite link variants to the canonical form yt-dlp
+// matches (see canonicalInstagram): www host, /reel/<id>/ for /reels/ and
+// user-prefixed links, /p/<id>/ for /tv/, highlight share slugs decoded, and
+// share-tracking parameters such as igsh dropped.
+// For PlatformYouTube, rewrite Shorts and live links to watch links.
+// For PlatformReddit, rewrite redd.it post links to reddit.com links.
 // For other platforms, the URL is returned unchanged.
 func NormalizeURL(raw string, platform Platform) string {
-	if platform != PlatformThreads {
+	if platform != PlatformThreads && platform != PlatformInstagram && platform != PlatformYouTube && platform != PlatformReddit {
 		return raw
 	}
 
@@ -64,6 +93,25 @@ func NormalizeURL(raw string, platform Platform) string {
 		return raw
 	}
 
+	var canonical func(*url.URL) *url.URL
+	switch platform {
+	case PlatformInstagram:
+		if pl, _, err := DetectPlatform(u.String()); err != nil || pl != PlatformInstagram {
+			return raw
+		}
+		return canonicalInstagram(u).String()
+	case PlatformYouTube:
+		canonical = canonicalYouTube
+	case PlatformReddit:
+		canonical = canonicalReddit
+	}
+	if canonical != nil {
+		if c := canonical(u); c != u {
+			return c.String()
+		}
+		return raw
+	}
+
 	lowerHost := strings.ToLower(u.Host)
 	if strings.HasSuffix(lowerHost, "threads.com") {
 		prefix := u.Host[:len(u.Host)-len("threads.com")]
@@ -71,4 +119,4 @@ func NormalizeURL(raw string, platform Platform) string {
 		return u.String()
 	}
 	return raw
-}
\ No newline at end of file
+}
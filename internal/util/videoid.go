@@ -0,0 +1,49 @@
+package util
+
+import "strings"
+
+// VideoIDFromURL returns the platform's video ID when it is part of the URL
+// (YouTube watch, youtu.be, and shorts links; Instagram post, reel, and story
+// item links; Facebook reel, watch, and video links; Reddit post links),
+// or "" when it can only be learned from the metadata.
+func VideoIDFromURL(raw string) string {
+	pl, u, err := DetectPlatform(raw)
+	if err != nil {
+		return ""
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch pl {
+	case PlatformYouTube:
+		if v := u.Query().Get("v"); v != "" {
+			return v
+		}
+		if strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "youtu.be") {
+			return segs[0]
+		}
+		if len(segs) >= 2 {
+			switch segs[0] {
+			case "shorts", "live", "embed":
+				return segs[1]
+			}
+		}
+	case PlatformInstagram:
+		if id := StoryItemID(raw); id != "" {
+			return id
+		}
+		if segs[0] == "share" {
+			break // share slugs aren't IDs; the link redirects to the post
+		}
+		// instagram.com/p/<id>/ and instagram.com/<user>/reel/<id>/
+		for i := 0; i+1 < len(segs); i++ {
+			switch segs[i] {
+			case "p", "reel", "reels", "tv":
+				return segs[i+1]
+			}
+		}
+	case PlatformFacebook:
+		return facebookVideoID(u)
+	case PlatformReddit:
+		return redditPostID(u)
+	}
+	return ""
+}
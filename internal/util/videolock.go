@@ -0,0 +1,148 @@
+package util
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ig2wa/internal/dirs"
+)
+
+// ErrVideoBusy is returned by LockVideo when another job holds the video's
+// lock and the caller chose not to wait.
+var ErrVideoBusy = errors.New("another sniplette job is already snipping this video")
+
+// errLockHeld is returned by tryLockFile when another open file holds the lock.
+var errLockHeld = errors.New("lock held")
+
+// lockPoll is how often a waiting LockVideo checks the lock again.
+const lockPoll = 2 * time.Second
+
+// VideoLock is a held per-video lock (see LockVideo).
+type VideoLock struct {
+	f *os.File
+}
+
+// LockVideo takes the lock for the video rawURL points to (see SameVideoKey),
+// shared by every sniplette process of this user through a file in the state
+// directory, locked with the OS's file locks (flock, LockFileEx). When another
+// process, or another job of this one, holds it, LockVideo waits for it until
+// ctx is done if wait is set, calling waiting once with the holder's PID;
+// otherwise it fails with ErrVideoBusy. The OS releases the locks of processes
+// that die, so there are no stale locks to take over.
+func LockVideo(ctx context.Context, rawURL string, wait bool, waiting func(pid int)) (*VideoLock, error) {
+	path, err := videoLockPath(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	notified := false
+	for {
+		l, pid, err := tryLockVideo(path, SameVideoKey(rawURL))
+		if l != nil || err != nil {
+			return l, err
+		}
+		if !wait {
+			return nil, fmt.Errorf("%w (pid %d)", ErrVideoBusy, pid)
+		}
+		if !notified && waiting != nil {
+			waiting(pid)
+			notified = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
+
+// videoLockPath returns the lock file of the video rawURL points to.
+func videoLockPath(rawURL string) (string, error) {
+	sd, err := dirs.StateDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(SameVideoKey(rawURL)))
+	return filepath.Join(sd, "locks", hex.EncodeToString(sum[:12])+".lock"), nil
+}
+
+// tryLockVideo takes the lock in path without waiting, writing this
+// process's PID and the video's key to it. When another holds it, the lock
+// is nil and pid is the holder's, as far as it wrote it.
+func tryLockVideo(path, key string) (l *VideoLock, pid int, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := tryLockFile(f); err != nil {
+		b, _ := io.ReadAll(f)
+		f.Close()
+		if errors.Is(err, errLockHeld) {
+			pid, _ = parseVideoLock(b)
+			return nil, pid, nil
+		}
+		return nil, 0, err
+	}
+	// The holder before us may have removed the file after we opened it
+	// (see Unlock); a lock on a file no longer at path locks nothing.
+	fi, ferr := f.Stat()
+	pi, perr := os.Stat(path)
+	if ferr != nil || perr != nil || !os.SameFile(fi, pi) {
+		f.Close()
+		return tryLockVideo(path, key)
+	}
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"+key+"\n"), 0)
+	}
+	return &VideoLock{f: f}, 0, nil
+}
+
+// parseVideoLock reads the PID and video key of a lock file's contents.
+func parseVideoLock(b []byte) (pid int, key string) {
+	first, rest, _ := strings.Cut(string(b), "\n")
+	pid, _ = strconv.Atoi(strings.TrimSpace(first))
+	return pid, strings.TrimSpace(rest)
+}
+
+// LockedVideos returns the keys (see SameVideoKey) of the videos a job of
+// any sniplette process of this user is snipping. It reads the lock files
+// without taking their locks, so it never makes a job think its video is
+// busy: a file whose process is alive counts as held.
+func LockedVideos() map[string]bool {
+	sd, err := dirs.StateDir()
+	if err != nil {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(sd, "locks", "*.lock"))
+	held := map[string]bool{}
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		if pid, key := parseVideoLock(b); pid > 0 && key != "" && processAlive(pid) {
+			held[key] = true
+		}
+	}
+	return held
+}
+
+// Unlock releases the lock and removes its file. It is safe to call on a nil
+// lock.
+func (l *VideoLock) Unlock() {
+	if l != nil {
+		_ = os.Remove(l.f.Name())
+		_ = l.f.Close()
+	}
+}
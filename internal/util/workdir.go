@@ -0,0 +1,228 @@
+package util
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ig2wa/internal/dirs"
+)
+
+// workdirRecord is the state entry kept for each live temp workdir, so that
+// `sniplette clean` can tell orphaned workdirs from ones still in use.
+type workdirRecord struct {
+	Dir     string    `json:"dir"`
+	PID     int       `json:"pid"`
+	Created time.Time `json:"created"`
+}
+
+func workdirStateDir() (string, error) {
+	d, err := dirs.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "workdirs"), nil
+}
+
+func workdirStatePath(dir string) (string, error) {
+	sd, err := workdirStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(sd, filepath.Base(dir)+".json"), nil
+}
+
+// trackWorkdir records dir as owned by this process. Best-effort.
+func trackWorkdir(dir string) {
+	p, err := workdirStatePath(dir)
+	if err != nil || os.MkdirAll(filepath.Dir(p), 0o755) != nil {
+		return
+	}
+	b, _ := json.Marshal(workdirRecord{Dir: dir, PID: os.Getpid(), Created: time.Now()})
+	_ = os.WriteFile(p, b, 0o644)
+}
+
+// RemoveTempWorkdir deletes a workdir made by MakeTempWorkdir and its state entry.
+// The parent is removed too when it is an emptied per-output temp base.
+func RemoveTempWorkdir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	forgetWorkdir(dir)
+	err := os.RemoveAll(dir)
+	if parent := filepath.Dir(dir); filepath.Base(parent) == OutputTempDirName {
+		_ = os.Remove(parent) // only succeeds when empty
+	}
+	return err
+}
+
+// OutputTempDirName is the hidden folder used for workdirs placed next to the output.
+const OutputTempDirName = ".sniplette-tmp"
+
+func forgetWorkdir(dir string) {
+	if p, err := workdirStatePath(dir); err == nil {
+		_ = os.Remove(p)
+	}
+}
+
+// trackedWorkdirs reads all workdir state entries.
+func trackedWorkdirs() []workdirRecord {
+	sd, err := workdirStateDir()
+	if err != nil {
+		return nil
+	}
+	files, _ := filepath.Glob(filepath.Join(sd, "*.json"))
+	var out []workdirRecord
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var rec workdirRecord
+		if json.Unmarshal(b, &rec) == nil && rec.Dir != "" {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// StaleTemp is a leftover temp item found by ScanStaleTemp.
+type StaleTemp struct {
+	Path    string
+	Bytes   int64
+	ModTime time.Time
+	Reason  string // "orphaned workdir", "partial download", "legacy temp dir"
+}
+
+// ScanStaleTemp finds leftovers older than maxAge: workdirs under
+// dirs.TempBaseDir whose owning process is gone (or that were never tracked),
+// partial downloads (.part/.ytdl) lying loose in the temp base, and legacy
+// os.TempDir()/ig2wa* dirs from older versions. Workdirs of running processes
+// are never returned. maxAge <= 0 matches everything not in use.
+func ScanStaleTemp(maxAge time.Duration) ([]StaleTemp, error) {
+	base, err := dirs.TempBaseDir()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	old := func(t time.Time) bool { return maxAge <= 0 || t.Before(cutoff) }
+
+	var out []StaleTemp
+	entries, err := os.ReadDir(base)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		p := filepath.Join(base, e.Name())
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if e.IsDir() {
+			if workdirInUse(p) {
+				continue
+			}
+			size, mod := dirUsage(p)
+			if old(mod) {
+				out = append(out, StaleTemp{Path: p, Bytes: size, ModTime: mod, Reason: "orphaned workdir"})
+			}
+			continue
+		}
+		if isPartial(e.Name()) && old(info.ModTime()) {
+			out = append(out, StaleTemp{Path: p, Bytes: info.Size(), ModTime: info.ModTime(), Reason: "partial download"})
+		}
+	}
+
+	// Workdirs placed elsewhere (e.g., next to the output) are found via state.
+	for _, rec := range trackedWorkdirs() {
+		if filepath.Dir(rec.Dir) == filepath.Clean(base) || processAlive(rec.PID) {
+			continue
+		}
+		if _, err := os.Stat(rec.Dir); err != nil {
+			forgetWorkdir(rec.Dir)
+			continue
+		}
+		size, mod := dirUsage(rec.Dir)
+		if old(mod) {
+			out = append(out, StaleTemp{Path: rec.Dir, Bytes: size, ModTime: mod, Reason: "orphaned workdir"})
+		}
+	}
+
+	legacy, _ := filepath.Glob(filepath.Join(os.TempDir(), "ig2wa*"))
+	for _, p := range legacy {
+		size, mod := dirUsage(p)
+		if old(mod) {
+			out = append(out, StaleTemp{Path: p, Bytes: size, ModTime: mod, Reason: "legacy temp dir"})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModTime.Before(out[j].ModTime) })
+	return out, nil
+}
+
+// RemoveStaleTemp deletes an item returned by ScanStaleTemp, with its state entry.
+func RemoveStaleTemp(st StaleTemp) error {
+	return RemoveTempWorkdir(st.Path)
+}
+
+// workdirInUse reports whether dir's owning process is still running.
+func workdirInUse(dir string) bool {
+	p, err := workdirStatePath(dir)
+	if err != nil {
+		return false
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return false
+	}
+	var rec workdirRecord
+	if json.Unmarshal(b, &rec) != nil || rec.PID <= 0 {
+		return false
+	}
+	return processAlive(rec.PID)
+}
+
+// ProcessAlive reports whether a process with the given PID is running.
+func ProcessAlive(pid int) bool {
+	return pid > 0 && processAlive(pid)
+}
+
+func isPartial(name string) bool {
+	return strings.HasSuffix(name, ".part") || strings.HasSuffix(name, ".ytdl")
+}
+
+// dirUsage returns the total size and newest modification time under path.
+func dirUsage(path string) (int64, time.Time) {
+	var size int64
+	var newest time.Time
+	_ = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	return size, newest
+}
+
+// existingAncestor returns path or its closest existing parent directory.
+func existingAncestor(path string) string {
+	p := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return p
+		}
+		p = parent
+	}
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+)
+
+// IsShortsURL reports whether raw is a YouTube Shorts link
+// (youtube.com/shorts/<id>). Shorts are vertical videos.
+func IsShortsURL(raw string) bool {
+	pl, u, err := DetectPlatform(strings.TrimSpace(raw))
+	if err != nil || pl != PlatformYouTube {
+		return false
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return len(segs) == 2 && strings.EqualFold(segs[0], "shorts") && segs[1] != ""
+}
+
+// canonicalYouTube rewrites Shorts and live links (youtube.com/shorts/<id>,
+// youtube.com/live/<id>) to the watch link of the same video, which every
+// yt-dlp and youtube-dl version accepts. The start time (t) is kept. Other
+// links are returned as they are.
+func canonicalYouTube(u *url.URL) *url.URL {
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segs) != 2 || segs[1] == "" {
+		return u
+	}
+	switch strings.ToLower(segs[0]) {
+	case "shorts", "live":
+	default:
+		return u
+	}
+	q := url.Values{"v": {segs[1]}}
+	if t := u.Query().Get("t"); t != "" {
+		q.Set("t", t)
+	}
+	return &url.URL{Scheme: "https", Host: "www.youtube.com", Path: "/watch", RawQuery: q.Encode()}
+}